@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditTimeout is the maximum delay to send an audit record to a remote endpoint
+const auditTimeout = 10 * time.Second
+
+// envAuditToken is the environment variable containing the bearer token sent to the audit endpoint
+const envAuditToken = "TGF_AUDIT_TOKEN"
+
+// auditRecord is an entry of the audit log, it identifies who ran what against which account
+type auditRecord struct {
+	Timestamp string `json:"timestamp"`
+	User      string `json:"user"`
+	Host      string `json:"host"`
+	runMetadata
+}
+
+// getCurrentUsername returns the name of the user running tgf
+func getCurrentUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+func (config *TGFConfig) getAuditRecord(start time.Time, exitCode int) auditRecord {
+	host, _ := os.Hostname()
+	return auditRecord{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		User:        getCurrentUsername(),
+		Host:        host,
+		runMetadata: config.getRunSummary(start, exitCode),
+	}
+}
+
+// appendAuditRecord adds the record as a JSON line at the end of a local file (readable only by the current user)
+func appendAuditRecord(filename string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// The record is written with a single call to avoid interleaving the lines of concurrent runs
+	_, err = file.Write(append(content, '\n'))
+	return err
+}
+
+// postAuditRecord sends the record as JSON to an HTTP(S) endpoint
+func postAuditRecord(url string, content []byte) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv(envAuditToken); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := newHTTPClient(auditTimeout).Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", url, response.Status)
+	}
+	return nil
+}
+
+// writeAuditLog records the invocation in the audit log, a failure to record it does not change the result of the run
+func (config *TGFConfig) writeAuditLog(start time.Time, exitCode int) {
+	content := must(json.Marshal(config.getAuditRecord(start, exitCode))).([]byte)
+	var err error
+	if isHTTPConfigSource(config.AuditLog) {
+		err = postAuditRecord(config.AuditLog, content)
+	} else {
+		filename := config.AuditLog
+		if strings.HasPrefix(filename, "~/") {
+			filename = filepath.Join(getHomeFolder(), filename[2:])
+		}
+		err = appendAuditRecord(filename, content)
+	}
+	if err != nil {
+		printWarning(msgAuditLogFailed, config.AuditLog, err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAuditLogFile(t *testing.T) {
+	folder := must(ioutil.TempDir("", "tgf-audit")).(string)
+	defer os.RemoveAll(folder)
+	filename := filepath.Join(folder, "logs", "audit.log")
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"apply"}), EntryPoint: "terragrunt", AuditLog: filename}
+	config.writeAuditLog(time.Now(), 0)
+	config.tgf.Unmanaged = []string{"destroy"}
+	config.writeAuditLog(time.Now(), 1)
+
+	lines := strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(filename)).([]byte))), "\n")
+	if assert.Len(t, lines, 2, "The records are appended") {
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+		assert.Equal(t, []interface{}{"destroy"}, record["arguments"])
+		assert.Equal(t, float64(1), record["exit-code"])
+		assert.Equal(t, getCurrentUsername(), record["user"])
+		assert.Equal(t, must(os.Getwd()), record["working-dir"])
+		assert.Contains(t, record, "timestamp")
+	}
+	info := must(os.Stat(filename)).(os.FileInfo)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestWriteAuditLogEndpoint(t *testing.T) {
+	defer os.Setenv(envAuditToken, os.Getenv(envAuditToken))
+
+	var received auditRecord
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), EntryPoint: "terragrunt", AuditLog: server.URL}
+	os.Setenv(envAuditToken, "secret")
+	config.writeAuditLog(time.Now(), 2)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, []string{"plan"}, received.Arguments)
+	assert.Equal(t, 2, received.ExitCode)
+	assert.Equal(t, "terragrunt", received.EntryPoint)
+}
+
+func TestPostAuditRecordError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	assert.EqualError(t, postAuditRecord(server.URL, []byte("{}")), server.URL+" returned 403 Forbidden")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+)
+
+// awsProfileVariables are the variables that refer to the host AWS configuration. Once the credentials have been resolved on
+// the host, they are not transmitted to the container to ensure that the container uses the resolved temporary credentials
+// instead of trying to run credential helpers (credential_process, aws-vault, SSO) that are not available in the image.
+var awsProfileVariables = []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE"}
+
+func isAWSProfileVariable(name string) bool {
+	for _, variable := range awsProfileVariables {
+		if name == variable {
+			return true
+		}
+	}
+	return false
+}
+
+// removeAWSProfileVariables ensures that the host AWS profile configuration is not transmitted to the container (unless it has
+// been explicitly set in the environment section of the configuration)
+func (config *TGFConfig) removeAWSProfileVariables() {
+	if config.awsSession == nil {
+		return
+	}
+	for _, variable := range awsProfileVariables {
+		if _, explicit := config.Environment[variable]; !explicit {
+			os.Unsetenv(variable)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveAWSProfileVariables(t *testing.T) {
+	for _, variable := range []string{"AWS_PROFILE", "AWS_SHARED_CREDENTIALS_FILE"} {
+		if value, defined := os.LookupEnv(variable); defined {
+			defer os.Setenv(variable, value)
+		} else {
+			defer os.Unsetenv(variable)
+		}
+	}
+	os.Setenv("AWS_PROFILE", "dev")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/home/user/.aws/credentials")
+
+	config := TGFConfig{Environment: map[string]string{"AWS_PROFILE": "dev"}}
+	config.removeAWSProfileVariables()
+	assert.Equal(t, "/home/user/.aws/credentials", os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), "Nothing is removed if there is no resolved session")
+
+	config.awsSession = session.Must(session.NewSession())
+	config.removeAWSProfileVariables()
+	assert.Equal(t, "", os.Getenv("AWS_SHARED_CREDENTIALS_FILE"))
+	assert.Equal(t, "dev", os.Getenv("AWS_PROFILE"), "Explicitly configured variables are kept")
+}
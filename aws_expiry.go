@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// credentialExpirationEnvVar is the variable used by the AWS tools to expose the expiration of temporary credentials
+	credentialExpirationEnvVar = "AWS_CREDENTIAL_EXPIRATION"
+
+	// credentialExpiryWarning is the remaining validity under which the user is warned that the credentials will expire soon
+	credentialExpiryWarning = 15 * time.Minute
+)
+
+// setCredentialExpirationEnv exposes the expiration of the current credentials as environment variable
+func setCredentialExpirationEnv(expiration time.Time) {
+	if expiration.IsZero() {
+		os.Unsetenv(credentialExpirationEnvVar)
+	} else {
+		os.Setenv(credentialExpirationEnvVar, expiration.UTC().Format(time.RFC3339))
+	}
+}
+
+// getCredentialsExpiration returns the expiration of the session credentials (zero if the credentials do not expire or if
+// it cannot be determined)
+func getCredentialsExpiration(awsSession *session.Session) time.Time {
+	if awsSession != nil && awsSession.Config.Credentials != nil {
+		if expiration, err := awsSession.Config.Credentials.ExpiresAt(); err == nil {
+			return expiration
+		}
+	}
+	if expiration, err := time.Parse(time.RFC3339, os.Getenv(credentialExpirationEnvVar)); err == nil {
+		return expiration
+	}
+	return time.Time{}
+}
+
+// checkCredentialsTTL ensures that the AWS credentials remain valid for at least the required duration and warns the user if
+// they are about to expire
+func (config *TGFConfig) checkCredentialsTTL(required time.Duration) error {
+	if config.awsExpiration.IsZero() {
+		if required > 0 && config.awsSession != nil {
+			config.tgf.Debug("# Unable to determine the expiration of the AWS credentials")
+		}
+		return nil
+	}
+
+	remaining := time.Until(config.awsExpiration).Truncate(time.Second)
+	if required > 0 && remaining < required {
+		return fmt.Errorf("The AWS credentials expire in %v (at %s), at least %v is required by --require-min-credential-ttl",
+			remaining, config.awsExpiration.Local().Format(time.Kitchen), required)
+	}
+	if remaining < credentialExpiryWarning {
+		printWarning(msgCredentialsExpireSoon, remaining, config.awsExpiration.Local().Format(time.Kitchen))
+	}
+	return nil
+}
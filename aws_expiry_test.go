@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCredentialsTTL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		expiration time.Time
+		required   time.Duration
+		wantErr    bool
+	}{
+		{"Unknown expiration", time.Time{}, time.Hour, false},
+		{"No requirement", time.Now().Add(5 * time.Minute), 0, false},
+		{"Enough time", time.Now().Add(2 * time.Hour), time.Hour, false},
+		{"Not enough time", time.Now().Add(30 * time.Minute), time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := TGFConfig{tgf: &TGFApplication{}, awsExpiration: tt.expiration}
+			err := config.checkCredentialsTTL(tt.required)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// getSharedCredentialsFile returns the location of the AWS shared credentials file
+func getSharedCredentialsFile() string {
+	if filename := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); filename != "" {
+		return filename
+	}
+	return filepath.Join(getHomeFolder(), ".aws", "credentials")
+}
+
+// updateSharedCredentials replaces (or adds) the profile section in the content of a shared credentials file
+func updateSharedCredentials(content, profile string, creds credentials.Value) string {
+	var lines []string
+	skip := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") {
+			match := reAWSProfile.FindStringSubmatch(line)
+			skip = match != nil && match[1] == profile
+		}
+		if !skip && (line != "" || len(lines) > 0) {
+			lines = append(lines, line)
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines,
+		fmt.Sprintf("[%s]", profile),
+		fmt.Sprintf("aws_access_key_id = %s", creds.AccessKeyID),
+		fmt.Sprintf("aws_secret_access_key = %s", creds.SecretAccessKey),
+	)
+	if creds.SessionToken != "" {
+		lines = append(lines, fmt.Sprintf("aws_session_token = %s", creds.SessionToken))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// exportCredentials prints (or writes) the resolved AWS credentials to be consumed by other tools
+func (config *TGFConfig) exportCredentials(format, profile string) int {
+	if config.awsSession == nil {
+		if err := config.InitAWS(""); err != nil {
+			printError(msgCredentialsUnresolved, err)
+			return 1
+		}
+	}
+	creds, err := config.awsSession.Config.Credentials.Get()
+	if err != nil {
+		printError(msgCredentialsUnresolved, err)
+		return 1
+	}
+
+	switch format {
+	case "json":
+		// Format used by credential_process, tgf can then be used as credential helper by other tools
+		result := map[string]interface{}{
+			"Version":         1,
+			"AccessKeyId":     creds.AccessKeyID,
+			"SecretAccessKey": creds.SecretAccessKey,
+		}
+		if creds.SessionToken != "" {
+			result["SessionToken"] = creds.SessionToken
+		}
+		if !config.awsExpiration.IsZero() {
+			result["Expiration"] = config.awsExpiration.UTC().Format(time.RFC3339)
+		}
+		Println(string(must(json.MarshalIndent(result, "", "  ")).([]byte)))
+	case "shared-file":
+		filename := getSharedCredentialsFile()
+		content, _ := ioutil.ReadFile(filename)
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err == nil {
+			err = ioutil.WriteFile(filename, []byte(updateSharedCredentials(string(content), profile, creds)), 0600)
+		}
+		if err != nil {
+			printError(msgFileWriteFailed, filename, err)
+			return 1
+		}
+		ErrPrintln(fmt.Sprintf("Credentials written to profile %s of %s", profile, filename))
+	default:
+		variables := [][2]string{
+			{"AWS_ACCESS_KEY_ID", creds.AccessKeyID},
+			{"AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey},
+			{"AWS_SESSION_TOKEN", creds.SessionToken},
+			{"AWS_REGION", os.Getenv("AWS_REGION")},
+		}
+		if !config.awsExpiration.IsZero() {
+			variables = append(variables, [2]string{credentialExpirationEnvVar, config.awsExpiration.UTC().Format(time.RFC3339)})
+		}
+		for _, variable := range variables {
+			if variable[1] != "" {
+				Printf("export %s=%s\n", variable[0], variable[1])
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSharedCredentials(t *testing.T) {
+	creds := credentials.Value{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "token"}
+	section := "[tgf]\naws_access_key_id = AKIA\naws_secret_access_key = secret\naws_session_token = token\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Empty file", "", section},
+		{"Other profile", "[default]\naws_access_key_id = OLD\n", "[default]\naws_access_key_id = OLD\n\n" + section},
+		{"Replace profile", "[tgf]\naws_access_key_id = OLD\n\n[other]\nregion = us-east-1\n", "[other]\nregion = us-east-1\n\n" + section},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, updateSharedCredentials(tt.content, "tgf", creds))
+		})
+	}
+}
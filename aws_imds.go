@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Delay used to detect the instance metadata service, it must be short since it is only reachable on EC2 instances
+const imdsTimeout = 500 * time.Millisecond
+
+// isECSTask returns true if the credentials are supplied by the ECS container credentials endpoint
+func isECSTask() bool {
+	return os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")+os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != ""
+}
+
+// initContainerSession returns a session using the container credentials endpoint (ECS task role or EKS Pod Identity).
+//
+// The credentials are not converted to static keys: the endpoint variables are forwarded to the container so that the credentials
+// are refreshed by the tools running in the container during long runs. The profile based resolution is skipped since there is
+// no AWS configuration in a task.
+func initContainerSession() (*session.Session, error) {
+	awsSession, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := awsSession.Config.Credentials.Get(); err != nil {
+		return nil, fmt.Errorf("Unable to get credentials from the container credentials endpoint: %v", err)
+	}
+	return awsSession, nil
+}
+
+// getContainerCredentialsVolumes returns the volumes required to use the container credentials endpoint in the container (the
+// authorization token file of EKS Pod Identity is rotated, so it is mounted rather than copied)
+func getContainerCredentialsVolumes() []string {
+	if filename := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); filename != "" {
+		filename = filepath.ToSlash(filename)
+		return []string{fmt.Sprintf("%s:%s:ro", filename, filename)}
+	}
+	return nil
+}
+
+// isEC2Instance returns true if the instance metadata service is reachable (IMDSv2 session tokens are used by the SDK).
+//
+// The credentials of the instance profile are then resolved on the host and supplied to the container, so the container does not
+// have to reach the metadata service (which is not possible if the hop limit is 1).
+func isEC2Instance() bool {
+	awsSession, err := session.NewSession(aws.NewConfig().
+		WithHTTPClient(newHTTPClient(imdsTimeout)).
+		WithMaxRetries(0))
+	if err != nil {
+		return false
+	}
+	return ec2metadata.New(awsSession).Available()
+}
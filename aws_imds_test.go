@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsECSTask(t *testing.T) {
+	const variable = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	if value, defined := os.LookupEnv(variable); defined {
+		defer os.Setenv(variable, value)
+	} else {
+		defer os.Unsetenv(variable)
+	}
+
+	os.Unsetenv(variable)
+	assert.Equal(t, os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "", isECSTask())
+	os.Setenv(variable, "/v2/credentials/1234")
+	assert.True(t, isECSTask())
+}
+
+func TestGetContainerCredentialsVolumes(t *testing.T) {
+	const variable = "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"
+	if value, defined := os.LookupEnv(variable); defined {
+		defer os.Setenv(variable, value)
+	} else {
+		defer os.Unsetenv(variable)
+	}
+
+	os.Unsetenv(variable)
+	assert.Empty(t, getContainerCredentialsVolumes())
+	os.Setenv(variable, "/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token")
+	assert.Equal(t, []string{
+		"/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token:/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token:ro",
+	}, getContainerCredentialsVolumes())
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/gruntwork-io/terragrunt/aws_helper"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Cached credentials are not used if they expire in less than this delay
+const credentialsExpiryMargin = 5 * time.Minute
+
+// cachedCredentials is the representation of temporary AWS credentials cached on disk
+type cachedCredentials struct {
+	AccessKeyID     string    `json:"access-key-id"`
+	SecretAccessKey string    `json:"secret-access-key"`
+	SessionToken    string    `json:"session-token"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+func getCredentialsCacheFile(key string) string {
+	return filepath.Join(getCacheFolder(), "aws-credentials", util.EncodeBase64Sha1(key))
+}
+
+// readCachedCredentials returns the cached credentials identified by key (and their expiration) if they are still valid
+func readCachedCredentials(key string) (credentials.Value, time.Time, bool) {
+	var cached cachedCredentials
+	content, err := ioutil.ReadFile(getCredentialsCacheFile(key))
+	if err != nil || json.Unmarshal(content, &cached) != nil || time.Until(cached.Expiration) < credentialsExpiryMargin {
+		runStats.cacheLookup(false)
+		return credentials.Value{}, time.Time{}, false
+	}
+	runStats.cacheLookup(true)
+	return credentials.Value{AccessKeyID: cached.AccessKeyID, SecretAccessKey: cached.SecretAccessKey, SessionToken: cached.SessionToken}, cached.Expiration, true
+}
+
+// writeCachedCredentials saves temporary credentials (readable only by the current user) until their expiration
+func writeCachedCredentials(key string, creds credentials.Value, expiration time.Time) {
+	filename := getCredentialsCacheFile(key)
+	content := must(json.Marshal(cachedCredentials{creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, expiration})).([]byte)
+	if os.MkdirAll(filepath.Dir(filename), 0700) == nil {
+		ioutil.WriteFile(filename, content, 0600)
+	}
+}
+
+// getMFAToken returns the MFA code, either from the configured mfa-command, from TGF_MFA_CODE or by prompting the user
+func (config *TGFConfig) getMFAToken(serial string) (string, error) {
+	if config.MFACommand != "" {
+		cmd, tempFile, err := utils.GetCommandFromString(config.MFACommand)
+		if err != nil {
+			return "", err
+		}
+		if tempFile != "" {
+			defer os.Remove(tempFile)
+		}
+		cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("Error while running mfa-command: %v", err)
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	return prompts.askRequired(fmt.Sprintf("Enter MFA code for %s", serial), envMFACode)
+}
+
+// getAWSConfigFile returns the location of the AWS shared configuration file
+func getAWSConfigFile() string {
+	if filename := os.Getenv("AWS_CONFIG_FILE"); filename != "" {
+		return filename
+	}
+	return filepath.Join(getHomeFolder(), ".aws", "config")
+}
+
+var reAWSConfigSetting = regexp.MustCompile(`^\s*([\w-]+)\s*=\s*(.*?)\s*$`)
+
+// getAWSProfileSetting returns the value of a setting defined for a profile in the AWS configuration content
+func getAWSProfileSetting(content, profile, setting string) string {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		if match := reAWSProfile.FindStringSubmatch(line); match != nil {
+			current = match[1]
+		} else if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			// Other sections (i.e. [sso-session name]) are not profiles
+			current = ""
+		} else if match := reAWSConfigSetting.FindStringSubmatch(line); match != nil && current == profile && match[1] == setting {
+			return match[2]
+		}
+	}
+	return ""
+}
+
+// initAWSSession initializes the AWS session for the profile (an empty profile means the default credentials chain).
+//
+// Web identity defined through AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE is used if there is no profile. SSO profiles are resolved through the AWS CLI (the login process is started if the SSO session is expired). If the profile
+// requires MFA, the resolved credentials are cached until their expiration to avoid prompting the user on each invocation.
+func (config *TGFConfig) initAWSSession(profile, profileName string) (*session.Session, error) {
+	if profileName == "" {
+		roleArn, sessionName, token, err := getEnvironmentWebIdentity()
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			creds, expiration, err := assumeRoleWithWebIdentity(roleArn, sessionName, token, 0, nil)
+			if err != nil {
+				return nil, err
+			}
+			return initAWSSessionWithCredentials(creds, expiration)
+		}
+	}
+
+	if profileName == "" && isECSTask() && os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		return initContainerSession()
+	}
+
+	content, _ := ioutil.ReadFile(getAWSConfigFile())
+	settingsProfile := profileName
+	if settingsProfile == "" {
+		settingsProfile = "default"
+	}
+
+	// Explicit credentials have precedence over the default profile
+	useDefaultProfile := profileName == "" && os.Getenv("AWS_ACCESS_KEY_ID") == ""
+	if sso := getSSOProfile(string(content), settingsProfile); sso != nil && (profileName != "" || useDefaultProfile) {
+		creds, expiration, err := sso.credentials()
+		if err != nil {
+			return nil, err
+		}
+		if region := getAWSProfileSetting(string(content), settingsProfile, "region"); region != "" && os.Getenv("AWS_REGION") == "" {
+			os.Setenv("AWS_REGION", region)
+		}
+		return initAWSSessionWithCredentials(creds, expiration)
+	}
+
+	if profileName != "" {
+		// The expiration of the previous credentials does not apply to the profile
+		os.Unsetenv(credentialExpirationEnvVar)
+	}
+	serial := getAWSProfileSetting(string(content), profileName, "mfa_serial")
+	if profileName == "" || serial == "" {
+		return aws_helper.InitAwsSession(profile)
+	}
+
+	key := "profile:" + profileName
+	if creds, expiration, ok := readCachedCredentials(key); ok {
+		return initAWSSessionWithCredentials(creds, expiration)
+	}
+
+	awsSession, err := config.initMFASession(profileName, serial)
+	if err != nil {
+		return nil, err
+	}
+	if creds, err := awsSession.Config.Credentials.Get(); err == nil {
+		if expiration, err := awsSession.Config.Credentials.ExpiresAt(); err == nil {
+			writeCachedCredentials(key, creds, expiration)
+		}
+	}
+	return awsSession, nil
+}
+
+// getMFASessionOptions returns the options of the session of a profile requiring MFA, the code is supplied by getMFAToken (the SDK
+// and terragrunt would prompt for it on stdout, ignoring mfa-command, TGF_MFA_CODE and --no-input)
+func (config *TGFConfig) getMFASessionOptions(profile, serial string) session.Options {
+	return session.Options{
+		Profile:                 profile,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: func() (string, error) { return config.getMFAToken(serial) },
+	}
+}
+
+// initMFASession initializes the session of a profile requiring MFA and exports its credentials in the environment (as
+// aws_helper.InitAwsSession does for the other profiles)
+func (config *TGFConfig) initMFASession(profile, serial string) (*session.Session, error) {
+	// The credentials of the environment would have precedence over the profile
+	for _, variable := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"} {
+		os.Unsetenv(variable)
+	}
+	awsSession, err := session.NewSessionWithOptions(config.getMFASessionOptions(profile, serial))
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing the session of the profile %s: %v", profile, err)
+	}
+	creds, err := awsSession.Config.Credentials.Get()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get the credentials of the profile %s: %v", profile, err)
+	}
+	if region := aws.StringValue(awsSession.Config.Region); region != "" && os.Getenv("AWS_REGION") == "" {
+		os.Setenv("AWS_REGION", region)
+	}
+	os.Setenv("AWS_ACCESS_KEY_ID", creds.AccessKeyID)
+	os.Setenv("AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey)
+	os.Setenv("AWS_SESSION_TOKEN", creds.SessionToken)
+	os.Unsetenv("AWS_PROFILE")
+	os.Unsetenv("AWS_DEFAULT_PROFILE")
+	return awsSession, nil
+}
+
+// initAWSSessionWithCredentials initializes the AWS session with already resolved credentials
+func initAWSSessionWithCredentials(creds credentials.Value, expiration time.Time) (*session.Session, error) {
+	setCredentialExpirationEnv(expiration)
+	os.Unsetenv("AWS_PROFILE")
+	os.Unsetenv("AWS_DEFAULT_PROFILE")
+	os.Setenv("AWS_ACCESS_KEY_ID", creds.AccessKeyID)
+	os.Setenv("AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey)
+	os.Setenv("AWS_SESSION_TOKEN", creds.SessionToken)
+	return aws_helper.InitAwsSession("")
+}
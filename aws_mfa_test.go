@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAWSProfileSetting(t *testing.T) {
+	t.Parallel()
+
+	content := String(`
+		[default]
+		region = us-east-1
+
+		[profile admin]
+		role_arn = arn:aws:iam::123456789012:role/admin
+		mfa_serial = arn:aws:iam::123456789012:mfa/john
+		source_profile = default
+	`).UnIndent().Str()
+
+	assert.Equal(t, "arn:aws:iam::123456789012:mfa/john", getAWSProfileSetting(content, "admin", "mfa_serial"))
+	assert.Equal(t, "", getAWSProfileSetting(content, "default", "mfa_serial"))
+	assert.Equal(t, "us-east-1", getAWSProfileSetting(content, "default", "region"))
+	assert.Equal(t, "", getAWSProfileSetting(content, "unknown", "region"))
+}
+
+func TestCachedCredentials(t *testing.T) {
+	key := fmt.Sprintf("test:TestCachedCredentials/%v", randInt())
+	defer os.Remove(getCredentialsCacheFile(key))
+
+	creds := credentials.Value{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "token"}
+	_, _, ok := readCachedCredentials(key)
+	assert.False(t, ok, "Nothing cached")
+
+	writeCachedCredentials(key, creds, time.Now().Add(time.Minute))
+	_, _, ok = readCachedCredentials(key)
+	assert.False(t, ok, "Credentials expiring soon should not be used")
+
+	writeCachedCredentials(key, creds, time.Now().Add(time.Hour))
+	cached, expiration, ok := readCachedCredentials(key)
+	assert.True(t, ok)
+	assert.Equal(t, creds, cached)
+	assert.True(t, time.Until(expiration) > 55*time.Minute)
+
+	info, err := os.Stat(getCredentialsCacheFile(key))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestGetMFATokenFromCommand(t *testing.T) {
+	t.Parallel()
+
+	config := TGFConfig{MFACommand: "echo 123456"}
+	token, err := config.getMFAToken("serial")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", token)
+}
+
+func TestMFASessionUsesTGFCode(t *testing.T) {
+	var tokenCode string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		tokenCode = r.Form.Get("TokenCode")
+		fmt.Fprint(w, String(`
+			<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+			  <AssumeRoleResult>
+			    <Credentials>
+			      <AccessKeyId>ASIAMFA</AccessKeyId>
+			      <SecretAccessKey>secret</SecretAccessKey>
+			      <SessionToken>token</SessionToken>
+			      <Expiration>2099-01-01T00:00:00Z</Expiration>
+			    </Credentials>
+			  </AssumeRoleResult>
+			</AssumeRoleResponse>
+		`).UnIndent().Str())
+	}))
+	defer server.Close()
+
+	folder := must(ioutil.TempDir("", "tgf-mfa")).(string)
+	defer os.RemoveAll(folder)
+	configFile, credentialsFile := filepath.Join(folder, "config"), filepath.Join(folder, "credentials")
+	must(ioutil.WriteFile(configFile, []byte(String(`
+		[profile admin]
+		region = us-east-1
+		role_arn = arn:aws:iam::123456789012:role/admin
+		mfa_serial = arn:aws:iam::123456789012:mfa/john
+		source_profile = source
+	`).UnIndent().Str()), 0600))
+	must(ioutil.WriteFile(credentialsFile, []byte(String(`
+		[source]
+		aws_access_key_id = AKIASOURCE
+		aws_secret_access_key = secret
+	`).UnIndent().Str()), 0600))
+	for variable, value := range map[string]string{"AWS_CONFIG_FILE": configFile, "AWS_SHARED_CREDENTIALS_FILE": credentialsFile, envMFACode: "654321"} {
+		defer os.Setenv(variable, os.Getenv(variable))
+		os.Setenv(variable, value)
+	}
+
+	config := TGFConfig{}
+	options := config.getMFASessionOptions("admin", "arn:aws:iam::123456789012:mfa/john")
+	options.Config.Endpoint = aws.String(server.URL)
+	awsSession, err := session.NewSessionWithOptions(options)
+	assert.NoError(t, err)
+	creds, err := awsSession.Config.Credentials.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIAMFA", creds.AccessKeyID)
+	assert.Equal(t, "654321", tokenCode, "The code must come from TGF_MFA_CODE instead of the terragrunt prompt")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+const stsRegionalEndpointsEnvVar = "AWS_STS_REGIONAL_ENDPOINTS"
+
+// partitionDefaultRegions contains the region used to reach the regional services of a partition when no region is configured
+var partitionDefaultRegions = map[string]string{
+	endpoints.AwsPartitionID:      endpoints.UsEast1RegionID,
+	endpoints.AwsUsGovPartitionID: endpoints.UsGovWest1RegionID,
+	endpoints.AwsCnPartitionID:    endpoints.CnNorth1RegionID,
+}
+
+// useRegionalSTSEndpoints ensures that STS calls made by tgf (and by the tools running in the container) use the regional endpoint
+// instead of the global one, which is not available in all partitions and adds latency and a single point of failure.
+// The user can still force the legacy behavior by setting AWS_STS_REGIONAL_ENDPOINTS=legacy.
+func useRegionalSTSEndpoints() {
+	if os.Getenv(stsRegionalEndpointsEnvVar) == "" {
+		os.Setenv(stsRegionalEndpointsEnvVar, "regional")
+	}
+}
+
+// getPartition returns the partition (aws, aws-us-gov, aws-cn, ...) that contains the region
+func getPartition(region string) endpoints.Partition {
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return partition
+	}
+	return endpoints.AwsPartition()
+}
+
+// getDefaultRegion returns the region that should be used to call AWS when none is configured.
+// If an ARN is supplied, the region is chosen in the partition of the ARN.
+func getDefaultRegion(resourceArn string) string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+	if parsed, err := arn.Parse(resourceArn); err == nil && partitionDefaultRegions[parsed.Partition] != "" {
+		return partitionDefaultRegions[parsed.Partition]
+	}
+	return endpoints.UsEast1RegionID
+}
+
+// getECRRegistry returns the hostname of the ECR registry of the account in the region (i.e. account.dkr.ecr.cn-north-1.amazonaws.com.cn)
+func getECRRegistry(account, region string) string {
+	return fmt.Sprintf("%s.dkr.ecr.%s.%s", account, region, getPartition(region).DNSSuffix())
+}
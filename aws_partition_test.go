@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPartition(t *testing.T) {
+	tests := []struct {
+		region string
+		want   string
+	}{
+		{"us-east-1", "aws"},
+		{"ca-central-1", "aws"},
+		{"us-gov-west-1", "aws-us-gov"},
+		{"cn-northwest-1", "aws-cn"},
+		{"", "aws"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			assert.Equal(t, tt.want, getPartition(tt.region).ID())
+		})
+	}
+}
+
+func TestGetECRRegistry(t *testing.T) {
+	assert.Equal(t, "123456789012.dkr.ecr.us-east-1.amazonaws.com", getECRRegistry("123456789012", "us-east-1"))
+	assert.Equal(t, "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", getECRRegistry("123456789012", "cn-north-1"))
+	assert.Equal(t, "123456789012.dkr.ecr.us-gov-east-1.amazonaws.com", getECRRegistry("123456789012", "us-gov-east-1"))
+
+	matches := reECR.FindStringSubmatch(getECRRegistry("123456789012", "cn-north-1") + "/tgf:latest")
+	assert.Equal(t, []string{"123456789012", "cn-north-1"}, matches[1:3])
+}
+
+func TestGetDefaultRegion(t *testing.T) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+
+	tests := []struct {
+		name string
+		arn  string
+		want string
+	}{
+		{"No ARN", "", "us-east-1"},
+		{"Commercial", "arn:aws:iam::123456789012:role/deploy", "us-east-1"},
+		{"GovCloud", "arn:aws-us-gov:iam::123456789012:role/deploy", "us-gov-west-1"},
+		{"China", "arn:aws-cn:iam::123456789012:role/deploy", "cn-north-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getDefaultRegion(tt.arn))
+		})
+	}
+
+	os.Setenv("AWS_REGION", "ca-central-1")
+	assert.Equal(t, "ca-central-1", getDefaultRegion("arn:aws-cn:iam::123456789012:role/deploy"))
+}
+
+func TestUseRegionalSTSEndpoints(t *testing.T) {
+	defer os.Setenv(stsRegionalEndpointsEnvVar, os.Getenv(stsRegionalEndpointsEnvVar))
+
+	os.Unsetenv(stsRegionalEndpointsEnvVar)
+	useRegionalSTSEndpoints()
+	assert.Equal(t, "regional", os.Getenv(stsRegionalEndpointsEnvVar))
+
+	os.Setenv(stsRegionalEndpointsEnvVar, "legacy")
+	useRegionalSTSEndpoints()
+	assert.Equal(t, "legacy", os.Getenv(stsRegionalEndpointsEnvVar))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// setRegionEnv defines the AWS region variables used by tgf and by the tools running in the container
+func setRegionEnv(region string) {
+	os.Setenv("AWS_REGION", region)
+	os.Setenv("AWS_DEFAULT_REGION", region)
+}
+
+// applyAWSRegion applies the region specified by aws-region (or --aws-region) to the current AWS session and to the container.
+// It has precedence over the region defined in the AWS profile.
+func (config *TGFConfig) applyAWSRegion() {
+	if config.AWSRegion == "" {
+		return
+	}
+	config.tgf.Debug("# Using AWS region %s", config.AWSRegion)
+	setRegionEnv(config.AWSRegion)
+	config.Environment["AWS_REGION"] = config.AWSRegion
+	config.Environment["AWS_DEFAULT_REGION"] = config.AWSRegion
+	if config.awsSession != nil && aws.StringValue(config.awsSession.Config.Region) != config.AWSRegion {
+		config.awsSession = config.awsSession.Copy(aws.NewConfig().WithRegion(config.AWSRegion))
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyAWSRegion(t *testing.T) {
+	for _, variable := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if value, defined := os.LookupEnv(variable); defined {
+			defer os.Setenv(variable, value)
+		} else {
+			defer os.Unsetenv(variable)
+		}
+	}
+
+	config := TGFConfig{
+		tgf:         &TGFApplication{},
+		Environment: map[string]string{"AWS_REGION": "us-east-1"},
+		awsSession:  session.Must(session.NewSession(aws.NewConfig().WithRegion("us-east-1"))),
+	}
+	config.applyAWSRegion()
+	assert.Equal(t, "us-east-1", aws.StringValue(config.awsSession.Config.Region), "Nothing is changed if aws-region is not specified")
+
+	config.AWSRegion = "eu-west-1"
+	config.applyAWSRegion()
+	assert.Equal(t, "eu-west-1", aws.StringValue(config.awsSession.Config.Region))
+	assert.Equal(t, map[string]string{"AWS_REGION": "eu-west-1", "AWS_DEFAULT_REGION": "eu-west-1"}, config.Environment)
+	assert.Equal(t, "eu-west-1", os.Getenv("AWS_DEFAULT_REGION"))
+}
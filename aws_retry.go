@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const awsMaxRetries = 10
+
+// awsRetryConfig returns the client configuration used for all the AWS calls made by tgf.
+//
+// Large parallel invocations (i.e. terragrunt run-all) make many concurrent STS and SSM calls that are throttled by AWS. The calls
+// are retried with an exponential backoff (with jitter) to spread them instead of failing on the first Rate Exceeded error.
+func awsRetryConfig() *aws.Config {
+	return request.WithRetryer(aws.NewConfig(), client.DefaultRetryer{
+		NumMaxRetries:    awsMaxRetries,
+		MinRetryDelay:    100 * time.Millisecond,
+		MaxRetryDelay:    5 * time.Second,
+		MinThrottleDelay: 500 * time.Millisecond,
+		MaxThrottleDelay: 30 * time.Second,
+	})
+}
+
+// describeAWSError converts the throttling errors (that persisted after all retries) into actionable messages
+func describeAWSError(err error) error {
+	if err != nil && request.IsErrorThrottle(err) {
+		return fmt.Errorf("%v\nAWS API rate exceeded after %d retries: reduce the number of concurrent tgf invocations (i.e. --terragrunt-parallelism with run-all) or try again later", err, awsMaxRetries)
+	}
+	return err
+}
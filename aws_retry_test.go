@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeAWSError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		actionable bool
+	}{
+		{"No error", nil, false},
+		{"Other error", fmt.Errorf("AccessDenied"), false},
+		{"Throttling", awserr.New("Throttling", "Rate exceeded", nil), true},
+		{"Too many requests", awserr.New("TooManyRequestsException", "Too many requests", nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := describeAWSError(tt.err)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.actionable, strings.Contains(err.Error(), "--terragrunt-parallelism"))
+		})
+	}
+}
+
+func TestAWSRetryConfig(t *testing.T) {
+	t.Parallel()
+
+	retryer, ok := awsRetryConfig().Retryer.(client.DefaultRetryer)
+	assert.True(t, ok)
+	assert.Equal(t, awsMaxRetries, retryer.MaxRetries())
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const defaultRoleDuration = time.Hour
+
+var reInvalidSessionName = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// getRoleSessionName returns the configured session name or a default one based on the current user
+func (config *TGFConfig) getRoleSessionName() string {
+	name := config.RoleSessionName
+	if name == "" {
+		name = "tgf"
+		if currentUser, err := user.Current(); err == nil {
+			name += "-" + currentUser.Username
+		}
+	}
+	name = reInvalidSessionName.ReplaceAllString(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// RoleHop describes a role assumed as part of a role chain
+type RoleHop struct {
+	RoleArn           string            `yaml:"role-arn,omitempty" json:"role-arn,omitempty" hcl:"role-arn,omitempty"`
+	ExternalID        string            `yaml:"external-id,omitempty" json:"external-id,omitempty" hcl:"external-id,omitempty"`
+	SessionName       string            `yaml:"session-name,omitempty" json:"session-name,omitempty" hcl:"session-name,omitempty"`
+	Tags              map[string]string `yaml:"tags,omitempty" json:"tags,omitempty" hcl:"tags,omitempty"`
+	TransitiveTagKeys []string          `yaml:"transitive-tag-keys,omitempty" json:"transitive-tag-keys,omitempty" hcl:"transitive-tag-keys,omitempty"`
+}
+
+// AWS limits the duration of the sessions obtained through role chaining to one hour
+const maxChainedRoleDuration = time.Hour
+
+// assumeRole assumes the configured role and role chain (if any) and injects the temporary credentials in the container environment
+func (config *TGFConfig) assumeRole() error {
+	defer timings.begin("credentials")()
+	if config.RoleArn != "" {
+		if err := config.assumeConfiguredRole(); err != nil {
+			return err
+		}
+	}
+	return config.assumeRoleChain()
+}
+
+// getRoleDuration returns the requested duration of the assumed role sessions
+func (config *TGFConfig) getRoleDuration() time.Duration {
+	if config.RoleDuration == 0 {
+		return defaultRoleDuration
+	}
+	return config.RoleDuration
+}
+
+func (config *TGFConfig) assumeConfiguredRole() error {
+	duration := config.getRoleDuration()
+	token, err := config.getWebIdentityToken()
+	if err != nil {
+		return fmt.Errorf("Unable to get web identity token to assume %s: %v", config.RoleArn, err)
+	}
+	if token != "" {
+		config.tgf.Debug("# Assuming role %s with web identity for %v", config.RoleArn, duration)
+		creds, expiration, err := assumeRoleWithWebIdentity(config.RoleArn, config.getRoleSessionName(), token, duration, config.getSessionPolicy(len(config.RoleChain) == 0))
+		if err != nil {
+			return err
+		}
+		config.setAWSCredentials(creds, expiration)
+		return nil
+	}
+
+	// The credentials are cached to avoid prompting the user for an MFA code and calling STS on each invocation, the host session
+	// is not even initialized if the cached credentials are still valid
+	cacheKey := strings.Join([]string{"role", config.RoleArn, config.getRoleSessionName(), config.getProfileName(), config.MFASerial}, "|")
+	if config.getSessionPolicy(len(config.RoleChain) == 0) != nil {
+		cacheKey += "|read-only"
+	}
+	if creds, expiration, ok := readCachedCredentials(cacheKey); ok {
+		config.tgf.Debug("# Using cached credentials for role %s", config.RoleArn)
+		config.setAWSCredentials(creds, expiration)
+		return nil
+	}
+
+	if config.awsSession == nil {
+		if err := config.InitAWS(""); err != nil {
+			return fmt.Errorf("Unable to initialize AWS session to assume %s: %v", config.RoleArn, err)
+		}
+	}
+
+	if aws.StringValue(config.awsSession.Config.Region) == "" {
+		// The regional STS endpoint requires a region, we use the default region of the role partition
+		config.awsSession = config.awsSession.Copy(aws.NewConfig().WithRegion(getDefaultRegion(config.RoleArn)))
+	}
+	config.tgf.Debug("# Assuming role %s for %v", config.RoleArn, duration)
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(config.RoleArn),
+		RoleSessionName: aws.String(config.getRoleSessionName()),
+		DurationSeconds: aws.Int64(int64(duration.Seconds())),
+		Tags:            getSessionTags(config.RoleSessionTags),
+		Policy:          config.getSessionPolicy(len(config.RoleChain) == 0),
+	}
+	if config.RoleSourceIdentity != "" {
+		input.SourceIdentity = aws.String(config.RoleSourceIdentity)
+	}
+	if config.MFASerial != "" {
+		token, err := config.getMFAToken(config.MFASerial)
+		if err != nil {
+			return fmt.Errorf("Unable to get MFA code for %s: %v", config.MFASerial, err)
+		}
+		input.SerialNumber, input.TokenCode = aws.String(config.MFASerial), aws.String(token)
+	}
+	response, err := sts.New(config.awsSession, awsRetryConfig()).AssumeRole(input)
+	if err != nil {
+		return fmt.Errorf("Unable to assume role %s: %v", config.RoleArn, describeAWSError(err))
+	}
+
+	creds := credentials.Value{
+		AccessKeyID:     aws.StringValue(response.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(response.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(response.Credentials.SessionToken),
+	}
+	expiration := aws.TimeValue(response.Credentials.Expiration)
+	writeCachedCredentials(cacheKey, creds, expiration)
+	config.setAWSCredentials(creds, expiration)
+	return nil
+}
+
+// assumeRoleChain assumes the roles of the chain in sequence, each role is assumed with the credentials of the previous one
+func (config *TGFConfig) assumeRoleChain() error {
+	if len(config.RoleChain) == 0 {
+		return nil
+	}
+	if config.awsSession == nil {
+		if err := config.InitAWS(""); err != nil {
+			return fmt.Errorf("Unable to initialize AWS session to assume the role chain: %v", err)
+		}
+	}
+
+	for i, hop := range config.RoleChain {
+		if hop.RoleArn == "" {
+			return fmt.Errorf("role-chain[%d]: role-arn is required", i)
+		}
+		duration := config.getRoleDuration()
+		if (i > 0 || config.RoleArn != "") && duration > maxChainedRoleDuration {
+			duration = maxChainedRoleDuration
+		}
+		sessionName := hop.SessionName
+		if sessionName == "" {
+			sessionName = config.getRoleSessionName()
+		}
+
+		input := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(hop.RoleArn),
+			RoleSessionName: aws.String(sessionName),
+			DurationSeconds: aws.Int64(int64(duration.Seconds())),
+			Tags:            getSessionTags(hop.Tags),
+			Policy:          config.getSessionPolicy(i == len(config.RoleChain)-1),
+		}
+		if hop.ExternalID != "" {
+			input.ExternalId = aws.String(hop.ExternalID)
+		}
+		if len(hop.TransitiveTagKeys) > 0 {
+			input.TransitiveTagKeys = aws.StringSlice(hop.TransitiveTagKeys)
+		}
+		if i == 0 && config.RoleArn == "" && config.RoleSourceIdentity != "" {
+			// The source identity is propagated to the chained sessions and cannot be changed once set
+			input.SourceIdentity = aws.String(config.RoleSourceIdentity)
+		}
+
+		config.tgf.Debug("# Assuming role %s (role-chain[%d]) for %v", hop.RoleArn, i, duration)
+		response, err := sts.New(config.awsSession, awsRetryConfig()).AssumeRole(input)
+		if err != nil {
+			return fmt.Errorf("Unable to assume role %s (role-chain[%d]): %v", hop.RoleArn, i, describeAWSError(err))
+		}
+		config.setAWSCredentials(credentials.Value{
+			AccessKeyID:     aws.StringValue(response.Credentials.AccessKeyId),
+			SecretAccessKey: aws.StringValue(response.Credentials.SecretAccessKey),
+			SessionToken:    aws.StringValue(response.Credentials.SessionToken),
+		}, aws.TimeValue(response.Credentials.Expiration))
+	}
+	return nil
+}
+
+// getSessionTags converts a map of tags into STS session tags (sorted by key)
+func getSessionTags(tags map[string]string) (result []*sts.Tag) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, &sts.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return
+}
+
+// setAWSCredentials replaces the credentials of the current session and of the container environment
+func (config *TGFConfig) setAWSCredentials(creds credentials.Value, expiration time.Time) {
+	config.Environment["AWS_ACCESS_KEY_ID"] = creds.AccessKeyID
+	config.Environment["AWS_SECRET_ACCESS_KEY"] = creds.SecretAccessKey
+	config.Environment["AWS_SESSION_TOKEN"] = creds.SessionToken
+	config.Environment[credentialExpirationEnvVar] = expiration.UTC().Format(time.RFC3339)
+	config.awsExpiration = expiration
+	delete(config.Environment, "AWS_PROFILE")
+	delete(config.Environment, "AWS_DEFAULT_PROFILE")
+
+	awsConfig := aws.NewConfig()
+	if config.awsSession != nil {
+		awsConfig = config.awsSession.Config.Copy()
+	} else if region := os.Getenv("AWS_REGION"); region != "" {
+		awsConfig = awsConfig.WithRegion(region)
+	}
+	awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentialsFromCreds(creds))
+	config.awsSession = session.Must(session.NewSession(awsConfig))
+	config.awsAccount = ""
+}
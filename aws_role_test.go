@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoleSessionName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		sessionName string
+		want        string
+	}{
+		{"Configured", "deploy@ci", "deploy@ci"},
+		{"Invalid characters", "john doe/ci", "john_doe_ci"},
+		{"Too long", strings.Repeat("x", 70), strings.Repeat("x", 64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := TGFConfig{RoleSessionName: tt.sessionName}
+			assert.Equal(t, tt.want, config.getRoleSessionName())
+		})
+	}
+
+	assert.True(t, strings.HasPrefix((&TGFConfig{}).getRoleSessionName(), "tgf-"))
+}
+
+func TestRoleChainConfig(t *testing.T) {
+	t.Parallel()
+
+	content := String(`
+		role-chain:
+		  - role-arn: arn:aws:iam::111111111111:role/audit
+		    external-id: secret-id
+		    tags:
+		      team: infra
+		      repo: live
+		    transitive-tag-keys: [team]
+		  - role-arn: arn:aws:iam::222222222222:role/deploy
+	`).UnIndent().Str()
+
+	var config TGFConfig
+	assert.NoError(t, collections.ConvertData(content, &config))
+	assert.Equal(t, []RoleHop{
+		{
+			RoleArn:           "arn:aws:iam::111111111111:role/audit",
+			ExternalID:        "secret-id",
+			Tags:              map[string]string{"team": "infra", "repo": "live"},
+			TransitiveTagKeys: []string{"team"},
+		},
+		{RoleArn: "arn:aws:iam::222222222222:role/deploy"},
+	}, config.RoleChain)
+
+	tags := getSessionTags(config.RoleChain[0].Tags)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "repo", *tags[0].Key, "Tags are sorted")
+	assert.Equal(t, "infra", *tags[1].Value)
+	assert.Nil(t, getSessionTags(nil))
+}
+
+func TestAssumeConfiguredRoleCached(t *testing.T) {
+	config := TGFConfig{
+		RoleArn:         "arn:aws:iam::123456789012:role/deploy",
+		RoleSessionName: "TestAssumeConfiguredRoleCached",
+		Environment:     map[string]string{},
+		awsProfile:      "test",
+		tgf:             NewTestApplication(nil),
+	}
+	key := strings.Join([]string{"role", config.RoleArn, config.getRoleSessionName(), config.getProfileName(), config.MFASerial}, "|")
+	defer os.Remove(getCredentialsCacheFile(key))
+	writeCachedCredentials(key, credentials.Value{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "token"}, time.Now().Add(time.Hour))
+
+	assert.NoError(t, config.assumeConfiguredRole())
+	assert.False(t, config.awsSessionTried, "The host session is not initialized when the role credentials are cached")
+	assert.Equal(t, "AKIA", config.Environment["AWS_ACCESS_KEY_ID"])
+}
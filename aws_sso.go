@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// ssoProfile contains the SSO (IAM Identity Center) settings of an AWS profile
+type ssoProfile struct {
+	name, startURL, session string
+}
+
+// getSSOProfile returns the SSO settings of the profile or nil if the profile does not use SSO
+func getSSOProfile(content, profile string) *ssoProfile {
+	result := ssoProfile{
+		name:     profile,
+		startURL: getAWSProfileSetting(content, profile, "sso_start_url"),
+		session:  getAWSProfileSetting(content, profile, "sso_session"),
+	}
+	if result.startURL == "" && result.session == "" {
+		return nil
+	}
+	return &result
+}
+
+// tokenCacheFile returns the file where the AWS CLI stores the SSO access token of the profile
+func (profile ssoProfile) tokenCacheFile() string {
+	key := profile.startURL
+	if profile.session != "" {
+		key = profile.session
+	}
+	hash := sha1.Sum([]byte(key))
+	return filepath.Join(getHomeFolder(), ".aws", "sso", "cache", hex.EncodeToString(hash[:])+".json")
+}
+
+// tokenExpired returns true if there is no valid SSO access token for the profile
+func (profile ssoProfile) tokenExpired() bool {
+	var token struct {
+		ExpiresAt string `json:"expiresAt"`
+	}
+	content, err := ioutil.ReadFile(profile.tokenCacheFile())
+	if err != nil || json.Unmarshal(content, &token) != nil {
+		return true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05UTC"} {
+		if expiration, err := time.Parse(layout, token.ExpiresAt); err == nil {
+			return time.Until(expiration) < credentialsExpiryMargin
+		}
+	}
+	return true
+}
+
+// login runs the SSO device authorization flow through the AWS CLI
+func (profile ssoProfile) login() error {
+	if !prompts.interactive {
+		return fmt.Errorf("The SSO session of profile %s is expired and the login requires a terminal, run aws sso login --profile %s", profile.name, profile.name)
+	}
+	printWarning(msgSSOLogin, profile.name)
+	cmd := exec.Command("aws", "sso", "login", "--profile", profile.name)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Unable to login with SSO profile %s (AWS CLI v2 is required): %v", profile.name, err)
+	}
+	return nil
+}
+
+// credentials returns the temporary credentials resolved by the AWS CLI for the SSO profile along with their expiration
+func (profile ssoProfile) credentials() (creds credentials.Value, expiration time.Time, err error) {
+	if profile.tokenExpired() {
+		if err = profile.login(); err != nil {
+			return
+		}
+	}
+
+	output, err := exec.Command("aws", "configure", "export-credentials", "--profile", profile.name, "--format", "process").Output()
+	if err != nil {
+		err = fmt.Errorf("Unable to export the credentials of SSO profile %s (AWS CLI v2.9+ is required): %v", profile.name, err)
+		return
+	}
+	var exported struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		SessionToken    string    `json:"SessionToken"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err = json.Unmarshal(output, &exported); err != nil {
+		err = fmt.Errorf("Unable to read the credentials of SSO profile %s: %v", profile.name, err)
+		return
+	}
+	creds = credentials.Value{AccessKeyID: exported.AccessKeyID, SecretAccessKey: exported.SecretAccessKey, SessionToken: exported.SessionToken}
+	return creds, exported.Expiration, nil
+}
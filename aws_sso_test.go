@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSSOProfile(t *testing.T) {
+	t.Parallel()
+
+	content := String(`
+		[profile legacy]
+		sso_start_url = https://example.awsapps.com/start
+		sso_account_id = 123456789012
+
+		[profile dev]
+		sso_session = company
+		sso_role_name = Developer
+
+		[sso-session company]
+		sso_start_url = https://company.awsapps.com/start
+
+		[profile static]
+		aws_access_key_id = AKIA
+	`).UnIndent().Str()
+
+	assert.Equal(t, &ssoProfile{name: "legacy", startURL: "https://example.awsapps.com/start"}, getSSOProfile(content, "legacy"))
+	assert.Equal(t, &ssoProfile{name: "dev", session: "company"}, getSSOProfile(content, "dev"))
+	assert.Nil(t, getSSOProfile(content, "static"))
+	assert.Nil(t, getSSOProfile(content, "company"), "sso-session sections are not profiles")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const webIdentityAudience = "sts.amazonaws.com"
+
+// getWebIdentityToken returns the OIDC token used to assume role-arn with web identity (empty if web identity is not used).
+//
+// The token is read from web-identity-token-file or web-identity-token-env (ex: GitLab id_tokens). If none is configured and
+// tgf is running in GitHub Actions without static credentials, the token is requested from the GitHub OIDC provider.
+func (config *TGFConfig) getWebIdentityToken() (string, error) {
+	switch {
+	case config.WebIdentityTokenFile != "":
+		content, err := ioutil.ReadFile(config.WebIdentityTokenFile)
+		return strings.TrimSpace(string(content)), err
+	case config.WebIdentityTokenEnv != "":
+		token := os.Getenv(config.WebIdentityTokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("Environment variable %s is empty", config.WebIdentityTokenEnv)
+		}
+		return token, nil
+	case os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL") != "" && os.Getenv("AWS_ACCESS_KEY_ID") == "":
+		return getGitHubActionsToken(os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"), os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"))
+	}
+	return "", nil
+}
+
+// getGitHubActionsToken requests an OIDC token for AWS from GitHub Actions (the job requires the id-token: write permission)
+func getGitHubActionsToken(requestURL, requestToken string) (string, error) {
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	query.Set("audience", webIdentityAudience)
+	parsed.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+requestToken)
+	response, err := newHTTPClient(httpConfigTimeout).Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Unable to get GitHub Actions OIDC token: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get GitHub Actions OIDC token (is the id-token: write permission granted?): %s", response.Status)
+	}
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("Unable to read GitHub Actions OIDC token: %v", err)
+	}
+	return result.Value, nil
+}
+
+// assumeRoleWithWebIdentity exchanges an OIDC token for temporary credentials, no AWS credentials are required
+func assumeRoleWithWebIdentity(roleArn, sessionName, token string, duration time.Duration, policy *string) (credentials.Value, time.Time, error) {
+	region := getDefaultRegion(roleArn)
+	anonymous := session.Must(session.NewSession(aws.NewConfig().WithRegion(region).WithCredentials(credentials.AnonymousCredentials)))
+	input := &sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(roleArn),
+		RoleSessionName:  aws.String(sessionName),
+		WebIdentityToken: aws.String(token),
+		Policy:           policy,
+	}
+	if duration > 0 {
+		input.DurationSeconds = aws.Int64(int64(duration.Seconds()))
+	}
+	response, err := sts.New(anonymous, awsRetryConfig()).AssumeRoleWithWebIdentity(input)
+	if err != nil {
+		return credentials.Value{}, time.Time{}, fmt.Errorf("Unable to assume role %s with web identity: %v", roleArn, describeAWSError(err))
+	}
+	creds := credentials.Value{
+		AccessKeyID:     aws.StringValue(response.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(response.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(response.Credentials.SessionToken),
+	}
+	return creds, aws.TimeValue(response.Credentials.Expiration), nil
+}
+
+// getEnvironmentWebIdentity returns the role and token defined through the standard AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE
+// variables (used by EKS and most CI systems)
+func getEnvironmentWebIdentity() (roleArn, sessionName, token string, err error) {
+	roleArn, tokenFile := os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleArn == "" || tokenFile == "" || os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		return "", "", "", nil
+	}
+	content, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", "", "", err
+	}
+	sessionName = os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "tgf"
+	}
+	return roleArn, sessionName, strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGitHubActionsToken(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "sts.amazonaws.com", r.URL.Query().Get("audience"))
+		assert.Equal(t, "1", r.URL.Query().Get("api-version"))
+		w.Write([]byte(`{"value": "oidc-token"}`))
+	}))
+	defer server.Close()
+
+	token, err := getGitHubActionsToken(server.URL+"?api-version=1", "request-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "oidc-token", token)
+
+	_, err = getGitHubActionsToken(server.URL, "invalid")
+	assert.Error(t, err)
+}
+
+func TestGetWebIdentityToken(t *testing.T) {
+	t.Parallel()
+
+	file := must(ioutil.TempFile("", "tgf-token")).(*os.File)
+	defer os.Remove(file.Name())
+	file.WriteString("file-token\n")
+	file.Close()
+	os.Setenv("TGF_TEST_OIDC_TOKEN", "env-token")
+	defer os.Unsetenv("TGF_TEST_OIDC_TOKEN")
+
+	token, err := (&TGFConfig{WebIdentityTokenFile: file.Name()}).getWebIdentityToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token", token)
+
+	token, err = (&TGFConfig{WebIdentityTokenEnv: "TGF_TEST_OIDC_TOKEN"}).getWebIdentityToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "env-token", token)
+
+	_, err = (&TGFConfig{WebIdentityTokenEnv: "TGF_TEST_UNDEFINED_TOKEN"}).getWebIdentityToken()
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// getRemoteConfigCacheFile returns the name of the file used to cache the remote configuration identified by key
+func getRemoteConfigCacheFile(key string) string {
+	return filepath.Join(getCacheFolder(), "config-cache", util.EncodeBase64Sha1(key))
+}
+
+// cachedRemoteConfig returns the content of a remote configuration source through a local cache.
+//
+// The source is fetched only if the cached copy is older than the configured TTL. If the source cannot be reached, the
+// cached copy is used (whatever its age) with a warning, so that network or AWS outages do not block local work.
+func (config *TGFConfig) cachedRemoteConfig(key string, fetch func() (string, error)) (string, error) {
+	app := config.tgf
+	filename := getRemoteConfigCacheFile(key)
+	info, statErr := os.Stat(filename)
+	if statErr == nil && time.Since(info.ModTime()) < app.RemoteConfigTTL {
+		if content, err := ioutil.ReadFile(filename); err == nil {
+			app.Debug("# Using cached configuration for %s\n", key)
+			runStats.cacheLookup(true)
+			return string(content), nil
+		}
+	}
+	runStats.cacheLookup(false)
+
+	endFetch := timings.begin("remote config")
+	content, err := fetch()
+	endFetch()
+	if err != nil {
+		if statErr != nil {
+			return "", err
+		}
+		cached, readErr := ioutil.ReadFile(filename)
+		if readErr != nil {
+			return "", err
+		}
+		printWarning(msgRemoteConfigCached, key, info.ModTime().Format(time.RFC1123), err)
+		return string(cached), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err == nil {
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			app.Debug("# Unable to cache configuration for %s: %v\n", key, err)
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedRemoteConfig(t *testing.T) {
+	key := fmt.Sprintf("test://TestCachedRemoteConfig/%v", randInt())
+	defer os.Remove(getRemoteConfigCacheFile(key))
+
+	app := &TGFApplication{RemoteConfigTTL: time.Hour}
+	config := &TGFConfig{tgf: app}
+	calls := 0
+	fetch := func(content string, err error) func() (string, error) {
+		return func() (string, error) { calls++; return content, err }
+	}
+
+	_, err := config.cachedRemoteConfig(key, fetch("", errors.New("unreachable")))
+	assert.Error(t, err, "No cache available")
+
+	content, err := config.cachedRemoteConfig(key, fetch("v1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", content)
+
+	content, err = config.cachedRemoteConfig(key, fetch("v2", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", content, "The cached copy is used within the TTL")
+	assert.Equal(t, 2, calls)
+
+	app.RemoteConfigTTL = 0
+	content, err = config.cachedRemoteConfig(key, fetch("", errors.New("unreachable")))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", content, "The cached copy is used if the source is unreachable")
+
+	content, err = config.cachedRemoteConfig(key, fetch("v2", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", content, "The source is fetched once the TTL is expired")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// CI systems detected by tgf
+const (
+	ciGeneric       = "generic"
+	ciGitHubActions = "github-actions"
+	ciGitLab        = "gitlab"
+)
+
+// ciSystems are detected from the variables defined by their runners (the first match wins, CI is defined by most of them)
+var ciSystems = []struct{ name, variable string }{
+	{ciGitHubActions, "GITHUB_ACTIONS"},
+	{ciGitLab, "GITLAB_CI"},
+	{"azure-pipelines", "TF_BUILD"},
+	{"bitbucket", "BITBUCKET_BUILD_NUMBER"},
+	{"buildkite", "BUILDKITE"},
+	{"circleci", "CIRCLECI"},
+	{"codebuild", "CODEBUILD_BUILD_ID"},
+	{"jenkins", "JENKINS_URL"},
+	{"teamcity", "TEAMCITY_VERSION"},
+	{ciGeneric, "CI"},
+}
+
+// ciSystem is the CI system running tgf (empty if tgf is not running in CI or if --no-ci is set)
+var ciSystem string
+
+// detectCI returns the CI system running tgf (empty if none is detected)
+func detectCI() string {
+	for _, system := range ciSystems {
+		if value, found := os.LookupEnv(system.variable); found && value != "" && !(system.name == ciGeneric && !String(value).ParseBool()) {
+			return system.name
+		}
+	}
+	return ""
+}
+
+// setCIMode enables (or disables) the adaptations to CI pipelines: no TTY, no prompt, no progress, collapsible log sections and
+// masked secrets
+func (app *TGFApplication) setCIMode() {
+	ciSystem = ""
+	if app.CI {
+		if ciSystem = detectCI(); ciSystem == "" {
+			ciSystem = ciGeneric
+		}
+		app.Debug("# Running in CI (%s)", ciSystem)
+	}
+}
+
+// isCI returns true if the adaptations to CI pipelines are enabled
+func isCI() bool { return ciSystem != "" }
+
+// logGroup starts a collapsible section in the CI log and returns the function ending it (GitHub Actions groups and GitLab sections
+// are supported, nothing is done otherwise)
+func logGroup(title string) func() {
+	return writeLogGroup(os.Stderr, ciSystem, title, time.Now)
+}
+
+func writeLogGroup(w io.Writer, system, title string, now func() time.Time) func() {
+	switch system {
+	case ciGitHubActions:
+		fmt.Fprintf(w, "::group::%s\n", escapeGitHubData(title))
+		return func() { fmt.Fprintln(w, "::endgroup::") }
+	case ciGitLab:
+		// https://docs.gitlab.com/ee/ci/jobs/#custom-collapsible-sections
+		name := fmt.Sprintf("tgf_%d", now().UnixNano())
+		fmt.Fprintf(w, "\033[0Ksection_start:%d:%s[collapsed=true]\r\033[0K%s\n", now().Unix(), name, title)
+		return func() { fmt.Fprintf(w, "\033[0Ksection_end:%d:%s\r\033[0K\n", now().Unix(), name) }
+	}
+	return func() {}
+}
+
+// maskCISecrets asks the CI system to mask the values of the secret variables supplied to the container, the credentials resolved by
+// tgf (i.e. assumed roles) are not known by the CI and would be printed as is (only GitHub Actions supports it, the other systems
+// only mask the variables defined in their settings)
+func maskCISecrets(w io.Writer, environment map[string]string) {
+	if ciSystem != ciGitHubActions {
+		return
+	}
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := environment[key]; maskSecret(key, value) != value {
+			fmt.Fprintf(w, "::add-mask::%s\n", escapeGitHubData(value))
+		}
+	}
+}
+
+// maskCIValue returns the value that can be printed in the logs, the secrets are masked in CI since the logs are often readable by
+// more people than the secrets
+func maskCIValue(name, value string) string {
+	if isCI() {
+		return maskSecret(name, value)
+	}
+	return value
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clearCIEnvironment removes the variables used to detect the CI systems and returns the function restoring them
+func clearCIEnvironment() func() {
+	saved := make(map[string]string)
+	for _, system := range ciSystems {
+		if value, found := os.LookupEnv(system.variable); found {
+			saved[system.variable] = value
+			os.Unsetenv(system.variable)
+		}
+	}
+	return func() {
+		for _, system := range ciSystems {
+			os.Unsetenv(system.variable)
+		}
+		for variable, value := range saved {
+			os.Setenv(variable, value)
+		}
+	}
+}
+
+func TestDetectCI(t *testing.T) {
+	defer clearCIEnvironment()()
+
+	tests := []struct {
+		name        string
+		environment map[string]string
+		want        string
+	}{
+		{"Not in CI", nil, ""},
+		{"CI disabled", map[string]string{"CI": "false"}, ""},
+		{"Generic", map[string]string{"CI": "true"}, ciGeneric},
+		{"GitHub Actions", map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, ciGitHubActions},
+		{"GitLab", map[string]string{"CI": "true", "GITLAB_CI": "true"}, ciGitLab},
+		{"Jenkins", map[string]string{"JENKINS_URL": "https://jenkins.example.com/"}, "jenkins"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer clearCIEnvironment()()
+			for variable, value := range tt.environment {
+				os.Setenv(variable, value)
+			}
+			assert.Equal(t, tt.want, detectCI())
+		})
+	}
+}
+
+func TestCIFlag(t *testing.T) {
+	defer clearCIEnvironment()()
+	defer func() { ciSystem = "" }()
+
+	NewTestApplication(nil)
+	assert.False(t, isCI(), "Not detected")
+
+	os.Setenv("GITLAB_CI", "true")
+	app := NewTestApplication(nil)
+	assert.True(t, app.CI)
+	assert.Equal(t, ciGitLab, ciSystem)
+
+	NewTestApplication([]string{"--no-ci"})
+	assert.False(t, isCI(), "Disabled by --no-ci")
+
+	os.Unsetenv("GITLAB_CI")
+	NewTestApplication([]string{"--ci"})
+	assert.Equal(t, ciGeneric, ciSystem, "Forced by --ci")
+}
+
+func TestWriteLogGroup(t *testing.T) {
+	t.Parallel()
+
+	now := func() time.Time { return time.Unix(1700000000, 42) }
+	tests := []struct {
+		system string
+		want   string
+	}{
+		{"", "output\n"},
+		{ciGeneric, "output\n"},
+		{ciGitHubActions, "::group::Refreshing image coveo/tgf:1.21.0\noutput\n::endgroup::\n"},
+		{ciGitLab, "\033[0Ksection_start:1700000000:tgf_1700000000000000042[collapsed=true]\r\033[0KRefreshing image coveo/tgf:1.21.0\noutput\n" +
+			"\033[0Ksection_end:1700000000:tgf_1700000000000000042\r\033[0K\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.system, func(t *testing.T) {
+			var buffer bytes.Buffer
+			end := writeLogGroup(&buffer, tt.system, "Refreshing image coveo/tgf:1.21.0", now)
+			buffer.WriteString("output\n")
+			end()
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestMaskCISecrets(t *testing.T) {
+	defer func(system string) { ciSystem = system }(ciSystem)
+	environment := map[string]string{"AWS_SESSION_TOKEN": "token", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_REGION": "us-east-1"}
+
+	var buffer bytes.Buffer
+	ciSystem = ciGitLab
+	maskCISecrets(&buffer, environment)
+	assert.Empty(t, buffer.String(), "Only supported by GitHub Actions")
+	assert.Equal(t, maskedValue, maskCIValue("AWS_SESSION_TOKEN", "token"))
+
+	ciSystem = ciGitHubActions
+	maskCISecrets(&buffer, environment)
+	assert.Equal(t, "::add-mask::secret\n::add-mask::token\n", buffer.String())
+
+	ciSystem = ""
+	assert.Equal(t, "token", maskCIValue("AWS_SESSION_TOKEN", "token"))
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/errors"
 	"github.com/coveooss/gotemplate/v3/hcl"
 	"github.com/coveooss/gotemplate/v3/template"
 	"github.com/coveord/kingpin/v2"
 	"github.com/fatih/color"
+	"github.com/gruntwork-io/terragrunt/util"
 )
 
 const description = `@color("underline", "DESCRIPTION:")
@@ -33,6 +35,16 @@ Terragrunt documentation could be found at @terragruntCoveo (Coveo fork)
 
 Terraform documentation could be found at @(terraform).
 
+@color("underline", "COMMANDS:")
+Any argument that is not a tgf command or a tgf argument is passed to the entry point (tgf plan ==> terragrunt plan).
+
+  @autoIndent(commands)
+
+@color("underline", "EXIT CODES:")
+The exit code of the entry point is returned as is, the following codes are returned if tgf fails by itself:
+
+  @autoIndent(exitCodes)
+
 @color("underline", "ENVIRONMENT VARIABLES:")
 Most of the arguments can be set through environment variables using the format TGF_ARG_NAME.
 
@@ -71,37 +83,89 @@ const (
 // TGFApplication allows proper management between managed and non managed arguments provided to kingpin
 type TGFApplication struct {
 	*kingpin.Application
+	Arguments         []string
+	AssumeYes         bool
 	AwsProfile        string
+	AwsRegion         string
+	CI                bool
+	Color             string
 	ConfigFiles       string
+	ConfigDump        bool
+	ConfigLint        bool
 	ConfigLocation    string
+	ConfigToken       string
+	ConfigMigrate     bool
+	DebugBundle       bool
 	DebugMode         bool
 	DisableUserConfig bool
 	DockerBuild       bool
 	DockerInteractive bool
 	DockerOptions     []string
+	DryRun            bool
+	ExportCredentials string
+	ExportProfile     string
 	Entrypoint        string
+	Events            string
 	FlushCache        bool
+	Foreach           string
+	ForeachJobs       int
+	ForeachReport     string
 	GetAllVersions    bool
 	GetCurrentVersion bool
 	GetImageName      bool
 	Image             string
 	ImageTag          string
 	ImageVersion      string
+	InitConfig        bool
+	InstanceProfile   bool
+	ListImages        bool
+	LogFormat         string
+	LogLevel          string
+	LogToFile         bool
+	WriteLock         bool
+	Locked            bool
 	LoggingLevel      string
+	MessageFormat     string
+	MetadataFile      string
 	MountHomeDir      bool
 	MountPoint        string
 	MountTempDir      bool
+	NotifyAfter       time.Duration
+	PRComment         bool
+	PRCommentFile     string
+	PickImage         bool
+	PrintPaths        bool
+	PromptUser        bool
 	PruneImages       bool
 	PsPath            string
+	Quiet             bool
+	ReadOnlyState     bool
 	Refresh           bool
+	RefreshOnly       bool
+	RemoteConfigTTL   time.Duration
+	RemoveImages      []string
+	RequiredCredTTL   time.Duration
+	Runner            string
+	SetValues         []string
+	StrictLint        bool
+	StrictOutput      bool
+	Timings           bool
+	TimingsFile       string
 	UseAWS            bool
 	UseLocalImage     bool
+	VerifyIntegrity   bool
+	Watch             bool
+	WatchDelay        time.Duration
+	WatchIgnore       []string
+	WhoHoldsLock      bool
 	WithCurrentUser   bool
 	WithDockerMount   bool
 }
 
 // NewTGFApplication returns an initialized copy of TGFApplication along with the parsed CLI arguments
 func NewTGFApplication(args []string) *TGFApplication {
+	// The color mode must be known before the description is rendered
+	configureColor(os.Getenv("TGF_COLOR"))
 	d := formatDescription()
 	base := kingpin.New("tgf", d).Author("Coveo").AllowUnmanaged().AutoShortcut().InitOnlyOnce().DefaultEnvars().UsageWriter(color.Output)
 	base.DeleteFlag("help")
@@ -114,35 +178,103 @@ func NewTGFApplication(args []string) *TGFApplication {
 	app.Flag("image", "Use the specified image instead of the default one").PlaceHolder("coveo/tgf").NoAutoShortcut().StringVar(&app.Image)
 	app.Flag("image-version", "Use a different version of docker image instead of the default one").PlaceHolder("version").Default("-").StringVar(&app.ImageVersion)
 	app.Flag("tag", "Use a different tag of docker image instead of the default one").Short('T').NoAutoShortcut().PlaceHolder("latest").Default("-").StringVar(&app.ImageTag)
+	app.Flag("pick-image", "Interactively select the image version among the local images matching the configuration (ignored if there is no terminal)").NoAutoShortcut().BoolVar(&app.PickImage)
+	app.Flag("verify-integrity", "Warn if the tgf executable does not match the checksum embedded in the released binary (modified or truncated file)").NoAutoShortcut().BoolVar(&app.VerifyIntegrity)
 	app.Flag("local-image", "If set, TGF will not pull the image when refreshing").BoolVar(&app.UseLocalImage)
 	app.Flag("get-image-name", "Just return the resulting image name").Alias("gi").BoolVar(&app.GetImageName)
 	app.Flag("refresh-image", "Force a refresh of the docker image").BoolVar(&app.Refresh)
 	app.Flag("entrypoint", "Override the entry point for docker").Short('E').PlaceHolder("terragrunt").StringVar(&app.Entrypoint)
-	app.Flag("current-version", "Get current version information").BoolVar(&app.GetCurrentVersion)
+	app.Flag("current-version", "Get current version information (add --json to get the build metadata as JSON)").Alias("version").BoolVar(&app.GetCurrentVersion)
 	app.Flag("all-versions", "Get versions of TGF & all others underlying utilities").BoolVar(&app.GetAllVersions)
+	app.Flag("config-dump", "Print the resolved configuration and the source of each value as JSON (secrets are masked)").BoolVar(&app.ConfigDump)
+	app.Flag("config-lint", "Report the dangerous settings of the resolved configuration (privileged mode, docker socket, unpinned image)").NoAutoShortcut().BoolVar(&app.ConfigLint)
+	app.Flag("lint-strict", "Exit with an error if --config-lint reports any issue").NoAutoShortcut().BoolVar(&app.StrictLint)
+	app.Flag("config-migrate", "Replace the deprecated keys in the configuration files of the current folder and its parents").BoolVar(&app.ConfigMigrate)
+	app.Flag("paths", "Print the folders and files used by tgf (configuration, cache)").NoAutoShortcut().BoolVar(&app.PrintPaths)
+	app.Flag("write-lock", "Write the resolved image digest, tgf version and configuration to "+lockFile+" (same as tgf lock)").NoAutoShortcut().BoolVar(&app.WriteLock)
+	app.Flag("locked", "Fail if the resolved image digest, tgf version or configuration differ from "+lockFile).NoAutoShortcut().BoolVar(&app.Locked)
+	app.Flag("init-config", "Interactively create a starter "+configFile+" file").BoolVar(&app.InitConfig)
 	app.Flag("logging-level", "Set the logging level (critical=0, error=1, warning=2, notice=3, info=4, debug=5, full=6)").Short('L').PlaceHolder("<level>").StringVar(&app.LoggingLevel)
+	app.Flag("tgf-log-format", "Set the format of tgf diagnostics (text or json), the output of the entry point is not affected and --log-format is passed to it").Envar("TGF_LOG_FORMAT").PlaceHolder("<format>").Default(logFormatText).NoAutoShortcut().EnumVar(&app.LogFormat, logFormatText, logFormatJSON)
+	app.Flag("message-format", "Set whether the ID of the tgf messages is printed before their text (text or id), the IDs are stable and can be matched by tools").PlaceHolder("<format>").Default(messageFormatText).NoAutoShortcut().EnumVar(&app.MessageFormat, messageFormatText, messageFormatID)
+	app.Flag("tgf-log-level", "Set the verbosity of tgf diagnostics (trace, debug, info, warn, error), --log-level is passed to the entry point").Envar("TGF_LOG").PlaceHolder("<level>").Default("info").NoAutoShortcut().EnumVar(&app.LogLevel, "trace", "debug", "info", "warn", "warning", "error")
+	app.Flag("log-to-file", "Keep a copy of tgf diagnostics (debug level) in a rotating log file under the cache folder").NoAutoShortcut().BoolVar(&app.LogToFile)
+	app.Flag("color", "Set when ANSI colors are used in tgf output (auto, always, never), auto disables colors if NO_COLOR is set or if the output is not a terminal").PlaceHolder("<mode>").Default(colorAuto).NoAutoShortcut().EnumVar(&app.Color, colorAuto, colorAlways, colorNever)
+	app.Flag("quiet", "Do not print any tgf output (notices, image refresh, usage), only the output of the entry point and tgf errors are printed").NoAutoShortcut().BoolVar(&app.Quiet)
+	app.Flag("strict-output", "Guarantee that only the entry point writes on stdout, the output of tgf and of the run-before/run-after scripts is redirected to stderr").NoAutoShortcut().BoolVar(&app.StrictOutput)
+	app.Flag("timings", "Print the time spent in each phase of the run (configuration, credentials, image refresh, container)").NoAutoShortcut().BoolVar(&app.Timings)
+	app.Flag("timings-file", "Write the time spent in each phase of the run as JSON in the specified file").PlaceHolder("<file>").NoAutoShortcut().StringVar(&app.TimingsFile)
+	app.Flag("events", "Write the lifecycle events of the run (phases, messages, exit code) as JSON lines on the Unix socket or named pipe").PlaceHolder("<path>").NoAutoShortcut().StringVar(&app.Events)
+	app.Flag("dry-run", "Resolve the configuration, the image and the environment, then print the docker command instead of running it (secrets are masked)").NoAutoShortcut().BoolVar(&app.DryRun)
+	app.Flag("foreach", "Run the command in every terragrunt folder matching the glob pattern (ex: 'live/*/*') and print a summary of the exit codes").PlaceHolder("<pattern>").NoAutoShortcut().StringVar(&app.Foreach)
+	app.Flag("foreach-jobs", "Maximum number of folders where the command is run at the same time with --foreach or run-ordered").PlaceHolder("<count>").Default("4").NoAutoShortcut().IntVar(&app.ForeachJobs)
+	app.Flag("foreach-report", "Write a JUnit XML report of the folders (one test case per folder) with --foreach or run-ordered").PlaceHolder("<file>").NoAutoShortcut().StringVar(&app.ForeachReport)
+	app.Flag("watch", "Run the command again each time files are changed under the current folder (until tgf is interrupted)").NoAutoShortcut().BoolVar(&app.Watch)
+	app.Flag("watch-delay", "Time without other change to wait before running the command again with --watch").PlaceHolder("<duration>").Default("500ms").NoAutoShortcut().DurationVar(&app.WatchDelay)
+	app.Flag("watch-ignore", "Pattern of the files or folders that do not trigger a new run with --watch (.git, .terraform and .terragrunt-cache are always ignored)").PlaceHolder("<pattern>").NoAutoShortcut().StringsVar(&app.WatchIgnore)
+	app.Flag("notify-after", "Display a desktop notification (or ring the terminal bell) when a run lasting more than the specified duration is completed").PlaceHolder("<duration>").NoAutoShortcut().DurationVar(&app.NotifyAfter)
+	app.Flag("debug-bundle", "Write a zip file with the resolved configuration (secrets masked), the environment checks, the recent logs and the versions to attach to an issue").NoAutoShortcut().BoolVar(&app.DebugBundle)
+	app.Flag("metadata-file", "Write a JSON summary of the run (tgf version, image digest, exit code, duration, AWS account, folder) in the specified file").PlaceHolder("<file>").NoAutoShortcut().StringVar(&app.MetadataFile)
+	app.Flag("pr-comment", "Print the output of the command as collapsible markdown with a summary of the resource changes (to post it as a pull request comment), the raw output is printed on stderr").NoAutoShortcut().BoolVar(&app.PRComment)
+	app.Flag("pr-comment-file", "Write the output of the command as collapsible markdown with a summary of the resource changes in the specified file").PlaceHolder("<file>").NoAutoShortcut().StringVar(&app.PRCommentFile)
 	app.Flag("debug-docker", "Print the docker command issued").Short('D').BoolVar(&app.DebugMode)
 	app.Flag("flush-cache", "Invoke terragrunt with --terragrunt-update-source to flush the cache").Short('F').BoolVar(&app.FlushCache)
+	app.Flag("ci", "Adapt the behavior to CI pipelines (no TTY, no prompt, no progress, collapsible log sections, masked secrets), ON if a CI system is detected, use --no-ci to disable").Default(fmt.Sprint(detectCI() != "")).NoAutoShortcut().BoolVar(&app.CI)
 	swFlagON("interactive", "Launch Docker in interactive mode").Alias("it").BoolVar(&app.DockerInteractive)
+	swFlagON("input", "Prompt the user when an answer is required (MFA code, confirmations, selections), it is never done if there is no terminal").NoAutoShortcut().BoolVar(&app.PromptUser)
+	app.Flag("yes", "Answer yes to all the confirmations (required to confirm an action if the user cannot be prompted)").NoAutoShortcut().BoolVar(&app.AssumeYes)
 	swFlagON("docker-build", "Enable docker build instructions configured in the config files").BoolVar(&app.DockerBuild)
 	swFlagON("home", "Enable mapping of the home directory").BoolVar(&app.MountHomeDir)
 	swFlagON("temp", "Map the temp folder to a local folder").BoolVar(&app.MountTempDir)
+	app.Flag("runner", "Run the command with the local docker daemon (default), in an ECS task on AWS Fargate, in a pod of a kubernetes cluster, on a remote docker host over SSH or with a runner plugin").PlaceHolder("<runner>").NoAutoShortcut().StringVar(&app.Runner)
 	app.Flag("mount-point", "Specify a mount point for the current folder").PlaceHolder("<folder>").StringVar(&app.MountPoint)
 	app.Flag("prune", "Remove all previous versions of the targeted image").BoolVar(&app.PruneImages)
 	app.Flag("docker-arg", "Supply extra argument to Docker").PlaceHolder("<opt>").StringsVar(&app.DockerOptions)
 	app.Flag("with-current-user", "Runs the docker command with the current user, using the --user arg").Alias("cu").BoolVar(&app.WithCurrentUser)
 	app.Flag("with-docker-mount", "Mounts the docker socket to the image so the host's docker api is usable").Alias("wd", "dm").BoolVar(&app.WithDockerMount)
 	app.Flag("ignore-user-config", "Ignore all tgf.user.config files").Alias("iu", "iuc").NoAutoShortcut().BoolVar(&app.DisableUserConfig)
+	app.Flag("require-min-credential-ttl", "Refuse to run if the AWS credentials expire in less than the specified duration").PlaceHolder("<duration>").DurationVar(&app.RequiredCredTTL)
+	app.Flag("instance-credentials", "Resolve the EC2 instance profile credentials (IMDSv2) on the host and supply them to the container").NoAutoShortcut().BoolVar(&app.InstanceProfile)
+	app.Flag("export-credentials", "Print the resolved AWS credentials (env, json) or write them in the AWS shared credentials file (shared-file)").PlaceHolder("<format>").EnumVar(&app.ExportCredentials, "env", "json", "shared-file")
+	app.Flag("export-profile", "Profile written by --export-credentials=shared-file").Default("tgf").PlaceHolder("<profile>").StringVar(&app.ExportProfile)
+	app.Flag("who-holds-lock", "Show who holds the terraform state lock of the current terragrunt folder (S3 backend with DynamoDB lock table)").NoAutoShortcut().BoolVar(&app.WhoHoldsLock)
 	swFlagON("aws", "Use AWS Parameter store to get configuration").BoolVar(&app.UseAWS)
 	app.Flag("profile", "Set the AWS profile configuration to use").Short('P').NoAutoShortcut().PlaceHolder("<AWS profile>").StringVar(&app.AwsProfile)
+	app.Flag("aws-region", "Set the AWS region used by tgf and supplied to the container (overrides the profile region)").PlaceHolder("<region>").StringVar(&app.AwsRegion)
 	app.Flag("ssm-path", "Parameter Store path used to find AWS common configuration shared by a team").PlaceHolder("<path>").Default(defaultSSMParameterFolder).StringVar(&app.PsPath)
 	app.Flag("config-files", "Set the files to look for (default: "+remoteDefaultConfigPath+")").PlaceHolder("<files>").StringVar(&app.ConfigFiles)
 	app.Flag("config-location", "Set the configuration location").PlaceHolder("<path>").StringVar(&app.ConfigLocation)
+	app.Flag("config-token", "Bearer token used to fetch the configuration from an HTTP(S) location").PlaceHolder("<token>").NoAutoShortcut().StringVar(&app.ConfigToken)
+	app.Flag("set", "Override a configuration key (ex: --set docker-image-tag=k8s, --set environment.VAR=value)").PlaceHolder("<key=value>").StringsVar(&app.SetValues)
+	app.Flag("remote-config-ttl", "Delay during which the cached remote configuration (SSM, config location, imports) is used without being refreshed").PlaceHolder("<duration>").Default("5m").DurationVar(&app.RemoteConfigTTL)
 
 	kingpin.CommandLine = app.Application
 	kingpin.HelpFlag = app.GetFlag("help-tgf")
 
+	app.Arguments = args
 	app.Parse(args)
+	configureColor(app.Color)
+	logFormat = app.LogFormat
+	messageFormat = app.MessageFormat
+	currentLogLevel, _ = parseLogLevel(app.LogLevel)
+	if app.DebugMode && currentLogLevel < logLevelDebug {
+		// --debug-docker implies the debug level
+		currentLogLevel = logLevelDebug
+	}
+	if app.Quiet {
+		currentLogLevel = logLevelError
+	}
+	app.DebugMode = currentLogLevel >= logLevelDebug
+	app.setCIMode()
+	prompts = newPrompter(os.Stdin, os.Stderr, app.PromptUser && !isCI() && isTerminal(os.Stdin), app.AssumeYes)
+	if app.LogToFile {
+		if file, err := openLogFile(getLogFile()); err != nil {
+			printWarning(msgLogFileFailed, getLogFile(), err)
+		} else {
+			logFile = file
+			writeLogFile(logLevelInfo, "tgf", fmt.Sprintf("tgf %s started in %s with arguments: %s", version, must(os.Getwd()), strings.Join(args, " ")))
+		}
+	}
 	return &app
 }
 
@@ -155,6 +287,8 @@ func formatDescription() string {
 		"parameterStoreKey": defaultSSMParameterFolder,
 		"config":            configFile,
 		"options":           getTgfConfigFields(),
+		"commands":          getSubcommandsHelp(),
+		"exitCodes":         getExitCodesHelp(),
 		"readme":            link(gitSource + "/blob/master/README.md"),
 		"latest":            link(gitSource + "/releases/latest"),
 		"terragruntCoveo":   link("https://github.com/coveo/terragrunt/blob/master/README.md"),
@@ -186,9 +320,12 @@ func (app *TGFApplication) Parse(args []string) (command string, err error) {
 
 // Debug print debug information
 func (app *TGFApplication) Debug(format string, args ...interface{}) {
-	if app.DebugMode {
-		ErrPrintf(color.HiBlackString(format+"\n", args...))
-	}
+	logMessage(logLevelDebug, "tgf", nil, format, args...)
+}
+
+// Trace print detailed diagnostic information (only printed with --tgf-log-level=trace)
+func (app *TGFApplication) Trace(format string, args ...interface{}) {
+	logMessage(logLevelTrace, "tgf", nil, format, args...)
 }
 
 // ShowHelp simply display the help context and quit execution
@@ -204,9 +341,80 @@ func (app *TGFApplication) ShowHelp(c *kingpin.ParseContext) error {
 
 // Run execute the application
 func (app *TGFApplication) Run() int {
+	app.startEvents()
+	if app.VerifyIntegrity {
+		checkIntegrity()
+	}
+	if len(app.Unmanaged) > 0 {
+		if command := getSubcommand(app.Unmanaged[0]); command != nil {
+			return command.execute(app, app.Unmanaged[1:])
+		}
+	}
+	return app.run()
+}
+
+func (app *TGFApplication) run() int {
 	if app.GetCurrentVersion {
-		Printf("tgf v%s\n", version)
-		return 0
+		// --json is not a tgf argument since it would be taken from the entry point (terraform output --json)
+		return printVersion(util.ListContainsElement(app.Unmanaged, "--json") || util.ListContainsElement(app.Unmanaged, "-json"))
+	}
+	if app.PrintPaths {
+		return printPaths()
+	}
+	if app.InitConfig {
+		return runInitWizard()
+	}
+	if app.ConfigMigrate {
+		return migrateConfigFiles(app)
+	}
+	if app.DebugBundle {
+		return runDebugBundle(app)
+	}
+	if app.Foreach != "" {
+		return app.runForeach()
+	}
+	if app.Watch {
+		return app.watch()
+	}
+	return app.runOnce()
+}
+
+// runOnce loads the configuration and runs the command
+func (app *TGFApplication) runOnce() int {
+	start := time.Now()
+	endConfiguration := timings.begin("configuration")
+	config := InitConfig(app)
+	endConfiguration()
+	exitCode := config.Run()
+	app.reportTimings()
+	if app.MetadataFile != "" {
+		config.writeMetadata(app.MetadataFile, start, exitCode)
+	}
+	if app.NotifyAfter > 0 {
+		config.notifyCompletion(time.Since(start), exitCode)
+	}
+	if config.AuditLog != "" {
+		config.writeAuditLog(start, exitCode)
+	}
+	if config.commandOutput != nil && config.runImage != "" {
+		if config.isPlanArchived() {
+			config.archivePlanArtifacts(start, exitCode)
+		}
+		if app.PRComment || app.PRCommentFile != "" {
+			config.writePRComment(start, exitCode)
+		}
+	}
+	if config.Metrics.enabled() && config.runImage != "" {
+		config.sendMetrics(start, exitCode)
+	}
+	if len(config.Webhooks) > 0 && config.runImage != "" {
+		config.sendRunWebhooks(start, exitCode)
+	}
+	if isGitHubActions() && config.runImage != "" {
+		config.writeGitHubSummary(start, exitCode)
+	}
+	if tracing.enabled() {
+		tracing.export(config.getRunMetadata(start, exitCode))
 	}
-	return InitConfig(app).Run()
+	return exitCode
 }
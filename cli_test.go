@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -20,6 +21,13 @@ func NewTestApplication(args []string) *TGFApplication {
 	return NewTGFApplication(args)
 }
 
+// skipOnWindows skips the tests relying on the Unix tools (shell scripts used as fake programs, Unix sockets)
+func skipOnWindows(t *testing.T, reason string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Not supported on Windows: " + reason)
+	}
+}
+
 func TestNewApplicationWithOptionsAndAliases(t *testing.T) {
 	t.Parallel()
 
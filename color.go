@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// Modes supported by --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// configureColor enables or disables the ANSI colors of tgf output.
+//
+// In auto mode, colors are disabled if NO_COLOR is defined (see https://no-color.org), if TERM is dumb or if the diagnostics are
+// not written to a terminal (CI logs, log aggregation).
+func configureColor(mode string) {
+	switch mode {
+	case colorAlways:
+		color.NoColor = false
+	case colorNever:
+		color.NoColor = true
+	default:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		color.NoColor = noColor || os.Getenv("TERM") == "dumb" || !isTerminal(os.Stderr)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureColor(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	if value, defined := os.LookupEnv("NO_COLOR"); defined {
+		defer os.Setenv("NO_COLOR", value)
+	} else {
+		defer os.Unsetenv("NO_COLOR")
+	}
+
+	configureColor(colorAlways)
+	assert.False(t, color.NoColor)
+	assert.Equal(t, "\x1b[33mwarning\x1b[0m", warningString("warning"))
+
+	configureColor(colorNever)
+	assert.True(t, color.NoColor)
+	assert.Equal(t, "warning", warningString("warning"))
+
+	os.Setenv("NO_COLOR", "1")
+	configureColor(colorAuto)
+	assert.True(t, color.NoColor)
+
+	// Tests are not run in a terminal
+	os.Unsetenv("NO_COLOR")
+	configureColor(colorAuto)
+	assert.Equal(t, !isTerminal(os.Stderr), color.NoColor)
+}
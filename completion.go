@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coveord/kingpin/v2"
+)
+
+const (
+	completionCommand = "completion"
+	completionValues  = "__values"
+)
+
+// Kinds of values that are resolved when the completion is requested (they depend on the user environment)
+const (
+	completionAliases     = "aliases"
+	completionEntrypoints = "entrypoints"
+	completionProfiles    = "profiles"
+	completionRunners     = "runners"
+)
+
+// completionDynamicFlags associates the flags with the kind of values resolved at completion time
+var completionDynamicFlags = map[string]string{
+	"entrypoint": completionEntrypoints,
+	"profile":    completionProfiles,
+	"runner":     completionRunners,
+}
+
+// completionShells contains the completion script generators for each supported shell
+var completionShells = map[string]func(io.Writer, []completionFlag){
+	"bash": writeBashCompletion,
+	"fish": writeFishCompletion,
+	"zsh":  writeZshCompletion,
+}
+
+// completionFlag describes a tgf flag as it is presented by the completion scripts
+type completionFlag struct {
+	name       string
+	short      string
+	help       string
+	negatable  bool
+	takesValue bool
+	values     []string
+	dynamic    string
+}
+
+var reEnumOptions = regexp.MustCompile(`^enum value must be one of (.*), got `)
+
+// getEnumOptions returns the accepted values of an enum flag (or nil if the flag is not an enum).
+// The options are not exposed by kingpin, so they are extracted from the error returned on an invalid value.
+func getEnumOptions(value kingpin.Value) []string {
+	if !strings.HasSuffix(fmt.Sprintf("%T", value), ".enumValue") {
+		return nil
+	}
+	if err := value.Set(""); err != nil {
+		if match := reEnumOptions.FindStringSubmatch(err.Error()); match != nil {
+			return strings.Split(match[1], ",")
+		}
+	}
+	return nil
+}
+
+// getCompletionFlags returns the visible flags of the application
+func (app *TGFApplication) getCompletionFlags() (flags []completionFlag) {
+	for _, model := range app.Model().Flags {
+		if model.Hidden {
+			continue
+		}
+		flag := completionFlag{
+			name:       model.Name,
+			help:       strings.TrimPrefix(strings.SplitN(model.Help, ", use --no-", 2)[0], "ON by default: "),
+			negatable:  model.IsBoolFlag() && len(model.Default) > 0 && model.Default[0] == "true",
+			takesValue: !model.IsBoolFlag(),
+			values:     getEnumOptions(model.Value),
+			dynamic:    completionDynamicFlags[model.Name],
+		}
+		if model.Short != 0 {
+			flag.short = string(model.Short)
+		}
+		flags = append(flags, flag)
+	}
+	return
+}
+
+// getCompletionValues returns the values of the requested kind
+func (app *TGFApplication) getCompletionValues(kind string) []string {
+	switch kind {
+	case completionProfiles:
+		return getAWSProfiles()
+	case completionRunners:
+		return append([]string{runnerDocker, runnerFargate, runnerKubernetes, runnerSSH}, getPluginNames(pluginRunner)...)
+	case completionEntrypoints, completionAliases:
+		// The remote configuration is not fetched to avoid AWS calls (and MFA prompts) during completion
+		app.UseAWS = false
+		config := InitConfig(app)
+		if kind == completionEntrypoints {
+			return uniqueStrings([]string{"terragrunt", "terraform", "tofu", config.EntryPoint})
+		}
+		aliases := make([]string, 0, len(config.Aliases))
+		for alias := range config.Aliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		return aliases
+	}
+	return nil
+}
+
+// uniqueStrings returns the list without duplicates (the order is preserved)
+func uniqueStrings(values []string) (result []string) {
+	found := make(map[string]bool)
+	for _, value := range values {
+		if !found[value] {
+			found[value] = true
+			result = append(result, value)
+		}
+	}
+	return
+}
+
+// runCompletion prints the completion script of the requested shell (or the values requested by a completion script)
+func (app *TGFApplication) runCompletion(args []string) int {
+	if len(args) == 2 && args[0] == completionValues {
+		for _, value := range app.getCompletionValues(args[1]) {
+			fmt.Println(value)
+		}
+		return 0
+	}
+
+	shells := make([]string, 0, len(completionShells))
+	for shell := range completionShells {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	if len(args) != 1 || completionShells[args[0]] == nil {
+		printError(msgCommandUsage, fmt.Sprintf("tgf %s <shell> (supported shells: %s)", completionCommand, strings.Join(shells, ", ")))
+		return 1
+	}
+	completionShells[args[0]](os.Stdout, app.getCompletionFlags())
+	return 0
+}
+
+// completionValuesCommand returns the command used by the scripts to get the dynamic values
+func completionValuesCommand(kind string) string {
+	return fmt.Sprintf("tgf %s %s %s 2>/dev/null", completionCommand, completionValues, kind)
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var names, cases []string
+	for _, flag := range flags {
+		names = append(names, "--"+flag.name)
+		if flag.negatable {
+			names = append(names, "--no-"+flag.name)
+		}
+		if flag.short != "" {
+			names = append(names, "-"+flag.short)
+		}
+		pattern := "--" + flag.name
+		if flag.short != "" {
+			pattern += "|-" + flag.short
+		}
+		switch {
+		case flag.dynamic != "":
+			cases = append(cases, fmt.Sprintf("        %s) COMPREPLY=($(compgen -W \"$(%s)\" -- \"$cur\")); return ;;", pattern, completionValuesCommand(flag.dynamic)))
+		case len(flag.values) > 0:
+			cases = append(cases, fmt.Sprintf("        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;", pattern, strings.Join(flag.values, " ")))
+		case flag.takesValue:
+			cases = append(cases, fmt.Sprintf("        %s) return ;;", pattern))
+		}
+	}
+
+	fmt.Fprintln(w, "# bash completion for tgf, generated by: tgf completion bash")
+	fmt.Fprintln(w, "_tgf_completion() {")
+	fmt.Fprintln(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"")
+	fmt.Fprintln(w, "    if [[ \"$prev\" == \"=\" && $COMP_CWORD -gt 1 ]]; then")
+	fmt.Fprintln(w, "        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    case \"$prev\" in")
+	fmt.Fprintln(w, strings.Join(cases, "\n"))
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "    if [[ \"$cur\" == -* ]]; then")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "    else")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s $(%s)\" -- \"$cur\"))\n", strings.Join(getSubcommandNames(), " "), completionValuesCommand(completionAliases))
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _tgf_completion tgf")
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	quote := strings.NewReplacer("'", "'\\''").Replace
+	escape := func(help string) string { return quote(strings.NewReplacer("[", "\\[", "]", "\\]").Replace(help)) }
+
+	fmt.Fprintln(w, "#compdef tgf")
+	fmt.Fprintln(w, "# zsh completion for tgf, generated by: tgf completion zsh")
+	fmt.Fprintln(w, "_tgf() {")
+	fmt.Fprintln(w, "    _arguments -s \\")
+	for _, flag := range flags {
+		var action string
+		switch {
+		case flag.dynamic != "":
+			action = fmt.Sprintf(":%s:{compadd -- $(%s)}", flag.name, completionValuesCommand(flag.dynamic))
+		case len(flag.values) > 0:
+			action = fmt.Sprintf(":%s:(%s)", flag.name, strings.Join(flag.values, " "))
+		case flag.takesValue:
+			action = fmt.Sprintf(":%s:_files", flag.name)
+		}
+		long, short := "--"+flag.name, "-"+flag.short
+		if flag.takesValue {
+			long, short = long+"=", short+"+"
+		}
+		spec := fmt.Sprintf("%s'[%s]%s'", long, escape(flag.help), quote(action))
+		if flag.short != "" {
+			spec = fmt.Sprintf("'(-%s --%s)'{%s,%s}'[%s]%s'", flag.short, flag.name, short, long, escape(flag.help), quote(action))
+		}
+		fmt.Fprintf(w, "        %s \\\n", spec)
+		if flag.negatable {
+			fmt.Fprintf(w, "        '--no-%s[Disable --%s]' \\\n", flag.name, flag.name)
+		}
+	}
+	fmt.Fprintf(w, "        '1::command:{compadd -- %s $(%s)}' \\\n", strings.Join(getSubcommandNames(), " "), completionValuesCommand(completionAliases))
+	fmt.Fprintln(w, "        '*::argument:_files'")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "compdef _tgf tgf")
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	quote := func(s string) string { return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s) + "'" }
+
+	fmt.Fprintln(w, "# fish completion for tgf, generated by: tgf completion fish")
+	for _, flag := range flags {
+		line := "complete -c tgf -l " + flag.name
+		if flag.short != "" {
+			line += " -s " + flag.short
+		}
+		line += " -d " + quote(flag.help)
+		switch {
+		case flag.dynamic != "":
+			line += fmt.Sprintf(" -x -a '(%s)'", completionValuesCommand(flag.dynamic))
+		case len(flag.values) > 0:
+			line += " -x -a " + quote(strings.Join(flag.values, " "))
+		case flag.takesValue:
+			line += " -r"
+		}
+		fmt.Fprintln(w, line)
+		if flag.negatable {
+			fmt.Fprintf(w, "complete -c tgf -l no-%s -d %s\n", flag.name, quote("Disable --"+flag.name))
+		}
+	}
+	for _, command := range subcommands {
+		fmt.Fprintf(w, "complete -c tgf -n __fish_use_subcommand -a %s -d %s\n", command.name, quote(command.help))
+	}
+	fmt.Fprintf(w, "complete -c tgf -n __fish_use_subcommand -a '(%s)' -d %s\n", completionValuesCommand(completionAliases), quote("tgf alias"))
+}
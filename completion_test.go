@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnumOptions(t *testing.T) {
+	app := NewTestApplication([]string{"--tgf-log-format", "json"})
+
+	assert.Equal(t, []string{logFormatText, logFormatJSON}, getEnumOptions(app.GetFlag("tgf-log-format").Model().Value))
+	assert.Equal(t, logFormatJSON, app.LogFormat, "The value must not be altered")
+	assert.Nil(t, getEnumOptions(app.GetFlag("profile").Model().Value))
+}
+
+func TestGetCompletionFlags(t *testing.T) {
+	app := NewTestApplication(nil)
+	flags := make(map[string]completionFlag)
+	for _, flag := range app.getCompletionFlags() {
+		flags[flag.name] = flag
+	}
+
+	assert.Equal(t, completionFlag{name: "profile", short: "P", help: "Set the AWS profile configuration to use", takesValue: true, dynamic: completionProfiles}, flags["profile"])
+	assert.Equal(t, completionFlag{name: "interactive", help: "Launch Docker in interactive mode", negatable: true}, flags["interactive"])
+	assert.Equal(t, []string{colorAuto, colorAlways, colorNever}, flags["color"].values)
+	assert.Contains(t, flags, "entrypoint")
+	for name, kind := range completionDynamicFlags {
+		assert.Equal(t, kind, flags[name].dynamic, "Flag %s must exist", name)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	flags := []completionFlag{
+		{name: "profile", short: "P", help: "Set the profile", takesValue: true, dynamic: completionProfiles},
+		{name: "color", help: "Set [colors]", takesValue: true, values: []string{"auto", "never"}},
+		{name: "home", help: "Mount the user's home", negatable: true},
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			`--profile|-P) COMPREPLY=($(compgen -W "$(tgf completion __values profiles 2>/dev/null)" -- "$cur")); return ;;`,
+			`--color) COMPREPLY=($(compgen -W "auto never" -- "$cur")); return ;;`,
+			`COMPREPLY=($(compgen -W "--profile -P --color --home --no-home" -- "$cur"))`,
+			`complete -o default -F _tgf_completion tgf`,
+		}},
+		{"zsh", []string{
+			`'(-P --profile)'{-P+,--profile=}'[Set the profile]:profile:{compadd -- $(tgf completion __values profiles 2>/dev/null)}'`,
+			`--color='[Set \[colors\]]:color:(auto never)'`,
+			`--home'[Mount the user'\''s home]'`,
+			`'--no-home[Disable --home]'`,
+		}},
+		{"fish", []string{
+			`complete -c tgf -l profile -s P -d 'Set the profile' -x -a '(tgf completion __values profiles 2>/dev/null)'`,
+			`complete -c tgf -l color -d 'Set [colors]' -x -a 'auto never'`,
+			`complete -c tgf -l home -d 'Mount the user\'s home'`,
+			`complete -c tgf -l no-home -d 'Disable --home'`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buffer bytes.Buffer
+			completionShells[tt.shell](&buffer, flags)
+			for _, want := range tt.want {
+				assert.Contains(t, buffer.String(), want)
+			}
+		})
+	}
+}
+
+func TestRunCompletionInvalidShell(t *testing.T) {
+	app := NewTestApplication(nil)
+	assert.Equal(t, 1, app.runCompletion([]string{"powershell"}))
+	assert.Equal(t, 1, app.runCompletion(nil))
+}
@@ -7,20 +7,21 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
-	"os/user"
 	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/blang/semver"
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/fatih/color"
-	"github.com/gruntwork-io/terragrunt/aws_helper"
+	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/hashicorp/go-getter"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -46,6 +47,7 @@ type TGFConfig struct {
 	Image                   string            `yaml:"docker-image,omitempty" json:"docker-image,omitempty" hcl:"docker-image,omitempty"`
 	ImageVersion            *string           `yaml:"docker-image-version,omitempty" json:"docker-image-version,omitempty" hcl:"docker-image-version,omitempty"`
 	ImageTag                *string           `yaml:"docker-image-tag,omitempty" json:"docker-image-tag,omitempty" hcl:"docker-image-tag,omitempty"`
+	ImageFamily             string            `yaml:"image-family,omitempty" json:"image-family,omitempty" hcl:"image-family,omitempty"`
 	ImageBuild              string            `yaml:"docker-image-build,omitempty" json:"docker-image-build,omitempty" hcl:"docker-image-build,omitempty"`
 	ImageBuildFolder        string            `yaml:"docker-image-build-folder,omitempty" json:"docker-image-build-folder,omitempty" hcl:"docker-image-build-folder,omitempty"`
 	ImageBuildTag           string            `yaml:"docker-image-build-tag,omitempty" json:"docker-image-build-tag,omitempty" hcl:"docker-image-build-tag,omitempty"`
@@ -55,15 +57,66 @@ type TGFConfig struct {
 	DockerOptions           []string          `yaml:"docker-options,omitempty" json:"docker-options,omitempty" hcl:"docker-options,omitempty"`
 	RecommendedImageVersion string            `yaml:"recommended-image-version,omitempty" json:"recommended-image-version,omitempty" hcl:"recommended-image-version,omitempty"`
 	RequiredVersionRange    string            `yaml:"required-image-version,omitempty" json:"required-image-version,omitempty" hcl:"required-image-version,omitempty"`
+	RequiredToolVersions    map[string]string `yaml:"required-tool-versions,omitempty" json:"required-tool-versions,omitempty" hcl:"required-tool-versions,omitempty"`
+	ToolVersionCheck        string            `yaml:"tool-version-check,omitempty" json:"tool-version-check,omitempty" hcl:"tool-version-check,omitempty"`
 	RecommendedTGFVersion   string            `yaml:"tgf-recommended-version,omitempty" json:"tgf-recommended-version,omitempty" hcl:"tgf-recommended-version,omitempty"`
 	Environment             map[string]string `yaml:"environment,omitempty" json:"environment,omitempty" hcl:"environment,omitempty"`
 	RunBefore               string            `yaml:"run-before,omitempty" json:"run-before,omitempty" hcl:"run-before,omitempty"`
 	RunAfter                string            `yaml:"run-after,omitempty" json:"run-after,omitempty" hcl:"run-after,omitempty"`
+	Hooks                   HooksConfig       `yaml:"hooks,omitempty" json:"hooks,omitempty" hcl:"hooks,omitempty"`
+	PolicyGates             []PolicyGate      `yaml:"policy-gates,omitempty" json:"policy-gates,omitempty" hcl:"policy-gates,omitempty"`
 	Aliases                 map[string]string `yaml:"alias,omitempty" json:"alias,omitempty" hcl:"alias,omitempty"`
+	AWSRegion               string            `yaml:"aws-region,omitempty" json:"aws-region,omitempty" hcl:"aws-region,omitempty"`
+	AWSOverrides            []AWSOverride     `yaml:"aws-overrides,omitempty" json:"aws-overrides,omitempty" hcl:"aws-overrides,omitempty"`
+	Profiles                ProfileConfigs    `yaml:"profiles,omitempty" json:"profiles,omitempty" hcl:"profiles,omitempty"`
+	CredentialSources       []string          `yaml:"credential-sources,omitempty" json:"credential-sources,omitempty" hcl:"credential-sources,omitempty"`
+	RoleArn                 string            `yaml:"role-arn,omitempty" json:"role-arn,omitempty" hcl:"role-arn,omitempty"`
+	RoleSessionName         string            `yaml:"role-session-name,omitempty" json:"role-session-name,omitempty" hcl:"role-session-name,omitempty"`
+	RoleDuration            time.Duration     `yaml:"role-duration,omitempty" json:"role-duration,omitempty" hcl:"role-duration,omitempty"`
+	MFASerial               string            `yaml:"mfa-serial,omitempty" json:"mfa-serial,omitempty" hcl:"mfa-serial,omitempty"`
+	MFACommand              string            `yaml:"mfa-command,omitempty" json:"mfa-command,omitempty" hcl:"mfa-command,omitempty"`
+	RoleSessionTags         map[string]string `yaml:"role-session-tags,omitempty" json:"role-session-tags,omitempty" hcl:"role-session-tags,omitempty"`
+	RoleSourceIdentity      string            `yaml:"role-source-identity,omitempty" json:"role-source-identity,omitempty" hcl:"role-source-identity,omitempty"`
+	RoleChain               []RoleHop         `yaml:"role-chain,omitempty" json:"role-chain,omitempty" hcl:"role-chain,omitempty"`
+	WebIdentityTokenFile    string            `yaml:"web-identity-token-file,omitempty" json:"web-identity-token-file,omitempty" hcl:"web-identity-token-file,omitempty"`
+	WebIdentityTokenEnv     string            `yaml:"web-identity-token-env,omitempty" json:"web-identity-token-env,omitempty" hcl:"web-identity-token-env,omitempty"`
+	AuditLog                string            `yaml:"audit-log,omitempty" json:"audit-log,omitempty" hcl:"audit-log,omitempty"`
+	Webhooks                []Webhook         `yaml:"webhooks,omitempty" json:"webhooks,omitempty" hcl:"webhooks,omitempty"`
+	Metrics                 MetricsConfig     `yaml:"metrics,omitempty" json:"metrics,omitempty" hcl:"metrics,omitempty"`
+	PlanArtifacts           PlanArchiveConfig `yaml:"plan-artifacts,omitempty" json:"plan-artifacts,omitempty" hcl:"plan-artifacts,omitempty"`
+	Infracost               InfracostConfig   `yaml:"infracost,omitempty" json:"infracost,omitempty" hcl:"infracost,omitempty"`
+	TerragruntCache         string            `yaml:"terragrunt-cache,omitempty" json:"terragrunt-cache,omitempty" hcl:"terragrunt-cache,omitempty"`
+	RunConfirmations        []RunConfirmation `yaml:"run-confirmations,omitempty" json:"run-confirmations,omitempty" hcl:"run-confirmations,omitempty"`
+	Runner                  string            `yaml:"runner,omitempty" json:"runner,omitempty" hcl:"runner,omitempty"`
+	Kubernetes              KubernetesConfig  `yaml:"kubernetes,omitempty" json:"kubernetes,omitempty" hcl:"kubernetes,omitempty"`
+	Fargate                 FargateConfig     `yaml:"fargate,omitempty" json:"fargate,omitempty" hcl:"fargate,omitempty"`
+	SSH                     SSHConfig         `yaml:"ssh,omitempty" json:"ssh,omitempty" hcl:"ssh,omitempty"`
 
 	runBeforeCommands, runAfterCommands []string
 	imageBuildConfigs                   []TGFConfigBuild // List of config built from previous build configs
 	tgf                                 *TGFApplication
+	awsSession                          *session.Session  // The AWS session resolved by InitAWS
+	awsSessionErr                       error             // The error returned by the lazy initialization of the AWS session
+	awsSessionTried                     bool              // The lazy initialization of the AWS session has been attempted
+	awsProfile                          string            // The AWS profile used to resolve the session
+	awsAccount                          string            // The AWS account of the session (lazily resolved)
+	awsExpiration                       time.Time         // The expiration of the AWS credentials (zero if unknown)
+	sources                             map[string]string // The source that supplied each configuration key
+	credentialVolumes                   []string          // The volumes required by the credential sources
+	runImage                            string            // The image used to start the container
+	terraformSummary                    *terraformSummary // The statistics printed by terraform (only collected in GitHub Actions)
+	commandOutput                       *outputCapture    // The output of the command (only collected to archive the plans or write the PR comment)
+	hostCacheFolder                     string            // The host folder mounted as the terragrunt cache (--temp)
+	terragruntCacheFolder               string            // The host folder mounted as the terragrunt download folder (terragrunt-cache: central)
+	costEstimate                        *costEstimate     // The monthly cost of the plans estimated by infracost
+	git                                 *gitMetadata      // The git repository of the current folder (nil if there is none)
+}
+
+// configData contains the raw content of a configuration source
+type configData struct {
+	Name   string
+	Raw    string
+	Config *TGFConfig
 }
 
 // TGFConfigBuild contains an entry specifying how to customize the current docker image
@@ -138,15 +191,30 @@ func (config TGFConfig) String() string {
 
 // InitAWS tries to open an AWS session and init AWS environment variable on success
 func (config *TGFConfig) InitAWS(profile string) error {
-	_, err := aws_helper.InitAwsSession(profile)
+	defer timings.begin("credentials")()
+	profileName := profile
+	if profileName == "" {
+		profileName = os.Getenv("AWS_PROFILE")
+	}
+	useRegionalSTSEndpoints()
+	awsSession, err := config.initAWSSession(profile, profileName)
 	if err != nil {
 		return err
 	}
+	config.awsSession, config.awsProfile, config.awsAccount = awsSession, profileName, ""
+	config.awsExpiration = getCredentialsExpiration(awsSession)
+
+	if creds, err := awsSession.Config.Credentials.Get(); err == nil {
+		config.tgf.Debug("# AWS credentials resolved on the host by %s", creds.ProviderName)
+		if creds.ProviderName == endpointcreds.ProviderName {
+			config.credentialVolumes = append(config.credentialVolumes, getContainerCredentialsVolumes()...)
+		}
+	}
 
 	for _, s := range os.Environ() {
 		if strings.HasPrefix(s, "AWS_") {
 			split := strings.SplitN(s, "=", 2)
-			if len(split) < 2 {
+			if len(split) < 2 || isAWSProfileVariable(split[0]) {
 				continue
 			}
 			config.Environment[split[0]] = split[1]
@@ -155,71 +223,85 @@ func (config *TGFConfig) InitAWS(profile string) error {
 	return nil
 }
 
+// ensureAWSSession initializes the AWS session the first time a feature needs it (parameter store, KMS values, AWS overrides, role
+// assumption, container credentials), so the runs that do not use AWS do not pay the resolution of the credentials chain.
+// The initialization is attempted only once, nil is returned without session if there is no AWS configuration.
+func (config *TGFConfig) ensureAWSSession() error {
+	if config.awsSession != nil || config.awsSessionTried {
+		return config.awsSessionErr
+	}
+	config.awsSessionTried = true
+	if config.awsConfigExist() {
+		config.awsSessionErr = config.InitAWS("")
+	}
+	return config.awsSessionErr
+}
+
 // setDefaultValues sets the uninitialized values from the config files and the parameter store
 // Priorities (Higher overwrites lower values):
 // 1. Configuration location files
 // 2. SSM Parameter Config
-// 3. tgf.user.config
-// 4. .tgf.config
+// 3. $XDG_CONFIG_HOME/tgf/tgf.user.config
+// 4. tgf.user.config
+// 5. .tgf.config
+// 6. TGF_<KEY> environment variables
 func (config *TGFConfig) setDefaultValues() {
 	app := config.tgf
 
 	//app.PsPath, app.ConfigLocation, app.ConfigFiles
-	type configData struct {
-		Name   string
-		Raw    string
-		Config *TGFConfig
-	}
 	configsData := []configData{}
 
-	// Fetch SSM configs
-	if config.awsConfigExist() {
-		if err := config.InitAWS(""); err != nil {
-			printError("Unable to authentify to AWS: %v\nPararameter store is ignored\n", err)
-		} else {
-			if app.ConfigLocation == "" {
-				values := config.readSSMParameterStore(app.PsPath)
-				app.ConfigLocation = values[remoteConfigLocationParameter]
-				if app.ConfigFiles == "" {
-					app.ConfigFiles = values[remoteConfigPathsParameter]
-				}
-			}
-		}
-	}
-
-	for _, configFile := range config.findRemoteConfigFiles(app.ConfigLocation, app.ConfigFiles) {
-		configsData = append(configsData, configData{Name: "RemoteConfigFile", Raw: configFile})
+	// The region specified on the command line must be used to retrieve the remote configuration
+	if app.AwsRegion != "" {
+		setRegionEnv(app.AwsRegion)
 	}
 
-	if config.awsConfigExist() {
-		// Only fetch SSM parameters if no ConfigFile was found
-		if len(configsData) == 0 {
-			ssmConfig := parseSsmConfig(config.readSSMParameterStore(app.PsPath))
-			if ssmConfig != "" {
-				configsData = append(configsData, configData{Name: "AWS/ParametersStore", Raw: ssmConfig})
-			}
+	// The remote sources (SSM parameters and configuration location files) are fetched while the local files are read, the results
+	// are merged afterward in the order of their priority
+	var remoteConfigs, fileConfigs []configData
+	resolveConcurrently(2, func(source int) {
+		if source == 0 {
+			remoteConfigs = config.readRemoteConfigs()
+		} else {
+			fileConfigs = config.readConfigFiles()
 		}
-	}
+	})
+	configsData = append(append(append(configsData, remoteConfigs...), config.readPluginConfigs()...), fileConfigs...)
 
-	// Fetch file configs
-	for _, configFile := range config.findConfigFiles(must(os.Getwd()).(string)) {
-		app.Debug("# Reading configuration from %s\n", configFile)
-		bytes, err := ioutil.ReadFile(configFile)
+	// Fetch environment variables configs (TGF_<KEY>), they have precedence over the files
+	configsData = append(configsData, getEnvironmentConfigs()...)
 
-		if err != nil {
-			fmt.Fprintln(os.Stderr, errorString("Error while loading configuration file %s\n%v", configFile, err))
-			continue
+	// Evaluate templates and resolve imported configuration fragments
+	for i := range configsData {
+		configData := &configsData[i]
+		configData.Raw = migrateDeprecatedKeys(configData.Name, configData.Raw)
+		if raw, err := config.processTemplate(configData.Name, configData.Raw); err != nil {
+			fmt.Fprintln(os.Stderr, errorString("Error while evaluating templates in configuration from %s\n%v", configData.Name, err))
+		} else {
+			configData.Raw = raw
 		}
-		configsData = append(configsData, configData{Name: configFile, Raw: string(bytes)})
 	}
+	configsData = config.expandImports(configsData)
+	configsData = config.expandPlatformOverrides(configsData, runtime.GOOS, runtime.GOARCH)
 
 	// Parse/Unmarshal configs
 	for i := range configsData {
 		configData := &configsData[i]
+		if raw, err := resolveFileReferences(configData.Name, configData.Raw); err != nil {
+			fmt.Fprintln(os.Stderr, errorString("Error while reading file reference in configuration from %s\n%v", configData.Name, err))
+		} else {
+			configData.Raw = raw
+		}
+		if raw, err := resolveKMSReferences(configData.Raw, config.decryptKMS); err != nil {
+			fmt.Fprintln(os.Stderr, errorString("Error while decrypting KMS value in configuration from %s\n%v", configData.Name, err))
+		} else {
+			configData.Raw = raw
+		}
 		if err := collections.ConvertData(configData.Raw, config); err != nil {
 			fmt.Fprintln(os.Stderr, errorString("Error while loading configuration from %s\nConfiguration file must be valid YAML, JSON or HCL\n%v", configData.Name, err))
 		}
 		collections.ConvertData(configData.Raw, &configData.Config)
+		config.trackSources(configData.Name, configData.Raw)
 	}
 
 	// Special case for image build configs and run before/after, we must build a list of instructions from all configs
@@ -299,19 +381,20 @@ func (config *TGFConfig) validate() (errors []error) {
 
 // ValidateVersion ensures that the current version is compliant with the setting (mainly those in the parameter store1)
 func (config *TGFConfig) ValidateVersion() bool {
+	defer timings.begin("version check")()
 	version := config.tgf.ImageVersion
 	for _, err := range config.validate() {
 		switch err := err.(type) {
 		case ConfigWarning:
-			printWarning("%v", err)
+			printWarning(msgConfigWarning, err)
 		case VersionMistmatchError:
-			printError("%v", err)
+			printError(msgVersionMismatch, err)
 			if version == "-" {
 				// We consider this as a fatal error only if the version has not been explicitly specified on the command line
 				return false
 			}
 		default:
-			printError("%v", err)
+			printError(msgConfigInvalid, err)
 			return false
 		}
 	}
@@ -338,6 +421,12 @@ func (config *TGFConfig) GetImageName() string {
 		}
 		suffix += *config.ImageTag
 	}
+	if family := config.getImageFamilySuffix(); family != "" && shouldAddTag {
+		if suffix != "" {
+			suffix += tagSeparator
+		}
+		suffix += family
+	}
 	if len(suffix) > 1 {
 		return fmt.Sprintf("%s:%s", config.Image, suffix)
 	}
@@ -345,9 +434,21 @@ func (config *TGFConfig) GetImageName() string {
 }
 
 // parseAliases will parse the original argument list and replace aliases only in the first argument.
+//
+// If the alias is expressed as a complete command line (starting with tgf or the entry point name), the program name is removed.
 func (config *TGFConfig) parseAliases(args []string) []string {
+	return config.expandAlias(args, nil)
+}
+
+func (config *TGFConfig) expandAlias(args []string, expanded []string) []string {
 	if len(args) > 0 {
 		if replace := String(config.Aliases[args[0]]); replace != "" {
+			for _, previous := range expanded {
+				if previous == args[0] {
+					printWarning(msgAliasRecursive, args[0], strings.Join(expanded, " -> "), args[0])
+					return args
+				}
+			}
 			var result collections.StringArray
 			replace, quoted := replace.Protect()
 			result = replace.Fields()
@@ -356,7 +457,10 @@ func (config *TGFConfig) parseAliases(args []string) []string {
 					result[i] = result[i].RestoreProtected(quoted).ReplaceN(`="`, "=", 1).Trim(`"`)
 				}
 			}
-			return append(config.parseAliases(result.Strings()), args[1:]...)
+			if len(result) > 0 && (result[0] == "tgf" || config.EntryPoint != "" && result[0].Str() == filepath.Base(config.EntryPoint)) {
+				result = result[1:]
+			}
+			return append(config.expandAlias(result.Strings(), append(expanded, args[0])), args[1:]...)
 		}
 	}
 	return args
@@ -371,14 +475,54 @@ func (config *TGFConfig) ParseAliases() {
 	}
 }
 
-func (config *TGFConfig) readSSMParameterStore(ssmParameterFolder string) map[string]string {
-	config.tgf.Debug("# Reading configuration from SSM %s\n", ssmParameterFolder)
-	values := make(map[string]string)
-	for _, parameter := range must(aws_helper.GetSSMParametersByPath(ssmParameterFolder, "")).([]*ssm.Parameter) {
-		key := strings.TrimLeft(strings.Replace(*parameter.Name, ssmParameterFolder, "", 1), "/")
-		values[key] = *parameter.Value
+// readRemoteConfigs returns the configurations from the configuration location files or, if there is none, from the SSM parameters
+func (config *TGFConfig) readRemoteConfigs() (configsData []configData) {
+	app := config.tgf
+
+	// Fetch SSM configs (the AWS session is only initialized if the cached parameters are expired)
+	awsConfigExist := config.awsConfigExist()
+	if awsConfigExist && app.ConfigLocation == "" {
+		values := config.readSSMParameterStore(app.PsPath)
+		app.ConfigLocation = values[remoteConfigLocationParameter]
+		if app.ConfigFiles == "" {
+			app.ConfigFiles = values[remoteConfigPathsParameter]
+		}
 	}
-	return values
+
+	for _, configFile := range config.findRemoteConfigFiles(app.ConfigLocation, app.ConfigFiles) {
+		configsData = append(configsData, configData{Name: "RemoteConfigFile", Raw: configFile})
+	}
+
+	if awsConfigExist && config.awsSessionErr == nil {
+		// Only fetch SSM parameters if no ConfigFile was found
+		if len(configsData) == 0 {
+			ssmConfig := parseSsmConfig(config.readSSMParameterStore(app.PsPath))
+			if ssmConfig != "" {
+				configsData = append(configsData, configData{Name: "AWS/ParametersStore", Raw: ssmConfig})
+			}
+		}
+	}
+	return
+}
+
+// readConfigFiles returns the configurations from the local files (the user level configuration file has the lowest priority)
+func (config *TGFConfig) readConfigFiles() (configsData []configData) {
+	app := config.tgf
+	configFiles := config.findConfigFiles(must(os.Getwd()).(string))
+	if userConfig := getUserConfigFile(); !app.DisableUserConfig && util.FileExists(userConfig) {
+		configFiles = append([]string{userConfig}, configFiles...)
+	}
+	for _, configFile := range configFiles {
+		app.Debug("# Reading configuration from %s\n", configFile)
+		bytes, err := ioutil.ReadFile(configFile)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, errorString("Error while loading configuration file %s\n%v", configFile, err))
+			continue
+		}
+		configsData = append(configsData, configData{Name: configFile, Raw: string(bytes)})
+	}
+	return
 }
 
 func (config *TGFConfig) findRemoteConfigFiles(location, files string) []string {
@@ -398,50 +542,69 @@ func (config *TGFConfig) findRemoteConfigFiles(location, files string) []string
 	tempDir := must(ioutil.TempDir("", "tgf-config-files")).(string)
 	defer os.RemoveAll(tempDir)
 
+	// The files are fetched concurrently, but they are returned in the order of the configured paths
+	contents := make([]string, len(configPaths))
+	resolveConcurrently(len(configPaths), func(i int) {
+		content, err := config.fetchConfigFile(location+configPaths[i], path.Join(tempDir, configPaths[i]))
+		if err != nil {
+			printWarning(msgConfigUnavailable, err)
+		}
+		contents[i] = content
+	})
+
 	configs := []string{}
-	for _, configPath := range configPaths {
-		fullConfigPath := location + configPath
-		destConfigPath := path.Join(tempDir, configPath)
+	for _, content := range contents {
+		if content != "" {
+			configs = append(configs, content)
+		}
+	}
+	return configs
+}
+
+// resolveConcurrently calls resolve for each index in a separate goroutine and waits until all of them are completed
+func resolveConcurrently(count int, resolve func(index int)) {
+	var wg sync.WaitGroup
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		go func(i int) {
+			defer wg.Done()
+			resolve(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// fetchConfigFile retrieves a configuration file using go-getter and returns its content
+func (config *TGFConfig) fetchConfigFile(fullConfigPath, destConfigPath string) (string, error) {
+	return config.cachedRemoteConfig(fullConfigPath, func() (string, error) {
 		config.tgf.Debug("# Reading configuration from %s\n", fullConfigPath)
-		source := must(getter.Detect(fullConfigPath, must(os.Getwd()).(string), getter.Detectors)).(string)
+		if isHTTPConfigSource(fullConfigPath) {
+			return fetchHTTPConfig(fullConfigPath, config.tgf.ConfigToken, getRemoteConfigCacheFile(fullConfigPath))
+		}
+		source, err := getter.Detect(fullConfigPath, must(os.Getwd()).(string), getter.Detectors)
+		if err != nil {
+			return "", fmt.Errorf("Error fetching config at %s: %v", fullConfigPath, err)
+		}
 
-		err := getter.Get(destConfigPath, source)
+		p := startProgress("Fetching the configuration")
+		err = getter.Get(destConfigPath, source)
+		p.stop()
 		if err == nil {
 			_, err = os.Stat(destConfigPath)
 			if os.IsNotExist(err) {
 				err = errors.New("Config file was not found at the source")
 			}
 		}
-
 		if err != nil {
-			printWarning("Error fetching config at %s: %v", source, err)
-			continue
-		}
-
-		if content, err := ioutil.ReadFile(destConfigPath); err != nil {
-			printWarning("Error reading fetched config file %s: %v", configPath, err)
-		} else {
-			contentString := string(content)
-			if contentString != "" {
-				configs = append(configs, contentString)
-			}
+			return "", fmt.Errorf("Error fetching config at %s: %v", source, err)
 		}
-	}
-
-	return configs
-}
 
-func parseSsmConfig(parameterValues map[string]string) string {
-	ssmConfig := ""
-	for key, value := range parameterValues {
-		isDict := strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}")
-		isList := strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")
-		if !isDict && !isList {
-			value = fmt.Sprintf("\"%s\"", value)
+		content, err := ioutil.ReadFile(destConfigPath)
+		if err != nil {
+			return "", fmt.Errorf("Error reading fetched config file %s: %v", fullConfigPath, err)
 		}
-		ssmConfig += fmt.Sprintf("%s: %s\n", key, value)
-	}
-	return ssmConfig
+		return string(content), nil
+	})
 }
 
 // Check if there is an AWS configuration available.
@@ -459,21 +622,16 @@ func (config TGFConfig) awsConfigExist() bool {
 		return true
 	}
 
-	if _, err := exec.LookPath("aws"); err == nil {
-		// If aws program is installed, we also consider that we are in an AWS environment.
+	// The results of the host probes are kept between runs
+	probes := getHostProbes()
+	if isECSTask() || app.InstanceProfile && probes.isEC2Instance() {
+		// The credentials are provided by the ECS task role or by the EC2 instance profile
 		return true
 	}
 
+	// If aws program is installed, we also consider that we are in an AWS environment.
 	// Otherwise, we check if the current user has a folder named .aws defined under its home directory.
-	currentUser, err := user.Current()
-	if err != nil {
-		return false
-	}
-	awsFolder, err := os.Stat(filepath.Join(currentUser.HomeDir, ".aws"))
-	if err != nil {
-		return false
-	}
-	return awsFolder.IsDir()
+	return probes.hasAWSCLI() || probes.hasAWSFolder()
 }
 
 // Return the list of configuration files found from the current working directory up to the root folder
@@ -498,17 +656,44 @@ func (config TGFConfig) findConfigFiles(folder string) (result []string) {
 }
 
 func getTgfConfigFields() []string {
+	fields := getTgfConfigKeys()
+	for i := range fields {
+		fields[i] = color.GreenString(fields[i])
+	}
+	return fields
+}
+
+// getTgfConfigKeys returns the name of all configuration keys
+func getTgfConfigKeys() []string {
 	fields := []string{}
 	classType := reflect.ValueOf(TGFConfig{}).Type()
 	for i := 0; i < classType.NumField(); i++ {
 		tagValue := classType.Field(i).Tag.Get("yaml")
 		if tagValue != "" {
-			fields = append(fields, color.GreenString(strings.Replace(tagValue, ",omitempty", "", -1)))
+			fields = append(fields, strings.Replace(tagValue, ",omitempty", "", -1))
 		}
 	}
 	return fields
 }
 
+// getConfigEnvVar returns the name of the environment variable that could be used to set a configuration key
+func getConfigEnvVar(key string) string {
+	return "TGF_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// getEnvironmentConfigs returns a configuration source for each configuration key defined through a TGF_<KEY> environment variable,
+// the value is assigned to the key as is (quotes and backslashes included)
+func getEnvironmentConfigs() (configs []configData) {
+	for _, key := range getTgfConfigKeys() {
+		envVar := getConfigEnvVar(key)
+		if value, ok := os.LookupEnv(envVar); ok && value != "" {
+			raw := must(yaml.Marshal(map[string]interface{}{key: convertConfigValue(value)})).([]byte)
+			configs = append(configs, configData{Name: "env:" + envVar, Raw: string(raw)})
+		}
+	}
+	return
+}
+
 // CheckVersionRange compare a version with a range of values
 // Check https://github.com/blang/semver/blob/master/README.md for more information
 func CheckVersionRange(version, compare string) (bool, error) {
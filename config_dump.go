@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+const (
+	sourceCommandLine = "command line"
+	maskedValue       = "********"
+)
+
+var reSecretName = regexp.MustCompile(`(?i)(secret|token|password|passwd|credential|private|_key$|^key$|auth)`)
+
+// maskSecret returns a masked value if the variable name looks like a secret
+func maskSecret(name, value string) string {
+	if value != "" && reSecretName.MatchString(name) {
+		return maskedValue
+	}
+	return value
+}
+
+// setSource records the source that supplied the value of a configuration key
+func (config *TGFConfig) setSource(key, source string) {
+	if config.sources == nil {
+		config.sources = make(map[string]string)
+	}
+	if config.tgf != nil {
+		if previous := config.sources[key]; previous != "" && previous != source {
+			config.tgf.Trace("# %s set by %s (overrides %s)", key, source, previous)
+		} else {
+			config.tgf.Trace("# %s set by %s", key, source)
+		}
+	}
+	config.sources[key] = source
+}
+
+// trackSources records the source of all keys defined in a configuration content
+func (config *TGFConfig) trackSources(source, content string) {
+	var values map[string]interface{}
+	if err := collections.ConvertData(content, &values); err != nil {
+		return
+	}
+	for key := range values {
+		config.setSource(key, source)
+	}
+}
+
+// effectiveConfig returns the resolved configuration as a map (secrets masked) along with the source of each key
+func (config *TGFConfig) effectiveConfig() (values map[string]interface{}, sources map[string]string) {
+	values = make(map[string]interface{})
+	overrides, profiles := config.AWSOverrides, config.Profiles
+	config.AWSOverrides, config.Profiles = nil, nil
+	must(json.Unmarshal(must(json.Marshal(config)).([]byte), &values))
+	config.AWSOverrides, config.Profiles = overrides, profiles
+	if len(overrides) > 0 {
+		values["aws-overrides"] = jsonCompatible(overrides)
+	}
+	if len(profiles) > 0 {
+		values["profiles"] = jsonCompatible(profiles)
+	}
+	if config.Refresh != 0 {
+		values["docker-refresh"] = config.Refresh.String()
+	}
+	if config.RoleDuration != 0 {
+		values["role-duration"] = config.RoleDuration.String()
+	}
+	if len(config.Environment) > 0 {
+		environment := make(map[string]string, len(config.Environment))
+		for key, value := range config.Environment {
+			environment[key] = maskSecret(key, value)
+		}
+		values["environment"] = environment
+	}
+
+	sources = make(map[string]string)
+	for key := range values {
+		if source := config.sources[key]; source != "" {
+			sources[key] = source
+		} else {
+			sources[key] = "default"
+		}
+	}
+	return
+}
+
+// debugSources prints the effective value of each configuration key along with the source that supplied it
+func (config *TGFConfig) debugSources() {
+	if config.tgf == nil || !config.tgf.DebugMode {
+		return
+	}
+	values, sources := config.effectiveConfig()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	config.tgf.Debug("# Effective configuration:")
+	for _, key := range keys {
+		value, isString := values[key].(string)
+		if !isString {
+			value = string(must(json.Marshal(values[key])).([]byte))
+		}
+		config.tgf.Debug("#   %s = %s (from %s)", key, value, sources[key])
+	}
+}
+
+// dumpConfig prints the resolved configuration as JSON
+func (config *TGFConfig) dumpConfig() int {
+	values, sources := config.effectiveConfig()
+	result := map[string]interface{}{
+		"config":  values,
+		"sources": sources,
+	}
+	Println(string(must(json.MarshalIndent(result, "", "  ")).([]byte)))
+	return 0
+}
+
+// jsonCompatible converts the maps decoded from YAML (map[interface{}]interface{}) into values that can be marshalled to JSON
+func jsonCompatible(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []AWSOverride:
+		result := make([]interface{}, len(value))
+		for i := range value {
+			result[i] = map[string]interface{}{
+				"account": value[i].Account,
+				"profile": value[i].Profile,
+				"region":  value[i].Region,
+				"config":  jsonCompatible(value[i].Config),
+			}
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = jsonCompatible(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i := range value {
+			result[i] = jsonCompatible(value[i])
+		}
+		return result
+	}
+	return value
+}
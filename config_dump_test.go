@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, value, want string
+	}{
+		{"AWS_SECRET_ACCESS_KEY", "abc", maskedValue},
+		{"GITHUB_TOKEN", "abc", maskedValue},
+		{"DB_PASSWORD", "abc", maskedValue},
+		{"TF_VAR_api_key", "abc", maskedValue},
+		{"AWS_REGION", "us-east-1", "us-east-1"},
+		{"KEYBOARD", "qwerty", "qwerty"},
+		{"SECRET", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, maskSecret(tt.name, tt.value))
+		})
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	t.Parallel()
+
+	config := &TGFConfig{
+		Image:       "coveo/tgf",
+		Refresh:     2 * time.Hour,
+		Environment: map[string]string{"MY_TOKEN": "secret", "TEAM": "infra"},
+		AWSOverrides: []AWSOverride{
+			{Region: "eu-*", Config: map[string]interface{}{"environment": map[interface{}]interface{}{"A": "b"}}},
+		},
+	}
+	config.trackSources("/project/.tgf.config", "docker-image: coveo/tgf\nenvironment: {TEAM: infra}")
+	config.setSource("docker-refresh", sourceCommandLine)
+
+	values, sources := config.effectiveConfig()
+	assert.Equal(t, "coveo/tgf", values["docker-image"])
+	assert.Equal(t, "2h0m0s", values["docker-refresh"])
+	assert.Equal(t, map[string]string{"MY_TOKEN": maskedValue, "TEAM": "infra"}, values["environment"])
+	assert.Len(t, values["aws-overrides"], 1)
+	assert.Equal(t, "/project/.tgf.config", sources["docker-image"])
+	assert.Equal(t, "/project/.tgf.config", sources["environment"])
+	assert.Equal(t, sourceCommandLine, sources["docker-refresh"])
+	assert.Equal(t, "default", sources["aws-overrides"])
+}
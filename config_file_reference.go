@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const fileReferencePrefix = "file://"
+
+// resolveFileReferences replaces the values starting with file:// by the content of the referenced file.
+//
+// This allows secrets and multi-line values (certificates, kubeconfig) to be kept out of the configuration files. Relative
+// paths are resolved from the folder of the configuration file that contains the reference and ~ refers to the home folder.
+func resolveFileReferences(source, content string) (string, error) {
+	if !strings.Contains(content, fileReferencePrefix) {
+		return content, nil
+	}
+	var data map[string]interface{}
+	if err := collections.ConvertData(content, &data); err != nil {
+		// The error will be reported when the configuration is loaded
+		return content, nil
+	}
+
+	folder := ""
+	if filepath.IsAbs(source) {
+		folder = filepath.Dir(source)
+	}
+	resolved, err := resolveFileReferenceValue(folder, data)
+	if err != nil {
+		return content, err
+	}
+	bytes, err := yaml.Marshal(resolved)
+	return string(bytes), err
+}
+
+func resolveFileReferenceValue(folder string, value interface{}) (interface{}, error) {
+	return transformStringValues(value, func(value string) (string, error) {
+		if !strings.HasPrefix(value, fileReferencePrefix) {
+			return value, nil
+		}
+		filename := strings.TrimPrefix(value, fileReferencePrefix)
+		if strings.HasPrefix(filename, "~/") {
+			filename = filepath.Join(getHomeFolder(), filename[2:])
+		} else if !filepath.IsAbs(filename) && folder != "" {
+			filename = filepath.Join(folder, filename)
+		}
+		content, err := ioutil.ReadFile(filename)
+		return strings.TrimRight(string(content), "\r\n"), err
+	})
+}
+
+// transformStringValues applies the transformation to all string values found in the configuration data (recursively)
+func transformStringValues(value interface{}, transform func(string) (string, error)) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return transform(value)
+	case collections.IDictionary:
+		return transformStringValues(value.AsMap(), transform)
+	case collections.IGenericList:
+		return transformStringValues(value.AsArray(), transform)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			transformed, err := transformStringValues(item, transform)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = transformed
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			transformed, err := transformStringValues(item, transform)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = transformed
+		}
+		return result, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveFileReferences(t *testing.T) {
+	t.Parallel()
+
+	folder := must(ioutil.TempDir("", "tgf-file-reference")).(string)
+	defer os.RemoveAll(folder)
+	ioutil.WriteFile(filepath.Join(folder, "token"), []byte("secret\n"), 0600)
+	ioutil.WriteFile(filepath.Join(folder, "ca.pem"), []byte("line1\nline2\n"), 0600)
+	source := filepath.Join(folder, configFile)
+
+	tests := []struct {
+		name    string
+		content string
+		want    TGFConfig
+		wantErr bool
+	}{
+		{"No reference", "docker-image: coveo/tgf", TGFConfig{Image: "coveo/tgf"}, false},
+		{"Relative", "environment:\n  TOKEN: file://token", TGFConfig{Environment: map[string]string{"TOKEN": "secret"}}, false},
+		{"Absolute", `{"environment": {"CA": "file://` + filepath.Join(folder, "ca.pem") + `"}}`, TGFConfig{Environment: map[string]string{"CA": "line1\nline2"}}, false},
+		{"List", "docker-options: [file://token]", TGFConfig{DockerOptions: []string{"secret"}}, false},
+		{"Missing file", "environment:\n  TOKEN: file://missing", TGFConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := resolveFileReferences(source, tt.content)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var config TGFConfig
+			assert.NoError(t, collections.ConvertData(content, &config))
+			assert.Equal(t, tt.want, config)
+		})
+	}
+}
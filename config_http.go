@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const httpConfigTimeout = 30 * time.Second
+
+// isHTTPConfigSource returns true if the configuration source is a plain HTTP(S) URL (not forced to a go-getter getter)
+func isHTTPConfigSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// fetchHTTPConfig retrieves a configuration file from an HTTP(S) endpoint.
+//
+// The ETag returned by the server is kept beside the cached copy and sent back through If-None-Match, so the
+// configuration is only transferred again if it has changed. If a token is supplied, it is sent as a bearer token.
+func fetchHTTPConfig(url, token, cacheFile string) (string, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	etagFile := cacheFile + ".etag"
+	cached, cacheErr := ioutil.ReadFile(cacheFile)
+	if etag, err := ioutil.ReadFile(etagFile); err == nil && cacheErr == nil {
+		request.Header.Set("If-None-Match", string(etag))
+	}
+
+	p := startProgress("Downloading the configuration")
+	defer p.stop()
+	response, err := newHTTPClient(httpConfigTimeout).Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching config at %s: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return string(cached), nil
+	case http.StatusOK:
+		content, err := ioutil.ReadAll(&progressReader{Reader: response.Body, progress: p, total: response.ContentLength})
+		if err != nil {
+			return "", fmt.Errorf("Error reading config at %s: %v", url, err)
+		}
+		if etag := response.Header.Get("ETag"); etag != "" {
+			os.MkdirAll(filepath.Dir(etagFile), 0755)
+			ioutil.WriteFile(etagFile, []byte(etag), 0600)
+		} else {
+			os.Remove(etagFile)
+		}
+		return string(content), nil
+	}
+	return "", fmt.Errorf("Error fetching config at %s: %s", url, response.Status)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchHTTPConfig(t *testing.T) {
+	t.Parallel()
+
+	transfers := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		transfers++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("docker-image: coveo/tgf"))
+	}))
+	defer server.Close()
+
+	folder := must(ioutil.TempDir("", "tgf-http-config")).(string)
+	defer os.RemoveAll(folder)
+	cacheFile := filepath.Join(folder, "cache")
+
+	_, err := fetchHTTPConfig(server.URL, "invalid", cacheFile)
+	assert.EqualError(t, err, "Error fetching config at "+server.URL+": 401 Unauthorized")
+
+	content, err := fetchHTTPConfig(server.URL, "secret", cacheFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "docker-image: coveo/tgf", content)
+	ioutil.WriteFile(cacheFile, []byte(content), 0600)
+
+	content, err = fetchHTTPConfig(server.URL, "secret", cacheFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "docker-image: coveo/tgf", content)
+	assert.Equal(t, 1, transfers, "The content should not be transferred if it has not changed")
+}
+
+func TestFindRemoteConfigFilesConcurrently(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first file is the slowest, it must still be returned first
+		delay := map[string]time.Duration{"/first.config": 300 * time.Millisecond, "/second.config": 200 * time.Millisecond}[r.URL.Path]
+		time.Sleep(delay)
+		if r.URL.Path == "/missing.config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".config")))
+	}))
+	defer server.Close()
+	for _, name := range []string{"first", "second", "missing"} {
+		defer os.Remove(getRemoteConfigCacheFile(server.URL + "/" + name + ".config"))
+	}
+
+	config := &TGFConfig{tgf: &TGFApplication{}}
+	start := time.Now()
+	configs := config.findRemoteConfigFiles(server.URL, "first.config:missing.config:second.config")
+	assert.Equal(t, []string{"first", "second"}, configs)
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "The files should be fetched concurrently")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+const importKey = "import"
+
+// getImports returns the list of configuration fragments imported by a configuration content.
+// The import key can either be a single value or a list of values.
+func getImports(content string) (imports []string) {
+	var data map[string]interface{}
+	if err := collections.ConvertData(content, &data); err != nil {
+		return nil
+	}
+	switch value := data[importKey].(type) {
+	case nil:
+	case string:
+		imports = append(imports, value)
+	case []interface{}:
+		for _, item := range value {
+			imports = append(imports, fmt.Sprint(item))
+		}
+	default:
+		printWarning(msgImportInvalid, importKey, value)
+	}
+	return
+}
+
+// expandImports returns the configuration sources with their imported fragments inserted before them
+// (the importing configuration has precedence over the imported fragments).
+func (config *TGFConfig) expandImports(configsData []configData) (result []configData) {
+	for _, data := range configsData {
+		result = append(result, config.resolveImports(data, nil)...)
+	}
+	return
+}
+
+func (config *TGFConfig) resolveImports(data configData, stack []string) (result []configData) {
+	stack = append(stack, data.Name)
+	for _, source := range getImports(data.Raw) {
+		source = resolveImportSource(data.Name, source)
+		for _, previous := range stack {
+			if previous == source {
+				printError(msgImportCycle, strings.Join(stack, " -> "), source)
+				return append(result, data)
+			}
+		}
+
+		content, err := config.readImport(source)
+		if err == nil {
+			content, err = config.processTemplate(source, migrateDeprecatedKeys(source, content))
+		}
+		if err != nil {
+			printError(msgImportFailed, source, data.Name, err)
+			continue
+		}
+		result = append(result, config.resolveImports(configData{Name: source, Raw: content}, stack)...)
+	}
+	return append(result, data)
+}
+
+// resolveImportSource returns the location of an imported fragment, local relative paths are resolved from the importing file folder
+func resolveImportSource(importer, source string) string {
+	if strings.Contains(source, "://") || strings.Contains(source, "::") || filepath.IsAbs(source) {
+		return source
+	}
+	if filepath.IsAbs(importer) {
+		return filepath.Join(filepath.Dir(importer), source)
+	}
+	return must(filepath.Abs(source)).(string)
+}
+
+// readImport returns the content of a local or remote configuration fragment
+func (config *TGFConfig) readImport(source string) (string, error) {
+	if filepath.IsAbs(source) {
+		config.tgf.Debug("# Importing configuration from %s\n", source)
+		content, err := ioutil.ReadFile(source)
+		return string(content), err
+	}
+
+	tempDir := must(ioutil.TempDir("", "tgf-config-imports")).(string)
+	defer os.RemoveAll(tempDir)
+	return config.fetchConfigFile(source, filepath.Join(tempDir, "import"))
+}
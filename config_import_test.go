@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetImports(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, getImports("docker-image: coveo/tgf"))
+	assert.Equal(t, []string{"common.yaml"}, getImports("import: common.yaml"))
+	assert.Equal(t, []string{"a.yaml", "b.yaml"}, getImports("import: [a.yaml, b.yaml]"))
+}
+
+func TestExpandImports(t *testing.T) {
+	tempDir, _ := filepath.EvalSymlinks(must(ioutil.TempDir("", "TestExpandImports")).(string))
+	defer os.RemoveAll(tempDir)
+
+	write := func(name, content string) string {
+		filename := filepath.Join(tempDir, name)
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+	common := write("common.yaml", "import: base.yaml\nlogging-level: info")
+	base := write("base.yaml", "docker-image: coveo/base")
+	cycleA := write("cycle-a.yaml", "import: cycle-b.yaml")
+	cycleB := write("cycle-b.yaml", "import: cycle-a.yaml")
+
+	config := &TGFConfig{tgf: &TGFApplication{}}
+	names := func(configsData []configData) (result []string) {
+		for _, data := range configsData {
+			result = append(result, data.Name)
+		}
+		return
+	}
+
+	main := filepath.Join(tempDir, configFile)
+	result := config.expandImports([]configData{{Name: main, Raw: "import: common.yaml\ndocker-image: coveo/tgf"}})
+	assert.Equal(t, []string{base, common, main}, names(result))
+
+	result = config.expandImports([]configData{{Name: cycleA, Raw: "import: cycle-b.yaml"}})
+	assert.Equal(t, []string{cycleB, cycleA}, names(result))
+}
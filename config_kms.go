@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coveooss/gotemplate/v3/collections"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const kmsReferencePrefix = "kms://"
+
+type kmsDecryptFunc func(ciphertext []byte) ([]byte, error)
+
+// getKMSDecrypter returns a function that decrypts the ciphertext with the KMS key that has been used to encrypt it
+func getKMSDecrypter(awsSession *session.Session) kmsDecryptFunc {
+	return func(ciphertext []byte) ([]byte, error) {
+		if awsSession == nil {
+			return nil, fmt.Errorf("an AWS session is required to decrypt %s values", kmsReferencePrefix)
+		}
+		output, err := kms.New(awsSession, awsRetryConfig()).Decrypt(&kms.DecryptInput{CiphertextBlob: ciphertext})
+		if err != nil {
+			return nil, describeAWSError(err)
+		}
+		return output.Plaintext, nil
+	}
+}
+
+// decryptKMS decrypts a KMS value of the configuration, the AWS session is initialized if it is the first feature that needs it
+func (config *TGFConfig) decryptKMS(ciphertext []byte) ([]byte, error) {
+	config.ensureAWSSession()
+	return getKMSDecrypter(config.awsSession)(ciphertext)
+}
+
+// resolveKMSReferences replaces the values starting with kms:// (followed by the base64 encoded ciphertext) by their decrypted value.
+//
+// The ciphertext is generated with: aws kms encrypt --key-id <key> --plaintext fileb://<(echo -n value) --query CiphertextBlob --output text
+func resolveKMSReferences(content string, decrypt kmsDecryptFunc) (string, error) {
+	if !strings.Contains(content, kmsReferencePrefix) {
+		return content, nil
+	}
+	var data map[string]interface{}
+	if err := collections.ConvertData(content, &data); err != nil {
+		// The error will be reported when the configuration is loaded
+		return content, nil
+	}
+
+	resolved, err := transformStringValues(data, func(value string) (string, error) {
+		if !strings.HasPrefix(value, kmsReferencePrefix) {
+			return value, nil
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, kmsReferencePrefix))
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value: %v", kmsReferencePrefix, err)
+		}
+		plaintext, err := decrypt(ciphertext)
+		return string(plaintext), err
+	})
+	if err != nil {
+		return content, err
+	}
+	bytes, err := yaml.Marshal(resolved)
+	return string(bytes), err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveKMSReferences(t *testing.T) {
+	t.Parallel()
+
+	// The fake decrypter simply reverses the ciphertext
+	decrypt := func(ciphertext []byte) ([]byte, error) {
+		if string(ciphertext) == "invalid" {
+			return nil, fmt.Errorf("InvalidCiphertextException")
+		}
+		result := make([]byte, len(ciphertext))
+		for i := range ciphertext {
+			result[len(ciphertext)-1-i] = ciphertext[i]
+		}
+		return result, nil
+	}
+	encode := func(value string) string {
+		return kmsReferencePrefix + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    TGFConfig
+		wantErr bool
+	}{
+		{"No reference", "docker-image: coveo/tgf", TGFConfig{Image: "coveo/tgf"}, false},
+		{"Environment", "environment:\n  TOKEN: " + encode("terces"), TGFConfig{Environment: map[string]string{"TOKEN": "secret"}}, false},
+		{"List", "docker-options: [" + encode("321") + "]", TGFConfig{DockerOptions: []string{"123"}}, false},
+		{"Invalid base64", "environment:\n  TOKEN: kms://not base64", TGFConfig{}, true},
+		{"Decrypt error", "environment:\n  TOKEN: " + encode("invalid"), TGFConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := resolveKMSReferences(tt.content, decrypt)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var config TGFConfig
+			assert.NoError(t, collections.ConvertData(content, &config))
+			assert.Equal(t, tt.want, config)
+		})
+	}
+}
+
+func TestKMSDecrypterWithoutSession(t *testing.T) {
+	t.Parallel()
+	_, err := getKMSDecrypter(nil)([]byte("value"))
+	assert.Error(t, err)
+}
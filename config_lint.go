@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type lintSeverity int
+
+const (
+	lintLow lintSeverity = iota
+	lintMedium
+	lintHigh
+)
+
+func (severity lintSeverity) String() string {
+	switch severity {
+	case lintHigh:
+		return "HIGH"
+	case lintMedium:
+		return "MEDIUM"
+	}
+	return "LOW"
+}
+
+func (severity lintSeverity) color() func(string, ...interface{}) string {
+	switch severity {
+	case lintHigh:
+		return color.RedString
+	case lintMedium:
+		return color.YellowString
+	}
+	return color.CyanString
+}
+
+// lintFinding describes a potentially dangerous setting found in the configuration
+type lintFinding struct {
+	severity lintSeverity
+	key      string
+	message  string
+}
+
+// lintDockerOption returns the findings related to a docker option (the option may contain its value, i.e. --pid=host)
+func lintDockerOption(option, value string) (findings []lintFinding) {
+	add := func(severity lintSeverity, format string, args ...interface{}) {
+		findings = append(findings, lintFinding{severity, "docker-options", fmt.Sprintf(format, args...)})
+	}
+	if strings.Contains(option, "=") {
+		option, value = Split2(option, "=")
+	}
+	switch option {
+	case "--privileged":
+		if value == "" || value == "true" {
+			add(lintHigh, "Container runs in privileged mode (%s)", option)
+		}
+		return
+	case "--cap-add":
+		if strings.EqualFold(value, "ALL") || strings.EqualFold(value, "SYS_ADMIN") {
+			add(lintHigh, "Container is granted the %s capability", value)
+		}
+		return
+	case "--security-opt":
+		if strings.HasSuffix(value, "unconfined") {
+			add(lintHigh, "Security profile disabled (%s %s)", option, value)
+		}
+		return
+	case "--pid", "--ipc", "--network", "--net", "--uts", "--userns":
+		if value == "host" {
+			add(lintMedium, "Container shares the host namespace (%s=%s)", option, value)
+		}
+		return
+	case "-v", "--volume", "--mount":
+		switch {
+		case strings.Contains(value, dockerSocketFile) || strings.Contains(value, "docker.sock"):
+			add(lintHigh, "Docker socket is mounted in the container (%s %s)", option, value)
+		case strings.HasPrefix(value, "/:") || strings.Contains(value, "source=/,") || strings.Contains(value, "src=/,"):
+			add(lintHigh, "Host root folder is mounted in the container (%s %s)", option, value)
+		}
+		return
+	case "-e", "--env":
+		if strings.Contains(value, "*") {
+			add(lintMedium, "Wildcard environment variable passthrough (%s %s)", option, value)
+		}
+	}
+	return
+}
+
+// lint returns the dangerous settings found in the resolved configuration
+func (config *TGFConfig) lint() (findings []lintFinding) {
+	options := config.DockerOptions
+	for i := 0; i < len(options); i++ {
+		option, value := options[i], ""
+		if !strings.Contains(option, "=") && strings.HasPrefix(option, "-") && i+1 < len(options) && !strings.HasPrefix(options[i+1], "-") {
+			value = options[i+1]
+		}
+		optionFindings := lintDockerOption(option, value)
+		if len(optionFindings) > 0 && value != "" {
+			// The value has been consumed by the option
+			i++
+		}
+		findings = append(findings, optionFindings...)
+	}
+
+	keys := make([]string, 0, len(config.Environment))
+	for key := range config.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.Contains(key, "*") {
+			findings = append(findings, lintFinding{lintMedium, "environment", fmt.Sprintf("Wildcard environment variable passthrough (%s)", key)})
+		}
+	}
+
+	version := ""
+	if config.ImageVersion != nil {
+		version = *config.ImageVersion
+	}
+	if version == "" || version == "latest" || strings.HasSuffix(config.Image, ":latest") {
+		findings = append(findings, lintFinding{lintMedium, "docker-image-version", fmt.Sprintf("Image %s is not pinned to a specific version", config.GetImageName())})
+	} else if !reVersion.MatchString(version) {
+		findings = append(findings, lintFinding{lintLow, "docker-image-version", fmt.Sprintf("Image version %s is not a semantic version", version)})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].severity > findings[j].severity })
+	return
+}
+
+// runLint prints the dangerous settings found in the configuration, if strict is set, findings are reported as an error
+func (config *TGFConfig) runLint(strict bool) int {
+	findings := config.lint()
+	if len(findings) == 0 {
+		Println("No issue found in the configuration")
+		return 0
+	}
+	for _, finding := range findings {
+		source := config.sources[finding.key]
+		if source == "" {
+			source = "default"
+		}
+		Printf("%s %-20s %s (source: %s)\n", finding.severity.color()("%-6s", finding.severity), finding.key, finding.message, source)
+	}
+	if strict {
+		printError(msgConfigLintIssues, len(findings))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	version := "1.20.3"
+	latest := "latest"
+	tests := []struct {
+		name   string
+		config TGFConfig
+		want   []string
+	}{
+		{"Safe", TGFConfig{Image: "coveo/tgf", ImageVersion: &version, DockerOptions: []string{"--read-only", "-v", "/tmp:/tmp"}}, nil},
+		{"Unpinned", TGFConfig{Image: "coveo/tgf"}, []string{"MEDIUM docker-image-version"}},
+		{"Latest", TGFConfig{Image: "coveo/tgf", ImageVersion: &latest}, []string{"MEDIUM docker-image-version"}},
+		{
+			"Docker options",
+			TGFConfig{Image: "coveo/tgf", ImageVersion: &version, DockerOptions: []string{
+				"--network=host", "--privileged", "-v", "/var/run/docker.sock:/var/run/docker.sock", "--cap-add", "SYS_ADMIN",
+			}},
+			[]string{"HIGH docker-options", "HIGH docker-options", "HIGH docker-options", "MEDIUM docker-options"},
+		},
+		{
+			"Wildcard environment",
+			TGFConfig{Image: "coveo/tgf", ImageVersion: &version, Environment: map[string]string{"AWS_*": ""}},
+			[]string{"MEDIUM environment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for _, finding := range tt.config.lint() {
+				result = append(result, finding.severity.String()+" "+finding.key)
+			}
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+// deprecatedKeys maps the legacy configuration key names to their current name
+var deprecatedKeys = map[string]string{
+	"recommended-image": "recommended-image-version",
+}
+
+// reKey matches a key (quoted or not) followed by a YAML, JSON or HCL assignment
+var reKey = regexp.MustCompile(`^"?([\w-]+)"?\s*[:=]`)
+
+// getTopLevelKeyOffsets returns the position of the tokens that may be a key of the root object (YAML and HCL keys at the beginning
+// of an unindented line or keys of the root JSON object). The nested keys, the values and the comments are ignored.
+func getTopLevelKeyOffsets(content string) (offsets []int) {
+	rootDepth := 0
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		rootDepth = 1
+	}
+	depth, expectKey, previous := 0, true, byte('\n')
+	for i := 0; i < len(content); i++ {
+		char := content[i]
+		switch {
+		case char == '\n':
+			expectKey = expectKey || depth == 0
+		case char == ' ' || char == '\t' || char == '\r':
+			if depth == 0 && previous == '\n' {
+				// An indented line contains a nested key or a multi-lines value
+				expectKey = false
+			}
+			continue
+		case char == '#' && strings.ContainsRune(" \t\n", rune(previous)):
+			if end := strings.IndexByte(content[i:], '\n'); end > 0 {
+				i += end - 1
+			} else {
+				i = len(content)
+			}
+			continue
+		case char == '"' || char == '\'' && (expectKey || strings.ContainsRune(":=,[{\n", rune(previous))):
+			if expectKey && depth == rootDepth {
+				offsets = append(offsets, i)
+			}
+			for i++; i < len(content) && content[i] != char; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+			expectKey = false
+		case char == '{' || char == '[':
+			depth++
+			expectKey = char == '{' && depth == rootDepth
+		case char == '}' || char == ']':
+			depth--
+		case char == ',':
+			expectKey = depth == rootDepth && depth > 0
+		default:
+			if expectKey && depth == rootDepth {
+				offsets = append(offsets, i)
+			}
+			expectKey = false
+		}
+		previous = char
+	}
+	return
+}
+
+// warnedDeprecatedKeys ensures that each deprecated key is only reported once per execution
+var warnedDeprecatedKeys = make(map[string]bool)
+
+// migrateConfigContent replaces the deprecated keys of the root object by their current name and returns the list of replaced keys
+func migrateConfigContent(content string) (string, []string) {
+	var data map[string]interface{}
+	if err := collections.ConvertData(content, &data); err != nil {
+		// The error will be reported when the configuration is loaded
+		return content, nil
+	}
+	found := false
+	for key := range deprecatedKeys {
+		if _, exist := data[key]; exist {
+			found = true
+		}
+	}
+	if !found {
+		return content, nil
+	}
+
+	var replaced []string
+	offsets := getTopLevelKeyOffsets(content)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		match := reKey.FindStringSubmatchIndex(content[offsets[i]:])
+		if match == nil {
+			continue
+		}
+		start, end := offsets[i]+match[2], offsets[i]+match[3]
+		if newKey, deprecated := deprecatedKeys[content[start:end]]; deprecated {
+			replaced = append([]string{content[start:end]}, replaced...)
+			content = content[:start] + newKey + content[end:]
+		}
+	}
+	return content, replaced
+}
+
+// migrateDeprecatedKeys returns the content with the current key names and warns the user about deprecated keys
+func migrateDeprecatedKeys(source, content string) string {
+	result, replaced := migrateConfigContent(content)
+	for _, key := range replaced {
+		if !warnedDeprecatedKeys[key] {
+			warnedDeprecatedKeys[key] = true
+			printWarning(msgDeprecatedKey, key, source, deprecatedKeys[key])
+		}
+	}
+	return result
+}
+
+// migrateConfigFiles rewrites the configuration files of the current folder and its parents to replace the deprecated keys
+func migrateConfigFiles(app *TGFApplication) int {
+	config := TGFConfig{tgf: app}
+	for _, file := range config.findConfigFiles(must(os.Getwd()).(string)) {
+		info, err := os.Stat(file)
+		if err != nil {
+			printError(msgConfigInvalid, err)
+			continue
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			printError(msgFileReadFailed, file, err)
+			continue
+		}
+		result, replaced := migrateConfigContent(string(content))
+		if len(replaced) == 0 {
+			continue
+		}
+		if err := ioutil.WriteFile(file, []byte(result), info.Mode()); err != nil {
+			printError(msgFileWriteFailed, file, err)
+			return 1
+		}
+		for _, key := range replaced {
+			ErrPrintln(fmt.Sprintf("%s: %s => %s", file, key, deprecatedKeys[key]))
+		}
+	}
+	return 0
+}
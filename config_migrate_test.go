@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateConfigContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		want     string
+		replaced []string
+	}{
+		{"Nothing to migrate", "docker-image: coveo/tgf\n", "docker-image: coveo/tgf\n", nil},
+		{"YAML", "docker-image: coveo/tgf\nrecommended-image: \">=1.20\"\n", "docker-image: coveo/tgf\nrecommended-image-version: \">=1.20\"\n", []string{"recommended-image"}},
+		{"YAML comments", "# recommended-image: 1.19\nrecommended-image: 1.20 # recommended-image: 1.19\n", "# recommended-image: 1.19\nrecommended-image-version: 1.20 # recommended-image: 1.19\n", []string{"recommended-image"}},
+		{"JSON", `{ "alias": {"recommended-image": "b"}, "recommended-image": "1.20" }`, `{ "alias": {"recommended-image": "b"}, "recommended-image-version": "1.20" }`, []string{"recommended-image"}},
+		{"JSON multiline", "{\n  \"recommended-image\": \"1.20\"\n}", "{\n  \"recommended-image-version\": \"1.20\"\n}", []string{"recommended-image"}},
+		{"HCL", "recommended-image = \">=1.20\"", "recommended-image-version = \">=1.20\"", []string{"recommended-image"}},
+		{"Nested keys are not replaced", "recommended-image: 1.20\nalias:\n  recommended-image: echo\n", "recommended-image-version: 1.20\nalias:\n  recommended-image: echo\n", []string{"recommended-image"}},
+		{"Only nested keys", "alias:\n  recommended-image: echo\n", "alias:\n  recommended-image: echo\n", nil},
+		{"Values are not replaced", "run-before: 'echo recommended-image: x'\nrecommended-image: 1.20\n", "run-before: 'echo recommended-image: x'\nrecommended-image-version: 1.20\n", []string{"recommended-image"}},
+		{"Multi-lines values are not replaced", "run-before: |\n  recommended-image: x\nrecommended-image: 1.20\n", "run-before: |\n  recommended-image: x\nrecommended-image-version: 1.20\n", []string{"recommended-image"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced := migrateConfigContent(tt.content)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.replaced, replaced)
+		})
+	}
+}
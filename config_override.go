@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coveooss/gotemplate/v3/collections"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// AWSOverride contains configuration values that are only applied if the current AWS context matches all the criteria.
+// Criteria are evaluated as glob patterns and an empty criterion matches any value.
+type AWSOverride struct {
+	Account string                 `yaml:"account,omitempty" json:"account,omitempty" hcl:"account,omitempty"`
+	Profile string                 `yaml:"profile,omitempty" json:"profile,omitempty" hcl:"profile,omitempty"`
+	Region  string                 `yaml:"region,omitempty" json:"region,omitempty" hcl:"region,omitempty"`
+	Config  map[string]interface{} `yaml:"config,omitempty" json:"config,omitempty" hcl:"config,omitempty"`
+}
+
+// ProfileConfigs contains configuration values bound to AWS profile names
+type ProfileConfigs map[string]map[string]interface{}
+
+// awsContext describes the AWS identity resolved for the current execution
+type awsContext struct {
+	account, profile, region string
+}
+
+func (override AWSOverride) matches(context awsContext) bool {
+	match := func(pattern, value string) bool {
+		if pattern == "" {
+			return true
+		}
+		matched, _ := filepath.Match(pattern, value)
+		return matched
+	}
+	return match(override.Account, context.account) && match(override.Profile, context.profile) && match(override.Region, context.region)
+}
+
+// getAWSContext returns the current AWS account, profile and region.
+// The account is only resolved (through an STS call) if one of the overrides requires it.
+func (config *TGFConfig) getAWSContext() (context awsContext) {
+	context.profile = config.awsProfile
+	context.region = os.Getenv("AWS_REGION")
+	if config.awsSession == nil {
+		return
+	}
+	if region := aws.StringValue(config.awsSession.Config.Region); region != "" {
+		context.region = region
+	}
+	for _, override := range config.AWSOverrides {
+		if override.Account != "" {
+			context.account = config.getAWSAccount()
+			break
+		}
+	}
+	return
+}
+
+// getAWSAccount returns the AWS account of the current session (the result is cached)
+func (config *TGFConfig) getAWSAccount() string {
+	if config.awsAccount == "" && config.ensureAWSSession() == nil && config.awsSession != nil {
+		identity, err := sts.New(config.awsSession, awsRetryConfig()).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+		if err != nil {
+			printWarning(msgAWSAccountUnresolved, describeAWSError(err))
+			return ""
+		}
+		config.awsAccount = aws.StringValue(identity.Account)
+	}
+	return config.awsAccount
+}
+
+// getProfileName returns the name of the selected AWS profile (--profile, AWS_PROFILE or default)
+func (config *TGFConfig) getProfileName() string {
+	switch {
+	case config.awsProfile != "":
+		return config.awsProfile
+	case config.tgf != nil && config.tgf.AwsProfile != "":
+		return config.tgf.AwsProfile
+	case os.Getenv("AWS_PROFILE") != "":
+		return os.Getenv("AWS_PROFILE")
+	}
+	return "default"
+}
+
+// applyProfileConfig applies the configuration section bound to the selected AWS profile.
+// This allows the profile to be the single selector for the role, the region and the image of a given environment.
+func (config *TGFConfig) applyProfileConfig() {
+	profile := config.getProfileName()
+	values, ok := config.Profiles[profile]
+	if !ok {
+		return
+	}
+	config.tgf.Debug("# Applying configuration of AWS profile %s", profile)
+	content, err := yaml.Marshal(values)
+	if err == nil {
+		err = collections.ConvertData(string(content), config)
+	}
+	if err != nil {
+		printError(msgProfileConfigFailed, profile, err)
+		return
+	}
+	config.trackSources(fmt.Sprintf("profiles[%s]", profile), string(content))
+}
+
+// applyAWSOverrides applies the configuration overrides matching the current AWS context and returns true if any of them has been
+// applied. It must be called after the AWS credentials have been resolved (the role assumed) to match the account of the role.
+func (config *TGFConfig) applyAWSOverrides() (applied bool) {
+	if len(config.AWSOverrides) == 0 {
+		return
+	}
+	if config.ensureAWSSession(); config.awsSession == nil {
+		config.tgf.Debug("# AWS overrides are ignored since there is no AWS session")
+		return
+	}
+
+	context := config.getAWSContext()
+	for i, override := range config.AWSOverrides {
+		if !override.matches(context) {
+			continue
+		}
+		config.tgf.Debug("# Applying AWS override (account=%q, profile=%q, region=%q)", override.Account, override.Profile, override.Region)
+		content, err := yaml.Marshal(override.Config)
+		if err == nil {
+			err = collections.ConvertData(string(content), config)
+		}
+		if err == nil {
+			config.trackSources(fmt.Sprintf("aws-overrides[%d]", i), string(content))
+			applied = true
+		}
+		if err != nil {
+			printError(msgAWSOverrideFailed, override.Account, override.Profile, override.Region, err)
+		}
+	}
+	return
+}
+
+// parseSetValues converts a list of key=value assignments (dotted keys are used to specify nested values) into a map
+func parseSetValues(assignments []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, assignment := range assignments {
+		key, value := Split2(assignment, "=")
+		if key == "" || !strings.Contains(assignment, "=") {
+			return nil, fmt.Errorf("Invalid --set value %q, the format must be key=value", assignment)
+		}
+
+		var converted interface{} = value
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			if err := collections.ConvertData(value, &converted); err != nil {
+				return nil, fmt.Errorf("Invalid --set value %q: %v", assignment, err)
+			}
+		}
+
+		setNestedValue(result, strings.Split(key, "."), converted)
+	}
+	return result, nil
+}
+
+// setNestedValue sets a value in a tree of maps, the intermediate maps are created if they do not exist
+func setNestedValue(target map[string]interface{}, path []string, value interface{}) {
+	current := target
+	for _, part := range path[:len(path)-1] {
+		var child map[string]interface{}
+		switch existing := current[part].(type) {
+		case map[string]interface{}:
+			child = existing
+		case collections.IDictionary:
+			child = existing.AsMap()
+		default:
+			child = make(map[string]interface{})
+		}
+		current[part] = child
+		current = child
+	}
+	current[path[len(path)-1]] = value
+}
+
+// applySetValues applies the values supplied through --set on top of the merged configuration
+func (config *TGFConfig) applySetValues(assignments []string) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	values, err := parseSetValues(assignments)
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := collections.ConvertData(string(content), config); err != nil {
+		return err
+	}
+	config.trackSources(sourceCommandLine, string(content))
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAWSOverrideMatches(t *testing.T) {
+	t.Parallel()
+
+	context := awsContext{account: "123456789012", profile: "prod-admin", region: "us-east-1"}
+	tests := []struct {
+		name     string
+		override AWSOverride
+		want     bool
+	}{
+		{"No criteria", AWSOverride{}, true},
+		{"Account", AWSOverride{Account: "123456789012"}, true},
+		{"Other account", AWSOverride{Account: "210987654321"}, false},
+		{"Profile pattern", AWSOverride{Profile: "prod-*"}, true},
+		{"Region pattern", AWSOverride{Region: "eu-*"}, false},
+		{"All criteria", AWSOverride{Account: "123456789012", Profile: "prod-*", Region: "us-*"}, true},
+		{"One criterion fails", AWSOverride{Account: "123456789012", Profile: "dev"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.override.matches(context))
+		})
+	}
+}
+
+func TestApplyAWSOverridesAfterRegion(t *testing.T) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+	config := &TGFConfig{
+		tgf:          &TGFApplication{},
+		Environment:  map[string]string{},
+		AWSRegion:    "eu-west-1",
+		AWSOverrides: []AWSOverride{{Region: "eu-*", Config: map[string]interface{}{"docker-image-version": "1.20.3"}}},
+		awsSession:   session.Must(session.NewSession(aws.NewConfig().WithRegion("us-east-1"))),
+	}
+	config.applyAWSRegion()
+	assert.True(t, config.applyAWSOverrides(), "The override matches the region of aws-region, not the one of the host session")
+	assert.Equal(t, "1.20.3", *config.ImageVersion)
+	assert.Equal(t, "aws-overrides[0]", config.sources["docker-image-version"])
+
+	config.AWSOverrides[0].Region = "ca-*"
+	assert.False(t, config.applyAWSOverrides())
+}
+
+func TestApplyProfileConfig(t *testing.T) {
+	t.Parallel()
+
+	profiles := ProfileConfigs{
+		"prod": {"role-arn": "arn:aws:iam::123456789012:role/deploy", "aws-region": "us-east-1", "required-image-version": ">= 1.20"},
+		"dev":  {"aws-region": "ca-central-1"},
+	}
+	tests := []struct {
+		name    string
+		profile string
+		want    TGFConfig
+	}{
+		{"Prod", "prod", TGFConfig{RoleArn: "arn:aws:iam::123456789012:role/deploy", AWSRegion: "us-east-1", RequiredVersionRange: ">= 1.20"}},
+		{"Dev", "dev", TGFConfig{AWSRegion: "ca-central-1"}},
+		{"Unbound profile", "other", TGFConfig{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &TGFConfig{tgf: &TGFApplication{AwsProfile: tt.profile}, Profiles: profiles}
+			config.applyProfileConfig()
+			assert.Equal(t, tt.want.RoleArn, config.RoleArn)
+			assert.Equal(t, tt.want.AWSRegion, config.AWSRegion)
+			assert.Equal(t, tt.want.RequiredVersionRange, config.RequiredVersionRange)
+			if tt.want.AWSRegion != "" {
+				assert.Equal(t, "profiles["+tt.profile+"]", config.sources["aws-region"])
+			}
+		})
+	}
+}
+
+func TestApplySetValues(t *testing.T) {
+	t.Parallel()
+
+	config := &TGFConfig{Image: "coveo/tgf", Environment: map[string]string{"A": "1"}}
+	err := config.applySetValues([]string{
+		"docker-image-version=1.20",
+		"environment.B=2",
+		`docker-options=["--read-only", "--rm"]`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "coveo/tgf", config.Image)
+	assert.Equal(t, "1.20", *config.ImageVersion)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2"}, config.Environment)
+	assert.Equal(t, []string{"--read-only", "--rm"}, config.DockerOptions)
+	assert.Equal(t, sourceCommandLine, config.sources["environment"])
+
+	assert.Error(t, config.applySetValues([]string{"no-value"}))
+	assert.Error(t, config.applySetValues([]string{"=value"}))
+}
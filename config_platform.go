@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const platformOverridesKey = "platform-overrides"
+
+// PlatformOverride contains configuration values that are only applied if the host operating system and architecture match.
+// Criteria are evaluated as glob patterns and an empty criterion matches any value.
+type PlatformOverride struct {
+	OS     string                 `yaml:"os,omitempty" json:"os,omitempty" hcl:"os,omitempty"`
+	Arch   string                 `yaml:"arch,omitempty" json:"arch,omitempty" hcl:"arch,omitempty"`
+	Config map[string]interface{} `yaml:"config,omitempty" json:"config,omitempty" hcl:"config,omitempty"`
+}
+
+func (override PlatformOverride) matches(goos, goarch string) bool {
+	match := func(pattern, value string) bool {
+		if pattern == "" {
+			return true
+		}
+		matched, _ := filepath.Match(pattern, value)
+		return matched
+	}
+	return match(override.OS, goos) && match(override.Arch, goarch)
+}
+
+// getPlatformOverrides returns the platform overrides defined in a configuration content
+func getPlatformOverrides(content string) []PlatformOverride {
+	var data struct {
+		Overrides []PlatformOverride `yaml:"platform-overrides" json:"platform-overrides" hcl:"platform-overrides"`
+	}
+	if err := collections.ConvertData(content, &data); err != nil {
+		return nil
+	}
+	return data.Overrides
+}
+
+// expandPlatformOverrides returns the configuration sources with the platform overrides matching the host (goos, goarch)
+// inserted right after the source that defines them (they have precedence over their source but not over the following sources).
+func (config *TGFConfig) expandPlatformOverrides(configsData []configData, goos, goarch string) (result []configData) {
+	for _, data := range configsData {
+		result = append(result, data)
+		for i, override := range getPlatformOverrides(data.Raw) {
+			if !override.matches(goos, goarch) {
+				continue
+			}
+			content, err := yaml.Marshal(override.Config)
+			if err != nil {
+				printError(msgPlatformOverrideFailed, override.OS, override.Arch, data.Name, err)
+				continue
+			}
+			config.tgf.Debug("# Applying platform override (os=%q, arch=%q) from %s", override.OS, override.Arch, data.Name)
+			result = append(result, configData{Name: fmt.Sprintf("%s (%s[%d])", data.Name, platformOverridesKey, i), Raw: string(content)})
+		}
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPlatformOverrides(t *testing.T) {
+	t.Parallel()
+
+	content := String(`
+		docker-image-tag: amd
+		platform-overrides:
+		  - os: darwin
+		    config:
+		      docker-options: ["--mount", "type=bind,source=/Users,target=/Users"]
+		  - arch: arm*
+		    config:
+		      docker-image-tag: arm
+	`).UnIndent().Str()
+
+	tests := []struct {
+		name   string
+		goos   string
+		goarch string
+		want   []string
+	}{
+		{"No match", "linux", "amd64", []string{"file"}},
+		{"OS", "darwin", "amd64", []string{"file", "file (platform-overrides[0])"}},
+		{"Arch", "linux", "arm64", []string{"file", "file (platform-overrides[1])"}},
+		{"Both", "darwin", "arm64", []string{"file", "file (platform-overrides[0])", "file (platform-overrides[1])"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &TGFConfig{tgf: &TGFApplication{}}
+			result := config.expandPlatformOverrides([]configData{{Name: "file", Raw: content}, {Name: "env", Raw: "docker-image: test"}}, tt.goos, tt.goarch)
+			var names []string
+			for _, data := range result {
+				names = append(names, data.Name)
+			}
+			assert.Equal(t, append(tt.want, "env"), names)
+		})
+	}
+
+	config := &TGFConfig{tgf: &TGFApplication{}}
+	result := config.expandPlatformOverrides([]configData{{Name: "file", Raw: content}}, "linux", "arm64")
+	assert.Equal(t, "docker-image-tag: arm\n", result[1].Raw)
+}
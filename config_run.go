@@ -12,46 +12,106 @@ func (config *TGFConfig) Run() int {
 
 	// If AWS profile is supplied, we freeze the current session
 	if app.AwsProfile != "" {
-		must(config.InitAWS(app.AwsProfile))
+		if err := config.InitAWS(app.AwsProfile); err != nil {
+			return failWith(exitCredentials, err)
+		}
+	}
+	config.applyProfileConfig()
+	if err := config.applySetValues(app.SetValues); err != nil {
+		return failWith(exitConfig, err)
 	}
 
-	if app.Image != "" {
-		config.Image = app.Image
-		config.RecommendedImageVersion = ""
-		config.RequiredVersionRange = ""
-		config.ImageVersion = nil
-		config.ImageTag = nil
+	config.applyCommandLineOverrides()
+	config.debugSources()
+	if app.ConfigDump {
+		return config.dumpConfig()
 	}
-	if app.ImageVersion != "-" {
-		config.ImageVersion = &app.ImageVersion
+	if app.ConfigLint {
+		return config.runLint(app.StrictLint)
 	}
-	if app.ImageTag != "-" {
-		config.ImageTag = &app.ImageTag
+	if app.ListImages {
+		return config.listImages()
 	}
-	if app.Entrypoint != "" {
-		config.EntryPoint = app.Entrypoint
+	if len(app.RemoveImages) > 0 {
+		return config.removeImages(app.RemoveImages)
+	}
+	if !app.GetImageName && !app.RefreshOnly && !app.PruneImages && config.RoleArn == "" {
+		// The AWS credentials are resolved on the host to be supplied to the container (if a role is configured, the host
+		// session is only initialized if the credentials of the role are not cached)
+		if err := config.ensureAWSSession(); err != nil {
+			printError(msgAWSSessionFailed, err)
+		}
+	}
+	config.applyAWSRegion()
+	if err := config.assumeRole(); err != nil {
+		return failWith(exitCredentials, err)
+	}
+	if config.applyAWSOverrides() {
+		// The overrides match the resolved identity and region, the values supplied on the command line still take precedence
+		if err := config.applySetValues(app.SetValues); err != nil {
+			return failWith(exitConfig, err)
+		}
+		config.applyCommandLineOverrides()
+		config.applyAWSRegion()
+		config.debugSources()
+	}
+	if app.PickImage {
+		config.pickImageVersion()
+	}
+	if err := config.validateImageFamily(); err != nil {
+		return failWith(exitConfig, err)
 	}
 	if !config.ValidateVersion() {
-		return 1
+		return exitConfig
 	}
 
 	if app.GetAllVersions {
-		if filepath.Base(config.EntryPoint) != "terragrunt" {
-			printError(("--all-version works only with terragrunt as the entrypoint"))
+		switch filepath.Base(config.EntryPoint) {
+		case "terragrunt":
+			app.Unmanaged = []string{"get-versions"}
+		case imageFamilyTerraform, imageFamilyTofu:
+			app.Unmanaged = []string{"version"}
+		default:
+			printError(msgAllVersionsUnsupported)
 			return 1
 		}
 		Println("TGF version", version)
-		app.Unmanaged = []string{"get-versions"}
+	}
+
+	if app.ReadOnlyState && config.RoleArn == "" && len(config.RoleChain) == 0 {
+		printWarning(msgStateNotReadOnly)
+	}
+	if err := config.checkCredentialsTTL(app.RequiredCredTTL); err != nil {
+		return failWith(exitCredentials, err)
+	}
+	if app.ExportCredentials != "" {
+		return config.exportCredentials(app.ExportCredentials, app.ExportProfile)
+	}
+	if app.WhoHoldsLock {
+		return config.whoHoldsLock()
+	}
+	if err := config.resolveCredentialSources(); err != nil {
+		return failWith(exitCredentials, err)
 	}
 
 	docker := dockerConfig{config}
 	imageName := config.GetImageName()
-	if lastRefresh(imageName) > config.Refresh || config.IsPartialVersion() || !checkImage(imageName) || app.Refresh {
-		docker.refreshImage(imageName)
+	remote := config.getRunner() != runnerDocker
+	if remote {
+		if err := config.validateRunner(); err != nil {
+			return failWith(exitConfig, err)
+		}
+	} else if lastRefresh(imageName) > config.Refresh || config.IsPartialVersion() || !checkImage(imageName) || app.Refresh {
+		if app.DryRun {
+			printInfo("docker", map[string]interface{}{"image": imageName}, msgDryRunRefreshSkipped, imageName)
+		} else {
+			endGroup := logGroup("Refreshing image " + imageName)
+			docker.refreshImage(imageName)
+			endGroup()
+		}
 	}
-
-	if app.LoggingLevel != "" {
-		config.LogLevel = app.LoggingLevel
+	if app.RefreshOnly {
+		return 0
 	}
 
 	if app.PruneImages {
@@ -59,19 +119,81 @@ func (config *TGFConfig) Run() int {
 		return 0
 	}
 
-	if config.EntryPoint == "terragrunt" && app.Unmanaged == nil && !app.DebugMode && !app.GetImageName {
+	if app.WriteLock {
+		return config.writeLock(imageName)
+	}
+	if app.Locked {
+		if err := config.verifyLock(imageName); err != nil {
+			return failWith(exitConfig, err)
+		}
+	}
+
+	if !app.DryRun && !app.GetImageName {
+		if err := config.confirmRun(); err != nil {
+			printError(msgRunCancelled, err)
+			return 1
+		}
+	}
+
+	if config.EntryPoint == "terragrunt" && app.Unmanaged == nil && !app.DebugMode && !app.GetImageName && infoEnabled() {
 		title := color.New(color.FgYellow, color.Underline).SprintFunc()
 		ErrPrintln(title("\nTGF Usage\n"))
-		app.Usage(nil)
+		app.UsageWriter(color.Error).Usage(nil)
+	}
+
+	if remote && !app.GetImageName {
+		// The image is pulled by the remote runner, the local docker daemon is not used
+		run, err := config.newRemoteRun()
+		if err != nil {
+			return failWith(exitConfig, err)
+		}
+		return config.runRemote(run)
 	}
 
 	if config.ImageVersion == nil {
 		actualVersion := docker.GetActualImageVersion()
 		config.ImageVersion = &actualVersion
 		if !config.ValidateVersion() {
-			return 2
+			return exitConfig
 		}
 	}
 
 	return docker.call()
 }
+
+// applyCommandLineOverrides applies the configuration values supplied through the tgf arguments
+func (config *TGFConfig) applyCommandLineOverrides() {
+	app := config.tgf
+	if app.Image != "" {
+		config.Image = app.Image
+		config.RecommendedImageVersion = ""
+		config.RequiredVersionRange = ""
+		config.ImageVersion = nil
+		config.ImageTag = nil
+		config.setSource("docker-image", sourceCommandLine)
+	}
+	if app.ImageVersion != "-" {
+		config.ImageVersion = &app.ImageVersion
+		config.setSource("docker-image-version", sourceCommandLine)
+	}
+	if app.ImageTag != "-" {
+		config.ImageTag = &app.ImageTag
+		config.setSource("docker-image-tag", sourceCommandLine)
+	}
+	if app.Entrypoint != "" {
+		config.EntryPoint = app.Entrypoint
+		config.setSource("entry-point", sourceCommandLine)
+	}
+	if app.LoggingLevel != "" {
+		config.LogLevel = app.LoggingLevel
+		config.setSource("logging-level", sourceCommandLine)
+	}
+	if app.AwsRegion != "" {
+		config.AWSRegion = app.AwsRegion
+		config.setSource("aws-region", sourceCommandLine)
+	}
+	if app.Runner != "" {
+		config.Runner = app.Runner
+		config.setSource("runner", sourceCommandLine)
+	}
+}
@@ -60,5 +60,45 @@ func TestAllVersions(t *testing.T) {
 		exitCode := InitConfig(app).Run()
 		assert.Equal(t, 1, exitCode, "exitCode")
 	})
-	assert.Equal(t, color.RedString("--all-version works only with terragrunt as the entrypoint")+"\n", output)
+	assert.Equal(t, color.RedString("--all-versions works only with terragrunt, terraform or tofu as the entrypoint")+"\n", output)
+}
+
+// BenchmarkRunDryRun measures the overhead of tgf around the container (configuration, credentials, image lookup, docker arguments
+// and reporting), run it with make bench to catch performance regressions before a release
+func BenchmarkRunDryRun(b *testing.B) {
+	tempDir := must(filepath.EvalSymlinks(must(ioutil.TempDir("", "BenchmarkRunDryRun")).(string))).(string)
+	defer os.RemoveAll(tempDir)
+	currentDir := must(os.Getwd()).(string)
+	defer os.Chdir(currentDir)
+	must(os.MkdirAll(filepath.Join(tempDir, "live", "dev"), 0755))
+	must(os.Chdir(filepath.Join(tempDir, "live", "dev")))
+	must(ioutil.WriteFile(filepath.Join(tempDir, configFile), []byte(String(`
+		docker-image: coveo/tgf
+		docker-image-version: 1.21.0
+		environment:
+		  TF_IN_AUTOMATION: "1"
+	`).UnIndent().TrimSpace()), 0644))
+
+	for _, variable := range []string{"XDG_CACHE_HOME", "XDG_CONFIG_HOME"} {
+		defer os.Setenv(variable, os.Getenv(variable))
+		os.Setenv(variable, tempDir)
+	}
+	defer func(inspect func(string) (imageInfo, error)) { inspectImage = inspect }(inspectImage)
+	inspectImage = func(image string) (imageInfo, error) {
+		return imageInfo{Exists: true, ID: "sha256:1234", Digest: image + "@sha256:5678", Version: "1.21.0"}, nil
+	}
+	stdout, output, errOutput := os.Stdout, color.Output, color.Error
+	defer func() { os.Stdout, color.Output, color.Error = stdout, output, errOutput }()
+	devNull := must(os.OpenFile(os.DevNull, os.O_WRONLY, 0)).(*os.File)
+	defer devNull.Close()
+	os.Stdout, color.Output, color.Error = devNull, ioutil.Discard, ioutil.Discard
+
+	args := []string{"--no-aws", "--dry-run", "--no-interactive", "plan"}
+	touchImageRefresh("coveo/tgf:1.21.0")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if exitCode := NewTestApplication(args).Run(); exitCode != 0 {
+			b.Fatalf("Exit code %d", exitCode)
+		}
+	}
 }
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/gruntwork-io/terragrunt/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ssmBatchSize is the maximum number of parameters that can be retrieved by a single GetParameters call
+const ssmBatchSize = 10
+
+// ssmParameters is the cached representation of an SSM parameter tree
+type ssmParameters struct {
+	Fingerprint string            `json:"fingerprint"`
+	Versions    map[string]int64  `json:"versions,omitempty"`
+	Values      map[string]string `json:"values"`
+}
+
+// readSSMParameterStore returns all the parameters under the SSM folder (recursively), keyed by their path relative to the folder.
+//
+// The parameters are cached with their versions. Once the cache TTL is expired, only the parameters metadata is fetched and
+// the values of the parameters that have been added or modified are retrieved by batches (the whole tree is retrieved by path
+// if there is no previous version in the cache).
+func (config *TGFConfig) readSSMParameterStore(ssmParameterFolder string) map[string]string {
+	key := "ssm-tree:" + ssmParameterFolder
+	content, err := config.cachedRemoteConfig(key, func() (string, error) {
+		if err := config.ensureAWSSession(); err != nil {
+			return "", err
+		}
+		if config.awsSession == nil {
+			return "", fmt.Errorf("No AWS session available to read SSM %s", ssmParameterFolder)
+		}
+		client := ssm.New(config.awsSession, awsRetryConfig())
+		versions, err := getSSMVersions(client, ssmParameterFolder)
+		if err != nil {
+			return "", describeAWSError(err)
+		}
+		fingerprint := getSSMFingerprint(versions)
+
+		var cached ssmParameters
+		if previous, err := ioutil.ReadFile(getRemoteConfigCacheFile(key)); err == nil && json.Unmarshal(previous, &cached) == nil {
+			if cached.Fingerprint == fingerprint {
+				config.tgf.Debug("# SSM parameters under %s have not changed\n", ssmParameterFolder)
+				return string(previous), nil
+			}
+		}
+
+		var values map[string]string
+		if cached.Versions == nil {
+			config.tgf.Debug("# Reading configuration from SSM %s\n", ssmParameterFolder)
+			values, err = getSSMParameters(client, ssmParameterFolder)
+		} else {
+			values = make(map[string]string, len(versions))
+			var changed []string
+			for name, version := range versions {
+				if previous, ok := cached.Versions[name]; ok && previous == version {
+					values[getSSMKey(ssmParameterFolder, name)] = cached.Values[getSSMKey(ssmParameterFolder, name)]
+				} else {
+					changed = append(changed, name)
+				}
+			}
+			config.tgf.Debug("# Reading %d changed parameter(s) from SSM %s\n", len(changed), ssmParameterFolder)
+			err = getSSMParametersByName(client, ssmParameterFolder, changed, values)
+		}
+		if err != nil {
+			return "", describeAWSError(err)
+		}
+		bytes, err := json.Marshal(ssmParameters{Fingerprint: fingerprint, Versions: versions, Values: values})
+		return string(bytes), err
+	})
+	if err != nil {
+		printError(msgParameterStoreIgnored, ssmParameterFolder, err)
+		return nil
+	}
+
+	var parameters ssmParameters
+	must(json.Unmarshal([]byte(content), &parameters))
+	return parameters.Values
+}
+
+// getSSMKey returns the key of a parameter relative to the folder
+func getSSMKey(folder, name string) string {
+	return strings.Trim(strings.TrimPrefix(name, folder), "/")
+}
+
+// getSSMVersions returns the version of each parameter under the folder (without retrieving their values)
+func getSSMVersions(client *ssm.SSM, folder string) (map[string]int64, error) {
+	versions := make(map[string]int64)
+	input := &ssm.DescribeParametersInput{
+		MaxResults: aws.Int64(50),
+		ParameterFilters: []*ssm.ParameterStringFilter{{
+			Key:    aws.String("Path"),
+			Option: aws.String("Recursive"),
+			Values: []*string{aws.String(folder)},
+		}},
+	}
+	err := client.DescribeParametersPages(input, func(page *ssm.DescribeParametersOutput, lastPage bool) bool {
+		for _, parameter := range page.Parameters {
+			versions[aws.StringValue(parameter.Name)] = aws.Int64Value(parameter.Version)
+		}
+		return true
+	})
+	return versions, err
+}
+
+// getSSMFingerprint returns a value that changes whenever a parameter is added, removed or updated
+func getSSMFingerprint(versions map[string]int64) string {
+	entries := make([]string, 0, len(versions))
+	for name, version := range versions {
+		entries = append(entries, fmt.Sprintf("%s:%d", name, version))
+	}
+	sort.Strings(entries)
+	return util.EncodeBase64Sha1(strings.Join(entries, "\n"))
+}
+
+// getSSMParameters returns the decrypted values of all parameters under the folder
+func getSSMParameters(client *ssm.SSM, folder string) (map[string]string, error) {
+	values := make(map[string]string)
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(folder),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+		MaxResults:     aws.Int64(ssmBatchSize),
+	}
+	err := client.GetParametersByPathPages(input, func(page *ssm.GetParametersByPathOutput, lastPage bool) bool {
+		for _, parameter := range page.Parameters {
+			values[getSSMKey(folder, aws.StringValue(parameter.Name))] = aws.StringValue(parameter.Value)
+		}
+		return true
+	})
+	return values, err
+}
+
+// getSSMParametersByName adds the decrypted values of the named parameters to values, the parameters are retrieved by batches
+// (a parameter deleted in the meantime is ignored)
+func getSSMParametersByName(client *ssm.SSM, folder string, names []string, values map[string]string) error {
+	sort.Strings(names)
+	for start := 0; start < len(names); start += ssmBatchSize {
+		end := start + ssmBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		response, err := client.GetParameters(&ssm.GetParametersInput{
+			Names:          aws.StringSlice(names[start:end]),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+		for _, parameter := range response.Parameters {
+			values[getSSMKey(folder, aws.StringValue(parameter.Name))] = aws.StringValue(parameter.Value)
+		}
+	}
+	return nil
+}
+
+// parseSsmConfig converts parameters into a configuration content.
+//
+// Parameters are merged by hierarchy (i.e. /default/tgf/environment/NAME defines the key NAME in environment) and
+// values looking like a dictionary or a list are interpreted.
+func parseSsmConfig(parameterValues map[string]string) string {
+	keys := make([]string, 0, len(parameterValues))
+	for key := range parameterValues {
+		keys = append(keys, key)
+	}
+	// Parents are processed before their children to allow children to be merged into their parent dictionary
+	sort.Strings(keys)
+
+	result := make(map[string]interface{})
+	for _, key := range keys {
+		setNestedValue(result, strings.Split(key, "/"), convertConfigValue(parameterValues[key]))
+	}
+	if len(result) == 0 {
+		return ""
+	}
+	return string(must(yaml.Marshal(result)).([]byte))
+}
+
+// convertConfigValue returns the value of a configuration key defined as a string, the lists and dictionaries can be written in JSON
+// or YAML flow style, any other value is kept as is
+func convertConfigValue(value string) interface{} {
+	var converted interface{} = value
+	isDict := strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}")
+	isList := strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")
+	if isDict || isList {
+		if err := collections.ConvertData(value, &converted); err != nil {
+			converted = value
+		}
+	}
+	return converted
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSsmConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		want       string
+	}{
+		{"Empty", nil, ""},
+		{"Simple values", map[string]string{"docker-image": "coveo/tgf", "docker-refresh": "1h"}, "docker-image: coveo/tgf\ndocker-refresh: 1h\n"},
+		{"Numbers are kept as string", map[string]string{"docker-image-version": "1.20"}, "docker-image-version: \"1.20\"\n"},
+		{"List", map[string]string{"docker-options": `["--read-only"]`}, "docker-options:\n- --read-only\n"},
+		{
+			"Hierarchy",
+			map[string]string{"environment/A": "1", "environment/B": "2"},
+			"environment:\n  A: \"1\"\n  B: \"2\"\n",
+		},
+		{
+			"Hierarchy merged with dictionary",
+			map[string]string{"environment": `{"A": "1"}`, "environment/B": "2"},
+			"environment:\n  A: \"1\"\n  B: \"2\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseSsmConfig(tt.parameters))
+		})
+	}
+}
+
+func TestReadSSMParameterStoreLazySession(t *testing.T) {
+	folder := fmt.Sprintf("/test/TestReadSSMParameterStoreLazySession/%v", randInt())
+	key := "ssm-tree:" + folder
+	defer os.Remove(getRemoteConfigCacheFile(key))
+
+	var buffer bytes.Buffer
+	stderr := color.Error
+	color.Error = &buffer
+	defer func() { color.Error = stderr }()
+
+	// The session is not initialized since the cached parameters are not expired
+	config := &TGFConfig{tgf: &TGFApplication{RemoteConfigTTL: time.Hour, UseAWS: true}}
+	must(os.MkdirAll(getRemoteConfigCacheFile(""), 0755))
+	must(ioutil.WriteFile(getRemoteConfigCacheFile(key), []byte(`{"values": {"docker-image": "coveo/tgf"}}`), 0600))
+	assert.Equal(t, map[string]string{"docker-image": "coveo/tgf"}, config.readSSMParameterStore(folder))
+	assert.False(t, config.awsSessionTried)
+	assert.Empty(t, buffer.String())
+
+	// The parameter store is ignored if the session cannot be initialized
+	os.Remove(getRemoteConfigCacheFile(key))
+	config.awsSessionTried, config.awsSessionErr = true, errors.New("MFA code required")
+	assert.Nil(t, config.readSSMParameterStore(folder))
+	assert.Contains(t, buffer.String(), "it is ignored: MFA code required")
+}
+
+func TestEnsureAWSSession(t *testing.T) {
+	t.Parallel()
+
+	config := &TGFConfig{tgf: &TGFApplication{UseAWS: false}}
+	assert.NoError(t, config.ensureAWSSession())
+	assert.True(t, config.awsSessionTried)
+	assert.Nil(t, config.awsSession, "No session without AWS configuration")
+
+	config = &TGFConfig{tgf: &TGFApplication{UseAWS: true}, awsSessionTried: true, awsSessionErr: errors.New("expired")}
+	assert.EqualError(t, config.ensureAWSSession(), "expired", "The initialization is not attempted again")
+}
+
+func TestReadSSMParameterStoreBatches(t *testing.T) {
+	folder := fmt.Sprintf("/test/TestReadSSMParameterStoreBatches/%v", randInt())
+	defer os.Remove(getRemoteConfigCacheFile("ssm-tree:" + folder))
+
+	type parameter struct {
+		Name    string
+		Value   string
+		Version int64
+	}
+	store := map[string]*parameter{}
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("%s/environment/VAR%02d", folder, i)
+		store[name] = &parameter{name, fmt.Sprint(i), 1}
+	}
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSSM.")
+		calls[action]++
+		var request struct{ Names []string }
+		must(json.NewDecoder(r.Body).Decode(&request))
+		parameters := []*parameter{}
+		switch action {
+		case "GetParameters":
+			assert.True(t, len(request.Names) <= ssmBatchSize)
+			for _, name := range request.Names {
+				parameters = append(parameters, store[name])
+			}
+		default:
+			for _, parameter := range store {
+				parameters = append(parameters, parameter)
+			}
+		}
+		must(json.NewEncoder(w).Encode(map[string]interface{}{"Parameters": parameters}))
+	}))
+	defer server.Close()
+
+	config := &TGFConfig{tgf: &TGFApplication{}, awsSessionTried: true}
+	config.awsSession = session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKIA", "secret", ""),
+	}))
+
+	// The whole tree is retrieved by path the first time
+	values := config.readSSMParameterStore(folder)
+	assert.Len(t, values, 25)
+	assert.Equal(t, "3", values["environment/VAR03"])
+	assert.Equal(t, map[string]int{"DescribeParameters": 1, "GetParametersByPath": 1}, calls)
+
+	// Nothing is retrieved if the parameters have not changed
+	calls = map[string]int{}
+	assert.Equal(t, values, config.readSSMParameterStore(folder))
+	assert.Equal(t, map[string]int{"DescribeParameters": 1}, calls)
+
+	// Only the modified and added parameters are retrieved, by batches
+	calls = map[string]int{}
+	for i := 0; i < 12; i++ {
+		updated := store[fmt.Sprintf("%s/environment/VAR%02d", folder, i)]
+		updated.Value, updated.Version = "updated", 2
+	}
+	store[folder+"/docker-image"] = &parameter{folder + "/docker-image", "coveo/tgf", 1}
+	delete(store, folder+"/environment/VAR24")
+	values = config.readSSMParameterStore(folder)
+	assert.Len(t, values, 25)
+	assert.Equal(t, "updated", values["environment/VAR03"])
+	assert.Equal(t, "20", values["environment/VAR20"])
+	assert.Equal(t, "coveo/tgf", values["docker-image"])
+	assert.NotContains(t, values, "environment/VAR24")
+	assert.Equal(t, map[string]int{"DescribeParameters": 1, "GetParameters": 2}, calls)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configTemplateContext is the context made available to gotemplate expressions in configuration files.
+// Values that are expensive to compute (git, AWS) are exposed as methods to ensure that they are only resolved if used.
+type configTemplateContext struct {
+	Env    map[string]string
+	config *TGFConfig
+}
+
+// GitBranch returns the current git branch
+func (context configTemplateContext) GitBranch() string { return getGitBranch() }
+
+// AWSAccount returns the AWS account of the current session
+func (context configTemplateContext) AWSAccount() string { return context.config.getAWSAccount() }
+
+// AWSRegion returns the AWS region of the current session
+func (context configTemplateContext) AWSRegion() string { return context.config.getAWSContext().region }
+
+// AWSPartition returns the AWS partition of the current session (aws, aws-us-gov, aws-cn)
+func (context configTemplateContext) AWSPartition() string {
+	return getPartition(context.config.getAWSContext().region).ID()
+}
+
+// ECRRegistry returns the ECR registry hostname of the current account and region (partition aware)
+func (context configTemplateContext) ECRRegistry() string {
+	return getECRRegistry(context.config.getAWSAccount(), context.config.getAWSContext().region)
+}
+
+// User returns the name of the current user
+func (context configTemplateContext) User() string {
+	if currentUser, err := user.Current(); err == nil {
+		return currentUser.Username
+	}
+	return ""
+}
+
+// Date returns the current date (YYYY-MM-DD)
+func (context configTemplateContext) Date() string { return time.Now().Format("2006-01-02") }
+
+// templatePrefix marks the configuration values containing gotemplate expressions, the other values are never evaluated (they may
+// contain literal {{ }} such as the docker --format strings)
+const templatePrefix = "template://"
+
+// getValues returns the context as a dictionary (required by gotemplate), the values not referenced by the expression are not resolved
+func (context configTemplateContext) getValues(expression string) collections.IDictionary {
+	values := map[string]interface{}{"Env": context.Env}
+	lazyValues := map[string]func() string{
+		"GitBranch":    context.GitBranch,
+		"AWSAccount":   context.AWSAccount,
+		"AWSRegion":    context.AWSRegion,
+		"AWSPartition": context.AWSPartition,
+		"ECRRegistry":  context.ECRRegistry,
+		"User":         context.User,
+		"Date":         context.Date,
+	}
+	for name, resolve := range lazyValues {
+		if strings.Contains(expression, "."+name) {
+			values[name] = resolve()
+		}
+	}
+	return collections.AsDictionary(values)
+}
+
+// processTemplate evaluates the gotemplate expressions of the configuration values starting with template://.
+//
+// In addition to the gotemplate functions, readFile (relative to the configuration file folder) and date (using go time layout) are
+// available.
+func (config *TGFConfig) processTemplate(source, content string) (string, error) {
+	if !strings.Contains(content, templatePrefix) {
+		return content, nil
+	}
+	var data map[string]interface{}
+	if err := collections.ConvertData(content, &data); err != nil {
+		// The error will be reported when the configuration is loaded
+		return content, nil
+	}
+
+	context := configTemplateContext{Env: make(map[string]string), config: config}
+	for _, value := range os.Environ() {
+		key, value := Split2(value, "=")
+		context.Env[key] = value
+	}
+
+	folder := ""
+	if filepath.IsAbs(source) {
+		folder = filepath.Dir(source)
+	}
+	funcs := map[string]interface{}{
+		"readFile": func(filename string) (string, error) {
+			if !filepath.IsAbs(filename) && folder != "" {
+				filename = filepath.Join(folder, filename)
+			}
+			content, err := ioutil.ReadFile(filename)
+			return strings.TrimSpace(string(content)), err
+		},
+		"date": func(layout string) string { return time.Now().Format(layout) },
+	}
+	options := template.OptionsSet{template.Math: true, template.Sprig: true, template.Data: true, template.Utils: true, template.StrictErrorCheck: true}
+
+	resolved, err := transformStringValues(data, func(value string) (string, error) {
+		if !strings.HasPrefix(value, templatePrefix) {
+			return value, nil
+		}
+		expression := strings.TrimPrefix(value, templatePrefix)
+		t, err := template.NewTemplate(folder, context.getValues(expression), "", options)
+		if err != nil {
+			return "", err
+		}
+		// The expression is evaluated in the context of the source folder, the functions must be added to that context
+		t.GetNewContext(filepath.Dir(source), true).AddFunctions(funcs, "tgf", nil)
+		return t.ProcessContent(expression, source)
+	})
+	if err != nil {
+		return "", err
+	}
+	bytes, err := yaml.Marshal(resolved)
+	return string(bytes), err
+}
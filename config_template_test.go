@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessTemplate(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestProcessTemplate")).(string)
+	defer os.RemoveAll(tempDir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "token"), []byte("secret\n"), 0600))
+	os.Setenv("TGF_TEST_TEAM", "infra")
+	defer os.Unsetenv("TGF_TEST_TEAM")
+
+	config := &TGFConfig{}
+	source := filepath.Join(tempDir, configFile)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"No template", "docker-image: coveo/tgf", "docker-image: coveo/tgf"},
+		{"Not opted in", `docker-options: ["--format={{ .ID }}"]`, `docker-options: ["--format={{ .ID }}"]`},
+		{"Env", "docker-image-tag: template://{{ .Env.TGF_TEST_TEAM }}-k8s", "docker-image-tag: infra-k8s\n"},
+		{"Env function", `docker-image-tag: template://{{ env "TGF_TEST_TEAM" }}`, "docker-image-tag: infra\n"},
+		{"Gotemplate function", `docker-image-tag: template://{{ upper (env "TGF_TEST_TEAM") }}`, "docker-image-tag: INFRA\n"},
+		{"Missing env", `docker-image-tag: template://{{ env "TGF_TEST_UNDEFINED" }}`, "docker-image-tag: \"\"\n"},
+		{"Read file", `token: template://{{ readFile "token" }}`, "token: secret\n"},
+		{"Date", `docker-image-tag: template://{{ date "2006" }}`, "docker-image-tag: \"" + time.Now().Format("2006") + "\"\n"},
+		{"User", `role-source-identity: template://{{ .User }}`, "role-source-identity: " + must(user.Current()).(*user.User).Username + "\n"},
+		{"Nested", "environment:\n  TEAM: template://{{ .Env.TGF_TEST_TEAM }}\n  FORMAT: '{{ .ID }}'", "environment:\n  FORMAT: '{{ .ID }}'\n  TEAM: infra\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.processTemplate(source, tt.content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, content := range []string{"docker-image-tag: template://{{ undefined }}", "docker-image-tag: template://{{ .Env.TGF_TEST_UNDEFINED }}"} {
+		_, err := config.processTemplate(source, content)
+		assert.Error(t, err, "The errors are reported for the values that opted in")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -181,7 +182,11 @@ func TestParseAliases(t *testing.T) {
 			"other_arg1": "will not be replaced",
 			"with_quote": `quoted arg1 "arg 2" arg3="arg4 arg5" -D -it --rm`,
 			"recursive":  "to_replace five",
+			"plan-all":   "terragrunt run-all plan --terragrunt-parallelism 4",
+			"refresh":    "tgf --refresh-image",
+			"loop":       "loop again",
 		},
+		EntryPoint: "/usr/local/bin/terragrunt",
 	}
 
 	tests := []struct {
@@ -197,6 +202,9 @@ func TestParseAliases(t *testing.T) {
 		{"Replaced 2", config, strings.Split("to_replace other_arg1", " "), []string{"one", "two", "three,four", "other_arg1"}},
 		{"Replaced with quote", config, strings.Split("with_quote 1 2 3", " "), []string{"quoted", "arg1", "arg 2", "arg3=arg4 arg5", "-D", "-it", "--rm", "1", "2", "3"}},
 		{"Recursive", config, strings.Split("recursive", " "), []string{"one", "two", "three,four", "five"}},
+		{"Entry point removed", config, strings.Split("plan-all --terragrunt-non-interactive", " "), []string{"run-all", "plan", "--terragrunt-parallelism", "4", "--terragrunt-non-interactive"}},
+		{"Tgf removed", config, strings.Split("refresh", " "), []string{"--refresh-image"}},
+		{"Infinite recursion", config, strings.Split("loop 1", " "), []string{"loop", "again", "1"}},
 	}
 
 	for _, tt := range tests {
@@ -299,3 +307,35 @@ func randInt() int {
 	random := rand.New(source)
 	return random.Int()
 }
+
+func TestEnvironmentConfigs(t *testing.T) {
+	os.Setenv("TGF_DOCKER_IMAGE_TAG", "k8s")
+	os.Setenv("TGF_DOCKER_OPTIONS", `["--read-only"]`)
+	defer os.Unsetenv("TGF_DOCKER_IMAGE_TAG")
+	defer os.Unsetenv("TGF_DOCKER_OPTIONS")
+
+	configs := getEnvironmentConfigs()
+	assert.Len(t, configs, 2)
+
+	config := &TGFConfig{}
+	for _, data := range configs {
+		assert.NoError(t, collections.ConvertData(data.Raw, config))
+	}
+	assert.Equal(t, "k8s", *config.ImageTag)
+	assert.Equal(t, []string{"--read-only"}, config.DockerOptions)
+	assert.Equal(t, "TGF_ENTRY_POINT", getConfigEnvVar("entry-point"))
+}
+
+func TestEnvironmentConfigsWithQuotes(t *testing.T) {
+	os.Setenv("TGF_DOCKER_IMAGE_TAG", `k8s "beta" \ 'rc'`)
+	os.Setenv("TGF_ENTRY_POINT", `{"not": "a dictionary"`)
+	defer os.Unsetenv("TGF_DOCKER_IMAGE_TAG")
+	defer os.Unsetenv("TGF_ENTRY_POINT")
+
+	config := &TGFConfig{}
+	for _, data := range getEnvironmentConfigs() {
+		assert.NoError(t, collections.ConvertData(data.Raw, config))
+	}
+	assert.Equal(t, `k8s "beta" \ 'rc'`, *config.ImageTag)
+	assert.Equal(t, `{"not": "a dictionary"`, config.EntryPoint)
+}
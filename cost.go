@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultInfracostImage is the image running infracost if none is configured
+const defaultInfracostImage = "infracost/infracost:ci-latest"
+
+// InfracostConfig enables the estimation of the cost of the plans with infracost
+type InfracostConfig struct {
+	Enabled   bool     `yaml:"enabled,omitempty" json:"enabled,omitempty" hcl:"enabled,omitempty"`
+	Image     string   `yaml:"image,omitempty" json:"image,omitempty" hcl:"image,omitempty"`
+	Arguments []string `yaml:"arguments,omitempty" json:"arguments,omitempty" hcl:"arguments,omitempty"`
+}
+
+// costEstimate is the monthly cost of the infrastructure once the plans are applied (the sum of all the plans of a run-all)
+type costEstimate struct {
+	Currency        string  `json:"currency"`
+	MonthlyCost     float64 `json:"monthly-cost"`
+	PastMonthlyCost float64 `json:"past-monthly-cost"`
+	DiffMonthlyCost float64 `json:"diff-monthly-cost"`
+	Plans           int     `json:"plans"`
+}
+
+func (cost costEstimate) String() string {
+	return fmt.Sprintf("%.2f %s per month (%+.2f %s)", cost.MonthlyCost, cost.Currency, cost.DiffMonthlyCost, cost.Currency)
+}
+
+// add sums the infracost breakdown report to the estimate
+func (cost *costEstimate) add(report []byte) error {
+	var breakdown struct {
+		Currency             string `json:"currency"`
+		TotalMonthlyCost     string `json:"totalMonthlyCost"`
+		PastTotalMonthlyCost string `json:"pastTotalMonthlyCost"`
+		DiffTotalMonthlyCost string `json:"diffTotalMonthlyCost"`
+	}
+	if err := json.Unmarshal(report, &breakdown); err != nil {
+		return fmt.Errorf("Invalid infracost report: %v", err)
+	}
+	// The costs are strings in the report and are empty if they cannot be computed
+	parse := func(value string) float64 {
+		result, _ := strconv.ParseFloat(value, 64)
+		return result
+	}
+	cost.Currency = breakdown.Currency
+	cost.MonthlyCost += parse(breakdown.TotalMonthlyCost)
+	cost.PastMonthlyCost += parse(breakdown.PastTotalMonthlyCost)
+	cost.DiffMonthlyCost += parse(breakdown.DiffTotalMonthlyCost)
+	cost.Plans++
+	return nil
+}
+
+// getContainerPath returns the location of a host file in the containers started by tgf
+func (config *TGFConfig) getContainerPath(hostPath string) string {
+	if resolved, err := filepath.EvalSymlinks(hostPath); err == nil {
+		hostPath = resolved
+	}
+	for folder, mount := range map[string]string{config.hostCacheFolder: "/var/tgf", config.terragruntCacheFolder: terragruntCacheMount} {
+		if folder == "" {
+			continue
+		}
+		if relative, err := filepath.Rel(folder, hostPath); err == nil && !strings.HasPrefix(relative, "..") {
+			return path.Join(mount, filepath.ToSlash(relative))
+		}
+	}
+	return filepath.ToSlash(filepath.Join("/", config.tgf.MountPoint, strings.TrimPrefix(hostPath, filepath.VolumeName(hostPath))))
+}
+
+// estimateCost runs infracost against the plans written by the command, the binary plans are converted to JSON with terraform in the
+// tgf image. A failure to estimate the cost does not change the result of the run.
+func (config *TGFConfig) estimateCost(image string, mountArgs []string, start time.Time, exitCode int) {
+	// terraform returns 2 if there are changes with -detailed-exitcode
+	if !config.Infracost.Enabled || !isPlanCommand(config.tgf.Unmanaged) || (exitCode != 0 && exitCode != 2) {
+		return
+	}
+	defer timings.begin("cost estimate")()
+	files := findPlanFiles(config.PlanArtifacts.Files, start, config.getPlanFolders(must(os.Getwd()).(string))...)
+	if len(files) == 0 {
+		printWarning(msgCostEstimateFailed, fmt.Errorf("The command has not written any plan file (use -out=<file>)"))
+		return
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	infracostImage := config.Infracost.Image
+	if infracostImage == "" {
+		infracostImage = defaultInfracostImage
+	}
+	var cost costEstimate
+	for _, name := range names {
+		planJSON := files[name]
+		if !strings.HasSuffix(planJSON, ".json") {
+			// The binary plans are converted in the folder of the plan since terraform requires the initialized working directory
+			args := append([]string{"run", "--rm", "--entrypoint", "terraform"}, mountArgs...)
+			args = append(args, "-w", path.Dir(config.getContainerPath(planJSON)), image, "show", "-json", filepath.Base(planJSON))
+			content, err := runContainerOutput(args)
+			if err != nil {
+				printWarning(msgCostEstimateFailed, fmt.Errorf("Unable to convert %s to JSON: %v", name, err))
+				return
+			}
+			planJSON += ".tgf-infracost.json"
+			if err := ioutil.WriteFile(planJSON, content, 0600); err != nil {
+				printWarning(msgCostEstimateFailed, err)
+				return
+			}
+			defer os.Remove(planJSON)
+		}
+		args := append([]string{"run", "--rm", "-e", "INFRACOST_API_KEY", "-e", "INFRACOST_CURRENCY", "--entrypoint", "infracost"}, mountArgs...)
+		args = append(append(args, infracostImage, "breakdown", "--path", config.getContainerPath(planJSON), "--format", "json"), config.Infracost.Arguments...)
+		config.tgf.Debug("# Estimating the cost of %s: docker %s", name, strings.Join(args, " "))
+		report, err := runContainerOutput(args)
+		if err == nil {
+			err = cost.add(report)
+		}
+		if err != nil {
+			printWarning(msgCostEstimateFailed, fmt.Errorf("Unable to estimate the cost of %s: %v", name, err))
+			return
+		}
+	}
+	config.costEstimate = &cost
+	printInfo("infracost", map[string]interface{}{"monthly-cost": cost.MonthlyCost, "diff-monthly-cost": cost.DiffMonthlyCost, "currency": cost.Currency}, msgCostEstimate, cost)
+}
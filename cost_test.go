@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostEstimateAdd(t *testing.T) {
+	t.Parallel()
+
+	var cost costEstimate
+	assert.NoError(t, cost.add([]byte(`{"currency": "USD", "totalMonthlyCost": "120.5", "pastTotalMonthlyCost": "100", "diffTotalMonthlyCost": "20.5"}`)))
+	assert.NoError(t, cost.add([]byte(`{"currency": "USD", "totalMonthlyCost": "10", "pastTotalMonthlyCost": "15", "diffTotalMonthlyCost": "-5"}`)))
+	assert.NoError(t, cost.add([]byte(`{"currency": "USD", "totalMonthlyCost": null, "diffTotalMonthlyCost": ""}`)))
+	assert.Equal(t, costEstimate{Currency: "USD", MonthlyCost: 130.5, PastMonthlyCost: 115, DiffMonthlyCost: 15.5, Plans: 3}, cost)
+	assert.Equal(t, "130.50 USD per month (+15.50 USD)", cost.String())
+	assert.Error(t, cost.add([]byte("Error: No INFRACOST_API_KEY environment variable is set.")))
+}
+
+func TestGetContainerPath(t *testing.T) {
+	t.Parallel()
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), hostCacheFolder: "/tmp/tgf-cache"}
+	assert.Equal(t, "/home/user/project/plan.out", config.getContainerPath("/home/user/project/plan.out"))
+	assert.Equal(t, "/var/tgf/abc/def/plan.out", config.getContainerPath("/tmp/tgf-cache/abc/def/plan.out"))
+	config.tgf.MountPoint = "/mnt"
+	assert.Equal(t, "/mnt/home/user/project/plan.out", config.getContainerPath("/home/user/project/plan.out"))
+}
+
+func TestEstimateCost(t *testing.T) {
+	tempDir := must(filepath.EvalSymlinks(must(ioutil.TempDir("", "TestEstimateCost")).(string))).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Chdir(must(os.Getwd()).(string))
+	os.Chdir(tempDir)
+	start := time.Now().Add(-time.Second)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "plan.out"), []byte("binary plan"), 0644))
+
+	defer func(saved func([]string) ([]byte, error)) { runContainerOutput = saved }(runContainerOutput)
+	var commands []string
+	runContainerOutput = func(args []string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		commands = append(commands, command)
+		if strings.Contains(command, "terraform") {
+			return []byte(`{"format_version": "1.2"}`), nil
+		}
+		converted := must(ioutil.ReadFile(filepath.Join(tempDir, "plan.out.tgf-infracost.json"))).([]byte)
+		assert.Equal(t, `{"format_version": "1.2"}`, string(converted), "The plan is converted before running infracost")
+		return []byte(`{"currency": "USD", "totalMonthlyCost": "42", "diffTotalMonthlyCost": "42"}`), nil
+	}
+	mountArgs := []string{"-v", "/home:/home", "-w", tempDir}
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan", "-out", "plan.out"}), Infracost: InfracostConfig{Enabled: true, Arguments: []string{"--show-skipped"}}}
+	config.estimateCost("coveo/tgf:1.21.0", mountArgs, start, 0)
+	assert.Equal(t, []string{
+		"run --rm --entrypoint terraform -v /home:/home -w " + tempDir + " -w " + tempDir + " coveo/tgf:1.21.0 show -json plan.out",
+		"run --rm -e INFRACOST_API_KEY -e INFRACOST_CURRENCY --entrypoint infracost -v /home:/home -w " + tempDir + " " + defaultInfracostImage + " breakdown --path " + tempDir + "/plan.out.tgf-infracost.json --format json --show-skipped",
+	}, commands)
+	assert.Equal(t, &costEstimate{Currency: "USD", MonthlyCost: 42, DiffMonthlyCost: 42, Plans: 1}, config.costEstimate)
+	_, err := os.Stat(filepath.Join(tempDir, "plan.out.tgf-infracost.json"))
+	assert.True(t, os.IsNotExist(err), "The converted plan is removed")
+	assert.Equal(t, config.costEstimate, config.getRunMetadata(start, 0).Cost)
+
+	commands = nil
+	config = &TGFConfig{tgf: NewTestApplication([]string{"apply", "plan.out"}), Infracost: InfracostConfig{Enabled: true}}
+	config.estimateCost("coveo/tgf:1.21.0", mountArgs, start, 0)
+	assert.Empty(t, commands, "The cost is only estimated for the plans")
+	assert.Nil(t, config.costEstimate)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// credentialSource resolves non AWS credentials on the host and supplies them to the container.
+// AWS credentials are always resolved by tgf (see InitAWS), the other sources must be enabled with credential-sources.
+type credentialSource interface {
+	// resolve returns the environment variables and the volumes (host:container) that must be supplied to the container
+	resolve(config *TGFConfig) (environment map[string]string, volumes []string, err error)
+}
+
+var credentialSources = map[string]credentialSource{
+	"gcp":   gcpCredentialSource{},
+	"azure": azureCredentialSource{},
+}
+
+// runCredentialCommand runs the cloud provider CLI and returns its output (overridden in tests)
+var runCredentialCommand = func(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// resolveCredentialSources resolves the credentials of all configured sources
+func (config *TGFConfig) resolveCredentialSources() error {
+	defer timings.begin("credentials")()
+	for _, name := range config.CredentialSources {
+		source, ok := credentialSources[strings.ToLower(name)]
+		if plugin, found := findPlugin(name, pluginCredentials); !ok && found {
+			source, ok = pluginCredentialSource{plugin}, true
+		}
+		if !ok {
+			names := getPluginNames(pluginCredentials)
+			for name := range credentialSources {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("Unknown credential source %s (available: %s)", name, strings.Join(names, ", "))
+		}
+		environment, volumes, err := source.resolve(config)
+		if err != nil {
+			return fmt.Errorf("Unable to resolve %s credentials: %v", name, err)
+		}
+		config.tgf.Debug("# %s credentials resolved on the host", name)
+		for key, value := range environment {
+			config.Environment[key] = value
+		}
+		config.credentialVolumes = append(config.credentialVolumes, volumes...)
+	}
+	return nil
+}
+
+// gcpCredentialSource supplies a short lived access token of the gcloud application default credentials
+type gcpCredentialSource struct{}
+
+func (gcpCredentialSource) resolve(config *TGFConfig) (map[string]string, []string, error) {
+	token, err := runCredentialCommand("gcloud", "auth", "application-default", "print-access-token")
+	if err != nil {
+		return nil, nil, fmt.Errorf("gcloud auth application-default print-access-token failed: %v", err)
+	}
+	environment := map[string]string{"GOOGLE_OAUTH_ACCESS_TOKEN": strings.TrimSpace(string(token))}
+
+	if os.Getenv("GOOGLE_PROJECT") == "" && os.Getenv("CLOUDSDK_CORE_PROJECT") == "" {
+		if project, err := runCredentialCommand("gcloud", "config", "get-value", "project"); err == nil && strings.TrimSpace(string(project)) != "" {
+			environment["GOOGLE_PROJECT"] = strings.TrimSpace(string(project))
+			environment["CLOUDSDK_CORE_PROJECT"] = environment["GOOGLE_PROJECT"]
+		}
+	}
+
+	var volumes []string
+	if filename := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); filename != "" {
+		filename = filepath.ToSlash(filename)
+		volumes = append(volumes, fmt.Sprintf("%s:%s:ro", convertDrive(filename), strings.TrimPrefix(filename, filepath.VolumeName(filename))))
+	}
+	return environment, volumes, nil
+}
+
+// azureCredentialSource supplies the subscription of the Azure CLI and its token cache
+type azureCredentialSource struct{}
+
+const azureContainerConfigDir = "/var/tgf-azure"
+
+func (azureCredentialSource) resolve(config *TGFConfig) (map[string]string, []string, error) {
+	output, err := runCredentialCommand("az", "account", "show", "--output", "json")
+	if err != nil {
+		return nil, nil, fmt.Errorf("az account show failed (run az login): %v", err)
+	}
+	var account struct {
+		ID       string `json:"id"`
+		TenantID string `json:"tenantId"`
+	}
+	if err := json.Unmarshal(output, &account); err != nil {
+		return nil, nil, fmt.Errorf("Unable to read the Azure account: %v", err)
+	}
+
+	environment := map[string]string{"AZURE_CONFIG_DIR": azureContainerConfigDir}
+	for key, value := range map[string]string{"ARM_SUBSCRIPTION_ID": account.ID, "ARM_TENANT_ID": account.TenantID} {
+		if os.Getenv(key) == "" && value != "" {
+			environment[key] = value
+		}
+	}
+
+	folder := os.Getenv("AZURE_CONFIG_DIR")
+	if folder == "" {
+		folder = filepath.Join(getHomeFolder(), ".azure")
+	}
+	return environment, []string{fmt.Sprintf("%s:%s", convertDrive(filepath.ToSlash(folder)), azureContainerConfigDir)}, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveCredentialSources(t *testing.T) {
+	defer func(original func(string, ...string) ([]byte, error)) { runCredentialCommand = original }(runCredentialCommand)
+	runCredentialCommand = func(name string, args ...string) ([]byte, error) {
+		switch name + " " + strings.Join(args, " ") {
+		case "gcloud auth application-default print-access-token":
+			return []byte("ya29.token\n"), nil
+		case "gcloud config get-value project":
+			return []byte("my-project\n"), nil
+		case "az account show --output json":
+			return []byte(`{"id": "subscription", "tenantId": "tenant"}`), nil
+		}
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+	for _, name := range []string{"GOOGLE_PROJECT", "CLOUDSDK_CORE_PROJECT", "GOOGLE_APPLICATION_CREDENTIALS", "ARM_SUBSCRIPTION_ID", "ARM_TENANT_ID", "AZURE_CONFIG_DIR"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	os.Setenv("AZURE_CONFIG_DIR", "/home/user/.azure")
+
+	config := &TGFConfig{tgf: &TGFApplication{}, Environment: map[string]string{}, CredentialSources: []string{"gcp", "Azure"}}
+	assert.NoError(t, config.resolveCredentialSources())
+	assert.Equal(t, map[string]string{
+		"GOOGLE_OAUTH_ACCESS_TOKEN": "ya29.token",
+		"GOOGLE_PROJECT":            "my-project",
+		"CLOUDSDK_CORE_PROJECT":     "my-project",
+		"ARM_SUBSCRIPTION_ID":       "subscription",
+		"ARM_TENANT_ID":             "tenant",
+		"AZURE_CONFIG_DIR":          azureContainerConfigDir,
+	}, config.Environment)
+	assert.Equal(t, []string{"/home/user/.azure:" + azureContainerConfigDir}, config.credentialVolumes)
+
+	config = &TGFConfig{tgf: &TGFApplication{}, Environment: map[string]string{}, CredentialSources: []string{"oracle"}}
+	assert.EqualError(t, config.resolveCredentialSources(), "Unknown credential source oracle (available: azure, gcp)")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// debugBundleLogSize is the maximum size of each log file included in the debug bundle (the most recent lines are kept)
+const debugBundleLogSize = 1024 * 1024
+
+// debugBundleVariables are the environment variables included in the debug bundle in addition to the TGF_ variables
+var debugBundleVariables = []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CONFIG_FILE", "DOCKER_HOST", "XDG_CONFIG_HOME", "XDG_CACHE_HOME"}
+
+// getDebugBundleName returns the name of the debug bundle file created at the specified time
+func getDebugBundleName(now time.Time) string {
+	return fmt.Sprintf("tgf-debug-%s.zip", now.Format("20060102-150405"))
+}
+
+// redactSecrets masks the string values whose key looks like a secret (recursively)
+func redactSecrets(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if text, isString := item.(string); isString {
+				result[key] = maskSecret(key, text)
+			} else {
+				result[key] = redactSecrets(item)
+			}
+		}
+		return result
+	case map[string]string:
+		result := make(map[string]string, len(value))
+		for key, item := range value {
+			result[key] = maskSecret(key, item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i := range value {
+			result[i] = redactSecrets(value[i])
+		}
+		return result
+	}
+	return value
+}
+
+// getDebugEnvironment returns the environment variables affecting tgf (secrets masked)
+func getDebugEnvironment() string {
+	var lines []string
+	for _, variable := range os.Environ() {
+		name, value := Split2(variable, "=")
+		if strings.HasPrefix(name, "TGF_") || util.ListContainsElement(debugBundleVariables, name) {
+			lines = append(lines, fmt.Sprintf("%s=%s", name, maskSecret(name, value)))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var reLogExport = regexp.MustCompile(`(?m)(export (\S+?)=)(.*)$`)
+
+// redactLog masks the values of the secret variables exported in the debug logs
+func redactLog(content []byte) []byte {
+	return reLogExport.ReplaceAllFunc(content, func(line []byte) []byte {
+		matches := reLogExport.FindSubmatch(line)
+		return []byte(string(matches[1]) + maskSecret(string(matches[2]), string(matches[3])))
+	})
+}
+
+// readLogTail returns the end of a log file (nil if the file does not exist)
+func readLogTail(filename string, size int64) []byte {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() > size {
+		file.Seek(-size, io.SeekEnd)
+	}
+	content, _ := ioutil.ReadAll(file)
+	return content
+}
+
+// writeDebugBundle writes the diagnostic information collected by tgf as a zip archive
+func writeDebugBundle(w io.Writer, d *doctor) error {
+	archive := zip.NewWriter(w)
+	add := func(name string, content []byte) {
+		if entry, err := archive.Create(name); err == nil {
+			entry.Write(content)
+		}
+	}
+
+	add("version.json", must(json.MarshalIndent(getVersionInfo(), "", "  ")).([]byte))
+
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+	var doctorOutput bytes.Buffer
+	d.write(&doctorOutput)
+	add("doctor.txt", doctorOutput.Bytes())
+
+	values, sources := d.config.effectiveConfig()
+	add("config.json", must(json.MarshalIndent(map[string]interface{}{"config": redactSecrets(values), "sources": sources}, "", "  ")).([]byte))
+	add("environment.txt", []byte(getDebugEnvironment()))
+
+	var docker bytes.Buffer
+	for _, args := range [][]string{{"version"}, {"info"}} {
+		output, err := runDoctorCommand("docker", args...)
+		fmt.Fprintf(&docker, "$ docker %s\n%s\n", strings.Join(args, " "), output)
+		if err != nil {
+			fmt.Fprintf(&docker, "Error: %v\n", err)
+		}
+		fmt.Fprintln(&docker)
+	}
+	add("docker.txt", docker.Bytes())
+
+	for _, filename := range []string{getLogFile(), getLogFile() + ".1"} {
+		if content := readLogTail(filename, debugBundleLogSize); content != nil {
+			add("logs/"+filepath.Base(filename), redactLog(content))
+		}
+	}
+	return archive.Close()
+}
+
+// runDebugBundle collects the configuration, the doctor checks, the logs and the versions in a zip file to attach to a support request
+func runDebugBundle(app *TGFApplication) int {
+	d := runDoctorChecks(app)
+	filename := getDebugBundleName(time.Now())
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err == nil {
+		err = writeDebugBundle(file, d)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		printError(msgDebugBundleFailed, filename, err)
+		return 1
+	}
+	ErrPrintf("Debug bundle written to %s, review its content before attaching it to an issue\n", filename)
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDebugBundleName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "tgf-debug-20230102-150405.zip", getDebugBundleName(time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)))
+}
+
+func TestRedactSecrets(t *testing.T) {
+	t.Parallel()
+
+	values := map[string]interface{}{
+		"docker-image":   "coveo/tgf",
+		"environment":    map[string]interface{}{"DB_PASSWORD": "hunter2", "TF_VAR_region": "us-east-1"},
+		"profiles":       []interface{}{map[string]interface{}{"api-token": "abc"}},
+		"docker-refresh": 3600,
+	}
+	assert.Equal(t, map[string]interface{}{
+		"docker-image":   "coveo/tgf",
+		"environment":    map[string]interface{}{"DB_PASSWORD": maskedValue, "TF_VAR_region": "us-east-1"},
+		"profiles":       []interface{}{map[string]interface{}{"api-token": maskedValue}},
+		"docker-refresh": 3600,
+	}, redactSecrets(values))
+	assert.Equal(t, map[string]string{"SECRET": maskedValue}, redactSecrets(map[string]string{"SECRET": "value"}))
+}
+
+func TestRedactLog(t *testing.T) {
+	t.Parallel()
+
+	log := "2023-01-02 debug tgf: export AWS_SECRET_ACCESS_KEY=abc\n2023-01-02 debug tgf: export TGF_COMMAND=terragrunt\n"
+	assert.Equal(t, "2023-01-02 debug tgf: export AWS_SECRET_ACCESS_KEY="+maskedValue+"\n2023-01-02 debug tgf: export TGF_COMMAND=terragrunt\n", string(redactLog([]byte(log))))
+}
+
+func TestReadLogTail(t *testing.T) {
+	folder := must(ioutil.TempDir("", "tgf-bundle")).(string)
+	defer os.RemoveAll(folder)
+	filename := filepath.Join(folder, "tgf.log")
+	ioutil.WriteFile(filename, []byte("0123456789"), 0644)
+
+	assert.Equal(t, "0123456789", string(readLogTail(filename, 100)))
+	assert.Equal(t, "6789", string(readLogTail(filename, 4)))
+	assert.Nil(t, readLogTail(filepath.Join(folder, "missing.log"), 100))
+}
+
+func TestWriteDebugBundle(t *testing.T) {
+	d := &doctor{config: &TGFConfig{tgf: NewTestApplication(nil), Image: "coveo/tgf", Environment: map[string]string{"API_TOKEN": "secret-value"}}}
+	d.report("Docker client", doctorPass, "version %s", "20.10.7")
+
+	var out bytes.Buffer
+	assert.NoError(t, writeDebugBundle(&out, d))
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	content := make(map[string]string)
+	for _, file := range archive.File {
+		reader := must(file.Open()).(io.ReadCloser)
+		content[file.Name] = string(must(ioutil.ReadAll(reader)).([]byte))
+		reader.Close()
+	}
+	assert.Contains(t, content, "version.json")
+	assert.Contains(t, content, "docker.txt")
+	assert.Contains(t, content, "environment.txt")
+	assert.Equal(t, "[PASS] Docker client    version 20.10.7\n", content["doctor.txt"])
+	assert.Contains(t, content["config.json"], `"docker-image": "coveo/tgf"`)
+	assert.NotContains(t, content["config.json"], "secret-value")
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getFreeDiskSpace returns the number of bytes available to the current user on the file system of the folder
+func getFreeDiskSpace(folder string) (uint64, error) {
+	path, err := syscall.UTF16PtrFromString(folder)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	getDiskFreeSpace := syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+	if result, _, err := getDiskFreeSpace.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&free)), 0, 0); result == 0 {
+		return 0, err
+	}
+	return free, nil
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -25,6 +24,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/mattn/go-isatty"
 )
 
 const (
@@ -33,43 +33,23 @@ const (
 	dockerSocketFile     = "/var/run/docker.sock"
 	dockerfilePattern    = "TGF_dockerfile"
 	maxDockerTagLength   = 128
+	dockerRunFailure     = 125 // Exit code returned by docker run if the container cannot be started
 )
 
 type dockerConfig struct{ *TGFConfig }
 
 func (docker *dockerConfig) call() int {
 	app, config := docker.tgf, docker.TGFConfig
-	args := app.Unmanaged
-	command := append(strings.Split(config.EntryPoint, " "), args...)
-
-	// Change the default log level for terragrunt
-	const logLevelArg = "--terragrunt-logging-level"
-	if !util.ListContainsElement(command, logLevelArg) && filepath.Base(config.EntryPoint) == "terragrunt" {
-		if config.LogLevel == "6" || strings.ToLower(config.LogLevel) == "full" {
-			config.LogLevel = "debug"
-			config.Environment["TF_LOG"] = "DEBUG"
-			config.Environment["TERRAGRUNT_DEBUG"] = "1"
-		}
-
-		// The log level option should not be supplied if there is no actual command
-		for _, arg := range args {
-			if !strings.HasPrefix(arg, "-") {
-				command = append(command, []string{logLevelArg, config.LogLevel}...)
-				break
-			}
-		}
-	}
-
-	if app.FlushCache && filepath.Base(config.EntryPoint) == "terragrunt" {
-		command = append(command, "--terragrunt-source-update")
-	}
-
+	command := config.Hooks.getContainerCommand(config.getCommand())
 	imageName := docker.getImage()
 
 	if app.GetImageName {
 		Println(imageName)
 		return 0
 	}
+	if exitCode := config.checkToolVersions(imageName); exitCode != 0 {
+		return exitCode
+	}
 
 	cwd := filepath.ToSlash(must(filepath.EvalSymlinks(must(os.Getwd()).(string))).(string))
 	currentDrive := fmt.Sprintf("%s/", filepath.VolumeName(cwd))
@@ -77,12 +57,13 @@ func (docker *dockerConfig) call() int {
 	rootFolder := strings.Split(strings.TrimPrefix(cwd, currentDrive), "/")[0]
 
 	dockerArgs := []string{
-		"run",
+		"run", "--label", tgfLabel + "=" + version,
 	}
-	if app.DockerInteractive {
-		dockerArgs = append(dockerArgs, "-it")
+	if app.DockerInteractive && !isCI() {
+		dockerArgs = append(dockerArgs, getInteractiveArgs(os.Stdin)...)
 	}
-	dockerArgs = append(dockerArgs, "-v", fmt.Sprintf("%s%s:%s", convertDrive(currentDrive), rootFolder, filepath.ToSlash(filepath.Join("/", app.MountPoint, rootFolder))), "-w", sourceFolder)
+	mountArgs := []string{"-v", fmt.Sprintf("%s%s:%s", convertDrive(currentDrive), rootFolder, filepath.ToSlash(filepath.Join("/", app.MountPoint, rootFolder))), "-w", sourceFolder}
+	dockerArgs = append(dockerArgs, mountArgs...)
 
 	if app.WithDockerMount {
 		withDockerMountArgs := []string{"-v", fmt.Sprintf(dockerSocketMountPattern, dockerSocketFile), "--group-add", getDockerGroup()}
@@ -108,6 +89,10 @@ func (docker *dockerConfig) call() int {
 		dockerArgs = append(dockerArgs, config.DockerOptions...)
 	}
 
+	for _, volume := range config.credentialVolumes {
+		dockerArgs = append(dockerArgs, "-v", volume)
+	}
+
 	if app.MountTempDir {
 		temp := filepath.ToSlash(filepath.Join(must(filepath.EvalSymlinks(os.TempDir())).(string), "tgf-cache"))
 		tempDrive := fmt.Sprintf("%s/", filepath.VolumeName(temp))
@@ -115,32 +100,33 @@ func (docker *dockerConfig) call() int {
 		if runtime.GOOS == "windows" {
 			os.Mkdir(temp, 0755)
 		}
-		dockerArgs = append(dockerArgs, "-v", fmt.Sprintf("%s%s:/var/tgf", convertDrive(tempDrive), tempFolder))
+		mountArgs = append(mountArgs, "-v", fmt.Sprintf("%s%s:/var/tgf", convertDrive(tempDrive), tempFolder))
+		dockerArgs = append(dockerArgs, mountArgs[len(mountArgs)-2:]...)
 		config.Environment["TERRAGRUNT_CACHE"] = "/var/tgf"
+		config.hostCacheFolder = temp
 	}
 
-	config.Environment["TGF_COMMAND"] = config.EntryPoint
-	config.Environment["TGF_VERSION"] = version
-	config.Environment["TGF_ARGS"] = strings.Join(os.Args, " ")
-	config.Environment["TGF_LAUNCH_FOLDER"] = sourceFolder
-	config.Environment["TGF_IMAGE_NAME"] = imageName // sha256 of image
-
-	if !strings.Contains(config.Image, "coveo/tgf") { // the tgf image injects its own image info
-		config.Environment["TGF_IMAGE"] = config.Image
-		if config.ImageVersion != nil {
-			config.Environment[tgfImageVersion] = *config.ImageVersion
-			if version, err := semver.Make(*config.ImageVersion); err == nil {
-				config.Environment["TGF_IMAGE_MAJ_MIN"] = fmt.Sprintf("%d.%d", version.Major, version.Minor)
-			}
-		}
-		if config.ImageTag != nil {
-			config.Environment["TGF_IMAGE_TAG"] = *config.ImageTag
+	switch config.TerragruntCache {
+	case "", terragruntCacheLocal:
+	case terragruntCacheCentral:
+		if volume, err := config.mountTerragruntCache(cwd); err != nil {
+			printWarning(msgTerragruntCacheFailed, getTerragruntCacheEntry(cwd), err)
+		} else {
+			mountArgs = append(mountArgs, "-v", volume)
+			dockerArgs = append(dockerArgs, "-v", volume)
 		}
+	default:
+		return failWith(exitConfig, fmt.Errorf("Invalid terragrunt-cache %q (valid values are %s and %s)", config.TerragruntCache, terragruntCacheLocal, terragruntCacheCentral))
 	}
 
+	config.setTGFEnvironment(imageName, sourceFolder)
+	commandSpan := config.traceCommand(config.getCommand())
+
+	config.removeAWSProfileVariables()
+	maskCISecrets(os.Stderr, config.Environment)
 	for key, val := range config.Environment {
 		os.Setenv(key, val)
-		app.Debug("export %v=%v", key, val)
+		app.Debug("export %v=%v", key, maskCIValue(key, val))
 	}
 
 	for _, do := range app.DockerOptions {
@@ -156,33 +142,117 @@ func (docker *dockerConfig) call() int {
 	dockerArgs = append(dockerArgs, imageName)
 	dockerArgs = append(dockerArgs, command...)
 	dockerCmd := exec.Command("docker", dockerArgs...)
-	dockerCmd.Stdin, dockerCmd.Stdout = os.Stdin, os.Stdout
-	var stderr bytes.Buffer
-	dockerCmd.Stderr = &stderr
+	dockerCmd.Stdin = os.Stdin
+	stderr := newTailBuffer(containerStderrLimit)
+	dockerCmd.Stderr = stderr
 
 	if len(config.Environment) > 0 {
 		app.Debug("")
 	}
 	app.Debug("%s\n", strings.Join(dockerCmd.Args, " "))
 
-	if err := runCommands(config.runBeforeCommands); err != nil {
-		return -1
+	if app.DryRun {
+		writeDryRun(os.Stdout, dockerCmd.Args, config.Environment, config.runBeforeCommands, config.runAfterCommands, config.Hooks)
+		return 0
 	}
-	if err := dockerCmd.Run(); err != nil {
-		if stderr.Len() > 0 {
-			ErrPrintf(errorString(stderr.String()))
-			ErrPrintf("\n%s %s\n", dockerCmd.Args[0], strings.Join(dockerArgs, " "))
+	lifecycle := &runLifecycle{config: config, component: "docker", started: "Starting container", exited: "Container exited"}
+	return lifecycle.execute(func() int {
+		if exitCode := config.runPolicyGates(imageName, mountArgs); exitCode != 0 {
+			return exitCode
+		}
+		dockerCmd.Stdout = lifecycle.stdout
+		touchImageUse(imageName)
+		exitCode, err := lifecycle.runCommand(imageName, command, commandSpan, nil, func() (int, error) {
+			if err := dockerCmd.Start(); err != nil {
+				return 0, err
+			}
+			stopRelay := relaySignals(dockerCmd.Process)
+			err := dockerCmd.Wait()
+			stopRelay()
+			if err != nil && stderr.Len() > 0 {
+				printError(msgContainerError, strings.TrimRight(stderr.String(), "\n"))
+				ErrPrintf("\n%s %s\n", dockerCmd.Args[0], strings.Join(dockerArgs, " "))
+
+				if runtime.GOOS == "windows" {
+					ErrPrintln(windowsMessage)
+				}
+				if isGitHubActions() {
+					writeGitHubAnnotations(os.Stderr, getGitHubFolder(must(os.Getwd()).(string)), stderr.String())
+				}
+			}
+			exitCode := dockerCmd.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+			if exitCode == dockerRunFailure {
+				// The container has not been started, we check if it is because the docker daemon is not available
+				if failure := getDockerFailure(exitCode, ""); failure.exitCode == exitDockerUnavailable {
+					exitCode = failWith(failure.exitCode, failure)
+				}
+			}
+			return exitCode, nil
+		})
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Docker is not available: %v", err))
+		}
+		config.estimateCost(imageName, mountArgs, lifecycle.start, exitCode)
+		return exitCode
+	})
+}
+
+// getCommand returns the entry point and its arguments
+func (config *TGFConfig) getCommand() []string {
+	app := config.tgf
+	args := app.Unmanaged
+	command := append(strings.Split(config.EntryPoint, " "), args...)
 
-			if runtime.GOOS == "windows" {
-				ErrPrintln(windowsMessage)
+	// Change the default log level for terragrunt
+	const logLevelArg = "--terragrunt-logging-level"
+	if !util.ListContainsElement(command, logLevelArg) && filepath.Base(config.EntryPoint) == "terragrunt" {
+		if config.LogLevel == "6" || strings.ToLower(config.LogLevel) == "full" {
+			config.LogLevel = "debug"
+			config.Environment["TF_LOG"] = "DEBUG"
+			config.Environment["TERRAGRUNT_DEBUG"] = "1"
+		}
+
+		// The log level option should not be supplied if there is no actual command
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				command = append(command, []string{logLevelArg, config.LogLevel}...)
+				break
 			}
 		}
 	}
-	if err := runCommands(config.runAfterCommands); err != nil {
-		ErrPrintf(errorString("%v", err))
+
+	if app.FlushCache && filepath.Base(config.EntryPoint) == "terragrunt" {
+		command = append(command, "--terragrunt-source-update")
 	}
+	return command
+}
 
-	return dockerCmd.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+// setTGFEnvironment adds the variables describing the run to the environment supplied to the container
+func (config *TGFConfig) setTGFEnvironment(imageName, launchFolder string) {
+	config.Environment["TGF_COMMAND"] = config.EntryPoint
+	config.Environment["TGF_VERSION"] = version
+	config.Environment["TGF_ARGS"] = strings.Join(os.Args, " ")
+	config.Environment["TGF_LAUNCH_FOLDER"] = launchFolder
+	config.Environment["TGF_IMAGE_NAME"] = imageName // sha256 of image
+	config.setImageFamilyEnvironment()
+	if config.git = getGitMetadata(); config.git != nil {
+		for key, value := range config.git.getEnvironment() {
+			config.Environment[key] = value
+		}
+	}
+
+	if !strings.Contains(config.Image, "coveo/tgf") { // the tgf image injects its own image info
+		config.Environment["TGF_IMAGE"] = config.Image
+		if config.ImageVersion != nil {
+			config.Environment[tgfImageVersion] = *config.ImageVersion
+			if version, err := semver.Make(*config.ImageVersion); err == nil {
+				config.Environment["TGF_IMAGE_MAJ_MIN"] = fmt.Sprintf("%d.%d", version.Major, version.Minor)
+			}
+		}
+		if config.ImageTag != nil {
+			config.Environment["TGF_IMAGE_TAG"] = *config.ImageTag
+		}
+	}
 }
 
 func runCommands(commands []string) error {
@@ -195,7 +265,10 @@ func runCommands(commands []string) error {
 			defer func() { os.Remove(tempFile) }()
 		}
 		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
-		if err := cmd.Run(); err != nil {
+		endGroup := logGroup("Running " + strings.SplitN(strings.TrimSpace(script), "\n", 2)[0])
+		err = cmd.Run()
+		endGroup()
+		if err != nil {
 			return err
 		}
 	}
@@ -205,6 +278,7 @@ func runCommands(commands []string) error {
 // Returns the image name to use
 // If docker-image-build option has been set, an image is dynamically built and the resulting image digest is returned
 func (docker *dockerConfig) getImage() (name string) {
+	defer timings.begin("image build")()
 	app := docker.tgf
 	name = docker.GetImageName()
 	if !strings.Contains(name, ":") {
@@ -247,7 +321,7 @@ func (docker *dockerConfig) getImage() (name string) {
 			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 			go func() {
 				<-c
-				Println("\nRemoving file", dockerFile)
+				ErrPrintln("\nRemoving file", dockerFile)
 				cleanup()
 				panic(errorString("Execution interrupted by user: %v", c))
 			}()
@@ -262,8 +336,12 @@ func (docker *dockerConfig) getImage() (name string) {
 			name = image + ":" + tag[0:maxDockerTagLength]
 		}
 		if app.Refresh || getImageHash(name) != ib.hash() {
+			if app.DryRun {
+				printInfo("docker", map[string]interface{}{"image": name}, msgDryRunBuildSkipped, name)
+				continue
+			}
 			label := fmt.Sprintf("hash=%s", ib.hash())
-			args := []string{"build", ".", "-f", dockerfilePattern, "--quiet", "--force-rm", "--label", label}
+			args := []string{"build", ".", "-f", dockerfilePattern, "--quiet", "--force-rm", "--label", label, "--label", tgfLabel + "=" + version}
 			if i == 0 && app.Refresh && !app.UseLocalImage {
 				args = append(args, "--pull")
 			}
@@ -279,9 +357,12 @@ func (docker *dockerConfig) getImage() (name string) {
 			if ib.Instructions != "" {
 				app.Debug("%s", ib.Instructions)
 			}
-			buildCmd.Stderr = os.Stderr
+			if infoEnabled() {
+				buildCmd.Stderr = os.Stderr
+			}
 			buildCmd.Dir = folder
 			must(buildCmd.Output())
+			invalidateImageCache(name)
 			pruneDangling()
 		}
 	}
@@ -289,18 +370,6 @@ func (docker *dockerConfig) getImage() (name string) {
 	return
 }
 
-var pruneDangling = func() {
-	cli, ctx := getDockerClient()
-	danglingFilters := filters.NewArgs()
-	danglingFilters.Add("dangling", "true")
-	if _, err := cli.ImagesPrune(ctx, danglingFilters); err != nil {
-		printError("Error pruning dangling images (Untagged): %v", err.Error())
-	}
-	if _, err := cli.ContainersPrune(ctx, filters.Args{}); err != nil {
-		printError("Error pruning unused containers: %v", err.Error())
-	}
-}
-
 func (docker *dockerConfig) prune(images ...string) {
 	cli, ctx := getDockerClient()
 	if len(images) > 0 {
@@ -323,7 +392,7 @@ func (docker *dockerConfig) prune(images ...string) {
 					}
 					upToDate, err := CheckVersionRange(actual, current)
 					if err != nil {
-						ErrPrintln("Check version for %s vs%s: %v", actual, current, err)
+						printWarning(msgImageVersionCheck, actual, current, err)
 					} else if !upToDate {
 						for _, tag := range image.RepoTags {
 							deleteImage(tag)
@@ -336,25 +405,34 @@ func (docker *dockerConfig) prune(images ...string) {
 	pruneDangling()
 }
 
-func deleteImage(id string) {
+func deleteImage(id string) error {
 	cli, ctx := getDockerClient()
 	items, err := cli.ImageRemove(ctx, id, types.ImageRemoveOptions{})
+	invalidateImageCache(id)
 	if err != nil {
-		printError((err.Error()))
+		printError(msgImageRemoveFailed, err)
 	}
 	for _, item := range items {
 		if item.Untagged != "" {
-			ErrPrintf("Untagged %s\n", item.Untagged)
+			printInfo("docker", map[string]interface{}{"image": item.Untagged}, msgImageUntagged, item.Untagged)
 		}
 		if item.Deleted != "" {
-			ErrPrintf("Deleted %s\n", item.Deleted)
+			printInfo("docker", map[string]interface{}{"image": item.Deleted}, msgImageDeleted, item.Deleted)
 		}
 	}
+	return err
 }
 
 // GetActualImageVersion returns the real image version stored in the environment variable TGF_IMAGE_VERSION
 func (docker *dockerConfig) GetActualImageVersion() string {
-	return getActualImageVersionInternal(docker.getImage())
+	image := docker.getImage()
+	if version := getActualImageVersionInternal(image); version != "" {
+		return version
+	}
+	if tool := docker.getToolVersionFamily(); tool != "" {
+		return getToolVersion(image, tool)
+	}
+	return ""
 }
 
 func getDockerClient() (*client.Client, context.Context) {
@@ -369,30 +447,12 @@ func getDockerClient() (*client.Client, context.Context) {
 var dockerClient *client.Client
 var dockerContext context.Context
 
-func getImageSummary(imageName string) *types.ImageSummary {
-	cli, ctx := getDockerClient()
-	// Find image
-	filters := filters.NewArgs()
-	filters.Add("reference", imageName)
-	images, err := cli.ImageList(ctx, types.ImageListOptions{Filters: filters})
-	if err != nil || len(images) != 1 {
-		return nil
-	}
-	return &images[0]
-}
-
 func getActualImageVersionInternal(imageName string) string {
-	if image := getImageSummary(imageName); image != nil {
-		return getActualImageVersionFromImageID(image.ID)
-	}
-	return ""
+	return lookupImage(imageName).Version
 }
 
 func getImageHash(imageName string) string {
-	if image := getImageSummary(imageName); image != nil {
-		return image.Labels["hash"]
-	}
-	return ""
+	return lookupImage(imageName).Hash
 }
 
 func getActualImageVersionFromImageID(imageID string) string {
@@ -412,41 +472,72 @@ func getActualImageVersionFromImageID(imageID string) string {
 }
 
 func checkImage(image string) bool {
-	var out bytes.Buffer
-	dockerCmd := exec.Command("docker", []string{"images", "-q", image}...)
-	dockerCmd.Stdout = &out
-	dockerCmd.Run()
-	return out.String() != ""
+	return lookupImage(image).Exists
 }
 
 // ECR Regex: https://regex101.com/r/GRxU06/1
-var reECR = regexp.MustCompile(`(?P<account>[0-9]+)\.dkr\.ecr\.(?P<region>[a-z0-9\-]+)\.amazonaws\.com`)
+var reECR = regexp.MustCompile(`(?P<account>[0-9]+)\.dkr\.ecr\.(?P<region>[a-z0-9\-]+)\.amazonaws\.com(\.cn)?`)
 
 func (docker *dockerConfig) refreshImage(image string) {
+	defer timings.begin("image refresh")()
 	app := docker.tgf
 	app.Refresh = true // Setting this to true will ensure that dependant built images will also be refreshed
 
 	if app.UseLocalImage {
-		ErrPrintf("Not refreshing %v because `local-image` is set\n", image)
+		printInfo("docker", map[string]interface{}{"image": image}, msgImageRefreshSkipped, image)
 		return
 	}
 
-	ErrPrintf("Checking if there is a newer version of docker image %v\n", image)
-	err := getDockerUpdateCmd(image).Run()
+	printInfo("docker", map[string]interface{}{"image": image}, msgImageRefresh, image)
+	// The digest of the tag in the registry is compared to the local image to avoid pulling it again if it has not changed,
+	// docker pull is used if the registry cannot be queried
+	if upToDate, err := isImageUpToDate(image); err != nil {
+		app.Debug("# Unable to get the digest of %s from its registry: %v", image, err)
+	} else if upToDate {
+		printInfo("docker", map[string]interface{}{"image": image}, msgImageUpToDate, image)
+		touchImageRefresh(image)
+		return
+	}
+
+	// The previous image is only inspected if the update must be notified
+	var previous imageInfo
+	if len(docker.Webhooks) > 0 {
+		previous, _ = inspectImage(image)
+	}
+	// The image is also pulled through the docker API if the metrics are sent since it reports the downloaded bytes
+	var err error
+	useAPI := progressEnabled() || docker.Metrics.enabled()
+	if useAPI {
+		if err = pullImageWithProgress(image); err != nil {
+			app.Debug("# Unable to pull %s through the docker API (%v), using docker pull", image, err)
+		}
+	}
+	if !useAPI || err != nil {
+		err = getDockerUpdateCmd(image).Run()
+	}
 	if err != nil {
 		matches, _ := utils.MultiMatch(image, reECR)
 		account, accountOk := matches["account"]
 		region, regionOk := matches["region"]
 		if accountOk && regionOk && docker.awsConfigExist() {
-			ErrPrintf("Failed to pull %v. It is an ECR image, trying again after a login.\n", image)
+			printInfo("docker", map[string]interface{}{"image": image, "account": account, "region": region}, msgECRLoginRetry, image)
 			loginToECR(account, region)
-			must(getDockerUpdateCmd(image).Run())
+			if err := getDockerUpdateCmd(image).Run(); err != nil {
+				panic(getDockerFailure(exitImagePull, "Unable to pull %s: %v", image, err))
+			}
 		} else {
-			panic(err)
+			panic(getDockerFailure(exitImagePull, "Unable to pull %s: %v", image, err))
 		}
 	}
 	touchImageRefresh(image)
-	ErrPrintln()
+	if previous.Exists {
+		if current, err := inspectImage(image); err == nil && current.ID != previous.ID {
+			docker.sendImageUpdatedWebhooks(image)
+		}
+	}
+	if logFormat == logFormatText && infoEnabled() {
+		ErrPrintln()
+	}
 }
 
 func loginToECR(account string, region string) {
@@ -463,7 +554,9 @@ func loginToECR(account string, region string) {
 
 func getDockerUpdateCmd(image string) *exec.Cmd {
 	dockerUpdateCmd := exec.Command("docker", "pull", image)
-	dockerUpdateCmd.Stdout, dockerUpdateCmd.Stderr = os.Stderr, os.Stderr
+	if infoEnabled() {
+		dockerUpdateCmd.Stdout, dockerUpdateCmd.Stderr = os.Stderr, os.Stderr
+	}
 	return dockerUpdateCmd
 }
 
@@ -477,7 +570,8 @@ func getEnviron(noHome bool) (result []string) {
 		}
 
 		if runtime.GOOS == "windows" {
-			if strings.Contains(strings.ToUpper(split[1]), `C:\`) || strings.Contains(varUpper, "WIN") {
+			// The variables referring to Windows folders (i.e. APPDATA, ProgramFiles) are meaningless in the container
+			if reWindowsPath.MatchString(split[1]) || strings.Contains(varUpper, "WIN") {
 				continue
 			}
 		}
@@ -495,6 +589,19 @@ func getEnviron(noHome bool) (result []string) {
 	return
 }
 
+// reWindowsPath matches the values containing a Windows absolute path (C:\... or C:/...)
+var reWindowsPath = regexp.MustCompile(`(^|[^A-Za-z])[A-Za-z]:[\\/]`)
+
+// getInteractiveArgs returns the docker arguments attaching the input of tgf to the container, a pseudo-TTY is only allocated if the
+// input is a console: docker cannot allocate it if the input is redirected or if tgf is started from a Cygwin or MSYS2 terminal (mintty,
+// Git Bash), these terminals must run tgf through winpty to get a pseudo-TTY
+func getInteractiveArgs(stdin *os.File) []string {
+	if isatty.IsTerminal(stdin.Fd()) {
+		return []string{"-it"}
+	}
+	return []string{"-i"}
+}
+
 // This function set the path converter function
 // For old Windows version still using docker-machine and VirtualBox,
 // it transforms the C:\ to /C/.
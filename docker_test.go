@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strconv"
@@ -100,3 +101,34 @@ func TestGetImage(t *testing.T) {
 		})
 	}
 }
+
+func TestGetInteractiveArgs(t *testing.T) {
+	t.Parallel()
+
+	file := must(ioutil.TempFile("", "TestGetInteractiveArgs")).(*os.File)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	assert.Equal(t, []string{"-i"}, getInteractiveArgs(file), "No pseudo-TTY is allocated if the input is redirected")
+}
+
+func TestWindowsPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{`C:\Program Files`, true},
+		{`d:/work`, true},
+		{`C:\Windows;D:\tools\bin`, true},
+		{`--profile=E:\profiles`, true},
+		{"us-east-1", false},
+		{"http://localhost:8080", false},
+		{"key:value", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, reWindowsPath.MatchString(tt.value))
+		})
+	}
+}
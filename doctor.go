@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/fatih/color"
+)
+
+// Settings of the environment diagnostics (tgf doctor)
+const (
+	doctorTimeout          = 30 * time.Second
+	doctorMinimumDiskSpace = 2 * 1024 * 1024 * 1024
+)
+
+// tgfVersionURL is the location of the latest tgf version number (also used by get-latest-tgf.sh)
+var tgfVersionURL = "https://coveo-bootstrap-us-east-1.s3.amazonaws.com/tgf_version.txt"
+
+type doctorStatus int
+
+const (
+	doctorPass doctorStatus = iota
+	doctorWarning
+	doctorFail
+)
+
+func (status doctorStatus) String() string {
+	switch status {
+	case doctorPass:
+		return color.GreenString("PASS")
+	case doctorWarning:
+		return color.YellowString("WARN")
+	}
+	return color.RedString("FAIL")
+}
+
+// doctorResult is the outcome of a single diagnostic
+type doctorResult struct {
+	name    string
+	status  doctorStatus
+	message string
+}
+
+// doctor runs the environment diagnostics, each check can rely on the result of the previous ones
+type doctor struct {
+	config  *TGFConfig
+	results []doctorResult
+}
+
+func (d *doctor) report(name string, status doctorStatus, format string, args ...interface{}) {
+	d.results = append(d.results, doctorResult{name, status, fmt.Sprintf(format, args...)})
+}
+
+// failed returns true if the named check has failed (or has not been executed)
+func (d *doctor) failed(name string) bool {
+	for _, result := range d.results {
+		if result.name == name {
+			return result.status == doctorFail
+		}
+	}
+	return true
+}
+
+// runDoctorCommand executes a command with a timeout and returns its trimmed output
+func runDoctorCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+func (d *doctor) checkDockerClient() {
+	if version, err := getDockerClientVersion(); err != nil {
+		d.report("Docker client", doctorFail, "docker is not available: %v", err)
+	} else {
+		d.report("Docker client", doctorPass, "version %s", version)
+	}
+}
+
+func (d *doctor) checkDockerDaemon() {
+	if d.failed("Docker client") {
+		d.report("Docker daemon", doctorFail, "skipped, the docker client is not available")
+		return
+	}
+	if version, err := getDockerServerVersion(); err != nil {
+		d.report("Docker daemon", doctorFail, "unable to connect to the docker daemon: %v", err)
+	} else {
+		d.report("Docker daemon", doctorPass, "version %s", version)
+	}
+}
+
+// diskSpaceStatus returns the status of the free space on a folder
+func diskSpaceStatus(free uint64) doctorStatus {
+	if free < doctorMinimumDiskSpace {
+		return doctorWarning
+	}
+	return doctorPass
+}
+
+func (d *doctor) checkDiskSpace() {
+	folders := []string{getCacheFolder(), os.TempDir()}
+	if root, err := runDoctorCommand("docker", "info", "--format", "{{.DockerRootDir}}"); err == nil && root != "" {
+		// The docker root folder is only checked if it is on the host (not in a virtual machine)
+		if _, err := os.Stat(root); err == nil {
+			folders = append(folders, root)
+		}
+	}
+	for _, folder := range folders {
+		os.MkdirAll(folder, 0755)
+		free, err := getFreeDiskSpace(folder)
+		if err != nil {
+			d.report("Disk space", doctorWarning, "unable to get the free space of %s: %v", folder, err)
+			continue
+		}
+		d.report("Disk space", diskSpaceStatus(free), "%.1f GB free in %s", float64(free)/(1024*1024*1024), folder)
+	}
+}
+
+func (d *doctor) checkConfiguration() {
+	config := d.config
+	files := config.findConfigFiles(must(os.Getwd()).(string))
+	if userConfig := getUserConfigFile(); !config.tgf.DisableUserConfig {
+		if _, err := os.Stat(userConfig); err == nil {
+			files = append([]string{userConfig}, files...)
+		}
+	}
+	if len(files) == 0 {
+		d.report("Configuration", doctorWarning, "no %s file found in the current folder or its parents", configFile)
+	}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = collections.ConvertData(string(content), &TGFConfig{})
+		}
+		if err != nil {
+			d.report("Configuration", doctorFail, "%s is invalid: %v", file, err)
+		} else {
+			d.report("Configuration", doctorPass, "%s", file)
+		}
+	}
+	for _, err := range config.validate() {
+		if _, isWarning := err.(ConfigWarning); isWarning {
+			d.report("Configuration", doctorWarning, "%v", err)
+		} else {
+			d.report("Configuration", doctorFail, "%v", err)
+		}
+	}
+}
+
+func (d *doctor) checkAWSCredentials() {
+	config := d.config
+	if config.ensureAWSSession(); config.awsSession == nil {
+		d.report("AWS credentials", doctorWarning, "no AWS configuration found, the parameter store and the AWS features are not available")
+		return
+	}
+	identity, err := sts.New(config.awsSession, awsRetryConfig()).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		d.report("AWS credentials", doctorFail, "the credentials are not valid: %v", describeAWSError(err))
+		return
+	}
+	message := fmt.Sprintf("%s (profile %s)", aws.StringValue(identity.Arn), config.getProfileName())
+	if !config.awsExpiration.IsZero() {
+		remaining := time.Until(config.awsExpiration).Truncate(time.Second)
+		message += fmt.Sprintf(", expires in %v", remaining)
+		if remaining < credentialExpiryWarning {
+			d.report("AWS credentials", doctorWarning, "%s", message)
+			return
+		}
+	}
+	d.report("AWS credentials", doctorPass, "%s", message)
+}
+
+func (d *doctor) checkImage() {
+	if d.failed("Docker daemon") {
+		d.report("Docker image", doctorFail, "skipped, the docker daemon is not available")
+		return
+	}
+	image := d.config.GetImageName()
+	if d.config.tgf.UseLocalImage {
+		if checkImage(image) {
+			d.report("Docker image", doctorPass, "%s is available locally", image)
+		} else {
+			d.report("Docker image", doctorFail, "%s is not available locally and --local-image is set", image)
+		}
+		return
+	}
+	if _, err := runDoctorCommand("docker", "manifest", "inspect", image); err != nil {
+		if checkImage(image) {
+			d.report("Docker image", doctorWarning, "%s is available locally, but it cannot be pulled: %v", image, err)
+		} else {
+			d.report("Docker image", doctorFail, "%s cannot be pulled: %v", image, err)
+		}
+		return
+	}
+	d.report("Docker image", doctorPass, "%s can be pulled", image)
+}
+
+// getLatestVersion returns the latest tgf version published
+func getLatestVersion() (string, error) {
+	p := startProgress("Looking up the latest tgf version")
+	defer p.stop()
+	response, err := newHTTPClient(doctorTimeout).Get(tgfVersionURL)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", tgfVersionURL, response.Status)
+	}
+	content, err := ioutil.ReadAll(&progressReader{Reader: response.Body, progress: p, total: response.ContentLength})
+	return strings.TrimSpace(string(content)), err
+}
+
+func (d *doctor) checkUpdate() {
+	latest, err := getLatestVersion()
+	if err != nil {
+		d.report("tgf update", doctorWarning, "unable to get the latest tgf version: %v", err)
+		return
+	}
+	if newer, err := CheckVersionRange(latest, ">"+version); err == nil && newer {
+		d.report("tgf update", doctorWarning, "tgf %s is available (current version is %s)", latest, version)
+		return
+	}
+	d.report("tgf update", doctorPass, "tgf %s is the latest version", version)
+}
+
+func (d *doctor) checkIntegrity() {
+	executable, err := os.Executable()
+	if err != nil {
+		d.report("tgf executable", doctorWarning, "unable to locate the executable: %v", err)
+		return
+	}
+	sealed, err := verifyIntegrity(executable)
+	switch {
+	case err != nil:
+		d.report("tgf executable", doctorFail, "%s has been modified or truncated: %v", executable, err)
+	case !sealed:
+		d.report("tgf executable", doctorWarning, "%s does not contain its checksum (development build)", executable)
+	default:
+		d.report("tgf executable", doctorPass, "%s matches its checksum", executable)
+	}
+}
+
+// write prints the result of each check and returns the exit code
+func (d *doctor) write(w io.Writer) int {
+	exitCode := 0
+	for _, result := range d.results {
+		fmt.Fprintf(w, "[%s] %-16s %s\n", result.status, result.name, result.message)
+		if result.status == doctorFail {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// runDoctorChecks resolves the configuration as a regular run would (errors are reported as failed checks) and runs all the checks
+func runDoctorChecks(app *TGFApplication) *doctor {
+	app.Unmanaged = nil
+
+	config := InitConfig(app)
+	if app.AwsProfile != "" {
+		if err := config.InitAWS(app.AwsProfile); err != nil {
+			printError(msgCredentialsUnresolved, err)
+		}
+	}
+	config.applyProfileConfig()
+	config.applyAWSOverrides()
+	if err := config.applySetValues(app.SetValues); err != nil {
+		printError(msgConfigInvalid, err)
+	}
+	config.applyCommandLineOverrides()
+
+	d := &doctor{config: config}
+	d.checkDockerClient()
+	d.checkDockerDaemon()
+	d.checkDiskSpace()
+	d.checkConfiguration()
+	d.checkAWSCredentials()
+	d.checkImage()
+	d.checkUpdate()
+	d.checkIntegrity()
+	return d
+}
+
+func runDoctor(app *TGFApplication, args []string) int {
+	if len(args) > 0 {
+		return printCommandUsage("doctor", "")
+	}
+	return runDoctorChecks(app).write(color.Output)
+}
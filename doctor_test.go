@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskSpaceStatus(t *testing.T) {
+	assert.Equal(t, doctorWarning, diskSpaceStatus(100*1024*1024))
+	assert.Equal(t, doctorPass, diskSpaceStatus(10*1024*1024*1024))
+}
+
+func TestGetFreeDiskSpace(t *testing.T) {
+	free, err := getFreeDiskSpace(os.TempDir())
+	assert.NoError(t, err)
+	assert.NotZero(t, free)
+}
+
+func TestDoctorWrite(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var out bytes.Buffer
+	d := doctor{}
+	d.report("Docker client", doctorPass, "version %s", "20.10.7")
+	assert.Equal(t, 0, d.write(&out))
+	assert.Equal(t, "[PASS] Docker client    version 20.10.7\n", out.String())
+	assert.False(t, d.failed("Docker client"))
+	assert.True(t, d.failed("Docker daemon"), "A check that has not been executed is considered as failed")
+
+	d.report("Docker daemon", doctorFail, "unable to connect")
+	assert.True(t, d.failed("Docker daemon"))
+	assert.Equal(t, 1, d.write(&out))
+}
+
+func TestDoctorCheckUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		latest     string
+		wantStatus doctorStatus
+	}{
+		{"Up to date", http.StatusOK, version, doctorPass},
+		{"Newer version", http.StatusOK, "999.0.0\n", doctorWarning},
+		{"Unreachable", http.StatusForbidden, "", doctorWarning},
+	}
+	defer func(url string) { tgfVersionURL = url }(tgfVersionURL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.latest)
+			}))
+			defer server.Close()
+			tgfVersionURL = server.URL
+
+			d := doctor{}
+			d.checkUpdate()
+			assert.Len(t, d.results, 1)
+			assert.Equal(t, tt.wantStatus, d.results[0].status, d.results[0].message)
+		})
+	}
+}
+
+func TestDoctorCheckConfiguration(t *testing.T) {
+	currentDir, _ := os.Getwd()
+	tempDir, _ := filepath.EvalSymlinks(must(ioutil.TempDir("", "TestDoctor")).(string))
+	defer func() {
+		os.Chdir(currentDir)
+		os.RemoveAll(tempDir)
+	}()
+	subFolder := filepath.Join(tempDir, "module")
+	os.MkdirAll(subFolder, 0755)
+	ioutil.WriteFile(filepath.Join(tempDir, configFile), []byte("docker-image: coveo/tgf\n"), 0644)
+	ioutil.WriteFile(filepath.Join(subFolder, configFile), []byte("docker-image: [invalid\n"), 0644)
+	assert.NoError(t, os.Chdir(subFolder))
+
+	app := NewTestApplication([]string{"--ignore-user-config"})
+	d := doctor{config: &TGFConfig{tgf: app, Image: "coveo/tgf"}}
+	d.checkConfiguration()
+
+	assert.Len(t, d.results, 2)
+	assert.Equal(t, doctorResult{"Configuration", doctorPass, filepath.Join(tempDir, configFile)}, d.results[0])
+	assert.Equal(t, doctorFail, d.results[1].status)
+	assert.Contains(t, d.results[1].message, filepath.Join(subFolder, configFile)+" is invalid")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var reShellSafe = regexp.MustCompile(`^[a-zA-Z0-9_@%+=:,./-]+$`)
+
+// shellQuote returns the argument quoted for a POSIX shell (if required)
+func shellQuote(arg string) string {
+	if reShellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// writeDryRun prints the docker invocation that would be executed along with the environment injected by tgf (secrets masked), the
+// scripts and the host hooks executed around it are listed (the container hooks are part of the command)
+func writeDryRun(w io.Writer, args []string, environment map[string]string, before, after []string, hooks HooksConfig) {
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(w, "# Environment injected by tgf (the variables of the current environment are also supplied to the container with -e)")
+	for _, key := range keys {
+		fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(maskSecret(key, environment[key])))
+	}
+	writeComments := func(label string, scripts []string) {
+		for _, script := range scripts {
+			fmt.Fprintf(w, "# %s: %s\n", label, strings.Replace(strings.TrimSpace(script), "\n", "\n#   ", -1))
+		}
+	}
+	writeComments("run-before", before)
+	writeComments(hookPreRun+" hook", getHookCommands(hooks.PreRun, false))
+
+	quoted := make([]string, len(args))
+	for i := range args {
+		quoted[i] = shellQuote(args[i])
+	}
+	fmt.Fprintln(w, strings.Join(quoted, " "))
+
+	writeComments("run-after", after)
+	writeComments(hookOnFailure+" hook", getHookCommands(hooks.OnFailure, false))
+	writeComments(hookPostRun+" hook", getHookCommands(hooks.PostRun, false))
+}
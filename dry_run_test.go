@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"--rm", "--rm"},
+		{"/home/user:/home/user", "/home/user:/home/user"},
+		{"HOME=/home/user", "HOME=/home/user"},
+		{"", "''"},
+		{"hello world", "'hello world'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			assert.Equal(t, tt.want, shellQuote(tt.arg))
+		})
+	}
+}
+
+func TestWriteDryRun(t *testing.T) {
+	var buffer bytes.Buffer
+	environment := map[string]string{
+		"TGF_COMMAND":       "terragrunt",
+		"AWS_SESSION_TOKEN": "FwoGZXIvYXdzE",
+		"MESSAGE":           "hello world",
+	}
+	args := []string{"docker", "run", "-e", "AWS_SESSION_TOKEN", "--rm", "coveo/tgf:1.21.0", "terragrunt", "plan", "-var", "name=my value"}
+	writeDryRun(&buffer, args, environment, []string{"echo before\necho again"}, []string{"echo after"}, HooksConfig{
+		PreRun:    []Hook{{Command: "./warm-cache.sh"}, {Command: "ls /var/tgf", Container: true}},
+		OnFailure: []Hook{{Command: "./collect-logs.sh"}},
+	})
+
+	assert.Equal(t, `# Environment injected by tgf (the variables of the current environment are also supplied to the container with -e)
+export AWS_SESSION_TOKEN='********'
+export MESSAGE='hello world'
+export TGF_COMMAND=terragrunt
+# run-before: echo before
+#   echo again
+# pre-run hook: ./warm-cache.sh
+docker run -e AWS_SESSION_TOKEN --rm coveo/tgf:1.21.0 terragrunt plan -var 'name=my value'
+# run-after: echo after
+# on-failure hook: ./collect-logs.sh
+`, buffer.String())
+}
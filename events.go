@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventStream writes the lifecycle events of the run as JSON lines on a Unix socket or a named pipe (--events), so the frontends (IDE
+// extensions, TUIs) can follow the run without parsing the output of tgf
+type eventStream struct {
+	sync.Mutex
+	now    func() time.Time
+	start  time.Time
+	w      io.WriteCloser
+	folder string
+}
+
+// runEvent is the JSON representation of an event, the fields depend on the type of event
+type runEvent struct {
+	Time   time.Time              `json:"time"`
+	Event  string                 `json:"event"`
+	PID    int                    `json:"pid"`
+	Folder string                 `json:"folder"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Types of events
+const (
+	eventRunStarted    = "run-started"
+	eventPhaseStarted  = "phase-started"
+	eventPhaseFinished = "phase-finished"
+	eventLog           = "log"
+	eventRunFinished   = "run-finished"
+)
+
+var events *eventStream
+
+// openEventStream connects to the Unix socket or opens the named pipe (a FIFO or a \\.\pipe\ name on Windows) where the events are
+// written, the frontend must be listening before tgf is started
+func openEventStream(address string) (*eventStream, error) {
+	var w io.WriteCloser
+	var err error
+	if info, statErr := os.Stat(address); statErr == nil && info.Mode()&os.ModeSocket != 0 {
+		w, err = net.Dial("unix", address)
+	} else {
+		w, err = os.OpenFile(address, os.O_WRONLY|os.O_APPEND, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	folder, _ := os.Getwd()
+	return &eventStream{now: time.Now, start: time.Now(), w: w, folder: folder}, nil
+}
+
+// emit writes the event, the stream is closed if the frontend is no longer listening
+func (stream *eventStream) emit(event string, fields map[string]interface{}) {
+	if stream == nil {
+		return
+	}
+	stream.Lock()
+	defer stream.Unlock()
+	if stream.w == nil {
+		return
+	}
+	content := must(json.Marshal(runEvent{Time: stream.now().UTC(), Event: event, PID: os.Getpid(), Folder: stream.folder, Fields: fields})).([]byte)
+	if _, err := stream.w.Write(append(content, '\n')); err != nil {
+		stream.w.Close()
+		stream.w = nil
+	}
+}
+
+// emitLog writes a message printed by tgf
+func (stream *eventStream) emitLog(level logLevel, component string, id messageID, message string, fields map[string]interface{}) {
+	// The debug messages are prefixed by # to be copied in a shell, they are written as the JSON log records
+	if message = strings.TrimLeft(strings.TrimSpace(message), "# "); stream == nil || message == "" {
+		return
+	}
+	event := map[string]interface{}{"level": level.String(), "component": component, "message": message}
+	if id != "" {
+		event["id"] = id
+	}
+	if len(fields) > 0 {
+		event["fields"] = fields
+	}
+	stream.emit(eventLog, event)
+}
+
+// close writes the exit code of the run and closes the stream
+func (stream *eventStream) close(exitCode int) {
+	if stream == nil {
+		return
+	}
+	stream.emit(eventRunFinished, map[string]interface{}{"exit-code": exitCode, "seconds": stream.now().Sub(stream.start).Seconds()})
+	stream.Lock()
+	defer stream.Unlock()
+	if stream.w != nil {
+		stream.w.Close()
+		stream.w = nil
+	}
+}
+
+// startEvents opens the event stream of the run (--events), the stream is closed by main once the exit code is known
+func (app *TGFApplication) startEvents() {
+	if app.Events == "" {
+		return
+	}
+	stream, err := openEventStream(app.Events)
+	if err != nil {
+		printWarning(msgEventsFailed, app.Events, err)
+		return
+	}
+	events, timings.events = stream, stream
+	stream.emit(eventRunStarted, map[string]interface{}{"version": version, "arguments": os.Args[1:]})
+}
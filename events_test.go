@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStream(t *testing.T) {
+	skipOnWindows(t, "the frontend listens on a Unix socket")
+	tempDir := must(ioutil.TempDir("", "TestEventStream")).(string)
+	defer os.RemoveAll(tempDir)
+	socket := filepath.Join(tempDir, "events.sock")
+	listener := must(net.Listen("unix", socket)).(net.Listener)
+	defer listener.Close()
+	received := make(chan []runEvent)
+	go func() {
+		var result []runEvent
+		if conn, err := listener.Accept(); err == nil {
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var event runEvent
+				must(json.Unmarshal(scanner.Bytes(), &event))
+				result = append(result, event)
+			}
+		}
+		received <- result
+	}()
+
+	stream, err := openEventStream(socket)
+	assert.NoError(t, err)
+	now := time.Date(2024, 3, 4, 15, 12, 0, 0, time.UTC)
+	stream.now, stream.start = func() time.Time { return now }, now.Add(-2*time.Second)
+	recorder := newTimingRecorder(stream.now)
+	recorder.events = stream
+	recorder.begin("configuration")()
+	stream.emitLog(logLevelWarning, "tgf", msgEventsFailed, "# Warning\n", nil)
+	stream.close(3)
+	stream.emit(eventLog, nil)
+
+	result := <-received
+	assert.Len(t, result, 4)
+	assert.Equal(t, []string{eventPhaseStarted, eventPhaseFinished, eventLog, eventRunFinished},
+		[]string{result[0].Event, result[1].Event, result[2].Event, result[3].Event})
+	assert.Equal(t, "configuration", result[1].Fields["phase"])
+	assert.Equal(t, map[string]interface{}{"level": "warn", "component": "tgf", "id": "events-failed", "message": "Warning"}, result[2].Fields)
+	assert.Equal(t, map[string]interface{}{"exit-code": 3.0, "seconds": 2.0}, result[3].Fields)
+	assert.Equal(t, os.Getpid(), result[0].PID)
+	assert.Equal(t, now, result[0].Time)
+}
+
+func TestEventStreamPipe(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestEventStreamPipe")).(string)
+	defer os.RemoveAll(tempDir)
+
+	_, err := openEventStream(filepath.Join(tempDir, "missing"))
+	assert.Error(t, err, "The named pipe is not created by tgf")
+
+	// A regular file is written as a named pipe would be
+	filename := filepath.Join(tempDir, "events")
+	must(ioutil.WriteFile(filename, nil, 0644))
+	stream, err := openEventStream(filename)
+	assert.NoError(t, err)
+	stream.emit(eventRunStarted, map[string]interface{}{"version": version})
+	stream.close(0)
+	var event runEvent
+	lines := bufio.NewScanner(must(os.Open(filename)).(*os.File))
+	assert.True(t, lines.Scan())
+	assert.NoError(t, json.Unmarshal(lines.Bytes(), &event))
+	assert.Equal(t, eventRunStarted, event.Event)
+	assert.True(t, lines.Scan())
+	assert.False(t, lines.Scan())
+
+	var disabled *eventStream
+	disabled.emit(eventLog, nil)
+	disabled.close(0)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Exit codes returned when tgf fails by itself, the exit code of the entry point is returned as is.
+// They are chosen among the sysexits.h codes to avoid the codes commonly returned by terraform and terragrunt.
+const (
+	exitPolicyViolation   = 65
+	exitDockerUnavailable = 69
+	exitImagePull         = 75
+	exitCredentials       = 77
+	exitConfig            = 78
+)
+
+// exitCodes documents the tgf exit codes (they are listed in the help)
+var exitCodes = []struct {
+	code        int
+	description string
+}{
+	{1, "Other tgf errors"},
+	{exitPolicyViolation, "The policy gates have reported findings at or above their severity threshold"},
+	{exitDockerUnavailable, "The docker client is not installed, the docker daemon or the remote runner cannot be reached"},
+	{exitImagePull, "The docker image cannot be pulled"},
+	{exitCredentials, "The AWS credentials cannot be resolved, the role cannot be assumed or the credentials expire too soon"},
+	{exitConfig, "The configuration is invalid or does not meet the version requirements"},
+}
+
+// getExitCodesHelp returns the list of the tgf exit codes formatted for the application help
+func getExitCodesHelp() string {
+	lines := make([]string, 0, len(exitCodes))
+	for _, exitCode := range exitCodes {
+		lines = append(lines, fmt.Sprintf("%3d  %s", exitCode.code, exitCode.description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tgfError is an error that terminates tgf with a specific exit code
+type tgfError struct {
+	exitCode int
+	message  string
+}
+
+func (e tgfError) Error() string { return e.message }
+
+func newTGFError(exitCode int, format string, args ...interface{}) tgfError {
+	return tgfError{exitCode, fmt.Sprintf(format, args...)}
+}
+
+// getExitCodeMessage returns the ID of the message used to report an error terminating tgf with the exit code
+func getExitCodeMessage(exitCode int) messageID {
+	switch exitCode {
+	case exitConfig:
+		return msgConfigInvalid
+	case exitCredentials:
+		return msgCredentialsError
+	case exitDockerUnavailable:
+		return msgDockerUnavailable
+	case exitImagePull:
+		return msgImagePullFailed
+	case exitPolicyViolation:
+		return msgPolicyViolation
+	}
+	return msgError
+}
+
+// failWith reports the error and returns the exit code matching its category
+func failWith(exitCode int, err error) int {
+	printError(getExitCodeMessage(exitCode), err)
+	return exitCode
+}
+
+// getDockerFailure returns the error to report when a docker command fails, docker is reported as unavailable if the daemon cannot
+// be reached (the cause is only checked after a failure to avoid adding a docker call to each run)
+func getDockerFailure(exitCode int, format string, args ...interface{}) tgfError {
+	if _, err := getDockerServerVersion(); err != nil {
+		return newTGFError(exitDockerUnavailable, "Docker is not available: %v", err)
+	}
+	return newTGFError(exitCode, format, args...)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCodesAreUnique(t *testing.T) {
+	found := make(map[int]bool)
+	for _, exitCode := range exitCodes {
+		assert.False(t, found[exitCode.code], "Exit code %d is defined twice", exitCode.code)
+		found[exitCode.code] = true
+		assert.NotEqual(t, dockerRunFailure, exitCode.code, "The exit codes of docker must not be reused")
+	}
+}
+
+func TestGetExitCodesHelp(t *testing.T) {
+	help := getExitCodesHelp()
+	assert.Contains(t, help, fmt.Sprintf("%3d  The docker image cannot be pulled", exitImagePull))
+	assert.Contains(t, help, fmt.Sprintf("%3d  The configuration is invalid", exitConfig))
+}
+
+func TestTGFError(t *testing.T) {
+	err := newTGFError(exitCredentials, "Unable to assume role %s", "admin")
+	assert.Equal(t, "Unable to assume role admin", err.Error())
+	assert.Equal(t, exitCredentials, err.exitCode)
+	assert.Equal(t, exitConfig, failWith(exitConfig, err))
+}
+
+func TestConfigErrorExitCode(t *testing.T) {
+	app := NewTestApplication([]string{"--no-aws", "--set", "invalid", "plan"})
+	assert.Equal(t, exitConfig, app.Run())
+}
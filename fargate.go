@@ -0,0 +1,367 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Default values of the fargate runner
+const (
+	defaultFargateContainer = "tgf"
+	fargateWorkspaceFile    = "/tmp/tgf-workspace.tar.gz"
+)
+
+// fargatePollDelay is the delay between the checks of the task status and logs (only changed by the tests)
+var fargatePollDelay = 2 * time.Second
+
+// fargateCredentialVariables are not transferred to the task, the AWS credentials of the task are provided by its task role (the host
+// credentials may expire before the end of the run and the run must survive a disconnection of the host)
+var fargateCredentialVariables = []string{
+	"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_CREDENTIAL_EXPIRATION",
+	"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+}
+
+// FargateConfig defines where and how the fargate runner launches the ECS tasks
+type FargateConfig struct {
+	Cluster        string   `yaml:"cluster,omitempty" json:"cluster,omitempty" hcl:"cluster,omitempty"`
+	TaskDefinition string   `yaml:"task-definition,omitempty" json:"task-definition,omitempty" hcl:"task-definition,omitempty"`
+	Container      string   `yaml:"container,omitempty" json:"container,omitempty" hcl:"container,omitempty"`
+	Subnets        []string `yaml:"subnets,omitempty" json:"subnets,omitempty" hcl:"subnets,omitempty"`
+	SecurityGroups []string `yaml:"security-groups,omitempty" json:"security-groups,omitempty" hcl:"security-groups,omitempty"`
+	PublicIP       bool     `yaml:"public-ip,omitempty" json:"public-ip,omitempty" hcl:"public-ip,omitempty"`
+	Bucket         string   `yaml:"bucket,omitempty" json:"bucket,omitempty" hcl:"bucket,omitempty"`
+	Prefix         string   `yaml:"prefix,omitempty" json:"prefix,omitempty" hcl:"prefix,omitempty"`
+}
+
+// getContainer returns the name of the container running the command in the task definition
+func (fargate FargateConfig) getContainer() string {
+	if fargate.Container == "" {
+		return defaultFargateContainer
+	}
+	return fargate.Container
+}
+
+// validate returns an error if a setting required to launch the task is missing
+func (fargate FargateConfig) validate() error {
+	var missing []string
+	if fargate.TaskDefinition == "" {
+		missing = append(missing, "fargate.task-definition")
+	}
+	if len(fargate.Subnets) == 0 {
+		missing = append(missing, "fargate.subnets")
+	}
+	if fargate.Bucket == "" {
+		missing = append(missing, "fargate.bucket")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("The fargate runner requires %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// getCopyCommand returns the AWS CLI command copying a file from or to the bucket
+func (fargate FargateConfig) getCopyCommand(source, destination, region string) string {
+	command := fmt.Sprintf("aws s3 cp --quiet %s %s", source, destination)
+	if region != "" {
+		command += " --region " + region
+	}
+	return command
+}
+
+// getScript returns the shell script executed by the task, the workspace is downloaded with the task role before running the command
+// and the files changed by the command are uploaded to changesKey once it is completed (the exit code of the command is kept).
+// The workspace contains the environment of the run (secrets included), so no URL giving access to it is put in the task definition or
+// in the RunTask parameters (they can be read with ecs:DescribeTasks or in CloudTrail).
+func (fargate FargateConfig) getScript(run remoteRun, key, changesKey, region string) string {
+	download := fargate.getCopyCommand(shellQuote(fmt.Sprintf("s3://%s/%s", fargate.Bucket, key)), fargateWorkspaceFile, region)
+	upload := fargate.getCopyCommand("-", shellQuote(fmt.Sprintf("s3://%s/%s", fargate.Bucket, changesKey)), region)
+	return fmt.Sprintf("%[1]s && tar -xzf %[2]s -C / && rm -f %[2]s && touch %[3]s && (%[4]s); code=$?; if [ -f %[3]s ]; then (%[5]s) | %[6]s; fi; exit $code",
+		download, fargateWorkspaceFile, remoteWorkspaceMarker, run.getScript(), run.getChangesScript(), upload)
+}
+
+// copyChanges extracts the files changed by the command, uploaded by the task to changesKey, in the local folder
+func (fargate FargateConfig) copyChanges(client *s3.S3, run remoteRun, changesKey string) error {
+	result, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String(fargate.Bucket), Key: aws.String(changesKey)})
+	if err != nil {
+		return describeAWSError(err)
+	}
+	defer result.Body.Close()
+	return run.readChanges(result.Body)
+}
+
+// getTaskDefinition returns the task definition running the image, a new revision of the configured task definition is registered if
+// its container does not use the image
+func (fargate FargateConfig) getTaskDefinition(client *ecs.ECS, image string) (*ecs.TaskDefinition, error) {
+	result, err := client.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String(fargate.TaskDefinition)})
+	if err != nil {
+		return nil, describeAWSError(err)
+	}
+	definition := result.TaskDefinition
+	container := findContainerDefinition(definition, fargate.getContainer())
+	if container == nil {
+		return nil, fmt.Errorf("The task definition %s has no container named %s", fargate.TaskDefinition, fargate.getContainer())
+	}
+	if aws.StringValue(container.Image) == image {
+		return definition, nil
+	}
+
+	container.Image = aws.String(image)
+	registered, err := client.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
+		Family:                  definition.Family,
+		ContainerDefinitions:    definition.ContainerDefinitions,
+		Cpu:                     definition.Cpu,
+		Memory:                  definition.Memory,
+		EphemeralStorage:        definition.EphemeralStorage,
+		ExecutionRoleArn:        definition.ExecutionRoleArn,
+		TaskRoleArn:             definition.TaskRoleArn,
+		NetworkMode:             definition.NetworkMode,
+		RequiresCompatibilities: definition.RequiresCompatibilities,
+		RuntimePlatform:         definition.RuntimePlatform,
+		Volumes:                 definition.Volumes,
+	})
+	if err != nil {
+		return nil, describeAWSError(err)
+	}
+	return registered.TaskDefinition, nil
+}
+
+func findContainerDefinition(definition *ecs.TaskDefinition, name string) *ecs.ContainerDefinition {
+	for _, container := range definition.ContainerDefinitions {
+		if aws.StringValue(container.Name) == name {
+			return container
+		}
+	}
+	return nil
+}
+
+// getLogConfiguration returns the CloudWatch log group and stream prefix receiving the output of the container, it is checked before
+// launching the task since the output of the command could not be followed otherwise
+func (fargate FargateConfig) getLogConfiguration(definition *ecs.TaskDefinition) (group, prefix string, err error) {
+	container := findContainerDefinition(definition, fargate.getContainer())
+	if container.LogConfiguration == nil || aws.StringValue(container.LogConfiguration.LogDriver) != ecs.LogDriverAwslogs {
+		return "", "", fmt.Errorf("The container %s of the task definition %s must use the awslogs log driver", fargate.getContainer(), aws.StringValue(definition.Family))
+	}
+	options := container.LogConfiguration.Options
+	return aws.StringValue(options["awslogs-group"]), aws.StringValue(options["awslogs-stream-prefix"]), nil
+}
+
+// getLogStream returns the CloudWatch log stream receiving the output of the container of the task
+func (fargate FargateConfig) getLogStream(prefix, taskArn string) string {
+	return fmt.Sprintf("%s/%s/%s", prefix, fargate.getContainer(), taskArn[strings.LastIndex(taskArn, "/")+1:])
+}
+
+// logTail prints the events added to a CloudWatch log stream since the previous call
+type logTail struct {
+	client        *cloudwatchlogs.CloudWatchLogs
+	group, stream string
+	token         *string
+}
+
+func (tail *logTail) print(w io.Writer) error {
+	for {
+		result, err := tail.client.GetLogEvents(&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(tail.group),
+			LogStreamName: aws.String(tail.stream),
+			NextToken:     tail.token,
+			StartFromHead: aws.Bool(true),
+		})
+		if err != nil {
+			if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+				// The stream is created once the container is started
+				return nil
+			}
+			return describeAWSError(err)
+		}
+		for _, event := range result.Events {
+			fmt.Fprintln(w, aws.StringValue(event.Message))
+		}
+		if len(result.Events) == 0 || aws.StringValue(result.NextForwardToken) == aws.StringValue(tail.token) {
+			tail.token = result.NextForwardToken
+			return nil
+		}
+		tail.token = result.NextForwardToken
+	}
+}
+
+// getFargateSession returns the AWS session used to launch the task
+func (config *TGFConfig) getFargateSession() (*session.Session, error) {
+	if config.awsSession == nil {
+		if err := config.InitAWS(""); err != nil {
+			return nil, err
+		}
+	}
+	return config.awsSession.Copy(awsRetryConfig()), nil
+}
+
+// runFargate runs the command in an ECS task launched on Fargate and returns the exit code of the command. The workspace is transferred
+// through S3 and the output of the command is read from CloudWatch, so the task is not interrupted if tgf is stopped or disconnected.
+func (config *TGFConfig) runFargate(run remoteRun) int {
+	app, fargate := config.tgf, config.Fargate
+	if err := fargate.validate(); err != nil {
+		return failWith(exitConfig, err)
+	}
+	for _, variable := range fargateCredentialVariables {
+		delete(run.environment, variable)
+	}
+	name := "tgf-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	key := strings.TrimPrefix(strings.TrimSuffix(fargate.Prefix, "/")+"/"+name+".tar.gz", "/")
+	changesKey := strings.TrimSuffix(key, ".tar.gz") + ".changes.tar"
+	if app.DryRun {
+		fmt.Fprintf(os.Stdout, "# Task launched by tgf with %s (the folder %s is transferred to %s through s3://%s/%s)\n", fargate.TaskDefinition, run.root, run.remoteRoot, fargate.Bucket, key)
+		writeDryRun(os.Stdout, []string{"sh", "-c", run.getScript()}, run.environment, config.runBeforeCommands, config.runAfterCommands, config.Hooks)
+		return 0
+	}
+
+	awsSession, err := config.getFargateSession()
+	if err != nil {
+		return failWith(exitCredentials, err)
+	}
+	s3Client, ecsClient := s3.New(awsSession), ecs.New(awsSession)
+	lifecycle := &runLifecycle{config: config, component: "fargate", started: "Starting task", exited: "Task stopped"}
+	return lifecycle.execute(func() int {
+		definition, err := fargate.getTaskDefinition(ecsClient, run.image)
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to get the task definition %s: %v", fargate.TaskDefinition, err))
+		}
+		group, streamPrefix, err := fargate.getLogConfiguration(definition)
+		if err != nil {
+			return failWith(exitConfig, err)
+		}
+
+		endTransfer := timings.begin("workspace transfer")
+		app.Debug("# Uploading %s to s3://%s/%s", run.root, fargate.Bucket, key)
+		reader, writer := io.Pipe()
+		go func() {
+			compressed := gzip.NewWriter(writer)
+			err := run.writeWorkspace(compressed)
+			if err == nil {
+				err = compressed.Close()
+			}
+			writer.CloseWithError(err)
+		}()
+		_, err = s3manager.NewUploaderWithClient(s3Client).Upload(&s3manager.UploadInput{
+			Bucket:               aws.String(fargate.Bucket),
+			Key:                  aws.String(key),
+			Body:                 reader,
+			ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+		})
+		reader.Close()
+		endTransfer()
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to upload %s to s3://%s/%s: %v", run.root, fargate.Bucket, key, describeAWSError(err)))
+		}
+		deleteWorkspace := true
+		defer func() {
+			if !deleteWorkspace {
+				return
+			}
+			for _, key := range []string{key, changesKey} {
+				if _, err := s3Client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(fargate.Bucket), Key: aws.String(key)}); err != nil {
+					printWarning(msgFargateCleanupFailed, fargate.Bucket, key, describeAWSError(err))
+				}
+			}
+		}()
+
+		assignPublicIP := ecs.AssignPublicIpDisabled
+		if fargate.PublicIP {
+			assignPublicIP = ecs.AssignPublicIpEnabled
+		}
+		input := &ecs.RunTaskInput{
+			TaskDefinition: definition.TaskDefinitionArn,
+			LaunchType:     aws.String(ecs.LaunchTypeFargate),
+			Count:          aws.Int64(1),
+			StartedBy:      aws.String("tgf"),
+			NetworkConfiguration: &ecs.NetworkConfiguration{AwsvpcConfiguration: &ecs.AwsVpcConfiguration{
+				Subnets:        aws.StringSlice(fargate.Subnets),
+				SecurityGroups: aws.StringSlice(fargate.SecurityGroups),
+				AssignPublicIp: aws.String(assignPublicIP),
+			}},
+			Overrides: &ecs.TaskOverride{ContainerOverrides: []*ecs.ContainerOverride{{
+				Name:    aws.String(fargate.getContainer()),
+				Command: aws.StringSlice([]string{"sh", "-c", fargate.getScript(run, key, changesKey, aws.StringValue(awsSession.Config.Region))}),
+			}}},
+		}
+		if fargate.Cluster != "" {
+			input.Cluster = aws.String(fargate.Cluster)
+		}
+		result, err := ecsClient.RunTask(input)
+		if err == nil && len(result.Tasks) == 0 {
+			if len(result.Failures) > 0 {
+				err = fmt.Errorf("%s", aws.StringValue(result.Failures[0].Reason))
+			} else {
+				err = fmt.Errorf("No task has been started")
+			}
+		}
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to run the task %s: %v", aws.StringValue(definition.TaskDefinitionArn), describeAWSError(err)))
+		}
+		taskArn := aws.StringValue(result.Tasks[0].TaskArn)
+		stream := fargate.getLogStream(streamPrefix, taskArn)
+
+		// The task keeps running if tgf is interrupted, the user can follow it in CloudWatch
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupt)
+
+		tail := &logTail{client: cloudwatchlogs.New(awsSession), group: group, stream: stream}
+		describe := &ecs.DescribeTasksInput{Cluster: input.Cluster, Tasks: []*string{aws.String(taskArn)}}
+		detached := false
+		exitCode, err := lifecycle.runCommand(run.image, run.command, run.span, map[string]interface{}{"task": taskArn}, func() (int, error) {
+			for {
+				tasks, err := ecsClient.DescribeTasks(describe)
+				if err != nil {
+					return 0, fmt.Errorf("Unable to get the status of the task %s: %v", taskArn, describeAWSError(err))
+				}
+				if err := tail.print(lifecycle.stdout); err != nil {
+					printWarning(msgFargateLogsUnavailable, group, stream, err)
+				}
+				if len(tasks.Tasks) > 0 && aws.StringValue(tasks.Tasks[0].LastStatus) == ecs.DesiredStatusStopped {
+					task := tasks.Tasks[0]
+					for _, container := range task.Containers {
+						if aws.StringValue(container.Name) == fargate.getContainer() && container.ExitCode != nil {
+							return int(aws.Int64Value(container.ExitCode)), nil
+						}
+					}
+					return 0, fmt.Errorf("The task %s stopped without running the command: %s", taskArn, aws.StringValue(task.StoppedReason))
+				}
+				select {
+				case <-interrupt:
+					detached = true
+					return 0, fmt.Errorf("The task %s has been detached", taskArn)
+				case <-time.After(fargatePollDelay):
+				}
+			}
+		})
+		if detached {
+			deleteWorkspace = false
+			printWarning(msgFargateTaskDetached, taskArn, group, stream)
+			return 1
+		}
+		if err != nil {
+			return failWith(exitDockerUnavailable, err)
+		}
+
+		endTransfer = timings.begin("workspace transfer")
+		err = fargate.copyChanges(s3Client, run, changesKey)
+		endTransfer()
+		if err != nil {
+			printWarning(msgRemoteChangesNotCopied, run.root, err)
+		}
+		return exitCode
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFargateConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fargate FargateConfig
+		wantErr string
+	}{
+		{"Empty", FargateConfig{}, "The fargate runner requires fargate.task-definition, fargate.subnets, fargate.bucket"},
+		{"No bucket", FargateConfig{TaskDefinition: "tgf", Subnets: []string{"subnet-1"}}, "The fargate runner requires fargate.bucket"},
+		{"Valid", FargateConfig{TaskDefinition: "tgf", Subnets: []string{"subnet-1"}, Bucket: "workspaces"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fargate.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunFargate(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestRunFargate")).(string)
+	defer os.RemoveAll(tempDir)
+	must(os.MkdirAll(filepath.Join(tempDir, "live"), 0755))
+	must(ioutil.WriteFile(filepath.Join(tempDir, "live", "terragrunt.hcl"), []byte("inputs = {}"), 0644))
+	defer func(delay time.Duration) { fargatePollDelay = delay }(fargatePollDelay)
+	fargatePollDelay = time.Millisecond
+	defer func(saved *timingRecorder) { timings = saved }(timings)
+	timings = newTimingRecorder(time.Now)
+	eventsFile := must(os.Create(filepath.Join(tempDir, "events"))).(*os.File)
+	timings.events = &eventStream{now: time.Now, start: time.Now(), w: eventsFile}
+
+	var command []string
+	var registered string
+	calls := map[string]int{}
+	workspace := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		action := target[strings.LastIndex(target, ".")+1:]
+		if target == "" {
+			action = r.Method + " " + r.URL.Path
+		}
+		calls[action]++
+		var request map[string]interface{}
+		switch action {
+		case "DescribeTaskDefinition":
+			fmt.Fprint(w, `{"taskDefinition": {"family": "tgf", "taskDefinitionArn": "arn:aws:ecs:us-east-1:123456789012:task-definition/tgf:1", "containerDefinitions": [
+				{"name": "tgf", "image": "coveo/tgf:1.20.0", "logConfiguration": {"logDriver": "awslogs", "options": {"awslogs-group": "/tgf", "awslogs-stream-prefix": "run"}}}
+			]}}`)
+		case "RegisterTaskDefinition":
+			must(json.NewDecoder(r.Body).Decode(&request))
+			containers := request["containerDefinitions"]
+			registered = containers.([]interface{})[0].(map[string]interface{})["image"].(string)
+			must(json.NewEncoder(w).Encode(map[string]interface{}{"taskDefinition": map[string]interface{}{
+				"family": "tgf", "taskDefinitionArn": "arn:aws:ecs:us-east-1:123456789012:task-definition/tgf:2", "containerDefinitions": containers,
+			}}))
+		case "RunTask":
+			must(json.NewDecoder(r.Body).Decode(&request))
+			override := request["overrides"].(map[string]interface{})["containerOverrides"].([]interface{})[0].(map[string]interface{})
+			for _, arg := range override["command"].([]interface{}) {
+				command = append(command, arg.(string))
+			}
+			fmt.Fprint(w, `{"tasks": [{"taskArn": "arn:aws:ecs:us-east-1:123456789012:task/default/abcd"}]}`)
+		case "DescribeTasks":
+			if calls[action] == 1 {
+				fmt.Fprint(w, `{"tasks": [{"lastStatus": "RUNNING"}]}`)
+			} else {
+				fmt.Fprint(w, `{"tasks": [{"lastStatus": "STOPPED", "containers": [{"name": "tgf", "exitCode": 3}]}]}`)
+			}
+		case "GetLogEvents":
+			must(json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, "run/tgf/abcd", request["logStreamName"])
+			switch {
+			case calls[action] == 1:
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type": "ResourceNotFoundException", "message": "The specified log stream does not exist."}`)
+			case request["nextToken"] == nil:
+				fmt.Fprint(w, `{"events": [{"message": "Plan: 1 to add"}], "nextForwardToken": "f/1"}`)
+			default:
+				fmt.Fprint(w, `{"events": [], "nextForwardToken": "f/1"}`)
+			}
+		default:
+			if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ".changes.tar") {
+				archive := tar.NewWriter(w)
+				must(archive.WriteHeader(&tar.Header{Name: "./plan.out", Mode: 0644, Size: 4, Typeflag: tar.TypeReg}))
+				must(archive.Write([]byte("plan")))
+				must(archive.Close())
+			}
+			if r.Method == http.MethodPut {
+				content := must(gzip.NewReader(r.Body)).(*gzip.Reader)
+				archive := tar.NewReader(content)
+				for header, err := archive.Next(); err == nil; header, err = archive.Next() {
+					workspace[header.Name] = string(must(ioutil.ReadAll(archive)).([]byte))
+				}
+			}
+		}
+	}))
+	defer server.Close()
+
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	output := must(os.Create(filepath.Join(tempDir, "output"))).(*os.File)
+	defer output.Close()
+	os.Stdout = output
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), Fargate: FargateConfig{
+		TaskDefinition: "tgf",
+		Subnets:        []string{"subnet-1"},
+		Bucket:         "workspaces",
+		Prefix:         "tgf/",
+	}}
+	config.awsSession = session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials("AKIA", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	}))
+	run := remoteRun{
+		image:       "coveo/tgf:1.21.0",
+		command:     []string{"terragrunt", "plan"},
+		environment: map[string]string{"TGF_COMMAND": "plan", "AWS_SESSION_TOKEN": "token"},
+		root:        filepath.Join(tempDir, "live"),
+		remoteRoot:  "/var/tgf",
+		workdir:     "/var/tgf",
+	}
+	assert.Equal(t, 3, config.runFargate(run), "The exit code of the command is returned")
+
+	assert.Equal(t, "coveo/tgf:1.21.0", registered, "A revision using the image is registered")
+	assert.Equal(t, map[string]string{
+		"tmp/tgf.env":            "TGF_COMMAND=plan\n",
+		"var/tgf/":               "",
+		"var/tgf/terragrunt.hcl": "inputs = {}",
+	}, workspace, "The credentials are not transferred")
+	if assert.Len(t, command, 3) {
+		assert.Contains(t, command[2], "aws s3 cp --quiet s3://workspaces/tgf/tgf-")
+		assert.Contains(t, command[2], " --region us-east-1 && ")
+		assert.NotContains(t, command[2], "X-Amz-", "No presigned URL is exposed in the task parameters")
+		assert.Contains(t, command[2], " && touch /tmp/tgf.workspace && ("+run.getScript()+"); code=$?; ")
+		assert.Contains(t, command[2], "("+run.getChangesScript()+") | aws s3 cp --quiet - s3://workspaces/tgf/tgf-")
+		assert.True(t, strings.HasSuffix(command[2], ".changes.tar --region us-east-1; fi; exit $code"), "The exit code of the command is kept")
+	}
+	assert.Equal(t, "plan", string(must(ioutil.ReadFile(filepath.Join(tempDir, "live", "plan.out"))).([]byte)), "The changed files are copied back")
+	eventsFile.Close()
+	assert.Equal(t, 1, strings.Count(string(must(ioutil.ReadFile(eventsFile.Name())).([]byte)), `"phase":"container","seconds"`), "The container phase is finished once")
+	for _, call := range []string{"RunTask", "DescribeTasks"} {
+		assert.NotZero(t, calls[call], call)
+	}
+	deleted := 0
+	for call, count := range calls {
+		if strings.HasPrefix(call, "DELETE /workspaces/tgf/tgf-") {
+			deleted += count
+		}
+	}
+	assert.Equal(t, 2, deleted, "The workspace and the changes are deleted")
+	assert.Equal(t, "Plan: 1 to add\n", string(must(ioutil.ReadFile(output.Name())).([]byte)))
+}
+
+func TestRunFargateRequiresAwslogs(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		action := target[strings.LastIndex(target, ".")+1:]
+		if target == "" {
+			action = r.Method + " " + r.URL.Path
+		}
+		calls[action]++
+		if action == "DescribeTaskDefinition" {
+			fmt.Fprint(w, `{"taskDefinition": {"family": "tgf", "taskDefinitionArn": "arn:aws:ecs:us-east-1:123456789012:task-definition/tgf:1", "containerDefinitions": [
+				{"name": "tgf", "image": "coveo/tgf:1.21.0"}
+			]}}`)
+		}
+	}))
+	defer server.Close()
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), Fargate: FargateConfig{
+		TaskDefinition: "tgf",
+		Subnets:        []string{"subnet-1"},
+		Bucket:         "workspaces",
+	}}
+	config.awsSession = session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials("AKIA", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	}))
+	run := remoteRun{image: "coveo/tgf:1.21.0", command: []string{"terragrunt", "plan"}, root: os.TempDir(), remoteRoot: "/var/tgf", workdir: "/var/tgf"}
+	assert.Equal(t, exitConfig, config.runFargate(run))
+	assert.Equal(t, map[string]int{"DescribeTaskDefinition": 1}, calls, "Nothing is uploaded or launched")
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// foreachResult is the outcome of the command in one of the --foreach folders
+type foreachResult struct {
+	folder   string
+	exitCode int
+	duration time.Duration
+	skipped  string   // The prerequisite whose failure has prevented the command from being run (run-ordered)
+	stdout   *os.File // The output of the command is spooled in temporary files to avoid keeping it in memory
+	stderr   *os.File
+}
+
+// flush writes the spooled output of the command and removes the temporary files
+func (result foreachResult) flush(stdout, stderr io.Writer) {
+	for _, output := range []struct {
+		file *os.File
+		w    io.Writer
+	}{{result.stdout, stdout}, {result.stderr, stderr}} {
+		if output.file == nil {
+			continue
+		}
+		if _, err := output.file.Seek(0, io.SeekStart); err == nil {
+			io.Copy(output.w, output.file)
+		}
+		output.file.Close()
+		os.Remove(output.file.Name())
+	}
+}
+
+// isTerragruntFolder returns true if the folder contains a terragrunt configuration
+func isTerragruntFolder(folder string) bool {
+	for _, name := range []string{"terragrunt.hcl", "terraform.tfvars"} {
+		if info, err := os.Stat(filepath.Join(folder, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// getForeachFolders returns the terragrunt folders matching the glob pattern (sorted)
+func getForeachFolders(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --foreach pattern %s: %v", pattern, err)
+	}
+	var folders []string
+	for _, match := range matches {
+		if isTerragruntFolder(match) {
+			folders = append(folders, match)
+		}
+	}
+	sort.Strings(folders)
+	return folders, nil
+}
+
+// getForeachArguments returns the arguments supplied to tgf in each folder (the --foreach arguments are removed).
+// The containers are not interactive since the input cannot be shared between several folders.
+func getForeachArguments(args []string) []string {
+	result := []string{"--no-interactive"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		for _, flag := range []string{"--foreach", "--foreach-jobs", "--foreach-report"} {
+			if arg == flag {
+				// The value is the next argument
+				arg, i = "", i+1
+				break
+			}
+			if strings.HasPrefix(arg, flag+"=") {
+				arg = ""
+				break
+			}
+		}
+		if arg != "" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// runForeachPool runs the function for each folder with at most jobs concurrent executions, the results are returned in the
+// order of the folders and each result is also sent to the done function as soon as it is available
+func runForeachPool(folders []string, jobs int, run func(folder string) foreachResult, done func(foreachResult)) []foreachResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]foreachResult, len(folders))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for worker := 0; worker < jobs && worker < len(folders); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result := run(folders[index])
+				mutex.Lock()
+				results[index] = result
+				done(result)
+				mutex.Unlock()
+			}
+		}()
+	}
+	for index := range folders {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// writeForeachSummary prints the exit code and the duration of the command in each folder
+func writeForeachSummary(w io.Writer, results []foreachResult) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FOLDER\tEXIT CODE\tDURATION")
+	for _, result := range results {
+		status := fmt.Sprint(result.exitCode)
+		if result.skipped != "" {
+			status = warningString("skipped (%s has failed)", result.skipped)
+		} else if result.exitCode != 0 {
+			status = errorString("%d", result.exitCode)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", result.folder, status, result.duration.Round(time.Second))
+	}
+	table.Flush()
+}
+
+// getForeachExitCode returns the highest exit code of the folders
+func getForeachExitCode(results []foreachResult) (exitCode int) {
+	for _, result := range results {
+		if result.exitCode > exitCode {
+			exitCode = result.exitCode
+		}
+	}
+	return
+}
+
+// runForeachFolder runs tgf in the folder, its output is kept to be printed once the command is completed
+func runForeachFolder(executable string, args []string, folder string) foreachResult {
+	result := foreachResult{folder: folder}
+	for _, file := range []**os.File{&result.stdout, &result.stderr} {
+		spool, err := ioutil.TempFile("", "tgf-foreach-")
+		if err != nil {
+			result.flush(ioutil.Discard, ioutil.Discard)
+			printError(msgError, err)
+			return foreachResult{folder: folder, exitCode: 1}
+		}
+		*file = spool
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.Dir, cmd.Stdout, cmd.Stderr = folder, result.stdout, result.stderr
+	for _, variable := range os.Environ() {
+		// The environment variables of the --foreach arguments must not be inherited to avoid running --foreach in each folder
+		if name, _ := Split2(variable, "="); !util.ListContainsElement([]string{"TGF_FOREACH", "TGF_FOREACH_JOBS", "TGF_FOREACH_REPORT"}, name) {
+			cmd.Env = append(cmd.Env, variable)
+		}
+	}
+	start := time.Now()
+	err := cmd.Run()
+	result.duration = time.Since(start)
+	if cmd.ProcessState != nil {
+		result.exitCode = cmd.ProcessState.ExitCode()
+	} else if err != nil {
+		result.exitCode = 1
+		fmt.Fprintln(result.stderr, err)
+	}
+	return result
+}
+
+// runForeach runs the same command in every terragrunt folder matching the --foreach pattern and prints a summary of the exit codes
+func (app *TGFApplication) runForeach() int {
+	folders, err := getForeachFolders(app.Foreach)
+	if err != nil {
+		printError(msgError, err)
+		return 1
+	}
+	if len(folders) == 0 {
+		printError(msgForeachNoMatch, app.Foreach)
+		return 1
+	}
+	executable := must(os.Executable()).(string)
+	args := getForeachArguments(app.Arguments)
+	app.Debug("Running %s %s in %d folder(s)", executable, strings.Join(args, " "), len(folders))
+
+	report := newForeachReport(app.ForeachReport)
+	results := runForeachPool(folders, app.ForeachJobs, func(folder string) foreachResult {
+		return runForeachFolder(executable, args, folder)
+	}, func(result foreachResult) {
+		ErrPrintln(color.New(color.Bold).Sprintf("==> %s (exit code %d)", result.folder, result.exitCode))
+		report.add(result)
+		result.flush(os.Stdout, os.Stderr)
+	})
+	ErrPrintln()
+	writeForeachSummary(color.Error, results)
+	report.write(getForeachCommand(args), results)
+	return getForeachExitCode(results)
+}
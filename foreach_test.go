@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetForeachArguments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"No argument", nil, []string{"--no-interactive"}},
+		{"Separated value", []string{"--foreach", "live/*", "plan"}, []string{"--no-interactive", "plan"}},
+		{"Inline value", []string{"--foreach=live/*", "--foreach-jobs=2", "-P", "dev", "plan"}, []string{"--no-interactive", "-P", "dev", "plan"}},
+		{"Jobs", []string{"--foreach-jobs", "8", "--foreach", "live/*", "validate"}, []string{"--no-interactive", "validate"}},
+		{"Report", []string{"--foreach", "live/*", "--foreach-report", "report.xml", "--foreach-report=other.xml", "plan"}, []string{"--no-interactive", "plan"}},
+		{"After separator", []string{"--foreach=live/*", "--", "plan", "--foreach", "x"}, []string{"--no-interactive", "--", "plan", "--foreach", "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getForeachArguments(tt.args))
+		})
+	}
+}
+
+func TestGetForeachFolders(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestGetForeachFolders")).(string)
+	defer os.RemoveAll(tempDir)
+	for _, file := range []string{"live/prod/terragrunt.hcl", "live/dev/terraform.tfvars", "live/modules/main.tf", "live/README.md"} {
+		path := filepath.Join(tempDir, file)
+		must(os.MkdirAll(filepath.Dir(path), 0755))
+		must(ioutil.WriteFile(path, nil, 0644))
+	}
+
+	folders, err := getForeachFolders(filepath.Join(tempDir, "live", "*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "live", "dev"), filepath.Join(tempDir, "live", "prod")}, folders)
+
+	_, err = getForeachFolders("[")
+	assert.Error(t, err)
+}
+
+func TestRunForeachPool(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	var running, maxRunning int
+	folders := []string{"a", "b", "c", "d", "e"}
+	var done []string
+	results := runForeachPool(folders, 2, func(folder string) foreachResult {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return foreachResult{folder: folder}
+	}, func(result foreachResult) { done = append(done, result.folder) })
+
+	assert.Equal(t, 2, maxRunning)
+	assert.Len(t, done, len(folders))
+	for i, result := range results {
+		assert.Equal(t, folders[i], result.folder)
+	}
+}
+
+func TestForeachSummary(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	results := []foreachResult{
+		{folder: "live/dev", exitCode: 0, duration: 12 * time.Second},
+		{folder: "live/prod", exitCode: 2, duration: 95 * time.Second},
+		{folder: "live/qa", exitCode: 1, duration: time.Second},
+	}
+	var buffer bytes.Buffer
+	writeForeachSummary(&buffer, results)
+	assert.Equal(t, []string{
+		"FOLDER     EXIT CODE  DURATION",
+		"live/dev   0          12s",
+		"live/prod  2          1m35s",
+		"live/qa    1          1s",
+	}, strings.Split(strings.TrimSpace(buffer.String()), "\n"))
+	assert.Equal(t, 2, getForeachExitCode(results))
+	assert.Equal(t, 0, getForeachExitCode(results[:1]))
+}
+
+func TestRunForeachFolder(t *testing.T) {
+	skipOnWindows(t, "the command is run by sh")
+	folder := must(ioutil.TempDir("", "TestRunForeachFolder")).(string)
+	defer os.RemoveAll(folder)
+
+	result := runForeachFolder("sh", []string{"-c", "pwd; echo error >&2; exit 3"}, folder)
+	assert.Equal(t, 3, result.exitCode)
+
+	var stdout, stderr bytes.Buffer
+	result.flush(&stdout, &stderr)
+	assert.Equal(t, must(filepath.EvalSymlinks(folder)).(string), strings.TrimSpace(stdout.String()))
+	assert.Equal(t, "error\n", stderr.String())
+	_, err := os.Stat(result.stdout.Name())
+	assert.True(t, os.IsNotExist(err), "The spooled output is removed once flushed")
+}
@@ -22,6 +22,7 @@ get_latest_tgf_version () {
 }
 
 script_end () {
+    $TGF integrity || exit 1
     echo 'Done.'
     $TGF --current-version
     exit 0
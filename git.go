@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// getGitOutput runs a git command in the current folder and returns its trimmed output (or an empty string on error)
+func getGitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// getGitBranch returns the current git branch name (or an empty string if we are not in a git repository)
+func getGitBranch() string {
+	return getGitOutput("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// ciBranchVariables are the variables defining the branch built by the CI systems, they are used when the commit is checked out
+// without branch (detached HEAD)
+var ciBranchVariables = []string{
+	"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "BUILD_SOURCEBRANCHNAME", "BITBUCKET_BRANCH", "BUILDKITE_BRANCH",
+	"CIRCLE_BRANCH", "CODEBUILD_WEBHOOK_HEAD_REF", "BRANCH_NAME", "GIT_BRANCH",
+}
+
+// gitMetadata describes the state of the git repository of the current folder
+type gitMetadata struct {
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// getGitMetadata returns the branch, the commit and the dirty state of the current folder (nil if it is not in a git repository)
+func getGitMetadata() *gitMetadata {
+	commit := getGitOutput("rev-parse", "HEAD")
+	if commit == "" {
+		return nil
+	}
+	metadata := gitMetadata{Commit: commit, Branch: getGitBranch()}
+	if metadata.Branch == "HEAD" {
+		metadata.Branch = ""
+		for _, variable := range ciBranchVariables {
+			if branch := os.Getenv(variable); branch != "" {
+				metadata.Branch = strings.TrimPrefix(strings.TrimPrefix(branch, "refs/heads/"), "origin/")
+				break
+			}
+		}
+	}
+	// The ignored files are not considered, so the files written by terragrunt and terraform do not make the repository dirty
+	metadata.Dirty = getGitOutput("status", "--porcelain") != ""
+	return &metadata
+}
+
+// getEnvironment returns the TGF_GIT_* variables describing the repository
+func (metadata gitMetadata) getEnvironment() map[string]string {
+	return map[string]string{
+		"TGF_GIT_BRANCH": metadata.Branch,
+		"TGF_GIT_COMMIT": metadata.Commit,
+		"TGF_GIT_DIRTY":  strconv.FormatBool(metadata.Dirty),
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGitMetadata(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestGetGitMetadata")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Chdir(must(os.Getwd()).(string))
+	os.Chdir(tempDir)
+	for _, variable := range ciBranchVariables {
+		defer os.Setenv(variable, os.Getenv(variable))
+		os.Unsetenv(variable)
+	}
+	git := func(args ...string) {
+		command := exec.Command("git", append([]string{"-c", "user.name=tgf", "-c", "user.email=tgf@example.com"}, args...)...)
+		output, err := command.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+
+	assert.Nil(t, getGitMetadata(), "The folder is not in a git repository")
+
+	git("init", "--quiet")
+	git("checkout", "--quiet", "-b", "feature")
+	must(ioutil.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte(".terragrunt-cache/\n"), 0644))
+	git("add", ".gitignore")
+	git("commit", "--quiet", "-m", "Initial commit")
+	commit := getGitOutput("rev-parse", "HEAD")
+	must(os.MkdirAll(filepath.Join(tempDir, ".terragrunt-cache"), 0755))
+	must(ioutil.WriteFile(filepath.Join(tempDir, ".terragrunt-cache", "plan.out"), []byte("plan"), 0644))
+	assert.Equal(t, &gitMetadata{Branch: "feature", Commit: commit}, getGitMetadata(), "The ignored files do not make the repository dirty")
+
+	must(ioutil.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(""), 0644))
+	metadata := getGitMetadata()
+	assert.Equal(t, &gitMetadata{Branch: "feature", Commit: commit, Dirty: true}, metadata)
+	assert.Equal(t, map[string]string{"TGF_GIT_BRANCH": "feature", "TGF_GIT_COMMIT": commit, "TGF_GIT_DIRTY": "true"}, metadata.getEnvironment())
+
+	git("checkout", "--quiet", "--detach")
+	os.Setenv("GITHUB_REF_NAME", "main")
+	assert.Equal(t, "main", getGitMetadata().Branch, "The branch of the CI is used if the commit is checked out without branch")
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitHub Actions workflow commands and files (https://docs.github.com/actions/using-workflows/workflow-commands-for-github-actions).
+// The commands are written on stderr (they are processed on both streams by the runner) to keep stdout for the entry point.
+const (
+	envGitHubStepSummary = "GITHUB_STEP_SUMMARY"
+	envGitHubWorkspace   = "GITHUB_WORKSPACE"
+)
+
+// isGitHubActions returns true if tgf is running in a GitHub Actions workflow (and --no-ci is not set)
+func isGitHubActions() bool { return ciSystem == ciGitHubActions }
+
+func escapeGitHubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
+
+var (
+	reANSIEscape        = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	reTerraformLocation = regexp.MustCompile(`\bon (\S+) line (\d+)`)
+)
+
+// terraformError is an error diagnostic printed by terraform
+type terraformError struct {
+	Summary string
+	Detail  []string
+	File    string
+	Line    int
+}
+
+// getTerraformErrors extracts the error diagnostics from the output of terraform (with or without the box drawn around them)
+func getTerraformErrors(output string) (errors []terraformError) {
+	var current *terraformError
+	for _, line := range strings.Split(reANSIEscape.ReplaceAllString(output, ""), "\n") {
+		line = strings.TrimRight(line, " \r")
+		boxed := strings.HasPrefix(line, "│")
+		content := strings.TrimSpace(strings.TrimPrefix(line, "│"))
+		switch {
+		case strings.HasPrefix(content, "Error: "):
+			errors = append(errors, terraformError{Summary: strings.TrimPrefix(content, "Error: ")})
+			current = &errors[len(errors)-1]
+		case current == nil:
+		case strings.HasPrefix(line, "╵") || !boxed && content == "" && len(current.Detail) > 0:
+			current = nil
+		case content != "":
+			if match := reTerraformLocation.FindStringSubmatch(content); match != nil && current.File == "" {
+				current.File, current.Line = match[1], must(strconv.Atoi(match[2])).(int)
+			}
+			current.Detail = append(current.Detail, content)
+		}
+	}
+	return
+}
+
+// writeGitHubAnnotations adds an error annotation to the workflow run for each terraform error found in the output
+func writeGitHubAnnotations(w io.Writer, folder, output string) {
+	for _, diagnostic := range getTerraformErrors(output) {
+		title := "Terraform error in " + folder
+		if diagnostic.File != "" {
+			title = fmt.Sprintf("Terraform error in %s (%s line %d)", folder, diagnostic.File, diagnostic.Line)
+		}
+		message := strings.Join(append([]string{diagnostic.Summary}, diagnostic.Detail...), "\n")
+		fmt.Fprintf(w, "::error title=%s::%s\n", escapeGitHubProperty(title), escapeGitHubData(message))
+	}
+}
+
+var (
+	rePlanStats  = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+	reApplyStats = regexp.MustCompile(`(?:Apply|Destroy) complete! Resources: (?:(\d+) added, (\d+) changed, )?(\d+) destroyed`)
+	reNoChanges  = regexp.MustCompile(`No changes\.`)
+)
+
+// terraformSummary accumulates the plan and apply statistics printed by terraform (for all the modules of a run-all)
+type terraformSummary struct {
+	mutex     sync.Mutex
+	partial   []byte
+	Plans     int
+	NoChanges int
+	Planned   [3]int
+	Applies   int
+	Applied   [3]int
+}
+
+// Write scans the output of the entry point line by line (it is used along with the actual output)
+func (summary *terraformSummary) Write(p []byte) (int, error) {
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+	summary.partial = append(summary.partial, p...)
+	for {
+		index := bytes.IndexByte(summary.partial, '\n')
+		if index < 0 {
+			break
+		}
+		summary.scan(reANSIEscape.ReplaceAllString(string(summary.partial[:index]), ""))
+		summary.partial = summary.partial[index+1:]
+	}
+	if len(summary.partial) > containerStderrLimit {
+		// The statistics are printed on short lines, the long ones are not kept
+		summary.partial = summary.partial[:0]
+	}
+	return len(p), nil
+}
+
+func (summary *terraformSummary) scan(line string) {
+	add := func(totals *[3]int, match []string) {
+		for i := range totals {
+			if value, err := strconv.Atoi(match[i+1]); err == nil {
+				totals[i] += value
+			}
+		}
+	}
+	if match := rePlanStats.FindStringSubmatch(line); match != nil {
+		summary.Plans++
+		add(&summary.Planned, match)
+	} else if match := reApplyStats.FindStringSubmatch(line); match != nil {
+		summary.Applies++
+		add(&summary.Applied, match)
+	} else if reNoChanges.MatchString(line) {
+		summary.NoChanges++
+	}
+}
+
+// getGitHubStdout returns the writer given as stdout to the entry point, its output is also scanned to report the terraform statistics
+// in the job summary when running in GitHub Actions
+func (config *TGFConfig) getGitHubStdout(stdout *os.File) io.Writer {
+	if !isGitHubActions() {
+		return stdout
+	}
+	config.terraformSummary = &terraformSummary{}
+	return io.MultiWriter(stdout, config.terraformSummary)
+}
+
+// getGitHubFolder returns the folder relative to the workspace of the workflow (if it is in the workspace)
+func getGitHubFolder(folder string) string {
+	if workspace := os.Getenv(envGitHubWorkspace); workspace != "" {
+		if relative, err := filepath.Rel(workspace, folder); err == nil && !strings.HasPrefix(relative, "..") {
+			return filepath.ToSlash(relative)
+		}
+	}
+	return folder
+}
+
+// getGitHubSummary returns the markdown describing the run added to the job summary
+func (config *TGFConfig) getGitHubSummary(metadata runMetadata) string {
+	folder := getGitHubFolder(metadata.WorkingDir)
+	command := strings.TrimSpace(filepath.Base(metadata.EntryPoint) + " " + strings.Join(metadata.Arguments, " "))
+	status := "succeeded"
+	if metadata.ExitCode != 0 {
+		status = "failed"
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "### tgf `%s` %s in `%s`\n\n| | |\n| --- | --- |\n", command, status, folder)
+	row := func(name, format string, args ...interface{}) {
+		fmt.Fprintf(&content, "| %s | %s |\n", name, fmt.Sprintf(format, args...))
+	}
+	row("Exit code", "%d", metadata.ExitCode)
+	row("Duration", "%v", (time.Duration(metadata.Duration * float64(time.Second))).Round(time.Second))
+	if metadata.Image != "" {
+		row("Image", "`%s`", metadata.Image)
+	}
+	if metadata.AWSAccount != "" {
+		row("AWS account", "%s", metadata.AWSAccount)
+	}
+	if summary := config.terraformSummary; summary != nil {
+		if summary.Plans > 0 || summary.NoChanges > 0 {
+			row("Plan", "%d to add, %d to change, %d to destroy (%d modules with changes, %d without changes)",
+				summary.Planned[0], summary.Planned[1], summary.Planned[2], summary.Plans, summary.NoChanges)
+		}
+		if summary.Applies > 0 {
+			row("Apply", "%d added, %d changed, %d destroyed (%d modules)", summary.Applied[0], summary.Applied[1], summary.Applied[2], summary.Applies)
+		}
+	}
+	return content.String() + "\n"
+}
+
+// writeGitHubSummary appends the description of the run to the job summary
+func (config *TGFConfig) writeGitHubSummary(start time.Time, exitCode int) {
+	filename := os.Getenv(envGitHubStepSummary)
+	if filename == "" {
+		return
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = file.WriteString(config.getGitHubSummary(config.getRunMetadata(start, exitCode)))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		printWarning(msgFileWriteFailed, filename, err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTerraformErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		output string
+		want   []terraformError
+	}{
+		{"No error", "Plan: 1 to add, 0 to change, 0 to destroy.\n", nil},
+		{"Boxed", String(`
+			╷
+			│ Error: Unsupported argument
+			│
+			│   on main.tf line 3, in resource "aws_s3_bucket" "logs":
+			│    3:   acl2 = "private"
+			│
+			│ An argument named "acl2" is not expected here.
+			╵
+			╷
+			│ Error: No valid credential sources found
+			│
+			│ Please see https://registry.terraform.io/providers/hashicorp/aws for more information.
+			╵
+			ERRO[0003] Terraform invocation failed in /var/tgf/live
+		`).UnIndent().Str(), []terraformError{
+			{
+				Summary: "Unsupported argument",
+				Detail:  []string{`on main.tf line 3, in resource "aws_s3_bucket" "logs":`, `3:   acl2 = "private"`, `An argument named "acl2" is not expected here.`},
+				File:    "main.tf",
+				Line:    3,
+			},
+			{
+				Summary: "No valid credential sources found",
+				Detail:  []string{"Please see https://registry.terraform.io/providers/hashicorp/aws for more information."},
+			},
+		}},
+		{"Colored without box", "\x1b[31mError: \x1b[0m\x1b[1mInvalid reference\x1b[0m\n\n  on vars.tf line 12:\n\nA reference must be quoted.\n\nOther output\n", []terraformError{
+			{Summary: "Invalid reference", Detail: []string{"on vars.tf line 12:"}, File: "vars.tf", Line: 12},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getTerraformErrors(tt.output))
+		})
+	}
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	writeGitHubAnnotations(&buffer, "live/dev", "Error: Invalid value: 100%\n\n  on main.tf line 1, in module:\n")
+	assert.Equal(t, "::error title=Terraform error in live/dev (main.tf line 1)::Invalid value: 100%25%0Aon main.tf line 1, in module:\n", buffer.String())
+}
+
+func TestTerraformSummary(t *testing.T) {
+	t.Parallel()
+
+	summary := &terraformSummary{}
+	for _, chunk := range []string{
+		"Plan: 1 to add, 2 to ",
+		"change, 0 to destroy.\n\x1b[1mNo changes.\x1b[0m Your infrastructure matches the configuration.\n",
+		"Apply complete! Resources: 1 added, 2 changed, 0 destroyed.\nDestroy complete! Resources: 3 destroyed.\n",
+		"Plan: 9 to add, 9 to change, 9 to destroy (without end of line)",
+	} {
+		summary.Write([]byte(chunk))
+	}
+	assert.Equal(t, 1, summary.Plans)
+	assert.Equal(t, 1, summary.NoChanges)
+	assert.Equal(t, [3]int{1, 2, 0}, summary.Planned)
+	assert.Equal(t, 2, summary.Applies)
+	assert.Equal(t, [3]int{1, 2, 3}, summary.Applied)
+}
+
+func TestGetGitHubSummary(t *testing.T) {
+	defer os.Setenv(envGitHubWorkspace, os.Getenv(envGitHubWorkspace))
+	os.Setenv(envGitHubWorkspace, "/home/runner/work/infra")
+
+	config := &TGFConfig{terraformSummary: &terraformSummary{Plans: 2, NoChanges: 1, Planned: [3]int{3, 1, 0}}}
+	summary := config.getGitHubSummary(runMetadata{
+		EntryPoint: "terragrunt",
+		Arguments:  []string{"run-all", "plan"},
+		ExitCode:   2,
+		Duration:   65.4,
+		Image:      "coveo/tgf:1.21.0",
+		WorkingDir: "/home/runner/work/infra/live/dev",
+	})
+	assert.Equal(t, String(`
+		### tgf `+"`terragrunt run-all plan`"+` failed in `+"`live/dev`"+`
+
+		| | |
+		| --- | --- |
+		| Exit code | 2 |
+		| Duration | 1m5s |
+		| Image | `+"`coveo/tgf:1.21.0`"+` |
+		| Plan | 3 to add, 1 to change, 0 to destroy (2 modules with changes, 1 without changes) |
+	`).UnIndent().TrimSpace().Str()+"\n\n", summary)
+}
+
+func TestWriteGitHubSummary(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestWriteGitHubSummary")).(string)
+	defer os.RemoveAll(tempDir)
+	filename := filepath.Join(tempDir, "summary.md")
+	defer os.Setenv(envGitHubStepSummary, os.Getenv(envGitHubStepSummary))
+	os.Setenv(envGitHubStepSummary, filename)
+	must(ioutil.WriteFile(filename, []byte("Previous step\n"), 0644))
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), EntryPoint: "terragrunt", runImage: "coveo/tgf:1.21.0"}
+	config.writeGitHubSummary(time.Now(), 0)
+	content := string(must(ioutil.ReadFile(filename)).([]byte))
+	assert.Contains(t, content, "Previous step\n### tgf `terragrunt` succeeded in ")
+	assert.Contains(t, content, "| Exit code | 0 |")
+}
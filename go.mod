@@ -6,7 +6,7 @@ replace github.com/gruntwork-io/terragrunt => github.com/coveooss/terragrunt v1.
 
 require (
 	github.com/Microsoft/go-winio v0.4.12 // indirect
-	github.com/aws/aws-sdk-go v1.20.15
+	github.com/aws/aws-sdk-go v1.44.327
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/coveooss/gotemplate/v3 v3.2.0
 	github.com/coveord/kingpin/v2 v2.3.1
@@ -17,7 +17,10 @@ require (
 	github.com/fatih/color v1.7.0
 	github.com/gruntwork-io/terragrunt v0.0.0-00010101000000-000000000000
 	github.com/hashicorp/go-getter v1.3.0
+	github.com/hashicorp/hcl2 v0.0.0-20190305174554-fdf8e232b64f
+	github.com/mattn/go-isatty v0.0.8
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
 	github.com/stretchr/testify v1.3.0
-	gopkg.in/yaml.v2 v2.2.2
+	github.com/zclconf/go-cty v0.0.0-20190212192503-19dda139b164
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// Stages of the run at which the hooks are executed
+const (
+	hookPreRun    = "pre-run"
+	hookPostRun   = "post-run"
+	hookOnFailure = "on-failure"
+)
+
+// Hook is a command executed by tgf at a stage of the run, on the host or in the container
+type Hook struct {
+	Command   string `yaml:"command,omitempty" json:"command,omitempty" hcl:"command,omitempty"`
+	Container bool   `yaml:"container,omitempty" json:"container,omitempty" hcl:"container,omitempty"`
+}
+
+// HooksConfig defines the commands executed before the command, after the command and when the command fails
+type HooksConfig struct {
+	PreRun    []Hook `yaml:"pre-run,omitempty" json:"pre-run,omitempty" hcl:"pre-run,omitempty"`
+	PostRun   []Hook `yaml:"post-run,omitempty" json:"post-run,omitempty" hcl:"post-run,omitempty"`
+	OnFailure []Hook `yaml:"on-failure,omitempty" json:"on-failure,omitempty" hcl:"on-failure,omitempty"`
+}
+
+// getHookCommands returns the commands of the hooks executed on the host (or in the container)
+func getHookCommands(hooks []Hook, container bool) (commands []string) {
+	for _, hook := range hooks {
+		if hook.Container == container && strings.TrimSpace(hook.Command) != "" {
+			commands = append(commands, hook.Command)
+		}
+	}
+	return
+}
+
+// getContainerCommand wraps the command in a shell script running the container hooks around it, the exit code of the command is
+// preserved (the pre-run hooks abort the run if they fail, the failures of the other hooks are only reported)
+func (hooks HooksConfig) getContainerCommand(command []string) []string {
+	preRun, onFailure, postRun := getHookCommands(hooks.PreRun, true), getHookCommands(hooks.OnFailure, true), getHookCommands(hooks.PostRun, true)
+	if len(preRun)+len(onFailure)+len(postRun) == 0 {
+		return command
+	}
+	var script strings.Builder
+	writeHooks := func(stage string, commands []string, failure string) {
+		for _, command := range commands {
+			fmt.Fprintf(&script, "TGF_RUN_HOOK=%s && (\n%s\n) || %s\n", stage, strings.TrimSpace(command), failure)
+		}
+	}
+	script.WriteString("export TGF_RUN_HOOK\n")
+	writeHooks(hookPreRun, preRun, "exit $?")
+	script.WriteString("unset TGF_RUN_HOOK\n\"$@\"\nexport TGF_RUN_EXIT_CODE=$?\n")
+	failure := fmt.Sprintf("echo %s >&2", shellQuote("tgf: the hook has failed"))
+	if len(onFailure) > 0 {
+		script.WriteString("if [ $TGF_RUN_EXIT_CODE -ne 0 ]; then\n")
+		writeHooks(hookOnFailure, onFailure, failure)
+		script.WriteString("fi\n")
+	}
+	writeHooks(hookPostRun, postRun, failure)
+	script.WriteString("exit $TGF_RUN_EXIT_CODE")
+	return append([]string{"sh", "-c", script.String(), "tgf"}, command...)
+}
+
+// getHookEnvironment returns the variables describing the run supplied to the hooks executed on the host (the exit code and the
+// duration are only defined after the command)
+func (config *TGFConfig) getHookEnvironment(stage string, start time.Time, exitCode int) []string {
+	metadata := config.getRunMetadata(start, exitCode)
+	environment := []string{
+		"TGF_RUN_HOOK=" + stage,
+		"TGF_RUN_ENTRYPOINT=" + metadata.EntryPoint,
+		"TGF_RUN_ARGUMENTS=" + strings.Join(metadata.Arguments, " "),
+		"TGF_RUN_IMAGE=" + metadata.Image,
+		"TGF_RUN_WORKING_DIR=" + metadata.WorkingDir,
+		"TGF_RUN_START_TIME=" + metadata.StartTime,
+		"TGF_RUN_AWS_ACCOUNT=" + metadata.AWSAccount,
+		"TGF_RUN_AWS_PROFILE=" + metadata.AWSProfile,
+	}
+	if stage != hookPreRun {
+		environment = append(environment,
+			fmt.Sprintf("TGF_RUN_EXIT_CODE=%d", metadata.ExitCode),
+			fmt.Sprintf("TGF_RUN_DURATION=%.3f", metadata.Duration),
+		)
+	}
+	return environment
+}
+
+// runHooks executes the hooks of the stage on the host, it stops at the first failure
+func (config *TGFConfig) runHooks(stage string, hooks []Hook, start time.Time, exitCode int) error {
+	commands := getHookCommands(hooks, false)
+	if len(commands) == 0 {
+		return nil
+	}
+	environment := append(os.Environ(), config.getHookEnvironment(stage, start, exitCode)...)
+	for _, script := range commands {
+		title := strings.SplitN(strings.TrimSpace(script), "\n", 2)[0]
+		cmd, tempFile, err := utils.GetCommandFromString(script)
+		if err != nil {
+			return fmt.Errorf("The %s hook %q has failed: %v", stage, title, err)
+		}
+		if tempFile != "" {
+			defer func() { os.Remove(tempFile) }()
+		}
+		cmd.Env = environment
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		endGroup := logGroup(fmt.Sprintf("Running %s hook %s", stage, title))
+		err = cmd.Run()
+		endGroup()
+		if err != nil {
+			return fmt.Errorf("The %s hook %q has failed: %v", stage, title, err)
+		}
+	}
+	return nil
+}
+
+// runPreRunHooks executes the pre-run hooks on the host and returns false if one of them has failed
+func (config *TGFConfig) runPreRunHooks() bool {
+	if err := config.runHooks(hookPreRun, config.Hooks.PreRun, time.Now(), 0); err != nil {
+		printError(msgHookFailed, err)
+		return false
+	}
+	return true
+}
+
+// runPostRunHooks executes the on-failure hooks (if the command has failed) and the post-run hooks on the host, their failures do
+// not change the exit code of the run
+func (config *TGFConfig) runPostRunHooks(start time.Time, exitCode int) {
+	if exitCode != 0 {
+		if err := config.runHooks(hookOnFailure, config.Hooks.OnFailure, start, exitCode); err != nil {
+			printError(msgHookFailed, err)
+		}
+	}
+	if err := config.runHooks(hookPostRun, config.Hooks.PostRun, start, exitCode); err != nil {
+		printError(msgHookFailed, err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetContainerCommand(t *testing.T) {
+	t.Parallel()
+
+	command := []string{"sh", "-c", `echo "command $TGF_RUN_HOOK"; exit $0`}
+	assert.Equal(t, command, HooksConfig{PreRun: []Hook{{Command: "echo host"}}}.getContainerCommand(command), "No container hook")
+
+	hooks := HooksConfig{
+		PreRun:    []Hook{{Command: "echo host"}, {Command: `echo "$TGF_RUN_HOOK"`, Container: true}},
+		OnFailure: []Hook{{Command: `echo "$TGF_RUN_HOOK $TGF_RUN_EXIT_CODE"`, Container: true}},
+		PostRun:   []Hook{{Command: "false", Container: true}, {Command: `echo "$TGF_RUN_HOOK $TGF_RUN_EXIT_CODE"`, Container: true}},
+	}
+	tests := []struct {
+		name     string
+		hooks    HooksConfig
+		exitCode string
+		want     int
+		output   string
+	}{
+		{"Success", hooks, "0", 0, "pre-run\ncommand \npost-run 0\n"},
+		{"Failure", hooks, "3", 3, "pre-run\ncommand \non-failure 3\npost-run 3\n"},
+		{"Pre-run failure", HooksConfig{PreRun: []Hook{{Command: "exit 4", Container: true}}}, "0", 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.hooks.getContainerCommand(append(command, tt.exitCode))
+			cmd := exec.Command(args[0], args[1:]...)
+			output, _ := cmd.Output()
+			assert.Equal(t, tt.want, cmd.ProcessState.ExitCode())
+			assert.Equal(t, tt.output, string(output))
+		})
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	skipOnWindows(t, "the hooks are shell commands")
+	tempDir := must(ioutil.TempDir("", "TestRunHooks")).(string)
+	defer os.RemoveAll(tempDir)
+	filename := filepath.Join(tempDir, "hooks.log")
+	hook := func(name string) Hook {
+		return Hook{Command: fmt.Sprintf(`echo "%s $TGF_RUN_HOOK $TGF_RUN_ENTRYPOINT ${TGF_RUN_EXIT_CODE:--}" >> %s`, name, filename)}
+	}
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), EntryPoint: "terragrunt", Hooks: HooksConfig{
+		PreRun:    []Hook{hook("cache"), {Command: "echo container >> " + filename, Container: true}},
+		OnFailure: []Hook{hook("notify")},
+		PostRun:   []Hook{hook("collect"), {Command: "exit 1"}, hook("skipped")},
+	}}
+	assert.True(t, config.runPreRunHooks())
+	config.runPostRunHooks(time.Now(), 0)
+	config.runPostRunHooks(time.Now(), 2)
+	assert.Equal(t, String(`
+		cache pre-run terragrunt -
+		collect post-run terragrunt 0
+		notify on-failure terragrunt 2
+		collect post-run terragrunt 2
+	`).UnIndent().TrimSpace().Str()+"\n", string(must(ioutil.ReadFile(filename)).([]byte)))
+
+	config.Hooks.PreRun = []Hook{{Command: "exit 1"}}
+	assert.False(t, config.runPreRunHooks(), "The run is aborted")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// hostProbesTTL is the delay during which the results of the host probes are reused by the following runs, they only change
+// when the host is reconfigured (i.e. the AWS CLI is installed)
+const hostProbesTTL = 24 * time.Hour
+
+// hostProbes are the results of the checks of the host environment done at startup (PATH lookups, home folder and instance
+// metadata probes). They are evaluated on first use and kept in the cache folder to avoid repeating them on each invocation.
+type hostProbes struct {
+	sync.Mutex  `json:"-"`
+	AWSCLI      *bool     `json:"aws-cli,omitempty"`
+	AWSFolder   *bool     `json:"aws-folder,omitempty"`
+	EC2Instance *bool     `json:"ec2-instance,omitempty"`
+	created     time.Time // The probes evaluated later do not extend the lifetime of the previous ones
+}
+
+var (
+	currentHostProbes     *hostProbes
+	currentHostProbesOnce sync.Once
+)
+
+// getHostProbesFilename returns the file where the results of the host probes are kept
+func getHostProbesFilename() string {
+	return filepath.Join(getCacheFolder(), "host-probes.json")
+}
+
+// getHostProbes returns the host probes of the previous runs (if they are not expired)
+func getHostProbes() *hostProbes {
+	currentHostProbesOnce.Do(func() {
+		currentHostProbes = loadHostProbes(getHostProbesFilename())
+	})
+	return currentHostProbes
+}
+
+func loadHostProbes(filename string) *hostProbes {
+	if info, err := os.Stat(filename); err == nil && time.Since(info.ModTime()) < hostProbesTTL {
+		probes := &hostProbes{created: info.ModTime()}
+		if content, err := ioutil.ReadFile(filename); err == nil && json.Unmarshal(content, probes) == nil {
+			return probes
+		}
+	}
+	return &hostProbes{created: time.Now()}
+}
+
+// probe returns the cached result of a probe, it is evaluated and saved if it has not been done yet
+func (probes *hostProbes) probe(result **bool, evaluate func() bool) bool {
+	probes.Lock()
+	defer probes.Unlock()
+	if *result == nil {
+		endProbe := timings.begin("host probes")
+		value := evaluate()
+		endProbe()
+		*result = &value
+		probes.save(getHostProbesFilename())
+	}
+	return **result
+}
+
+// save writes the probes in the cache folder (a failure only means that the probes are evaluated again on the next run)
+func (probes *hostProbes) save(filename string) {
+	content, err := json.Marshal(probes)
+	if err != nil || os.MkdirAll(filepath.Dir(filename), 0755) != nil {
+		return
+	}
+	if ioutil.WriteFile(filename, content, 0644) == nil {
+		os.Chtimes(filename, probes.created, probes.created)
+	}
+}
+
+// hasAWSCLI returns true if the aws program is installed
+func (probes *hostProbes) hasAWSCLI() bool {
+	return probes.probe(&probes.AWSCLI, func() bool {
+		_, err := exec.LookPath("aws")
+		return err == nil
+	})
+}
+
+// hasAWSFolder returns true if the current user has a folder named .aws under its home directory
+func (probes *hostProbes) hasAWSFolder() bool {
+	return probes.probe(&probes.AWSFolder, func() bool {
+		currentUser, err := user.Current()
+		if err != nil {
+			return false
+		}
+		awsFolder, err := os.Stat(filepath.Join(currentUser.HomeDir, ".aws"))
+		return err == nil && awsFolder.IsDir()
+	})
+}
+
+// isEC2Instance returns true if the instance metadata service is available
+func (probes *hostProbes) isEC2Instance() bool {
+	return probes.probe(&probes.EC2Instance, isEC2Instance)
+}
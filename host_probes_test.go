@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostProbes(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestHostProbes")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", tempDir)
+	filename := getHostProbesFilename()
+
+	probes := loadHostProbes(filename)
+	evaluations := 0
+	evaluate := func() bool { evaluations++; return true }
+	assert.True(t, probes.probe(&probes.EC2Instance, evaluate))
+	assert.True(t, probes.probe(&probes.EC2Instance, evaluate))
+	assert.Equal(t, 1, evaluations, "The probe should only be evaluated once")
+
+	// The probes are reused by the following runs
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	must(os.Chtimes(filename, created, created))
+	probes = loadHostProbes(filename)
+	assert.True(t, probes.probe(&probes.EC2Instance, evaluate))
+	assert.Equal(t, 1, evaluations, "The probe should be read from the previous run")
+	assert.False(t, probes.probe(&probes.AWSFolder, func() bool { return false }))
+	info := must(os.Stat(filename)).(os.FileInfo)
+	assert.Equal(t, created, info.ModTime(), "A new probe does not extend the lifetime of the previous ones")
+
+	// The probes are evaluated again once expired
+	expired := time.Now().Add(-hostProbesTTL)
+	must(os.Chtimes(filename, expired, expired))
+	probes = loadHostProbes(filename)
+	assert.True(t, probes.probe(&probes.EC2Instance, evaluate))
+	assert.Equal(t, 2, evaluations)
+
+	// An invalid file is ignored
+	must(ioutil.WriteFile(filepath.Join(tempDir, "invalid.json"), []byte("{"), 0644))
+	assert.Nil(t, loadHostProbes(filepath.Join(tempDir, "invalid.json")).EC2Instance)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpTransport is shared by all the HTTP requests of tgf (configuration, audit log, credentials, webhooks), so the connections
+// and the TLS sessions are reused when several requests are sent to the same host during a run
+var httpTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          20,
+	MaxIdleConnsPerHost:   4,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// newHTTPClient returns a client using the shared transport, the timeout limits the whole request (including reading the body)
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: httpTransport, Timeout: timeout}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// imageCacheTTL is the delay during which the result of a local image lookup is reused by the following runs, so a loop of tgf
+// invocations (i.e. run-all) does not query the docker daemon several times per run
+const imageCacheTTL = time.Minute
+
+// imageInfo is the result of the lookup of a local image
+type imageInfo struct {
+	Exists  bool   `json:"exists"`
+	ID      string `json:"id,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// getImageCacheFilename returns the file where the lookup of the image is cached
+func getImageCacheFilename(image string) string {
+	return filepath.Join(getCacheFolder(), "image-cache", util.EncodeBase64Sha1(image)+".json")
+}
+
+// inspectImage queries the docker daemon to get the information of a local image
+var inspectImage = func(imageName string) (info imageInfo, err error) {
+	cli, ctx := getDockerClient()
+	filters := filters.NewArgs()
+	filters.Add("reference", imageName)
+	images, err := cli.ImageList(ctx, types.ImageListOptions{Filters: filters})
+	if err != nil {
+		return
+	}
+	info.Exists = len(images) > 0
+	if len(images) != 1 {
+		// The details are only returned if the reference is not ambiguous
+		return
+	}
+	image := images[0]
+	info.ID, info.Digest, info.Hash = image.ID, image.ID, image.Labels["hash"]
+	if len(image.RepoDigests) > 0 {
+		info.Digest = image.RepoDigests[0]
+	}
+	info.Version = getActualImageVersionFromImageID(image.ID)
+	return
+}
+
+// lookupImage returns the information of a local image, the result of a recent lookup is reused
+func lookupImage(image string) imageInfo {
+	filename := getImageCacheFilename(image)
+	if stat, err := os.Stat(filename); err == nil && time.Since(stat.ModTime()) < imageCacheTTL {
+		var cached imageInfo
+		if content, err := ioutil.ReadFile(filename); err == nil && json.Unmarshal(content, &cached) == nil {
+			runStats.cacheLookup(true)
+			return cached
+		}
+	}
+	runStats.cacheLookup(false)
+	info, err := inspectImage(image)
+	if err != nil {
+		// The failures are not cached since the daemon may be available on the next run
+		return info
+	}
+	writeFileAtomic(filename, must(json.Marshal(info)).([]byte), 0644)
+	return info
+}
+
+// invalidateImageCache forgets the last lookup of an image once it has been pulled, built or removed
+func invalidateImageCache(image string) {
+	os.Remove(getImageCacheFilename(image))
+}
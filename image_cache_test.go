@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupImage(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestLookupImage")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", tempDir)
+
+	defer func(inspect func(string) (imageInfo, error)) { inspectImage = inspect }(inspectImage)
+	inspections := 0
+	var failure error
+	inspectImage = func(image string) (imageInfo, error) {
+		inspections++
+		return imageInfo{Exists: true, ID: "sha256:1234", Digest: image + "@sha256:5678", Version: "1.20.2"}, failure
+	}
+
+	const image = "coveo/tgf:1.20.2"
+	want := imageInfo{Exists: true, ID: "sha256:1234", Digest: image + "@sha256:5678", Version: "1.20.2"}
+	assert.Equal(t, want, lookupImage(image))
+	assert.Equal(t, want, lookupImage(image))
+	assert.True(t, checkImage(image))
+	assert.Equal(t, "1.20.2", getActualImageVersionInternal(image))
+	assert.Equal(t, 1, inspections, "The lookup should be cached")
+
+	invalidateImageCache(image)
+	lookupImage(image)
+	assert.Equal(t, 2, inspections, "The lookup should be done again once the image is changed")
+
+	expired := time.Now().Add(-imageCacheTTL)
+	must(os.Chtimes(getImageCacheFilename(image), expired, expired))
+	lookupImage(image)
+	assert.Equal(t, 3, inspections, "The lookup should be done again once the cache is expired")
+
+	failure = fmt.Errorf("Cannot connect to the Docker daemon")
+	lookupImage("coveo/tgf:other")
+	lookupImage("coveo/tgf:other")
+	assert.Equal(t, 5, inspections, "The failures should not be cached")
+}
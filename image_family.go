@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Image families supported by the image-family configuration key, they identify the program used by terragrunt in the image
+const (
+	imageFamilyTerraform = "terraform"
+	imageFamilyTofu      = "tofu"
+)
+
+var imageFamilies = []string{imageFamilyTerraform, imageFamilyTofu}
+
+// getImageFamily returns the family of the image, the tofu entry point implies the tofu family if none is configured
+func (config *TGFConfig) getImageFamily() string {
+	if config.ImageFamily != "" {
+		return config.ImageFamily
+	}
+	if filepath.Base(strings.Split(config.EntryPoint, " ")[0]) == imageFamilyTofu {
+		return imageFamilyTofu
+	}
+	return imageFamilyTerraform
+}
+
+// validateImageFamily returns an error if the configured image family is not supported
+func (config *TGFConfig) validateImageFamily() error {
+	family := config.getImageFamily()
+	for _, supported := range imageFamilies {
+		if family == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unsupported image family %q (supported families are %s)", family, strings.Join(imageFamilies, ", "))
+}
+
+// getImageFamilySuffix returns the suffix added to the image tag to select the images of the configured family (the terraform images
+// have none). The family implied by the entry point does not change the tag since it is generally used with the official images.
+func (config *TGFConfig) getImageFamilySuffix() string {
+	if config.ImageFamily != imageFamilyTerraform {
+		return config.ImageFamily
+	}
+	return ""
+}
+
+// getToolVersionFamily returns the program whose version is used as the image version if the image does not define TGF_IMAGE_VERSION
+// (empty if the image is not expected to be a terraform or tofu image)
+func (config *TGFConfig) getToolVersionFamily() string {
+	switch filepath.Base(config.EntryPoint) {
+	case imageFamilyTerraform, imageFamilyTofu:
+		return filepath.Base(config.EntryPoint)
+	}
+	if config.ImageFamily != "" {
+		return config.ImageFamily
+	}
+	return ""
+}
+
+// setImageFamilyEnvironment tells terragrunt to use the program of the image family (unless it is already configured)
+func (config *TGFConfig) setImageFamilyEnvironment() {
+	const tfPath = "TERRAGRUNT_TFPATH"
+	if config.getImageFamily() == imageFamilyTofu && filepath.Base(config.EntryPoint) == "terragrunt" {
+		if _, configured := config.Environment[tfPath]; !configured && os.Getenv(tfPath) == "" {
+			config.Environment[tfPath] = imageFamilyTofu
+		}
+	}
+}
+
+var reToolVersion = regexp.MustCompile(`(?m)^(Terraform|OpenTofu|terragrunt version) v(\d+\.\d+\.\d+\S*)`)
+
+// parseToolVersion returns the program and its version from the output of terraform version, tofu version (text or JSON, tofu keeps
+// the terraform_version key for compatibility) or terragrunt --version
+func parseToolVersion(output string) (tool, version string, err error) {
+	var versionJSON struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	if trimmed := strings.TrimSpace(output); strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &versionJSON); err != nil {
+			return "", "", err
+		}
+		if versionJSON.TerraformVersion == "" {
+			return "", "", fmt.Errorf("No version found in %s", trimmed)
+		}
+		return "", versionJSON.TerraformVersion, nil
+	}
+	match := reToolVersion.FindStringSubmatch(output)
+	if match == nil {
+		return "", "", fmt.Errorf("No version found in %q", strings.TrimSpace(output))
+	}
+	switch match[1] {
+	case "OpenTofu":
+		tool = imageFamilyTofu
+	case "terragrunt version":
+		tool = "terragrunt"
+	default:
+		tool = imageFamilyTerraform
+	}
+	return tool, match[2], nil
+}
+
+// getToolVersionFilename returns the file where the version of the program of an image is kept (the image ID is immutable)
+func getToolVersionFilename(imageID, tool string) string {
+	return filepath.Join(getCacheFolder(), "tool-versions", util.EncodeBase64Sha1(imageID+"/"+tool))
+}
+
+// runToolVersion runs the version command of the program in the image (only changed by the tests)
+var runToolVersion = func(image, tool string) (string, error) {
+	// terragrunt would pass the version command to terraform
+	versionArg := "version"
+	if tool == "terragrunt" {
+		versionArg = "--version"
+	}
+	output, err := exec.Command("docker", "run", "--rm", "--entrypoint", tool, image, versionArg).Output()
+	return string(output), err
+}
+
+// getToolVersion returns the version of the program of the image family, it is used as the image version for the images that do not
+// define TGF_IMAGE_VERSION (i.e. the official terraform and tofu images)
+func getToolVersion(image, tool string) string {
+	info := lookupImage(image)
+	if !info.Exists || info.ID == "" {
+		return ""
+	}
+	filename := getToolVersionFilename(info.ID, tool)
+	if content, err := ioutil.ReadFile(filename); err == nil {
+		return string(content)
+	}
+	output, err := runToolVersion(image, tool)
+	if err != nil {
+		logMessage(logLevelDebug, "docker", nil, "# Unable to get the %s version of %s: %v", tool, image, err)
+		return ""
+	}
+	_, version, err := parseToolVersion(output)
+	if err != nil {
+		logMessage(logLevelDebug, "docker", nil, "# Unable to get the %s version of %s: %v", tool, image, err)
+		return ""
+	}
+	writeFileAtomic(filename, []byte(version), 0644)
+	return version
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseToolVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		output  string
+		tool    string
+		version string
+		wantErr bool
+	}{
+		{"Terraform", "Terraform v1.5.7\non linux_amd64\n\nYour version of Terraform is out of date!", imageFamilyTerraform, "1.5.7", false},
+		{"OpenTofu", "OpenTofu v1.6.2\non linux_arm64\n+ provider registry.opentofu.org/hashicorp/aws v5.31.0", imageFamilyTofu, "1.6.2", false},
+		{"Pre-release", "OpenTofu v1.7.0-beta1\non darwin_arm64", imageFamilyTofu, "1.7.0-beta1", false},
+		{"Terragrunt", "terragrunt version v0.54.12\n", "terragrunt", "0.54.12", false},
+		{"JSON", `{"terraform_version": "1.6.2", "platform": "linux_amd64", "provider_selections": {}}`, "", "1.6.2", false},
+		{"Provider only", "+ provider registry.terraform.io/hashicorp/aws v5.31.0", "", "", true},
+		{"Invalid JSON", `{"terraform_version": `, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, version, err := parseToolVersion(tt.output)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.tool, tool)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestImageFamily(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      TGFConfig
+		family      string
+		image       string
+		toolVersion string
+		wantErr     bool
+	}{
+		{"Default", TGFConfig{Image: "coveo/tgf", EntryPoint: "terragrunt"}, imageFamilyTerraform, "coveo/tgf:1.21.0", "", false},
+		{"Tofu images", TGFConfig{Image: "coveo/tgf", EntryPoint: "terragrunt", ImageFamily: "tofu"}, imageFamilyTofu, "coveo/tgf:1.21.0-tofu", imageFamilyTofu, false},
+		{"Tofu images with tag", TGFConfig{Image: "coveo/tgf", EntryPoint: "terragrunt", ImageFamily: "tofu", ImageTag: &[]string{"aws"}[0]}, imageFamilyTofu, "coveo/tgf:1.21.0-aws-tofu", imageFamilyTofu, false},
+		{"Tofu entry point", TGFConfig{Image: "ghcr.io/opentofu/opentofu", EntryPoint: "tofu"}, imageFamilyTofu, "ghcr.io/opentofu/opentofu:1.21.0", imageFamilyTofu, false},
+		{"Terraform entry point", TGFConfig{Image: "hashicorp/terraform", EntryPoint: "terraform"}, imageFamilyTerraform, "hashicorp/terraform:1.21.0", imageFamilyTerraform, false},
+		{"Unknown", TGFConfig{Image: "coveo/tgf", EntryPoint: "terragrunt", ImageFamily: "pulumi"}, "pulumi", "coveo/tgf:1.21.0-pulumi", "pulumi", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ImageVersion = &[]string{"1.21.0"}[0]
+			assert.Equal(t, tt.family, tt.config.getImageFamily())
+			assert.Equal(t, tt.image, tt.config.GetImageName())
+			assert.Equal(t, tt.toolVersion, tt.config.getToolVersionFamily())
+			assert.Equal(t, tt.wantErr, tt.config.validateImageFamily() != nil)
+		})
+	}
+}
+
+func TestSetImageFamilyEnvironment(t *testing.T) {
+	defer os.Setenv("TERRAGRUNT_TFPATH", os.Getenv("TERRAGRUNT_TFPATH"))
+	os.Unsetenv("TERRAGRUNT_TFPATH")
+
+	config := TGFConfig{EntryPoint: "terragrunt", ImageFamily: imageFamilyTofu, Environment: map[string]string{}}
+	config.setImageFamilyEnvironment()
+	assert.Equal(t, "tofu", config.Environment["TERRAGRUNT_TFPATH"])
+
+	config = TGFConfig{EntryPoint: "terragrunt", ImageFamily: imageFamilyTofu, Environment: map[string]string{"TERRAGRUNT_TFPATH": "/opt/tofu"}}
+	config.setImageFamilyEnvironment()
+	assert.Equal(t, "/opt/tofu", config.Environment["TERRAGRUNT_TFPATH"], "The configured path is kept")
+
+	config = TGFConfig{EntryPoint: "terragrunt", Environment: map[string]string{}}
+	config.setImageFamilyEnvironment()
+	assert.NotContains(t, config.Environment, "TERRAGRUNT_TFPATH")
+}
+
+func TestGetToolVersion(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestGetToolVersion")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", tempDir)
+	defer func(inspect func(string) (imageInfo, error)) { inspectImage = inspect }(inspectImage)
+	inspectImage = func(image string) (imageInfo, error) {
+		return imageInfo{Exists: image != "missing", ID: "sha256:" + image}, nil
+	}
+	defer func(run func(string, string) (string, error)) { runToolVersion = run }(runToolVersion)
+	runs := 0
+	runToolVersion = func(image, tool string) (string, error) {
+		runs++
+		if tool != imageFamilyTofu {
+			return "", fmt.Errorf("executable file not found")
+		}
+		return "OpenTofu v1.6.2\non linux_amd64\n", nil
+	}
+
+	assert.Equal(t, "1.6.2", getToolVersion("opentofu", imageFamilyTofu))
+	assert.Equal(t, "1.6.2", getToolVersion("opentofu", imageFamilyTofu))
+	assert.Equal(t, 1, runs, "The version is kept in the cache")
+	assert.Equal(t, "", getToolVersion("opentofu", imageFamilyTerraform))
+	assert.Equal(t, "", getToolVersion("missing", imageFamilyTofu))
+	assert.Equal(t, 2, runs, "The version is not requested if the image does not exist")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// getLocalImageTags returns the tags of the local images of the repository
+func getLocalImageTags(image string) (tags []string) {
+	cli, ctx := getDockerClient()
+	filters := filters.NewArgs()
+	filters.Add("reference", image)
+	images, err := cli.ImageList(ctx, types.ImageListOptions{Filters: filters})
+	if err != nil {
+		printWarning(msgImageListFailed, image, err)
+		return nil
+	}
+	for _, image := range images {
+		tags = append(tags, image.RepoTags...)
+	}
+	return
+}
+
+// getImageVersionCandidates returns the image versions matching the configuration (most recent first).
+// The tags must be of the same flavor (i.e. k8s) and satisfy the partial version and the required version range.
+func (config *TGFConfig) getImageVersionCandidates(tags []string) []string {
+	flavor := ""
+	if config.ImageTag != nil {
+		flavor = *config.ImageTag
+	}
+
+	found := make(map[string]semver.Version)
+	for _, tag := range tags {
+		matches, _ := utils.MultiMatch(tag, reImage)
+		version := matches["version"]
+		if matches["image"] != config.Image || version == "" || matches["spec"] != flavor {
+			continue
+		}
+		if config.IsPartialVersion() && !strings.HasPrefix(version, *config.ImageVersion+".") {
+			continue
+		}
+		if config.RequiredVersionRange != "" {
+			if valid, err := CheckVersionRange(version, config.RequiredVersionRange); err != nil || !valid {
+				continue
+			}
+		}
+		parsed, err := semver.ParseTolerant(version)
+		if err != nil {
+			continue
+		}
+		found[version] = parsed
+	}
+
+	versions := make([]string, 0, len(found))
+	for version := range found {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return found[versions[i]].GT(found[versions[j]]) })
+	return versions
+}
+
+// fuzzyMatch returns true if all the characters of the filter appear in the value in the same order (case insensitive)
+func fuzzyMatch(filter, value string) bool {
+	value = strings.ToLower(value)
+	for _, char := range strings.ToLower(filter) {
+		index := strings.IndexRune(value, char)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(string(char)):]
+	}
+	return true
+}
+
+// pickChoice asks the user to select one of the choices, a text that is not a number filters the list.
+// The default choice is returned if the user enters nothing or if the input is closed.
+func pickChoice(reader *bufio.Reader, w io.Writer, title string, choices []string, defaultChoice string) string {
+	filtered := choices
+	for {
+		fmt.Fprintln(w, title)
+		for i, choice := range filtered {
+			if choice == defaultChoice {
+				choice += " (default)"
+			}
+			fmt.Fprintf(w, "  %d) %s\n", i+1, choice)
+		}
+		fmt.Fprint(w, "Enter a number, a text to filter the list or nothing to keep the default: ")
+
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err == nil {
+				fmt.Fprintln(w)
+			}
+			return defaultChoice
+		}
+		if index, convErr := strconv.Atoi(line); convErr == nil && index >= 1 && index <= len(filtered) {
+			return filtered[index-1]
+		}
+
+		var matching []string
+		for _, choice := range choices {
+			if fuzzyMatch(line, choice) {
+				matching = append(matching, choice)
+			}
+		}
+		switch {
+		case len(matching) == 1:
+			return matching[0]
+		case len(matching) == 0:
+			fmt.Fprintf(w, "Nothing matches %q\n", line)
+		default:
+			filtered = matching
+		}
+		if err != nil {
+			return defaultChoice
+		}
+	}
+}
+
+// pickImageVersion lets the user select the image version among the local images matching the configuration.
+// The configured version is kept if there is no choice or if the user cannot be prompted.
+func (config *TGFConfig) pickImageVersion() {
+	versions := config.getImageVersionCandidates(getLocalImageTags(config.Image))
+	if len(versions) < 2 {
+		config.tgf.Debug("# No image to pick, %d local image(s) match the configuration", len(versions))
+		return
+	}
+	if !prompts.interactive {
+		config.tgf.Debug("# There is no terminal to pick the image, using %s", config.GetImageName())
+		return
+	}
+
+	images := make([]string, len(versions))
+	for i := range versions {
+		images[i] = config.getImageNameWithVersion(versions[i])
+	}
+	defaultImage := config.GetImageName()
+	if config.ImageVersion == nil || config.IsPartialVersion() {
+		// The most recent image matching the configuration would be used
+		defaultImage = images[0]
+	}
+
+	selected := prompts.choose("Select the image to use:", images, defaultImage)
+	for i := range images {
+		if images[i] == selected && selected != defaultImage {
+			config.ImageVersion = &versions[i]
+			config.setSource("docker-image-version", sourceCommandLine)
+		}
+	}
+}
+
+// getImageNameWithVersion returns the image name that would be used with the specified version
+func (config *TGFConfig) getImageNameWithVersion(version string) string {
+	defer func(current *string) { config.ImageVersion = current }(config.ImageVersion)
+	config.ImageVersion = &version
+	return config.GetImageName()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetImageVersionCandidates(t *testing.T) {
+	tags := []string{
+		"coveo/tgf:1.20.1", "coveo/tgf:1.21.0", "coveo/tgf:1.21.3", "coveo/tgf:1.21.10",
+		"coveo/tgf:1.21.3-k8s", "coveo/tgf:latest", "coveo/tgf2:1.21.4", "other/image:1.21.5",
+	}
+	version, k8s := "1.21", "k8s"
+
+	tests := []struct {
+		name   string
+		config TGFConfig
+		want   []string
+	}{
+		{"All versions", TGFConfig{Image: "coveo/tgf"}, []string{"1.21.10", "1.21.3", "1.21.0", "1.20.1"}},
+		{"Partial version", TGFConfig{Image: "coveo/tgf", ImageVersion: &version}, []string{"1.21.10", "1.21.3", "1.21.0"}},
+		{"Required range", TGFConfig{Image: "coveo/tgf", RequiredVersionRange: ">=1.21.1 <1.21.5"}, []string{"1.21.3"}},
+		{"Flavor", TGFConfig{Image: "coveo/tgf", ImageTag: &k8s}, []string{"1.21.3"}},
+		{"No match", TGFConfig{Image: "coveo/unknown"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.getImageVersionCandidates(tags))
+		})
+	}
+}
+
+func TestImageNameWithVersion(t *testing.T) {
+	version, k8s := "1.21", "k8s"
+	config := TGFConfig{Image: "coveo/tgf", ImageVersion: &version, ImageTag: &k8s}
+	assert.Equal(t, "coveo/tgf:1.21.3-k8s", config.getImageNameWithVersion("1.21.3"))
+	assert.Equal(t, "coveo/tgf:1.21-k8s", config.GetImageName(), "The configuration must not be altered")
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	assert.True(t, fuzzyMatch("", "coveo/tgf:1.21.3"))
+	assert.True(t, fuzzyMatch("213", "coveo/tgf:1.21.3"))
+	assert.True(t, fuzzyMatch("TGF", "coveo/tgf:1.21.3"))
+	assert.False(t, fuzzyMatch("312", "coveo/tgf:1.21.3"))
+}
+
+func TestPickChoice(t *testing.T) {
+	choices := []string{"coveo/tgf:1.21.3", "coveo/tgf:1.21.0", "coveo/tgf:1.20.1"}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Default", "\n", "coveo/tgf:1.21.3"},
+		{"Closed input", "", "coveo/tgf:1.21.3"},
+		{"Number", "2\n", "coveo/tgf:1.21.0"},
+		{"Single match", ".20.\n", "coveo/tgf:1.20.1"},
+		{"Filter then number", "121\n2\n", "coveo/tgf:1.21.0"},
+		{"No match then default", "xyz\n\n", "coveo/tgf:1.21.3"},
+		{"Invalid number", "9\n\n", "coveo/tgf:1.21.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			got := pickChoice(bufio.NewReader(strings.NewReader(tt.input)), &output, "Select:", choices, choices[0])
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, output.String(), "1) coveo/tgf:1.21.3 (default)")
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// tgfLabel is the label added to the images built by tgf (its value is the tgf version)
+const tgfLabel = "tgf"
+
+// getRepository returns the repository of an image reference (without the tag)
+func getRepository(reference string) string {
+	if index := strings.LastIndex(reference, ":"); index > strings.LastIndex(reference, "/") {
+		return reference[:index]
+	}
+	return reference
+}
+
+// isTgfImage returns true if the image has been built by tgf or if it belongs to the configured repository
+func isTgfImage(image types.ImageSummary, repository string) bool {
+	if _, ok := image.Labels[tgfLabel]; ok {
+		return true
+	}
+	for _, tag := range image.RepoTags {
+		if getRepository(tag) == repository {
+			return true
+		}
+	}
+	return false
+}
+
+// getTgfImages returns the local images managed by tgf, most recent first
+func (config *TGFConfig) getTgfImages() ([]types.ImageSummary, error) {
+	cli, ctx := getDockerClient()
+	images, err := cli.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list the local images: %v", err)
+	}
+	result := make([]types.ImageSummary, 0, len(images))
+	for _, image := range images {
+		if isTgfImage(image, config.Image) {
+			result = append(result, image)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Created > result[j].Created })
+	return result, nil
+}
+
+// writeImageList prints the images with their tags, digest, size, creation and last use dates
+func writeImageList(w io.Writer, images []types.ImageSummary, lastUse func(tag string) time.Time) {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "IMAGE\tID\tDIGEST\tSIZE\tCREATED\tLAST USED")
+	for _, image := range images {
+		tags := image.RepoTags
+		if len(tags) == 0 || tags[0] == "<none>:<none>" {
+			tags = []string{"<none>"}
+		}
+		digest := "-"
+		if len(image.RepoDigests) > 0 {
+			digest = image.RepoDigests[0][strings.Index(image.RepoDigests[0], "@")+1:]
+		}
+		id := strings.TrimPrefix(image.ID, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		for _, tag := range tags {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", tag, id, digest, formatBytes(image.Size), formatTime(time.Unix(image.Created, 0)), formatTime(lastUse(tag)))
+		}
+	}
+	table.Flush()
+}
+
+// listImages prints the local images managed by tgf
+func (config *TGFConfig) listImages() int {
+	images, err := config.getTgfImages()
+	if err != nil {
+		return failWith(exitDockerUnavailable, err)
+	}
+	writeImageList(os.Stdout, images, getLastUse)
+	return 0
+}
+
+// removeImages deletes the specified images, the images that are not managed by tgf are left unchanged
+func (config *TGFConfig) removeImages(names []string) int {
+	images, err := config.getTgfImages()
+	if err != nil {
+		return failWith(exitDockerUnavailable, err)
+	}
+	exitCode := 0
+	for _, name := range names {
+		found := false
+		for _, image := range images {
+			if util.ListContainsElement(image.RepoTags, name) || strings.HasPrefix(strings.TrimPrefix(image.ID, "sha256:"), name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			printError(msgImageNotManaged, name)
+			exitCode = 1
+			continue
+		}
+		if deleteImage(name) != nil {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// pruneDangling removes the untagged images and the stopped containers created by tgf
+var pruneDangling = func() {
+	cli, ctx := getDockerClient()
+	danglingFilters := filters.NewArgs()
+	danglingFilters.Add("dangling", "true")
+	danglingFilters.Add("label", tgfLabel)
+	if _, err := cli.ImagesPrune(ctx, danglingFilters); err != nil {
+		printError(msgImagesPruneFailed, err.Error())
+	}
+	containerFilters := filters.NewArgs()
+	containerFilters.Add("label", tgfLabel)
+	if _, err := cli.ContainersPrune(ctx, containerFilters); err != nil {
+		printError(msgContainersPruneFailed, err.Error())
+	}
+}
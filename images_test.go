@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRepository(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{"coveo/tgf", "coveo/tgf"},
+		{"coveo/tgf:1.21.0-k8s", "coveo/tgf"},
+		{"registry:5000/coveo/tgf", "registry:5000/coveo/tgf"},
+		{"registry:5000/coveo/tgf:1.21.0", "registry:5000/coveo/tgf"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, getRepository(tt.reference), tt.reference)
+	}
+}
+
+func TestIsTgfImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		image types.ImageSummary
+		want  bool
+	}{
+		{"Configured repository", types.ImageSummary{RepoTags: []string{"coveo/tgf:1.21.0"}}, true},
+		{"Built by tgf", types.ImageSummary{RepoTags: []string{"other:1.0-abc"}, Labels: map[string]string{tgfLabel: "1.21.0"}}, true},
+		{"Untagged built by tgf", types.ImageSummary{Labels: map[string]string{tgfLabel: "1.21.0"}}, true},
+		{"Other repository", types.ImageSummary{RepoTags: []string{"coveo/tgf-other:1.0", "alpine:3"}}, false},
+		{"Other label", types.ImageSummary{RepoTags: []string{"alpine:3"}, Labels: map[string]string{"hash": "abc"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTgfImage(tt.image, "coveo/tgf"))
+		})
+	}
+}
+
+func TestWriteImageList(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2023, 1, 2, 15, 4, 0, 0, time.Local)
+	images := []types.ImageSummary{
+		{
+			ID:          "sha256:0123456789abcdef0123",
+			RepoTags:    []string{"coveo/tgf:1.21.0", "coveo/tgf:latest"},
+			RepoDigests: []string{"coveo/tgf@sha256:3c4f2b"},
+			Size:        300 * 1024 * 1024,
+			Created:     created.Unix(),
+		},
+		{ID: "sha256:fedcba", Size: 512, Created: created.Unix()},
+	}
+	lastUse := func(tag string) time.Time {
+		if tag == "coveo/tgf:latest" {
+			return created.Add(time.Hour)
+		}
+		return time.Time{}
+	}
+
+	var out bytes.Buffer
+	writeImageList(&out, images, lastUse)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, []string{"IMAGE", "ID", "DIGEST", "SIZE", "CREATED", "LAST", "USED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"coveo/tgf:1.21.0", "0123456789ab", "sha256:3c4f2b", "300.0", "MiB", "2023-01-02", "15:04", "-"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"coveo/tgf:latest", "0123456789ab", "sha256:3c4f2b", "300.0", "MiB", "2023-01-02", "15:04", "2023-01-02", "16:04"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"<none>", "fedcba", "-", "512", "B", "2023-01-02", "15:04", "-"}, strings.Fields(lines[3]))
+}
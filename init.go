@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// initWizard holds the values collected while interactively creating a starter configuration file
+type initWizard struct {
+	image      string
+	imageTag   string
+	version    string
+	refresh    string
+	entryPoint string
+	profile    string
+}
+
+// runInitWizard detects the local environment, asks the user a few questions and writes a starter .tgf.config file
+func runInitWizard() int {
+	wizard := initWizard{
+		image:      "coveo/tgf",
+		refresh:    "1h",
+		entryPoint: "terragrunt",
+	}
+	ErrPrintln("Welcome to tgf, this wizard will create a starter configuration file.\n")
+
+	if dockerVersion, err := getDockerClientVersion(); err != nil {
+		printWarning(msgDockerNotDetected, err)
+	} else {
+		ErrPrintf("Docker version %s detected\n", dockerVersion)
+	}
+
+	profiles := getAWSProfiles()
+	if len(profiles) > 0 {
+		ErrPrintf("AWS profiles detected: %s\n", strings.Join(profiles, ", "))
+	} else {
+		ErrPrintln("No AWS profile detected")
+	}
+
+	cwd := must(os.Getwd()).(string)
+	folder := cwd
+	if root := findGitRoot(cwd); root != "" {
+		ErrPrintf("Git repository detected at %s\n", root)
+		if layout := detectTerragruntLayout(root); layout != "" {
+			ErrPrintf("Project layout: %s\n", layout)
+		}
+		folder = root
+	}
+	ErrPrintln()
+
+	folder = prompts.ask("Folder where the configuration should be written", folder)
+	wizard.image = prompts.ask("Docker image", wizard.image)
+	wizard.version = prompts.ask("Docker image version (leave empty to always use the latest)", wizard.version)
+	wizard.imageTag = prompts.ask("Docker image tag (ex: aws, k8s, full)", wizard.imageTag)
+	wizard.refresh = prompts.ask("Delay between checks for a newer image", wizard.refresh)
+	wizard.entryPoint = prompts.ask("Entry point", wizard.entryPoint)
+	if len(profiles) > 0 {
+		wizard.profile = prompts.ask("Default AWS profile (leave empty to use the current environment)", wizard.profile)
+	}
+
+	target := filepath.Join(folder, configFile)
+	if _, err := os.Stat(target); err == nil {
+		if overwrite, err := prompts.confirm(fmt.Sprintf("%s already exists, overwrite it", target)); err != nil || !overwrite {
+			if err != nil {
+				printError(msgRunCancelled, err)
+			}
+			ErrPrintln("Configuration file left unchanged")
+			return 1
+		}
+	}
+
+	if err := ioutil.WriteFile(target, []byte(wizard.content()), 0644); err != nil {
+		printError(msgFileWriteFailed, target, err)
+		return 1
+	}
+	ErrPrintf("Configuration written to %s\n", target)
+	return 0
+}
+
+// content returns the configuration file content corresponding to the collected answers
+func (wizard *initWizard) content() string {
+	var lines []string
+	add := func(key, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+	add("docker-image", wizard.image)
+	if wizard.version != "" {
+		// The version is quoted to ensure that values like 1.20 are not interpreted as numbers
+		add("docker-image-version", fmt.Sprintf("%q", wizard.version))
+	}
+	add("docker-image-tag", wizard.imageTag)
+	add("docker-refresh", wizard.refresh)
+	add("entry-point", wizard.entryPoint)
+	if wizard.profile != "" {
+		lines = append(lines, "environment:", fmt.Sprintf("  AWS_PROFILE: %s", wizard.profile))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// getDockerClientVersion returns the version of the installed docker client
+func getDockerClientVersion() (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", err
+	}
+	out, err := exec.Command("docker", "version", "--format", "{{.Client.Version}}").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// getDockerServerVersion returns the version of the docker daemon (an error is returned if the daemon cannot be reached)
+func getDockerServerVersion() (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", err
+	}
+	out, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(out)); message != "" {
+			return "", fmt.Errorf("%s", message)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var reAWSProfile = regexp.MustCompile(`(?m)^\s*\[\s*(?:profile\s+)?([^\]\s]+)\s*\]`)
+
+// getAWSProfiles returns the sorted list of profiles defined in the user AWS configuration files
+func getAWSProfiles() []string {
+	files := []string{os.Getenv("AWS_CONFIG_FILE"), os.Getenv("AWS_SHARED_CREDENTIALS_FILE")}
+	if currentUser, err := user.Current(); err == nil {
+		files = append(files, filepath.Join(currentUser.HomeDir, ".aws", "config"), filepath.Join(currentUser.HomeDir, ".aws", "credentials"))
+	}
+
+	found := make(map[string]bool)
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if content, err := ioutil.ReadFile(file); err == nil {
+			for _, profile := range parseAWSProfiles(string(content)) {
+				found[profile] = true
+			}
+		}
+	}
+
+	profiles := make([]string, 0, len(found))
+	for profile := range found {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// parseAWSProfiles returns the profile names declared in an AWS config or credentials file content
+func parseAWSProfiles(content string) (profiles []string) {
+	for _, match := range reAWSProfile.FindAllStringSubmatch(content, -1) {
+		profiles = append(profiles, match[1])
+	}
+	return
+}
+
+// findGitRoot returns the root folder of the git repository containing the folder (or an empty string if there is none)
+func findGitRoot(folder string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(folder, ".git")); err == nil {
+			return folder
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return ""
+		}
+		folder = parent
+	}
+}
+
+// detectTerragruntLayout returns a short description of the terragrunt/terraform files found under the folder
+func detectTerragruntLayout(folder string) string {
+	var terragrunt, terraform int
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != folder && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.Name() == "terragrunt.hcl" || info.Name() == "terraform.tfvars":
+			terragrunt++
+		case strings.HasSuffix(info.Name(), ".tf"):
+			terraform++
+		}
+		return nil
+	})
+	if terragrunt+terraform == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d terragrunt configuration(s), %d terraform file(s)", terragrunt, terraform)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAWSProfiles(t *testing.T) {
+	t.Parallel()
+
+	content := String(`
+		[default]
+		region = us-east-1
+
+		[profile dev]
+		role_arn = arn:aws:iam::123456789012:role/dev
+		source_profile = default
+
+		[ prod ]
+		aws_access_key_id = AKIA
+	`).UnIndent().Str()
+
+	assert.Equal(t, []string{"default", "dev", "prod"}, parseAWSProfiles(content))
+	assert.Nil(t, parseAWSProfiles(""))
+}
+
+func TestInitWizardContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		wizard initWizard
+		want   string
+	}{
+		{
+			"Defaults",
+			initWizard{image: "coveo/tgf", refresh: "1h", entryPoint: "terragrunt"},
+			"docker-image: coveo/tgf\ndocker-refresh: 1h\nentry-point: terragrunt\n",
+		},
+		{
+			"All values",
+			initWizard{image: "coveo/tgf", version: "1.20", imageTag: "aws", refresh: "2h", entryPoint: "terraform", profile: "dev"},
+			"docker-image: coveo/tgf\ndocker-image-version: \"1.20\"\ndocker-image-tag: aws\ndocker-refresh: 2h\nentry-point: terraform\nenvironment:\n  AWS_PROFILE: dev\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.wizard.content())
+		})
+	}
+}
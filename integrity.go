@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// integrityChecksum is the SHA-256 checksum of the released binary preceded by a marker. It is written in the binary once it is built
+// (tgf integrity seal), the checksum is computed with its own location filled with zeros. The development builds are not sealed.
+var integrityChecksum = "tgf-integrity-sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+// getIntegrityChecksum returns the marker preceding the checksum and the checksum embedded in the running binary (empty if the binary
+// has not been sealed)
+func getIntegrityChecksum() (marker, checksum string) {
+	index := strings.Index(integrityChecksum, ":") + 1
+	marker, checksum = integrityChecksum[:index], integrityChecksum[index:]
+	if strings.Trim(checksum, "0") == "" {
+		checksum = ""
+	}
+	return
+}
+
+// findIntegrityChecksum returns the location of the checksum in the content of a binary, the marker must be found once
+func findIntegrityChecksum(content []byte, marker string) (int, error) {
+	matches := regexp.MustCompile(regexp.QuoteMeta(marker)+"[0-9a-f]{64}").FindAllIndex(content, -1)
+	if len(matches) != 1 {
+		return 0, fmt.Errorf("The checksum has been found %d times in the binary (the file may be truncated)", len(matches))
+	}
+	return matches[0][0] + len(marker), nil
+}
+
+// computeIntegrityChecksum returns the checksum of the binary content with the checksum location filled with zeros
+func computeIntegrityChecksum(content []byte, offset int) string {
+	hash := sha256.New()
+	hash.Write(content[:offset])
+	hash.Write(bytes.Repeat([]byte("0"), sha256.Size*2))
+	hash.Write(content[offset+sha256.Size*2:])
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// sealBinary writes the checksum of the binary in the binary (it does not have to be built for the current platform)
+func sealBinary(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	marker, _ := getIntegrityChecksum()
+	offset, err := findIntegrityChecksum(content, marker)
+	if err != nil {
+		return "", fmt.Errorf("Unable to seal %s: %v", filename, err)
+	}
+	checksum := computeIntegrityChecksum(content, offset)
+	file, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = file.WriteAt([]byte(checksum), int64(offset))
+	return checksum, err
+}
+
+// verifyIntegrity checks that the binary matches the checksum embedded in the running binary, it returns false if the running binary
+// has not been sealed
+func verifyIntegrity(filename string) (bool, error) {
+	marker, expected := getIntegrityChecksum()
+	if expected == "" {
+		return false, nil
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return true, err
+	}
+	offset, err := findIntegrityChecksum(content, marker)
+	if err != nil {
+		return true, err
+	}
+	if embedded := string(content[offset : offset+len(expected)]); embedded != expected {
+		return true, fmt.Errorf("The checksum of the file is %s instead of %s", embedded, expected)
+	}
+	if actual := computeIntegrityChecksum(content, offset); actual != expected {
+		return true, fmt.Errorf("The content of the file does not match its checksum (%s instead of %s)", actual, expected)
+	}
+	return true, nil
+}
+
+// checkIntegrity warns if the tgf executable has been modified since it has been released (--verify-integrity)
+func checkIntegrity() {
+	executable := must(os.Executable()).(string)
+	if _, err := verifyIntegrity(executable); err != nil {
+		printWarning(msgIntegrityFailed, executable, err)
+	}
+}
+
+// runIntegrityCommand verifies the tgf executable or seals the binaries (tgf integrity [seal <file>...])
+func runIntegrityCommand(app *TGFApplication, args []string) int {
+	if len(args) > 1 && args[0] == "seal" {
+		for _, filename := range args[1:] {
+			checksum, err := sealBinary(filename)
+			if err != nil {
+				printError(msgError, err)
+				return 1
+			}
+			Printf("%s  %s\n", checksum, filename)
+		}
+		return 0
+	}
+	if len(args) > 0 {
+		return printCommandUsage("integrity", "[seal <file>...]")
+	}
+	executable := must(os.Executable()).(string)
+	sealed, err := verifyIntegrity(executable)
+	switch {
+	case err != nil:
+		printError(msgIntegrityFailed, executable, err)
+		return 1
+	case !sealed:
+		printWarning(msgIntegrityNotSealed, executable)
+	default:
+		_, checksum := getIntegrityChecksum()
+		Printf("%s: the checksum %s is verified\n", executable, checksum)
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrity(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestIntegrity")).(string)
+	defer os.RemoveAll(tempDir)
+	defer func(value string) { integrityChecksum = value }(integrityChecksum)
+
+	marker, checksum := getIntegrityChecksum()
+	assert.Equal(t, "tgf-integrity-sha256:", marker)
+	assert.Empty(t, checksum, "The development builds are not sealed")
+
+	binary := filepath.Join(tempDir, "tgf")
+	content := "header" + integrityChecksum + "trailer"
+	must(ioutil.WriteFile(binary, []byte(content), 0755))
+	sealed, err := verifyIntegrity(binary)
+	assert.False(t, sealed)
+	assert.NoError(t, err)
+
+	checksum, err = sealBinary(binary)
+	assert.NoError(t, err)
+	assert.Len(t, checksum, 64)
+	sealedContent := string(must(ioutil.ReadFile(binary)).([]byte))
+	assert.Equal(t, "header"+marker+checksum+"trailer", sealedContent)
+	resealed, err := sealBinary(binary)
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, resealed, "Sealing twice gives the same checksum")
+
+	integrityChecksum = marker + checksum
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Sealed", sealedContent, ""},
+		{"Modified", strings.Replace(sealedContent, "trailer", "Trailer", 1), "does not match its checksum"},
+		{"Truncated", sealedContent[:len(sealedContent)-3], "does not match its checksum"},
+		{"Checksum truncated", sealedContent[:len(sealedContent)-20], "found 0 times"},
+		{"Other checksum", "header" + marker + strings.Repeat("1", 64) + "trailer", "instead of " + checksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			must(ioutil.WriteFile(binary, []byte(tt.content), 0755))
+			sealed, err := verifyIntegrity(binary)
+			assert.True(t, sealed)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealBinaryWithoutMarker(t *testing.T) {
+	binary := must(ioutil.TempFile("", "TestSealBinary")).(*os.File)
+	binary.WriteString("no marker")
+	binary.Close()
+	defer os.Remove(binary.Name())
+
+	_, err := sealBinary(binary.Name())
+	assert.Error(t, err)
+}
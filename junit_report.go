@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// junitOutputLimit is the maximum size of the output kept for each failed folder (the end of the output is kept)
+const junitOutputLimit = 64 * 1024
+
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		Time      string          `xml:"time,attr"`
+		Timestamp string          `xml:"timestamp,attr"`
+		Cases     []junitTestCase `xml:"testcase"`
+	}
+
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Skipped   *junitMessage `xml:"skipped,omitempty"`
+	}
+
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Content string `xml:",chardata"`
+	}
+)
+
+// foreachReport collects the outputs of the failed folders to write the JUnit XML report of --foreach and run-ordered
+// (--foreach-report), the outputs must be collected before the spooled files are flushed
+type foreachReport struct {
+	file    string
+	start   time.Time
+	outputs map[string]string
+}
+
+// newForeachReport returns the report written in the file (nil if no report is requested)
+func newForeachReport(file string) *foreachReport {
+	if file == "" {
+		return nil
+	}
+	return &foreachReport{file: file, start: time.Now(), outputs: make(map[string]string)}
+}
+
+// add keeps the end of the output of the folder if the command has failed
+func (report *foreachReport) add(result foreachResult) {
+	if report == nil || result.exitCode == 0 || result.skipped != "" {
+		return
+	}
+	var output strings.Builder
+	for _, file := range []*os.File{result.stdout, result.stderr} {
+		if file == nil {
+			continue
+		}
+		offset := int64(0)
+		if info, err := file.Stat(); err == nil && info.Size() > junitOutputLimit {
+			offset = info.Size() - junitOutputLimit
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err == nil {
+			io.Copy(&output, file)
+		}
+	}
+	report.outputs[result.folder] = output.String()
+}
+
+// getJUnitReport returns the report of the results, each folder is a test case of the suite named after the command
+func (report *foreachReport) getJUnitReport(command string, results []foreachResult) junitTestSuites {
+	suite := junitTestSuite{
+		Name:      command,
+		Tests:     len(results),
+		Time:      formatJUnitTime(time.Since(report.start)),
+		Timestamp: report.start.UTC().Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range results {
+		testCase := junitTestCase{Name: result.folder, ClassName: "tgf", Time: formatJUnitTime(result.duration)}
+		switch {
+		case result.skipped != "":
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: fmt.Sprintf("%s has failed", result.skipped)}
+		case result.exitCode != 0:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("exit code %d", result.exitCode), Content: report.outputs[result.folder]}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	return junitTestSuites{
+		Name:     "tgf",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+// write writes the JUnit XML report of the results in the file
+func (report *foreachReport) write(command string, results []foreachResult) {
+	if report == nil {
+		return
+	}
+	content, err := xml.MarshalIndent(report.getJUnitReport(command, results), "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(report.file, append([]byte(xml.Header), append(content, '\n')...), 0644)
+	}
+	if err != nil {
+		printWarning(msgFileWriteFailed, report.file, err)
+	}
+}
+
+// formatJUnitTime returns the duration in seconds as expected by the JUnit XML format
+func formatJUnitTime(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// getForeachCommand returns the command run in each folder, it names the test suite of the report
+func getForeachCommand(args []string) string {
+	return strings.Join(append([]string{"tgf"}, args[1:]...), " ")
+}
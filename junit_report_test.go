@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForeachReport(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestForeachReport")).(string)
+	defer os.RemoveAll(tempDir)
+
+	spool := func(content string) *os.File {
+		file := must(ioutil.TempFile(tempDir, "spool-")).(*os.File)
+		must(file.WriteString(content))
+		return file
+	}
+	results := []foreachResult{
+		{folder: "live/vpc", duration: 1500 * time.Millisecond, stdout: spool("Apply complete!\n")},
+		{folder: "live/db", exitCode: 1, duration: 2 * time.Second, stdout: spool("Planning...\n"), stderr: spool("Error: <invalid>\n")},
+		{folder: "live/app", skipped: "live/db"},
+	}
+	report := newForeachReport(filepath.Join(tempDir, "report.xml"))
+	for _, result := range results {
+		report.add(result)
+		result.flush(ioutil.Discard, ioutil.Discard)
+	}
+	report.write(getForeachCommand([]string{"--no-interactive", "apply"}), results)
+
+	content := string(must(ioutil.ReadFile(filepath.Join(tempDir, "report.xml"))).([]byte))
+	assert.True(t, strings.HasPrefix(content, xml.Header))
+	assert.Contains(t, content, `<testcase name="live/db" classname="tgf" time="2.000">`)
+	assert.Contains(t, content, `<skipped message="live/db has failed"></skipped>`)
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal([]byte(content), &parsed))
+	assert.Equal(t, 3, parsed.Tests)
+	assert.Equal(t, 1, parsed.Failures)
+	assert.Equal(t, 1, parsed.Skipped)
+	assert.Len(t, parsed.Suites, 1)
+	assert.Equal(t, "tgf apply", parsed.Suites[0].Name)
+	assert.Equal(t, "1.500", parsed.Suites[0].Cases[0].Time)
+	assert.Nil(t, parsed.Suites[0].Cases[0].Failure)
+	assert.Equal(t, "exit code 1", parsed.Suites[0].Cases[1].Failure.Message)
+	assert.Equal(t, "Planning...\nError: <invalid>\n", parsed.Suites[0].Cases[1].Failure.Content)
+}
+
+func TestForeachReportDisabled(t *testing.T) {
+	t.Parallel()
+
+	report := newForeachReport("")
+	assert.Nil(t, report)
+	// The methods of a disabled report do nothing
+	report.add(foreachResult{folder: "live/db", exitCode: 1})
+	report.write("tgf plan", nil)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Default values of the kubernetes runner
+const (
+	defaultKubernetesTimeout      = 6 * time.Hour
+	defaultKubernetesStartTimeout = 5 * time.Minute
+)
+
+// kubectlProgram is the program used to communicate with the cluster (only changed by the tests)
+var kubectlProgram = "kubectl"
+
+// KubernetesConfig defines where and how the kubernetes runner creates the pods
+type KubernetesConfig struct {
+	Context        string            `yaml:"context,omitempty" json:"context,omitempty" hcl:"context,omitempty"`
+	Namespace      string            `yaml:"namespace,omitempty" json:"namespace,omitempty" hcl:"namespace,omitempty"`
+	ServiceAccount string            `yaml:"service-account,omitempty" json:"service-account,omitempty" hcl:"service-account,omitempty"`
+	Requests       map[string]string `yaml:"requests,omitempty" json:"requests,omitempty" hcl:"requests,omitempty"`
+	Limits         map[string]string `yaml:"limits,omitempty" json:"limits,omitempty" hcl:"limits,omitempty"`
+	NodeSelector   map[string]string `yaml:"node-selector,omitempty" json:"node-selector,omitempty" hcl:"node-selector,omitempty"`
+	Timeout        time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty" hcl:"timeout,omitempty"`
+	StartTimeout   time.Duration     `yaml:"start-timeout,omitempty" json:"start-timeout,omitempty" hcl:"start-timeout,omitempty"`
+}
+
+// command returns a kubectl command using the configured context and namespace
+func (kube KubernetesConfig) command(args ...string) *exec.Cmd {
+	var global []string
+	if kube.Context != "" {
+		global = append(global, "--context", kube.Context)
+	}
+	if kube.Namespace != "" {
+		global = append(global, "--namespace", kube.Namespace)
+	}
+	return exec.Command(kubectlProgram, append(global, args...)...)
+}
+
+// getTimeout returns the maximum lifetime of the pod
+func (kube KubernetesConfig) getTimeout() time.Duration {
+	if kube.Timeout == 0 {
+		return defaultKubernetesTimeout
+	}
+	return kube.Timeout
+}
+
+// getStartTimeout returns the maximum delay to wait for the pod to be scheduled and started
+func (kube KubernetesConfig) getStartTimeout() time.Duration {
+	if kube.StartTimeout == 0 {
+		return defaultKubernetesStartTimeout
+	}
+	return kube.StartTimeout
+}
+
+// getPodManifest returns the definition of the pod running the image. The pod only waits until the workspace is transferred and
+// the command is executed through kubectl exec, it is deleted by tgf once the command is completed (or by the cluster once its
+// deadline is reached if tgf has been killed).
+func (kube KubernetesConfig) getPodManifest(name string, run remoteRun) map[string]interface{} {
+	timeout := int64(kube.getTimeout().Seconds())
+	container := map[string]interface{}{
+		"name":       "tgf",
+		"image":      run.image,
+		"command":    []string{"sh", "-c", "sleep " + strconv.FormatInt(timeout, 10)},
+		"workingDir": run.workdir,
+		"stdin":      true,
+	}
+	resources := map[string]interface{}{}
+	if len(kube.Requests) > 0 {
+		resources["requests"] = kube.Requests
+	}
+	if len(kube.Limits) > 0 {
+		resources["limits"] = kube.Limits
+	}
+	if len(resources) > 0 {
+		container["resources"] = resources
+	}
+
+	spec := map[string]interface{}{
+		"restartPolicy":                 "Never",
+		"activeDeadlineSeconds":         timeout,
+		"terminationGracePeriodSeconds": 5,
+		"containers":                    []interface{}{container},
+	}
+	if kube.ServiceAccount != "" {
+		spec["serviceAccountName"] = kube.ServiceAccount
+	}
+	if len(kube.NodeSelector) > 0 {
+		spec["nodeSelector"] = kube.NodeSelector
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":   name,
+			"labels": map[string]string{tgfLabel: version},
+		},
+		"spec": spec,
+	}
+}
+
+// runKubernetes runs the command in a pod of the configured cluster and returns the exit code of the command
+func (config *TGFConfig) runKubernetes(run remoteRun) int {
+	app, kube := config.tgf, config.Kubernetes
+	name := "tgf-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	manifest := must(json.MarshalIndent(kube.getPodManifest(name, run), "", "  ")).([]byte)
+	if app.DryRun {
+		fmt.Fprintf(os.Stdout, "# Pod created by tgf (the folder %s is transferred to %s)\n%s\n", run.root, run.remoteRoot, manifest)
+		writeDryRun(os.Stdout, []string{kubectlProgram, "exec", "-i", name, "--", "sh", "-c", run.getScript()}, run.environment, config.runBeforeCommands, config.runAfterCommands, config.Hooks)
+		return 0
+	}
+
+	lifecycle := &runLifecycle{config: config, component: "kubernetes", started: "Starting pod", exited: "Pod command exited"}
+	return lifecycle.execute(func() int {
+		// The interruptions are handled by kubectl, tgf must survive them to delete the pod
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupt)
+
+		app.Debug("# Creating pod %s with image %s", name, run.image)
+		create := kube.command("create", "-f", "-")
+		create.Stdin, create.Stderr = bytes.NewReader(manifest), os.Stderr
+		if err := create.Run(); err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to create the pod %s: %v", name, err))
+		}
+		defer func() {
+			app.Debug("# Deleting pod %s", name)
+			if err := kube.command("delete", "pod", name, "--wait=false").Run(); err != nil {
+				printWarning(msgKubernetesPodNotDeleted, name, err)
+			}
+		}()
+
+		wait := kube.command("wait", "--for=condition=Ready", "pod/"+name, "--timeout="+kube.getStartTimeout().String())
+		wait.Stderr = os.Stderr
+		if err := wait.Run(); err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("The pod %s has not started: %v", name, err))
+		}
+
+		endTransfer := timings.begin("workspace transfer")
+		reader, writer := io.Pipe()
+		go func() { writer.CloseWithError(run.writeWorkspace(writer)) }()
+		transfer := kube.command("exec", "-i", name, "--", "sh", "-c", "tar -xf - -C / && touch "+remoteWorkspaceMarker)
+		transfer.Stdin, transfer.Stderr = reader, os.Stderr
+		err := transfer.Run()
+		reader.Close()
+		endTransfer()
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to transfer %s to the pod %s: %v", run.root, name, err))
+		}
+
+		args := []string{"exec", "-i"}
+		if app.DockerInteractive && !isCI() && isTerminal(os.Stdin) {
+			args = append(args, "-t")
+		}
+		command := kube.command(append(args, name, "--", "sh", "-c", run.getScript())...)
+		command.Stdin, command.Stdout, command.Stderr = os.Stdin, lifecycle.stdout, os.Stderr
+		exitCode, err := lifecycle.runCommand(run.image, run.command, run.span, map[string]interface{}{"pod": name}, func() (int, error) { return runProcess(command) })
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to run the command in the pod %s: %v", name, err))
+		}
+
+		endTransfer = timings.begin("workspace transfer")
+		err = run.copyChanges(kube.command("exec", name, "--", "sh", "-c", run.getChangesScript()))
+		endTransfer()
+		if err != nil {
+			printWarning(msgRemoteChangesNotCopied, run.root, err)
+		}
+		return exitCode
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPodManifest(t *testing.T) {
+	t.Parallel()
+
+	run := remoteRun{image: "coveo/tgf:1.21.0", workdir: "/var/tgf/live"}
+	tests := []struct {
+		name string
+		kube KubernetesConfig
+		want func(container, spec map[string]interface{})
+	}{
+		{"Default", KubernetesConfig{}, func(container, spec map[string]interface{}) {
+			assert.Equal(t, []string{"sh", "-c", "sleep 21600"}, container["command"])
+			assert.NotContains(t, container, "resources")
+			assert.NotContains(t, spec, "serviceAccountName")
+			assert.Equal(t, int64(21600), spec["activeDeadlineSeconds"])
+		}},
+		{"Configured", KubernetesConfig{
+			ServiceAccount: "terraform",
+			Requests:       map[string]string{"cpu": "1"},
+			Limits:         map[string]string{"memory": "2Gi"},
+			NodeSelector:   map[string]string{"pool": "ci"},
+			Timeout:        time.Hour,
+		}, func(container, spec map[string]interface{}) {
+			assert.Equal(t, []string{"sh", "-c", "sleep 3600"}, container["command"])
+			assert.Equal(t, map[string]interface{}{"requests": map[string]string{"cpu": "1"}, "limits": map[string]string{"memory": "2Gi"}}, container["resources"])
+			assert.Equal(t, "terraform", spec["serviceAccountName"])
+			assert.Equal(t, map[string]string{"pool": "ci"}, spec["nodeSelector"])
+			assert.Equal(t, int64(3600), spec["activeDeadlineSeconds"])
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := tt.kube.getPodManifest("tgf-test", run)
+			spec := manifest["spec"].(map[string]interface{})
+			container := spec["containers"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, "coveo/tgf:1.21.0", container["image"])
+			assert.Equal(t, "/var/tgf/live", container["workingDir"])
+			tt.want(container, spec)
+		})
+	}
+}
+
+func TestRunKubernetes(t *testing.T) {
+	skipOnWindows(t, "kubectl is replaced by a shell script")
+	tempDir := must(ioutil.TempDir("", "TestRunKubernetes")).(string)
+	defer os.RemoveAll(tempDir)
+	log := filepath.Join(tempDir, "kubectl.log")
+	workspace := filepath.Join(tempDir, "workspace.tar")
+	must(ioutil.WriteFile(filepath.Join(tempDir, "kubectl"), []byte(fmt.Sprintf(String(`
+		#!/bin/sh
+		echo "$*" >> %[1]s
+		case "$*" in
+		  *" create -f -") cat > /dev/null;;
+		  *" -- sh -c tar "*) cat > %[2]s;;
+		  *" -- sh -c cd "*) tar -cf - -C %[3]s plan.out;;
+		  *" -- sh -c "*) exit 3;;
+		esac
+	`).UnIndent().TrimSpace().Str(), log, workspace, filepath.Join(tempDir, "remote"))), 0755))
+	defer func(program string) { kubectlProgram = program }(kubectlProgram)
+	kubectlProgram = filepath.Join(tempDir, "kubectl")
+
+	must(os.MkdirAll(filepath.Join(tempDir, "live"), 0755))
+	must(os.MkdirAll(filepath.Join(tempDir, "remote"), 0755))
+	must(ioutil.WriteFile(filepath.Join(tempDir, "remote", "plan.out"), []byte("plan"), 0644))
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), Kubernetes: KubernetesConfig{Context: "ci", Namespace: "tgf"}}
+	run := remoteRun{image: "coveo/tgf:1.21.0", command: []string{"terragrunt", "plan"}, root: filepath.Join(tempDir, "live"), remoteRoot: "/var/tgf", workdir: "/var/tgf"}
+	assert.Equal(t, 3, config.runKubernetes(run), "The exit code of the command is returned")
+
+	calls := strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(log)).([]byte))), "\n")
+	if assert.Len(t, calls, 6) {
+		name := strings.TrimPrefix(strings.Fields(calls[1])[6], "pod/")
+		assert.Equal(t, "--context ci --namespace tgf create -f -", calls[0])
+		assert.Equal(t, "--context ci --namespace tgf wait --for=condition=Ready pod/"+name+" --timeout=5m0s", calls[1])
+		assert.Equal(t, "--context ci --namespace tgf exec -i "+name+" -- sh -c tar -xf - -C / && touch /tmp/tgf.workspace", calls[2])
+		assert.Equal(t, "--context ci --namespace tgf exec -i "+name+" -- sh -c "+run.getScript(), calls[3])
+		assert.Equal(t, "--context ci --namespace tgf exec "+name+" -- sh -c "+run.getChangesScript(), calls[4])
+		assert.Equal(t, "--context ci --namespace tgf delete pod "+name+" --wait=false", calls[5])
+	}
+	assert.FileExists(t, workspace)
+	assert.Equal(t, "plan", string(must(ioutil.ReadFile(filepath.Join(tempDir, "live", "plan.out"))).([]byte)), "The files changed by the command are copied back")
+}
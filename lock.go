@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+const lockFile = ".tgf.lock"
+
+// tgfLock contains the resolved elements that must not change between runs when using --locked
+type tgfLock struct {
+	Version     string                 `json:"tgf-version"`
+	Image       string                 `json:"image"`
+	ImageDigest string                 `json:"image-digest"`
+	Config      map[string]interface{} `json:"config"`
+}
+
+// getImageDigest returns the repository digest of a local image (or its ID if the image has not been pulled from a registry)
+func getImageDigest(imageName string) string {
+	return lookupImage(imageName).Digest
+}
+
+// currentLock returns the lock corresponding to the current configuration
+func (config *TGFConfig) currentLock(imageName string) tgfLock {
+	values, _ := config.effectiveConfig()
+	return tgfLock{
+		Version:     version,
+		Image:       imageName,
+		ImageDigest: getImageDigest(imageName),
+		Config:      values,
+	}
+}
+
+// findLockFile returns the lock file of the current folder or its parents
+func findLockFile() string {
+	folder := must(os.Getwd()).(string)
+	for {
+		filename := filepath.Join(folder, lockFile)
+		if _, err := os.Stat(filename); err == nil {
+			return filename
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return ""
+		}
+		folder = parent
+	}
+}
+
+// writeLock writes the lock file in the current folder
+func (config *TGFConfig) writeLock(imageName string) int {
+	lock := config.currentLock(imageName)
+	content := must(json.MarshalIndent(lock, "", "  ")).([]byte)
+	if err := ioutil.WriteFile(lockFile, append(content, '\n'), 0644); err != nil {
+		printError(msgFileWriteFailed, lockFile, err)
+		return 1
+	}
+	Println("Wrote", lockFile, "for", lock.ImageDigest)
+	return 0
+}
+
+// verifyLock ensures that the current configuration matches the lock file
+func (config *TGFConfig) verifyLock(imageName string) error {
+	filename := findLockFile()
+	if filename == "" {
+		return fmt.Errorf("No %s file found, use --lock to create it", lockFile)
+	}
+	var locked tgfLock
+	content, err := ioutil.ReadFile(filename)
+	if err == nil {
+		err = json.Unmarshal(content, &locked)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to read %s: %v", filename, err)
+	}
+
+	if drifts := locked.diff(config.currentLock(imageName)); len(drifts) > 0 {
+		message := fmt.Sprintf("The current configuration does not match %s:", filename)
+		for _, drift := range drifts {
+			message += "\n  " + drift
+		}
+		return fmt.Errorf("%s\nUse --lock to update the lock file", message)
+	}
+	return nil
+}
+
+// diff returns the differences between the locked values and the actual values
+func (lock tgfLock) diff(actual tgfLock) (drifts []string) {
+	compare := func(name string, locked, actual interface{}) {
+		if !reflect.DeepEqual(locked, actual) {
+			drifts = append(drifts, fmt.Sprintf("%s: locked %v, got %v", name, jsonValue(locked), jsonValue(actual)))
+		}
+	}
+	compare("tgf-version", lock.Version, actual.Version)
+	compare("image", lock.Image, actual.Image)
+	compare("image-digest", lock.ImageDigest, actual.ImageDigest)
+
+	keys := make(map[string]bool)
+	for key := range lock.Config {
+		keys[key] = true
+	}
+	for key := range actual.Config {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		compare(key, lock.Config[key], actual.Config[key])
+	}
+	return
+}
+
+func jsonValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	return string(must(json.Marshal(value)).([]byte))
+}
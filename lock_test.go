@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockDiff(t *testing.T) {
+	t.Parallel()
+
+	locked := tgfLock{
+		Version:     "1.21.0",
+		Image:       "coveo/tgf:1.20.3",
+		ImageDigest: "coveo/tgf@sha256:1234",
+		Config:      map[string]interface{}{"docker-image": "coveo/tgf", "docker-refresh": "1h0m0s"},
+	}
+
+	tests := []struct {
+		name   string
+		actual tgfLock
+		want   []string
+	}{
+		{"Identical", locked, nil},
+		{
+			"Digest",
+			tgfLock{Version: "1.21.0", Image: "coveo/tgf:1.20.3", ImageDigest: "coveo/tgf@sha256:5678", Config: locked.Config},
+			[]string{`image-digest: locked "coveo/tgf@sha256:1234", got "coveo/tgf@sha256:5678"`},
+		},
+		{
+			"Config",
+			tgfLock{Version: "1.22.0", Image: "coveo/tgf:1.20.3", ImageDigest: "coveo/tgf@sha256:1234", Config: map[string]interface{}{"docker-image": "coveo/tgf", "entry-point": "terraform"}},
+			[]string{
+				`tgf-version: locked "1.21.0", got "1.22.0"`,
+				`docker-refresh: locked "1h0m0s", got <none>`,
+				`entry-point: locked <none>, got "terraform"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, locked.diff(tt.actual))
+		})
+	}
+}
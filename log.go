@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Formats supported by --tgf-log-format
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logFormat defines how tgf writes its own diagnostics, the output of the entry point is never altered
+var logFormat = logFormatText
+
+// logOutput is the writer used for structured log records
+var logOutput io.Writer = os.Stderr
+
+// logLevel is the verbosity of tgf diagnostics
+type logLevel int
+
+// Levels supported by --tgf-log-level
+const (
+	logLevelError logLevel = iota
+	logLevelWarning
+	logLevelInfo
+	logLevelDebug
+	logLevelTrace
+)
+
+var logLevelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+// currentLogLevel is the most verbose level of the diagnostics printed by tgf
+var currentLogLevel = logLevelInfo
+
+func (level logLevel) String() string { return logLevelNames[level] }
+
+// parseLogLevel returns the level corresponding to the name (warning is accepted as an alias of warn)
+func parseLogLevel(name string) (logLevel, error) {
+	name = strings.ToLower(name)
+	if name == "warning" {
+		name = "warn"
+	}
+	for level, levelName := range logLevelNames {
+		if name == levelName {
+			return logLevel(level), nil
+		}
+	}
+	return logLevelInfo, fmt.Errorf("Invalid log level %s (valid levels are %s)", name, strings.Join(logLevelNames, ", "))
+}
+
+// logMessage prints the diagnostic (or writes it as a structured record) if its level is enabled
+func logMessage(level logLevel, component string, fields map[string]interface{}, format string, args ...interface{}) {
+	logCatalogMessage(level, component, fields, "", format, args...)
+}
+
+// logUserMessage prints a message of the catalog along with its ID
+func logUserMessage(level logLevel, component string, fields map[string]interface{}, id messageID, args ...interface{}) {
+	format, known := id.getMessageFormat()
+	if !known {
+		id = ""
+	}
+	logCatalogMessage(level, component, fields, id, format, args...)
+}
+
+func logCatalogMessage(level logLevel, component string, fields map[string]interface{}, id messageID, format string, args ...interface{}) {
+	if level > currentLogLevel && (logFile == nil || level > logLevelDebug) {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	writeLogFile(level, component, message)
+	if level > currentLogLevel {
+		return
+	}
+	events.emitLog(level, component, id, message, fields)
+	if logFormat == logFormatJSON {
+		if message = strings.TrimLeft(strings.TrimSpace(message), "# "); message != "" {
+			writeLogRecord(level.String(), component, id, message, fields)
+		}
+		return
+	}
+	if id != "" && messageFormat == messageFormatID {
+		message = fmt.Sprintf("[%s] %s", id, message)
+	}
+	switch level {
+	case logLevelError:
+		ErrPrintln(errorString("%s", message))
+	case logLevelWarning:
+		ErrPrintln(warningString("%s", message))
+	case logLevelInfo:
+		ErrPrintln(message)
+	default:
+		ErrPrintln(color.HiBlackString("%s", message))
+	}
+}
+
+// Settings of the diagnostics log file (--log-to-file)
+const (
+	logFileName    = "tgf.log"
+	logFileMaxSize = 5 * 1024 * 1024
+	logFileBackups = 3
+)
+
+// logFile receives a copy of the diagnostics (at least at debug level) when --log-to-file is set
+var logFile io.Writer
+
+// getLogFile returns the location of the diagnostics log file
+func getLogFile() string { return filepath.Join(getCacheFolder(), "logs", logFileName) }
+
+// openLogFile opens the diagnostics log file in append mode, the file is rotated first if it exceeds the maximum size
+func openLogFile(filename string) (*os.File, error) {
+	if info, err := os.Stat(filename); err == nil && info.Size() >= logFileMaxSize {
+		rotateLogFile(filename, logFileBackups)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+// rotateLogFile renames filename to filename.1 (filename.1 to filename.2 and so on), the oldest backup is discarded
+func rotateLogFile(filename string, backups int) {
+	os.Remove(fmt.Sprintf("%s.%d", filename, backups))
+	for i := backups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", filename, i), fmt.Sprintf("%s.%d", filename, i+1))
+	}
+	os.Rename(filename, filename+".1")
+}
+
+// writeLogFile writes a diagnostic in the log file (if enabled) with its timestamp, level and component
+func writeLogFile(level logLevel, component, message string) {
+	if logFile == nil {
+		return
+	}
+	message = strings.TrimLeft(strings.TrimSpace(message), "# ")
+	if message == "" {
+		return
+	}
+	fmt.Fprintf(logFile, "%s %-5s [%s] %s\n", time.Now().Format(time.RFC3339), strings.ToUpper(level.String()), component, message)
+}
+
+// logRecord is a structured log record written to stderr when --tgf-log-format=json is used
+type logRecord struct {
+	Level     string                 `json:"level"`
+	Timestamp time.Time              `json:"timestamp"`
+	Component string                 `json:"component"`
+	MessageID messageID              `json:"message-id,omitempty"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// writeLogRecord writes a structured log record on a single line
+func writeLogRecord(level, component string, id messageID, message string, fields map[string]interface{}) {
+	record := logRecord{Level: level, Timestamp: time.Now().UTC(), Component: component, MessageID: id, Message: message, Fields: fields}
+	fmt.Fprintln(logOutput, string(must(json.Marshal(record)).([]byte)))
+}
+
+// printInfo prints an informational message about a tgf operation (image pull, prune, etc.)
+func printInfo(component string, fields map[string]interface{}, id messageID, args ...interface{}) {
+	logUserMessage(logLevelInfo, component, fields, id, args...)
+}
+
+// infoEnabled returns true if tgf is allowed to print informational output (disabled by --quiet or --tgf-log-level=warn)
+func infoEnabled() bool { return currentLogLevel >= logLevelInfo }
+
+// logMetadata records information about the run that is only useful to log pipelines (there is no text equivalent)
+func logMetadata(component, message string, fields map[string]interface{}) {
+	if logFormat == logFormatJSON && currentLogLevel >= logLevelInfo {
+		writeLogRecord(logLevelInfo.String(), component, "", message, fields)
+	}
+}
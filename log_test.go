@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLogFormat(t *testing.T) {
+	var buffer bytes.Buffer
+	logOutput, logFormat, currentLogLevel = &buffer, logFormatJSON, logLevelDebug
+	defer func() { logOutput, logFormat, currentLogLevel = os.Stderr, logFormatText, logLevelInfo }()
+
+	printError("Unable to %s", "pull")
+	printWarning("Version %s is deprecated", "1.0")
+	printInfo("docker", map[string]interface{}{"image": "coveo/tgf"}, "Checking image %s", "coveo/tgf")
+	logMetadata("docker", "Container exited", map[string]interface{}{"exit-code": 0})
+	(&TGFApplication{}).Debug("# Using AWS region %s", "us-east-1")
+	(&TGFApplication{}).Trace("Not printed")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 5)
+	var records []logRecord
+	for _, line := range lines {
+		var record logRecord
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		assert.False(t, record.Timestamp.IsZero())
+		records = append(records, record)
+	}
+	assert.Equal(t, logRecord{Level: "error", Component: "tgf", Message: "Unable to pull", Timestamp: records[0].Timestamp}, records[0])
+	assert.Equal(t, "warn", records[1].Level)
+	assert.Equal(t, "docker", records[2].Component)
+	assert.Equal(t, map[string]interface{}{"image": "coveo/tgf"}, records[2].Fields)
+	assert.Equal(t, "Container exited", records[3].Message)
+	assert.Equal(t, logRecord{Level: "debug", Component: "tgf", Message: "Using AWS region us-east-1", Timestamp: records[4].Timestamp}, records[4])
+}
+
+func TestParseLogLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    logLevel
+		wantErr bool
+	}{
+		{"trace", logLevelTrace, false},
+		{"DEBUG", logLevelDebug, false},
+		{"info", logLevelInfo, false},
+		{"warn", logLevelWarning, false},
+		{"warning", logLevelWarning, false},
+		{"error", logLevelError, false},
+		{"verbose", logLevelInfo, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogLevel(tt.name)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestLogLevelFiltering(t *testing.T) {
+	var buffer bytes.Buffer
+	logOutput, logFormat, currentLogLevel = &buffer, logFormatJSON, logLevelWarning
+	defer func() { logOutput, logFormat, currentLogLevel = os.Stderr, logFormatText, logLevelInfo }()
+
+	printError("error")
+	printWarning("warning")
+	printInfo("docker", nil, "info")
+	logMetadata("docker", "metadata", nil)
+	(&TGFApplication{}).Debug("debug")
+
+	assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+}
+
+func TestLogFile(t *testing.T) {
+	folder := must(ioutil.TempDir("", "tgf-log")).(string)
+	defer os.RemoveAll(folder)
+	filename := filepath.Join(folder, "logs", logFileName)
+
+	file, err := openLogFile(filename)
+	assert.NoError(t, err)
+	logFile, currentLogLevel = file, logLevelWarning
+	defer func() { logFile, currentLogLevel = nil, logLevelInfo }()
+
+	(&TGFApplication{}).Debug("# Reading configuration from %s", "SSM")
+	(&TGFApplication{}).Trace("Not written")
+	file.Close()
+
+	content := string(must(ioutil.ReadFile(filename)).([]byte))
+	assert.Contains(t, content, "DEBUG [tgf] Reading configuration from SSM\n")
+	assert.NotContains(t, content, "Not written")
+}
+
+func TestRotateLogFile(t *testing.T) {
+	t.Parallel()
+
+	folder := must(ioutil.TempDir("", "tgf-log")).(string)
+	defer os.RemoveAll(folder)
+	filename := filepath.Join(folder, logFileName)
+	for _, name := range []string{"", ".1", ".2"} {
+		assert.NoError(t, ioutil.WriteFile(filename+name, []byte(name), 0600))
+	}
+
+	rotateLogFile(filename, 2)
+	_, err := os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "", string(must(ioutil.ReadFile(filename+".1")).([]byte)))
+	assert.Equal(t, ".1", string(must(ioutil.ReadFile(filename+".2")).([]byte)))
+	_, err = os.Stat(filename + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLogLevelArguments(t *testing.T) {
+	defer func() { currentLogLevel = logLevelInfo }()
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantLevel logLevel
+		wantDebug bool
+	}{
+		{"Default", []string{}, logLevelInfo, false},
+		{"Log level", []string{"--tgf-log-level", "warn"}, logLevelWarning, false},
+		{"Debug docker", []string{"-D"}, logLevelDebug, true},
+		{"Trace", []string{"--tgf-log-level", "trace", "-D"}, logLevelTrace, true},
+		{"Quiet", []string{"--quiet"}, logLevelError, false},
+		{"Quiet has precedence", []string{"--quiet", "-D"}, logLevelError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewTestApplication(tt.args)
+			assert.Equal(t, tt.wantLevel, currentLogLevel)
+			assert.Equal(t, tt.wantDebug, app.DebugMode)
+			assert.Equal(t, tt.wantLevel >= logLevelInfo, infoEnabled())
+		})
+	}
+}
@@ -16,24 +16,43 @@ import (
 // Version is initialized at build time through -ldflags "-X main.Version=<version number>"
 var version = "1.21.0"
 
+// Build metadata initialized at build time through -ldflags "-X main.commit=<sha> -X main.date=<date>" (set by goreleaser)
+var (
+	commit = "none"
+	date   = "unknown"
+)
+
 func main() {
 	// Handle eventual panic message
 	defer func() {
 		if err := recover(); err != nil {
+			if err, isTGFError := err.(tgfError); isTGFError {
+				printError(msgError, err)
+				events.close(err.exitCode)
+				os.Exit(err.exitCode)
+			}
 			if _, isManaged := err.(errors.Managed); String(os.Getenv(envDebug)).ParseBool() || !isManaged {
-				printError("%[1]v (%[1]T)", err)
+				printError(msgInternalError, err)
 				debug.PrintStack()
 			} else {
-				printError("%v", err)
+				printError(msgError, err)
 			}
+			events.close(1)
 			os.Exit(1)
 		}
 	}()
-	os.Exit(NewTGFApplication(os.Args[1:]).Run())
+	exitCode := NewTGFApplication(os.Args[1:]).Run()
+	events.close(exitCode)
+	os.Exit(exitCode)
 }
 
-func printError(format string, args ...interface{})   { ErrPrintln(errorString(format, args...)) }
-func printWarning(format string, args ...interface{}) { ErrPrintln(warningString(format, args...)) }
+func printError(id messageID, args ...interface{}) {
+	logUserMessage(logLevelError, "tgf", nil, id, args...)
+}
+
+func printWarning(id messageID, args ...interface{}) {
+	logUserMessage(logLevelWarning, "tgf", nil, id, args...)
+}
 
 type (
 	// String is imported from gotemplate/collections
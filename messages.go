@@ -0,0 +1,199 @@
+package main
+
+// Formats supported by --message-format
+const (
+	messageFormatText = "text"
+	messageFormatID   = "id"
+)
+
+// messageFormat defines whether the ID of the messages is printed before their text
+var messageFormat = messageFormatText
+
+// messageID identifies a user message, tools should match on the ID since the text of the message may change
+type messageID string
+
+// IDs of the user messages
+const (
+	msgAliasRecursive          messageID = "alias-recursive"
+	msgAllVersionsUnsupported  messageID = "all-versions-unsupported"
+	msgAuditLogFailed          messageID = "audit-log-failed"
+	msgAWSAccountUnresolved    messageID = "aws-account-unresolved"
+	msgAWSOverrideFailed       messageID = "aws-override-failed"
+	msgAWSSessionFailed        messageID = "aws-session-failed"
+	msgCommandFailed           messageID = "command-failed"
+	msgCommandUsage            messageID = "command-usage"
+	msgConfigInvalid           messageID = "config-invalid"
+	msgConfigLintIssues        messageID = "config-lint-issues"
+	msgConfigUnavailable       messageID = "config-unavailable"
+	msgConfigWarning           messageID = "config-warning"
+	msgContainerError          messageID = "container-error"
+	msgContainersPruneFailed   messageID = "containers-prune-failed"
+	msgCostEstimate            messageID = "cost-estimate"
+	msgCostEstimateFailed      messageID = "cost-estimate-failed"
+	msgCredentialsError        messageID = "credentials-error"
+	msgCredentialsExpireSoon   messageID = "credentials-expire-soon"
+	msgCredentialsUnresolved   messageID = "credentials-unresolved"
+	msgDebugBundleFailed       messageID = "debug-bundle-failed"
+	msgDeprecatedKey           messageID = "deprecated-key"
+	msgDockerNotDetected       messageID = "docker-not-detected"
+	msgDockerUnavailable       messageID = "docker-unavailable"
+	msgDryRunBuildSkipped      messageID = "dry-run-build-skipped"
+	msgDryRunRefreshSkipped    messageID = "dry-run-refresh-skipped"
+	msgECRLoginRetry           messageID = "ecr-login-retry"
+	msgError                   messageID = "error"
+	msgEventsFailed            messageID = "events-failed"
+	msgFargateLogsUnavailable  messageID = "fargate-logs-unavailable"
+	msgFargateTaskDetached     messageID = "fargate-task-detached"
+	msgFargateCleanupFailed    messageID = "fargate-cleanup-failed"
+	msgFileReadFailed          messageID = "file-read-failed"
+	msgFileWriteFailed         messageID = "file-write-failed"
+	msgFolderMoveFailed        messageID = "folder-move-failed"
+	msgForeachNoMatch          messageID = "foreach-no-match"
+	msgHookFailed              messageID = "hook-failed"
+	msgImageDeleted            messageID = "image-deleted"
+	msgImageListFailed         messageID = "image-list-failed"
+	msgImageNotManaged         messageID = "image-not-managed"
+	msgImagePullFailed         messageID = "image-pull-failed"
+	msgImageRefresh            messageID = "image-refresh"
+	msgImageRefreshSkipped     messageID = "image-refresh-skipped"
+	msgImageRemoveFailed       messageID = "image-remove-failed"
+	msgImagesPruneFailed       messageID = "images-prune-failed"
+	msgImageUntagged           messageID = "image-untagged"
+	msgImageUpToDate           messageID = "image-up-to-date"
+	msgImageVersionCheck       messageID = "image-version-check-failed"
+	msgImportCycle             messageID = "import-cycle"
+	msgImportFailed            messageID = "import-failed"
+	msgImportInvalid           messageID = "import-invalid"
+	msgIntegrityFailed         messageID = "integrity-failed"
+	msgIntegrityNotSealed      messageID = "integrity-not-sealed"
+	msgInternalError           messageID = "internal-error"
+	msgKubernetesPodNotDeleted messageID = "kubernetes-pod-not-deleted"
+	msgLockTableUnreadable     messageID = "lock-table-unreadable"
+	msgLockUndecodable         messageID = "lock-undecodable"
+	msgLogFileFailed           messageID = "log-file-failed"
+	msgMetadataFailed          messageID = "metadata-failed"
+	msgParameterStoreIgnored   messageID = "parameter-store-ignored"
+	msgPlatformOverrideFailed  messageID = "platform-override-failed"
+	msgPluginFailed            messageID = "plugin-failed"
+	msgPluginIgnored           messageID = "plugin-ignored"
+	msgPluginInstalled         messageID = "plugin-installed"
+	msgMetricsFailed           messageID = "metrics-failed"
+	msgPlanArchived            messageID = "plan-archived"
+	msgPlanArchiveFailed       messageID = "plan-archive-failed"
+	msgPolicyViolation         messageID = "policy-violation"
+	msgProfileConfigFailed     messageID = "profile-config-failed"
+	msgRemoteChangesNotCopied  messageID = "remote-changes-not-copied"
+	msgRemoteConfigCached      messageID = "remote-config-cached"
+	msgRunCancelled            messageID = "run-cancelled"
+	msgSSHContainerNotRemoved  messageID = "ssh-container-not-removed"
+	msgSSOLogin                messageID = "sso-login"
+	msgStateLockUnavailable    messageID = "state-lock-unavailable"
+	msgStateNotReadOnly        messageID = "state-not-read-only"
+	msgTerragruntCacheCleaned  messageID = "terragrunt-cache-cleaned"
+	msgTerragruntCacheFailed   messageID = "terragrunt-cache-failed"
+	msgTerragruntConfigFailed  messageID = "terragrunt-config-failed"
+	msgTimingsFailed           messageID = "timings-failed"
+	msgToolVersionMismatch     messageID = "tool-version-mismatch"
+	msgTracesExportFailed      messageID = "traces-export-failed"
+	msgVersionMismatch         messageID = "version-mismatch"
+	msgWatchChanged            messageID = "watch-changed"
+	msgWebhookFailed           messageID = "webhook-failed"
+	msgWatchWaiting            messageID = "watch-waiting"
+)
+
+// messages is the catalog of the user messages (the text is a fmt format)
+var messages = map[messageID]string{
+	msgAliasRecursive:          "Alias %s is recursive (%s -> %s)",
+	msgAllVersionsUnsupported:  "--all-versions works only with terragrunt, terraform or tofu as the entrypoint",
+	msgAuditLogFailed:          "Unable to record the run in the audit log %s: %v",
+	msgAWSAccountUnresolved:    "Unable to retrieve the current AWS account: %v",
+	msgAWSOverrideFailed:       "Error while applying AWS override (account=%q, profile=%q, region=%q): %v",
+	msgAWSSessionFailed:        "Unable to initialize AWS session: %v",
+	msgCommandFailed:           "%v",
+	msgCommandUsage:            "Usage: %s",
+	msgConfigInvalid:           "%v",
+	msgConfigLintIssues:        "%d issue(s) found in the configuration",
+	msgConfigUnavailable:       "%v",
+	msgConfigWarning:           "%v",
+	msgContainerError:          "%s",
+	msgContainersPruneFailed:   "Error pruning unused containers: %v",
+	msgCostEstimate:            "Estimated cost: %v",
+	msgCostEstimateFailed:      "Unable to estimate the cost of the plan: %v",
+	msgCredentialsError:        "%v",
+	msgCredentialsExpireSoon:   "The AWS credentials expire in %v (at %s), long operations may fail",
+	msgCredentialsUnresolved:   "Unable to resolve AWS credentials: %v",
+	msgDebugBundleFailed:       "Unable to write the debug bundle %s: %v",
+	msgDeprecatedKey:           "Configuration key %s is deprecated (found in %s), use %s instead or run tgf --config-migrate",
+	msgDockerNotDetected:       "Docker does not seem to be available (%v), tgf will not be able to run until it is installed",
+	msgDockerUnavailable:       "%v",
+	msgDryRunBuildSkipped:      "Dry run, the image %s is not built",
+	msgDryRunRefreshSkipped:    "Dry run, the image %s is not refreshed",
+	msgECRLoginRetry:           "Failed to pull %v. It is an ECR image, trying again after a login.",
+	msgError:                   "%v",
+	msgEventsFailed:            "Unable to open the event stream %s: %v",
+	msgFargateLogsUnavailable:  "Unable to read the log stream %s/%s: %v",
+	msgFargateTaskDetached:     "The task %s keeps running, its output is available in the log stream %s/%s",
+	msgFargateCleanupFailed:    "Unable to delete the workspace s3://%s/%s: %v",
+	msgFileReadFailed:          "Unable to read %s: %v",
+	msgFileWriteFailed:         "Unable to write %s: %v",
+	msgFolderMoveFailed:        "Unable to move %s to %s: %v",
+	msgForeachNoMatch:          "No terragrunt folder matches %s",
+	msgHookFailed:              "%v",
+	msgImageDeleted:            "Deleted %s",
+	msgImageListFailed:         "Unable to list the local images of %s: %v",
+	msgImageNotManaged:         "%s is not a local image managed by tgf (see tgf images list)",
+	msgImagePullFailed:         "%v",
+	msgImageRefresh:            "Checking if there is a newer version of docker image %v",
+	msgImageRefreshSkipped:     "Not refreshing %v because `local-image` is set",
+	msgImageRemoveFailed:       "%v",
+	msgImagesPruneFailed:       "Error pruning dangling images (Untagged): %v",
+	msgImageUntagged:           "Untagged %s",
+	msgImageUpToDate:           "Docker image %v is up to date",
+	msgImageVersionCheck:       "Check version for %s vs %s: %v",
+	msgImportCycle:             "Import cycle detected: %s -> %s",
+	msgImportFailed:            "Error while importing %s from %s: %v",
+	msgImportInvalid:           "Invalid %s value %v, it must be a string or a list of strings",
+	msgIntegrityFailed:         "The tgf executable %s has been modified or truncated since its release, reinstall it: %v",
+	msgIntegrityNotSealed:      "The tgf executable %s does not contain its checksum (development build), its integrity cannot be verified",
+	msgInternalError:           "%[1]v (%[1]T)",
+	msgKubernetesPodNotDeleted: "Unable to delete the pod %s, it will be deleted by the cluster once its deadline is reached: %v",
+	msgLockTableUnreadable:     "Unable to read lock table %s: %v",
+	msgLockUndecodable:         "Unable to decode lock information of %s: %v",
+	msgLogFileFailed:           "Unable to open log file %s: %v",
+	msgMetadataFailed:          "Unable to write the run metadata to %s: %v",
+	msgParameterStoreIgnored:   "Unable to read the AWS parameter store %s, it is ignored: %v",
+	msgPlatformOverrideFailed:  "Error while applying platform override (os=%q, arch=%q) from %s: %v",
+	msgPluginFailed:            "%v",
+	msgPluginIgnored:           "Ignoring the plugin %s: %v",
+	msgPluginInstalled:         "The plugin %s has been installed in %s",
+	msgMetricsFailed:           "Unable to send the metrics to %s: %v",
+	msgPlanArchived:            "Plan artifacts archived in %s",
+	msgPlanArchiveFailed:       "Unable to archive the plan artifacts in %s: %v",
+	msgPolicyViolation:         "%v",
+	msgProfileConfigFailed:     "Error while applying configuration of AWS profile %s: %v",
+	msgRemoteChangesNotCopied:  "Unable to copy the files changed by the command back to %s: %v",
+	msgRemoteConfigCached:      "Unable to fetch %s, using cached copy from %s: %v",
+	msgRunCancelled:            "%v",
+	msgSSHContainerNotRemoved:  "Unable to remove the container %s from %s: %v",
+	msgSSOLogin:                "The SSO session of profile %s is expired, starting the login process",
+	msgStateLockUnavailable:    "%v",
+	msgStateNotReadOnly:        "No role is configured (role-arn or role-chain), the state is inspected with the full permissions of the current credentials",
+	msgTerragruntCacheCleaned:  "%d cached folder(s) removed from %s (%s)",
+	msgTerragruntCacheFailed:   "Unable to use the central terragrunt cache %s, the .terragrunt-cache folder is used: %v",
+	msgTerragruntConfigFailed:  "Unable to read terragrunt configuration in %s: %v",
+	msgTimingsFailed:           "Unable to write timings to %s: %v",
+	msgToolVersionMismatch:     "%v",
+	msgTracesExportFailed:      "Unable to export the traces: %v",
+	msgVersionMismatch:         "%v",
+	msgWatchChanged:            "%d file(s) changed (%s), running the command again",
+	msgWebhookFailed:           "Unable to send the %s event to %s: %v",
+	msgWatchWaiting:            "Command exited with code %d, waiting for changes (press Ctrl+C to stop)",
+}
+
+// getMessageFormat returns the text of a message, an ID that is not in the catalog is considered as the text itself
+func (id messageID) getMessageFormat() (format string, known bool) {
+	if format, known = messages[id]; !known {
+		format = string(id)
+	}
+	return
+}
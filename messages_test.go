@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	t.Parallel()
+
+	reID := regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
+	for id, format := range messages {
+		assert.Regexp(t, reID, string(id))
+		assert.NotEmpty(t, format, id)
+	}
+}
+
+func TestGetMessageFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id        messageID
+		want      string
+		wantKnown bool
+	}{
+		{msgImageDeleted, "Deleted %s", true},
+		{msgError, "%v", true},
+		{"Not in the catalog %s", "Not in the catalog %s", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			format, known := tt.id.getMessageFormat()
+			assert.Equal(t, tt.want, format)
+			assert.Equal(t, tt.wantKnown, known)
+		})
+	}
+}
+
+func TestMessageFormatID(t *testing.T) {
+	var buffer bytes.Buffer
+	stderr, noColor := color.Error, color.NoColor
+	color.Error, color.NoColor, messageFormat = &buffer, true, messageFormatID
+	defer func() { color.Error, color.NoColor, messageFormat = stderr, noColor, messageFormatText }()
+
+	printError(msgImageNotManaged, "coveo/tgf:old")
+	printWarning("Not in the catalog")
+	printInfo("docker", nil, msgImageDeleted, "coveo/tgf:old")
+
+	assert.Equal(t, []string{
+		"[image-not-managed] coveo/tgf:old is not a local image managed by tgf (see tgf images list)",
+		"Not in the catalog",
+		"[image-deleted] Deleted coveo/tgf:old",
+	}, strings.Split(strings.TrimSpace(buffer.String()), "\n"))
+}
+
+func TestMessageIDInJSONRecords(t *testing.T) {
+	var buffer bytes.Buffer
+	logOutput, logFormat = &buffer, logFormatJSON
+	defer func() { logOutput, logFormat = os.Stderr, logFormatText }()
+
+	printError(msgImageNotManaged, "coveo/tgf:old")
+	printWarning("Not in the catalog")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+	var record logRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, msgImageNotManaged, record.MessageID)
+	assert.Equal(t, "coveo/tgf:old is not a local image managed by tgf (see tgf images list)", record.Message)
+	assert.NotContains(t, lines[1], "message-id")
+}
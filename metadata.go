@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// runMetadata is the summary of a run written by --metadata-file to attach provenance information to the pipeline artifacts
+type runMetadata struct {
+	TGFVersion  string        `json:"tgf-version"`
+	Image       string        `json:"image,omitempty"`
+	ImageDigest string        `json:"image-digest,omitempty"`
+	EntryPoint  string        `json:"entrypoint"`
+	Arguments   []string      `json:"arguments"`
+	ExitCode    int           `json:"exit-code"`
+	StartTime   string        `json:"start-time"`
+	Duration    float64       `json:"duration"`
+	AWSAccount  string        `json:"aws-account,omitempty"`
+	AWSProfile  string        `json:"aws-profile,omitempty"`
+	WorkingDir  string        `json:"working-dir"`
+	Git         *gitMetadata  `json:"git,omitempty"`
+	Cost        *costEstimate `json:"cost,omitempty"`
+}
+
+// getRunMetadata returns the summary of the run, the image is only reported if the container has been started
+func (config *TGFConfig) getRunMetadata(start time.Time, exitCode int) runMetadata {
+	metadata := runMetadata{
+		TGFVersion: version,
+		Image:      config.runImage,
+		EntryPoint: config.EntryPoint,
+		Arguments:  config.tgf.Unmanaged,
+		ExitCode:   exitCode,
+		StartTime:  start.UTC().Format(time.RFC3339),
+		Duration:   time.Since(start).Seconds(),
+		WorkingDir: must(os.Getwd()).(string),
+		Git:        config.git,
+		Cost:       config.costEstimate,
+	}
+	if metadata.Arguments == nil {
+		metadata.Arguments = []string{}
+	}
+	if config.awsSession != nil {
+		metadata.AWSAccount = config.getAWSAccount()
+		metadata.AWSProfile = config.awsProfile
+	}
+	return metadata
+}
+
+// getRunSummary returns the summary of the run along with the digest of the image used
+func (config *TGFConfig) getRunSummary(start time.Time, exitCode int) runMetadata {
+	metadata := config.getRunMetadata(start, exitCode)
+	if metadata.Image != "" {
+		metadata.ImageDigest = getImageDigest(metadata.Image)
+	}
+	return metadata
+}
+
+// writeMetadata writes the summary of the run as JSON in the file
+func (config *TGFConfig) writeMetadata(filename string, start time.Time, exitCode int) {
+	metadata := config.getRunSummary(start, exitCode)
+	content := must(json.MarshalIndent(metadata, "", "  ")).([]byte)
+	if err := ioutil.WriteFile(filename, append(content, '\n'), 0644); err != nil {
+		printError(msgMetadataFailed, filename, err)
+	}
+}
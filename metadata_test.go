@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRunMetadata(t *testing.T) {
+	app := NewTestApplication([]string{"plan", "-out", "plan.out"})
+	config := &TGFConfig{tgf: app, EntryPoint: "terragrunt", runImage: "coveo/tgf:1.21.0"}
+	start := time.Now().Add(-2 * time.Second)
+
+	metadata := config.getRunMetadata(start, 2)
+	assert.Equal(t, version, metadata.TGFVersion)
+	assert.Equal(t, "coveo/tgf:1.21.0", metadata.Image)
+	assert.Equal(t, []string{"plan", "-out", "plan.out"}, metadata.Arguments)
+	assert.Equal(t, 2, metadata.ExitCode)
+	assert.Equal(t, start.UTC().Format(time.RFC3339), metadata.StartTime)
+	assert.True(t, metadata.Duration >= 2)
+	assert.Equal(t, must(os.Getwd()), metadata.WorkingDir)
+	assert.Empty(t, metadata.AWSAccount, "There is no AWS session")
+}
+
+func TestWriteMetadata(t *testing.T) {
+	folder := must(ioutil.TempDir("", "tgf-metadata")).(string)
+	defer os.RemoveAll(folder)
+	filename := filepath.Join(folder, "metadata.json")
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), EntryPoint: "terragrunt"}
+	config.writeMetadata(filename, time.Now(), 0)
+
+	var result map[string]interface{}
+	assert.NoError(t, json.Unmarshal(must(ioutil.ReadFile(filename)).([]byte), &result))
+	assert.Equal(t, float64(0), result["exit-code"])
+	assert.Equal(t, []interface{}{}, result["arguments"])
+	assert.NotContains(t, result, "image", "The container has not been started")
+	assert.NotContains(t, result, "aws-account")
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsTimeout is the maximum delay to send the metrics of a run
+const metricsTimeout = 10 * time.Second
+
+// MetricsConfig defines where the metrics of each run are sent (Prometheus Pushgateway and/or CloudWatch embedded metric format)
+type MetricsConfig struct {
+	Pushgateway   string            `yaml:"pushgateway,omitempty" json:"pushgateway,omitempty" hcl:"pushgateway,omitempty"`
+	Job           string            `yaml:"job,omitempty" json:"job,omitempty" hcl:"job,omitempty"`
+	CloudWatchEMF string            `yaml:"cloudwatch-emf,omitempty" json:"cloudwatch-emf,omitempty" hcl:"cloudwatch-emf,omitempty"`
+	Namespace     string            `yaml:"cloudwatch-namespace,omitempty" json:"cloudwatch-namespace,omitempty" hcl:"cloudwatch-namespace,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" hcl:"labels,omitempty"`
+}
+
+// runMetric is a value measured on a run, it is named name in Prometheus (with the tgf_run_ prefix) and emfName in CloudWatch
+type runMetric struct {
+	name    string
+	emfName string
+	help    string
+	unit    string
+	value   float64
+}
+
+// runStatistics counts the events of a run that are reported by the metrics
+type runStatistics struct {
+	sync.Mutex
+	pulledBytes int64
+	cacheHits   int
+	cacheMisses int
+}
+
+var runStats = &runStatistics{}
+
+// cacheLookup records the use of a local cache (remote configurations, image lookups, credentials)
+func (stats *runStatistics) cacheLookup(hit bool) {
+	stats.Lock()
+	defer stats.Unlock()
+	if hit {
+		stats.cacheHits++
+	} else {
+		stats.cacheMisses++
+	}
+}
+
+// addPulledBytes records the bytes downloaded while pulling an image
+func (stats *runStatistics) addPulledBytes(size int64) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.pulledBytes += size
+}
+
+// take returns the statistics of the run and resets them for the next run (--watch)
+func (stats *runStatistics) take() (result runStatistics) {
+	stats.Lock()
+	defer stats.Unlock()
+	result.pulledBytes, result.cacheHits, result.cacheMisses = stats.pulledBytes, stats.cacheHits, stats.cacheMisses
+	stats.pulledBytes, stats.cacheHits, stats.cacheMisses = 0, 0, 0
+	return
+}
+
+// enabled returns true if the metrics are sent somewhere
+func (metrics MetricsConfig) enabled() bool {
+	return metrics.Pushgateway != "" || metrics.CloudWatchEMF != ""
+}
+
+// getRunMetrics returns the metrics of the run
+func getRunMetrics(metadata runMetadata, stats *runStatistics) []runMetric {
+	return []runMetric{
+		{"duration_seconds", "Duration", "Duration of the run in seconds", "Seconds", metadata.Duration},
+		{"exit_code", "ExitCode", "Exit code of the run", "None", float64(metadata.ExitCode)},
+		{"image_pull_bytes", "ImagePullBytes", "Bytes downloaded to pull the image", "Bytes", float64(stats.pulledBytes)},
+		{"cache_hits", "CacheHits", "Lookups answered by the local caches", "Count", float64(stats.cacheHits)},
+		{"cache_misses", "CacheMisses", "Lookups that missed the local caches", "Count", float64(stats.cacheMisses)},
+	}
+}
+
+// getMetricLabels returns the labels (dimensions) identifying the run, the configured labels are added to them
+func (metrics MetricsConfig) getMetricLabels(metadata runMetadata) map[string]string {
+	labels := map[string]string{"entrypoint": metadata.EntryPoint}
+	for _, arg := range metadata.Arguments {
+		if !strings.HasPrefix(arg, "-") {
+			labels["command"] = arg
+			break
+		}
+	}
+	if metadata.AWSAccount != "" {
+		labels["aws_account"] = metadata.AWSAccount
+	}
+	for key, value := range metrics.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// sortedKeys returns the keys of the labels in alphabetical order
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getPushgatewayURL returns the URL of the metrics group of the job, the configured labels are part of the grouping key
+func (metrics MetricsConfig) getPushgatewayURL() string {
+	job := metrics.Job
+	if job == "" {
+		job = "tgf"
+	}
+	path := []string{strings.TrimSuffix(metrics.Pushgateway, "/"), "metrics", "job", url.PathEscape(job)}
+	for _, key := range sortedKeys(metrics.Labels) {
+		if value := metrics.Labels[key]; strings.Contains(value, "/") || value == "" {
+			// The values that cannot be part of a path are base64 encoded
+			path = append(path, key+"@base64", base64.RawURLEncoding.EncodeToString([]byte(value)))
+		} else {
+			path = append(path, key, url.PathEscape(value))
+		}
+	}
+	return strings.Join(path, "/")
+}
+
+// getPrometheusPayload returns the metrics in the Prometheus text exposition format
+func (metrics MetricsConfig) getPrometheusPayload(values []runMetric, labels map[string]string) []byte {
+	// The labels of the grouping key must not be repeated in the metrics
+	var formatted []string
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	for _, key := range sortedKeys(labels) {
+		if _, grouping := metrics.Labels[key]; !grouping {
+			formatted = append(formatted, fmt.Sprintf(`%s="%s"`, key, escape.Replace(labels[key])))
+		}
+	}
+	var buffer bytes.Buffer
+	for _, metric := range values {
+		name := "tgf_run_" + metric.name
+		fmt.Fprintf(&buffer, "# HELP %s %s\n# TYPE %s gauge\n%s{%s} %v\n", name, metric.help, name, name, strings.Join(formatted, ","), metric.value)
+	}
+	return buffer.Bytes()
+}
+
+// getEMFPayload returns the metrics as a CloudWatch embedded metric format record
+func (metrics MetricsConfig) getEMFPayload(values []runMetric, labels map[string]string, timestamp time.Time) []byte {
+	namespace := metrics.Namespace
+	if namespace == "" {
+		namespace = "tgf"
+	}
+	record := map[string]interface{}{}
+	definitions := make([]map[string]string, 0, len(values))
+	for _, metric := range values {
+		record[metric.emfName] = metric.value
+		definitions = append(definitions, map[string]string{"Name": metric.emfName, "Unit": metric.unit})
+	}
+	for key, value := range labels {
+		record[key] = value
+	}
+	record["_aws"] = map[string]interface{}{
+		"Timestamp": timestamp.UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  namespace,
+			"Dimensions": [][]string{sortedKeys(labels)},
+			"Metrics":    definitions,
+		}},
+	}
+	return must(json.Marshal(record)).([]byte)
+}
+
+// pushMetrics sends the metrics to the Pushgateway (the metrics with the same name in the group of the job are replaced)
+func (metrics MetricsConfig) pushMetrics(payload []byte) error {
+	request, err := http.NewRequest(http.MethodPost, metrics.getPushgatewayURL(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	response, err := newHTTPClient(metricsTimeout).Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s", response.Status)
+	}
+	return nil
+}
+
+// writeEMF sends the record to the CloudWatch agent (tcp:// or udp:// address) or appends it to a file (i.e. a log file collected
+// by the agent)
+func (metrics MetricsConfig) writeEMF(payload []byte) error {
+	payload = append(payload, '\n')
+	if parsed, err := url.Parse(metrics.CloudWatchEMF); err == nil && (parsed.Scheme == "tcp" || parsed.Scheme == "udp") {
+		connection, err := net.DialTimeout(parsed.Scheme, parsed.Host, metricsTimeout)
+		if err != nil {
+			return err
+		}
+		defer connection.Close()
+		connection.SetWriteDeadline(time.Now().Add(metricsTimeout))
+		_, err = connection.Write(payload)
+		return err
+	}
+	file, err := os.OpenFile(metrics.CloudWatchEMF, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(payload)
+	return err
+}
+
+// sendMetrics sends the metrics of the run once it is completed, a failure to send them does not change the result of the run
+func (config *TGFConfig) sendMetrics(start time.Time, exitCode int) {
+	metadata, stats := config.getRunMetadata(start, exitCode), runStats.take()
+	values, labels := getRunMetrics(metadata, &stats), config.Metrics.getMetricLabels(metadata)
+	if config.Metrics.Pushgateway != "" {
+		if err := config.Metrics.pushMetrics(config.Metrics.getPrometheusPayload(values, labels)); err != nil {
+			printWarning(msgMetricsFailed, getWebhookHost(config.Metrics.Pushgateway), err)
+		} else {
+			config.tgf.Debug("# Metrics pushed to %s", getWebhookHost(config.Metrics.Pushgateway))
+		}
+	}
+	if config.Metrics.CloudWatchEMF != "" {
+		if err := config.Metrics.writeEMF(config.Metrics.getEMFPayload(values, labels, time.Now())); err != nil {
+			printWarning(msgMetricsFailed, config.Metrics.CloudWatchEMF, err)
+		} else {
+			config.tgf.Debug("# Metrics sent to %s", config.Metrics.CloudWatchEMF)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPushgatewayURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		metrics MetricsConfig
+		want    string
+	}{
+		{"Default job", MetricsConfig{Pushgateway: "http://pushgateway:9091/"}, "http://pushgateway:9091/metrics/job/tgf"},
+		{"Job", MetricsConfig{Pushgateway: "http://pushgateway:9091", Job: "infra live"}, "http://pushgateway:9091/metrics/job/infra%20live"},
+		{"Grouping labels", MetricsConfig{Pushgateway: "http://pushgateway:9091", Labels: map[string]string{"team": "platform", "repo": "coveo/infra"}}, "http://pushgateway:9091/metrics/job/tgf/repo@base64/Y292ZW8vaW5mcmE/team/platform"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.metrics.getPushgatewayURL())
+		})
+	}
+}
+
+func TestMetricsPayloads(t *testing.T) {
+	t.Parallel()
+
+	metrics := MetricsConfig{Namespace: "Infra", Labels: map[string]string{"team": "platform"}}
+	metadata := runMetadata{EntryPoint: "terragrunt", Arguments: []string{"--terragrunt-working-dir", "plan"}, ExitCode: 1, Duration: 12.5, AWSAccount: "123456789012"}
+	labels := metrics.getMetricLabels(metadata)
+	assert.Equal(t, map[string]string{"entrypoint": "terragrunt", "command": "plan", "aws_account": "123456789012", "team": "platform"}, labels)
+	values := getRunMetrics(metadata, &runStatistics{pulledBytes: 2048, cacheHits: 3, cacheMisses: 1})
+
+	prometheus := string(metrics.getPrometheusPayload(values[:2], labels))
+	assert.Equal(t, String(`
+		# HELP tgf_run_duration_seconds Duration of the run in seconds
+		# TYPE tgf_run_duration_seconds gauge
+		tgf_run_duration_seconds{aws_account="123456789012",command="plan",entrypoint="terragrunt"} 12.5
+		# HELP tgf_run_exit_code Exit code of the run
+		# TYPE tgf_run_exit_code gauge
+		tgf_run_exit_code{aws_account="123456789012",command="plan",entrypoint="terragrunt"} 1
+	`).UnIndent().TrimSpace().Str(), strings.TrimSpace(prometheus))
+
+	var emf map[string]interface{}
+	assert.NoError(t, json.Unmarshal(metrics.getEMFPayload(values, labels, time.Unix(1600000000, 0)), &emf))
+	assert.Equal(t, float64(2048), emf["ImagePullBytes"])
+	assert.Equal(t, float64(3), emf["CacheHits"])
+	assert.Equal(t, "platform", emf["team"])
+	definition := emf["_aws"].(map[string]interface{})
+	assert.Equal(t, float64(1600000000000), definition["Timestamp"])
+	cloudWatch := definition["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Infra", cloudWatch["Namespace"])
+	assert.Equal(t, []interface{}{[]interface{}{"aws_account", "command", "entrypoint", "team"}}, cloudWatch["Dimensions"])
+	assert.Contains(t, cloudWatch["Metrics"], map[string]interface{}{"Name": "Duration", "Unit": "Seconds"})
+}
+
+func TestSendMetrics(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, body = r.URL.Path, string(must(ioutil.ReadAll(r.Body)).([]byte))
+	}))
+	defer server.Close()
+	tempDir := must(ioutil.TempDir("", "TestSendMetrics")).(string)
+	defer os.RemoveAll(tempDir)
+	emfFile := filepath.Join(tempDir, "emf.log")
+
+	runStats.take()
+	runStats.cacheLookup(true)
+	runStats.cacheLookup(false)
+	runStats.addPulledBytes(1024)
+	config := &TGFConfig{tgf: NewTestApplication([]string{"apply"}), EntryPoint: "terragrunt", Metrics: MetricsConfig{Pushgateway: server.URL, CloudWatchEMF: emfFile}}
+	config.sendMetrics(time.Now(), 2)
+
+	assert.Equal(t, "/metrics/job/tgf", path)
+	assert.Contains(t, body, `tgf_run_exit_code{command="apply",entrypoint="terragrunt"} 2`)
+	assert.Contains(t, body, `tgf_run_image_pull_bytes{command="apply",entrypoint="terragrunt"} 1024`)
+	assert.Contains(t, body, `tgf_run_cache_hits{command="apply",entrypoint="terragrunt"} 1`)
+	lines := strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(emfFile)).([]byte))), "\n")
+	assert.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"CacheMisses":1`)
+	assert.Equal(t, runStatistics{}, runStats.take(), "The statistics are reset for the next run")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// getNotificationCommand returns the command displaying a desktop notification on the operating system (nil if it is not supported)
+func getNotificationCommand(goos, title, message string) []string {
+	switch goos {
+	case "darwin":
+		quote := func(s string) string {
+			return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+		}
+		return []string{"osascript", "-e", fmt.Sprintf("display notification %s with title %s", quote(message), quote(title))}
+	case "linux":
+		return []string{"notify-send", title, message}
+	case "windows":
+		quote := func(s string) string { return "'" + strings.Replace(s, "'", "''", -1) + "'" }
+		script := "Add-Type -AssemblyName System.Windows.Forms; $icon = New-Object System.Windows.Forms.NotifyIcon; " +
+			"$icon.Icon = [System.Drawing.SystemIcons]::Information; $icon.Visible = $true; " +
+			fmt.Sprintf("$icon.ShowBalloonTip(10000, %s, %s, 'Info'); Start-Sleep -Seconds 10; $icon.Dispose()", quote(title), quote(message))
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	return nil
+}
+
+// getCompletionMessage returns the text of the notification sent once a run is completed
+func getCompletionMessage(entryPoint string, args []string, elapsed time.Duration, exitCode int) string {
+	command := strings.TrimSpace(filepath.Base(entryPoint) + " " + strings.Join(args, " "))
+	status := "completed"
+	if exitCode != 0 {
+		status = fmt.Sprintf("failed (exit code %d)", exitCode)
+	}
+	return fmt.Sprintf("%s %s in %v", command, status, elapsed.Round(time.Second))
+}
+
+// notifyCompletion emits a desktop notification if the run has lasted more than the configured delay.
+// A terminal bell is emitted if the desktop notification cannot be displayed.
+func (config *TGFConfig) notifyCompletion(elapsed time.Duration, exitCode int) {
+	if elapsed < config.tgf.NotifyAfter {
+		return
+	}
+	message := getCompletionMessage(config.EntryPoint, config.tgf.Unmanaged, elapsed, exitCode)
+	if command := getNotificationCommand(runtime.GOOS, "tgf", message); command != nil {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			notification := exec.Command(command[0], command[1:]...)
+			if runtime.GOOS == "windows" {
+				// The balloon is removed when powershell exits, tgf does not wait for it
+				err = notification.Start()
+			} else {
+				err = notification.Run()
+			}
+			if err == nil {
+				return
+			}
+			config.tgf.Debug("# Unable to display the desktop notification: %v", err)
+		}
+	}
+	if isTerminal(os.Stderr) {
+		ErrPrintf("\a")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNotificationCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		goos    string
+		message string
+		want    []string
+	}{
+		{"darwin", "plan completed", []string{"osascript", "-e", `display notification "plan completed" with title "tgf"`}},
+		{"darwin", `say "hi"`, []string{"osascript", "-e", `display notification "say \"hi\"" with title "tgf"`}},
+		{"linux", "plan completed", []string{"notify-send", "tgf", "plan completed"}},
+		{"windows", "it's done", []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", "Add-Type -AssemblyName System.Windows.Forms; " +
+			"$icon = New-Object System.Windows.Forms.NotifyIcon; $icon.Icon = [System.Drawing.SystemIcons]::Information; $icon.Visible = $true; " +
+			"$icon.ShowBalloonTip(10000, 'tgf', 'it''s done', 'Info'); Start-Sleep -Seconds 10; $icon.Dispose()"}},
+		{"plan9", "plan completed", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			assert.Equal(t, tt.want, getNotificationCommand(tt.goos, "tgf", tt.message))
+		})
+	}
+}
+
+func TestGetCompletionMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "terragrunt apply completed in 40m12s", getCompletionMessage("/usr/bin/terragrunt", []string{"apply"}, 40*time.Minute+12400*time.Millisecond, 0))
+	assert.Equal(t, "terraform failed (exit code 1) in 5s", getCompletionMessage("terraform", nil, 5*time.Second, 1))
+}
+
+func TestNotifyAfterFlag(t *testing.T) {
+	app := NewTestApplication([]string{"--notify-after", "10m", "apply"})
+	assert.Equal(t, 10*time.Minute, app.NotifyAfter)
+	assert.Equal(t, []string{"apply"}, app.Unmanaged)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const appFolderName = "tgf"
+
+// getHomeFolder returns the home folder of the current user
+func getHomeFolder() string {
+	return must(user.Current()).(*user.User).HomeDir
+}
+
+// getXDGFolder returns the folder defined by the XDG environment variable or its default value relative to the home folder.
+// On Windows, %LOCALAPPDATA%\tgf\<windowsFolder> is used as default.
+func getXDGFolder(envVar, defaultFolder, windowsFolder string) string {
+	if folder := os.Getenv(envVar); filepath.IsAbs(folder) {
+		return filepath.Join(folder, appFolderName)
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); runtime.GOOS == "windows" && localAppData != "" {
+		return filepath.Join(localAppData, appFolderName, windowsFolder)
+	}
+	return filepath.Join(getHomeFolder(), defaultFolder, appFolderName)
+}
+
+// getConfigFolder returns the folder containing the user level configuration ($XDG_CONFIG_HOME/tgf)
+func getConfigFolder() string { return getXDGFolder("XDG_CONFIG_HOME", ".config", "") }
+
+// getUserConfigFile returns the user level configuration file applied to all projects
+func getUserConfigFile() string { return filepath.Join(getConfigFolder(), userConfigFile) }
+
+// getLegacyTGFFolder returns the folder used by previous versions of tgf to store its state
+func getLegacyTGFFolder() string { return filepath.Join(getHomeFolder(), ".tgf") }
+
+var migrateCacheFolder sync.Once
+
+// getCacheFolder returns the folder where tgf keeps its state and cached files ($XDG_CACHE_HOME/tgf, %LOCALAPPDATA%\tgf\cache on
+// Windows to keep the cache apart from the configuration). On first use, the legacy ~/.tgf folder is moved to the new location.
+func getCacheFolder() string {
+	folder := getXDGFolder("XDG_CACHE_HOME", ".cache", "cache")
+	migrateCacheFolder.Do(func() {
+		legacy := getLegacyTGFFolder()
+		if _, err := os.Stat(legacy); err != nil {
+			return
+		}
+		if _, err := os.Stat(folder); err == nil {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(folder), 0755); err == nil {
+			if err := os.Rename(legacy, folder); err != nil {
+				printWarning(msgFolderMoveFailed, legacy, folder, err)
+			}
+		}
+	})
+	return folder
+}
+
+// printPaths displays the folders and files used by tgf
+func printPaths() int {
+	cache := getCacheFolder()
+	Printf("Configuration folder:     %s\n", getConfigFolder())
+	Printf("User configuration file:  %s\n", getUserConfigFile())
+	Printf("Cache folder:             %s\n", cache)
+	Printf("Remote configuration:     %s\n", filepath.Dir(getRemoteConfigCacheFile("")))
+	Printf("Log file (--log-to-file): %s\n", getLogFile())
+	Printf("Plugins folder:           %s\n", getPluginsFolder())
+	return 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetXDGFolder(t *testing.T) {
+	defer os.Unsetenv("TGF_TEST_XDG")
+	xdg := filepath.Join(os.TempDir(), "xdg")
+	defaultFolder := filepath.Join(getHomeFolder(), ".test", appFolderName)
+	if localAppData := os.Getenv("LOCALAPPDATA"); runtime.GOOS == "windows" && localAppData != "" {
+		defaultFolder = filepath.Join(localAppData, appFolderName, "test")
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Defined", xdg, filepath.Join(xdg, appFolderName)},
+		{"Undefined", "", defaultFolder},
+		{"Relative paths are ignored", "relative", defaultFolder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("TGF_TEST_XDG", tt.value)
+			assert.Equal(t, tt.want, getXDGFolder("TGF_TEST_XDG", ".test", "test"))
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Names of the artifacts added to the plan files
+const (
+	planOutputArtifact   = "plan.log"
+	planMetadataArtifact = "metadata.json"
+)
+
+// defaultPlanFiles are the patterns of the plan files collected if none are configured
+var defaultPlanFiles = []string{"*.tfplan", "tfplan", "*.plan", "plan.out", "*.tfplan.json"}
+
+// PlanArchiveConfig defines where the output and the files of the plans are archived
+type PlanArchiveConfig struct {
+	Destination string   `yaml:"destination,omitempty" json:"destination,omitempty" hcl:"destination,omitempty"`
+	Files       []string `yaml:"files,omitempty" json:"files,omitempty" hcl:"files,omitempty"`
+}
+
+// outputCapture keeps a copy of the output of the command
+type outputCapture struct {
+	sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (capture *outputCapture) Write(p []byte) (int, error) {
+	capture.Lock()
+	defer capture.Unlock()
+	return capture.buffer.Write(p)
+}
+
+// content returns the captured output without the terminal escape sequences
+func (capture *outputCapture) content() []byte {
+	capture.Lock()
+	defer capture.Unlock()
+	return reANSIEscape.ReplaceAll(capture.buffer.Bytes(), nil)
+}
+
+// isPlanCommand returns true if the arguments run a plan (plan, run-all plan or plan-all)
+func isPlanCommand(args []string) bool {
+	for _, arg := range args {
+		if arg == "plan" || arg == "plan-all" {
+			return true
+		}
+	}
+	return false
+}
+
+// getCommandStdout returns the writer given as stdout to the entry point, the output is also scanned for the GitHub Actions summary
+// and captured if the plan artifacts are archived or if a PR comment is written
+func (config *TGFConfig) getCommandStdout(stdout *os.File) io.Writer {
+	app := config.tgf
+	if app.PRComment {
+		// The comment replaces the output of the command on stdout, the output is still printed on stderr
+		stdout = os.Stderr
+	}
+	output := config.getGitHubStdout(stdout)
+	if !config.isPlanArchived() && !app.PRComment && app.PRCommentFile == "" {
+		return output
+	}
+	config.commandOutput = &outputCapture{}
+	return io.MultiWriter(output, config.commandOutput)
+}
+
+// isPlanArchived returns true if the artifacts of the command are archived (only for the plans)
+func (config *TGFConfig) isPlanArchived() bool {
+	return config.PlanArtifacts.Destination != "" && isPlanCommand(config.tgf.Unmanaged)
+}
+
+// getPlanFolders returns the host folders where the plan files may be written: the working folder and the terragrunt caches mounted
+// in the container (--temp, terragrunt-cache: central)
+func (config *TGFConfig) getPlanFolders(workingDir string) []string {
+	folders := []string{workingDir}
+	for _, folder := range []string{config.hostCacheFolder, config.terragruntCacheFolder} {
+		if folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+	return folders
+}
+
+// findPlanFiles returns the plan files written in the folders since the beginning of the run (the terragrunt cache folders are
+// searched too since terragrunt runs terraform in them), the files are identified by their path relative to their folder
+func findPlanFiles(patterns []string, since time.Time, folders ...string) map[string]string {
+	if len(patterns) == 0 {
+		patterns = defaultPlanFiles
+	}
+	files := make(map[string]string)
+	for _, folder := range folders {
+		filepath.Walk(folder, func(filename string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); filename != folder && (name == ".git" || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.ModTime().Before(since) {
+				return nil
+			}
+			for _, pattern := range patterns {
+				if matched, _ := filepath.Match(pattern, info.Name()); matched {
+					relative := must(filepath.Rel(folder, filename)).(string)
+					files[filepath.ToSlash(relative)] = filename
+					break
+				}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// getPlanArtifactsFolder returns the location of the artifacts of the run relative to the destination, the artifacts are grouped by
+// folder (relative to the git repository) and by run
+func getPlanArtifactsFolder(workingDir string, start time.Time) string {
+	return path.Join(getRepositoryFolder(workingDir), start.UTC().Format("20060102T150405Z"))
+}
+
+// getRepositoryFolder returns the folder prefixed by the name of its git repository (i.e. infra/live/dev), the name of the folder
+// is returned if it is not in a git repository
+func getRepositoryFolder(workingDir string) string {
+	folder := filepath.Base(workingDir)
+	if root := findGitRoot(workingDir); root != "" {
+		if relative, err := filepath.Rel(root, workingDir); err == nil {
+			folder = filepath.Join(filepath.Base(root), relative)
+		}
+	}
+	return filepath.ToSlash(folder)
+}
+
+// planArtifactsWriter stores an artifact at a location relative to the destination
+type planArtifactsWriter func(name string, content io.Reader) error
+
+// getPlanArtifactsWriter returns the function storing the artifacts in S3 or in a local folder (i.e. the artifacts folder of the CI)
+func (config *TGFConfig) getPlanArtifactsWriter(destination string, metadata runMetadata) (planArtifactsWriter, error) {
+	if !strings.HasPrefix(destination, "s3://") {
+		if strings.HasPrefix(destination, "~/") {
+			destination = filepath.Join(getHomeFolder(), destination[2:])
+		}
+		return func(name string, content io.Reader) error {
+			filename := filepath.Join(destination, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+				return err
+			}
+			file, err := os.Create(filename)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(file, content)
+			return err
+		}, nil
+	}
+
+	location := strings.SplitN(strings.TrimPrefix(destination, "s3://"), "/", 2)
+	bucket, prefix := location[0], ""
+	if len(location) == 2 {
+		prefix = location[1]
+	}
+	if err := config.ensureAWSSession(); err != nil || config.awsSession == nil {
+		return nil, fmt.Errorf("An AWS session is required to upload to %s: %v", destination, err)
+	}
+	uploader := s3manager.NewUploader(config.awsSession.Copy(awsRetryConfig()))
+	// The objects are tagged with the description of the run so they can be found without reading the metadata file
+	objectMetadata := map[string]*string{
+		"tgf-entrypoint": aws.String(metadata.EntryPoint),
+		"tgf-arguments":  aws.String(strings.Join(metadata.Arguments, " ")),
+		"tgf-exit-code":  aws.String(fmt.Sprint(metadata.ExitCode)),
+	}
+	if metadata.AWSAccount != "" {
+		objectMetadata["tgf-aws-account"] = aws.String(metadata.AWSAccount)
+	}
+	return func(name string, content io.Reader) error {
+		_, err := uploader.Upload(&s3manager.UploadInput{
+			Bucket:               aws.String(bucket),
+			Key:                  aws.String(path.Join(prefix, name)),
+			Body:                 content,
+			Metadata:             objectMetadata,
+			ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+		})
+		return describeAWSError(err)
+	}, nil
+}
+
+// archivePlanArtifacts stores the output of the plan, the plan files and the metadata of the run at the configured destination, a
+// failure to archive them does not change the result of the run
+func (config *TGFConfig) archivePlanArtifacts(start time.Time, exitCode int) {
+	metadata := config.getRunMetadata(start, exitCode)
+	destination := strings.TrimSuffix(config.PlanArtifacts.Destination, "/")
+	folder := getPlanArtifactsFolder(metadata.WorkingDir, start)
+	err := func() error {
+		write, err := config.getPlanArtifactsWriter(destination, metadata)
+		if err != nil {
+			return err
+		}
+		if err := write(path.Join(folder, planOutputArtifact), bytes.NewReader(config.commandOutput.content())); err != nil {
+			return err
+		}
+		files := findPlanFiles(config.PlanArtifacts.Files, start, config.getPlanFolders(metadata.WorkingDir)...)
+		for name, filename := range files {
+			content, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			if err := write(path.Join(folder, name), bytes.NewReader(content)); err != nil {
+				return err
+			}
+		}
+		config.tgf.Debug("# %d plan file(s) archived", len(files))
+		return write(path.Join(folder, planMetadataArtifact), bytes.NewReader(must(json.MarshalIndent(metadata, "", "  ")).([]byte)))
+	}()
+	if err != nil {
+		printWarning(msgPlanArchiveFailed, destination, err)
+		return
+	}
+	printInfo("tgf", map[string]interface{}{"destination": destination + "/" + folder}, msgPlanArchived, destination+"/"+folder)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPlanCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"plan", "-out", "plan.out"}, true},
+		{[]string{"run-all", "plan"}, true},
+		{[]string{"plan-all"}, true},
+		{[]string{"apply", "plan.out"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			assert.Equal(t, tt.want, isPlanCommand(tt.args))
+		})
+	}
+}
+
+func TestFindPlanFiles(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestFindPlanFiles")).(string)
+	defer os.RemoveAll(tempDir)
+	start := time.Now().Add(-time.Minute)
+	write := func(name string, modified time.Time) {
+		filename := filepath.Join(tempDir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(name), 0644))
+		assert.NoError(t, os.Chtimes(filename, modified, modified))
+	}
+	write("plan.out", time.Now())
+	write(".terragrunt-cache/abc/def/network.tfplan", time.Now())
+	write("old.tfplan", start.Add(-time.Hour))
+	write("main.tf", time.Now())
+	write(".git/plan.out", time.Now())
+
+	assert.Equal(t, map[string]string{
+		"plan.out": filepath.Join(tempDir, "plan.out"),
+		".terragrunt-cache/abc/def/network.tfplan": filepath.Join(tempDir, ".terragrunt-cache", "abc", "def", "network.tfplan"),
+	}, findPlanFiles(nil, start, tempDir))
+	assert.Equal(t, map[string]string{"main.tf": filepath.Join(tempDir, "main.tf")}, findPlanFiles([]string{"*.tf"}, start, tempDir))
+}
+
+func TestArchivePlanArtifacts(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestArchivePlanArtifacts")).(string)
+	defer os.RemoveAll(tempDir)
+	workingDir, destination := filepath.Join(tempDir, "infra", "live", "dev"), filepath.Join(tempDir, "artifacts")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, "infra", ".git"), 0755))
+	assert.NoError(t, os.MkdirAll(workingDir, 0755))
+	defer os.Chdir(must(os.Getwd()).(string))
+	os.Chdir(workingDir)
+	start := time.Now().Add(-time.Second)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workingDir, "plan.out"), []byte("binary plan"), 0644))
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan", "-out", "plan.out"}), EntryPoint: "terragrunt", PlanArtifacts: PlanArchiveConfig{Destination: destination}}
+	stdout := config.getCommandStdout(os.Stdout)
+	assert.NotNil(t, config.commandOutput)
+	stdout.Write([]byte("\x1b[1mPlan:\x1b[0m 1 to add, 0 to change, 0 to destroy.\n"))
+	config.archivePlanArtifacts(start, 2)
+
+	folder := filepath.Join(destination, "infra", "live", "dev", start.UTC().Format("20060102T150405Z"))
+	read := func(name string) string {
+		return string(must(ioutil.ReadFile(filepath.Join(folder, name))).([]byte))
+	}
+	assert.Equal(t, "Plan: 1 to add, 0 to change, 0 to destroy.\n", read(planOutputArtifact))
+	assert.Equal(t, "binary plan", read("plan.out"))
+	assert.Contains(t, read(planMetadataArtifact), `"exit-code": 2`)
+
+	config = &TGFConfig{tgf: NewTestApplication([]string{"apply"}), PlanArtifacts: PlanArchiveConfig{Destination: destination}}
+	assert.Equal(t, os.Stdout, config.getCommandStdout(os.Stdout), "The output is only captured for the plans")
+	assert.Nil(t, config.commandOutput)
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/go-getter"
+)
+
+// The plugins are executables named tgf-plugin-<name> in the plugins folder. tgf runs them with a JSON request on stdin and reads
+// a JSON response on stdout ({"result": ..., "error": "..."}), stderr is shown to the user. The runner plugins receive the request
+// in the file named by TGF_PLUGIN_REQUEST instead, their stdin is the one of tgf.
+const (
+	pluginPrefix          = "tgf-plugin-"
+	pluginProtocol        = 1
+	pluginRequestVariable = "TGF_PLUGIN_REQUEST"
+)
+
+// Capabilities declared by the plugins in their description, they are also the methods called by tgf
+const (
+	pluginConfig      = "config"
+	pluginCredentials = "credentials"
+	pluginRunner      = "runner"
+)
+
+// pluginInfo is the description returned by a plugin to the describe request
+type pluginInfo struct {
+	Name         string   `json:"name,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	path         string
+}
+
+type pluginRequest struct {
+	Protocol   int         `json:"protocol"`
+	TGFVersion string      `json:"tgf-version"`
+	Method     string      `json:"method"`
+	Params     interface{} `json:"params,omitempty"`
+}
+
+type pluginResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// pluginCacheEntry is the description of a plugin kept between runs, the plugin is described again if the executable changes
+type pluginCacheEntry struct {
+	ModTime time.Time  `json:"mod-time"`
+	Size    int64      `json:"size"`
+	Info    pluginInfo `json:"info"`
+}
+
+// getPluginsFolder returns the folder where the plugins are installed ($XDG_CONFIG_HOME/tgf/plugins)
+func getPluginsFolder() string { return filepath.Join(getConfigFolder(), "plugins") }
+
+func getPluginsCacheFile() string { return filepath.Join(getCacheFolder(), "plugins.json") }
+
+// getPluginName returns the name of the plugin from its executable name (or an empty string if it is not a plugin)
+func getPluginName(filename string) string {
+	if !strings.HasPrefix(filename, pluginPrefix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(filename, pluginPrefix), ".exe")
+}
+
+// supports returns true if the plugin has declared the capability
+func (plugin pluginInfo) supports(capability string) bool {
+	for _, supported := range plugin.Capabilities {
+		if supported == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// getPluginRequest returns the JSON request sent to a plugin
+func getPluginRequest(method string, params interface{}) []byte {
+	return append(must(json.Marshal(pluginRequest{pluginProtocol, version, method, params})).([]byte), '\n')
+}
+
+// command returns the command sending the request to the plugin
+func (plugin pluginInfo) command(method string, params interface{}) *exec.Cmd {
+	cmd := exec.Command(plugin.path)
+	cmd.Stdin, cmd.Stderr = bytes.NewReader(getPluginRequest(method, params)), os.Stderr
+	return cmd
+}
+
+// runnerCommand returns the command running the plugin as a runner. The request is written in a file only readable by the user (it
+// contains the environment of the run) and the stdin of tgf is forwarded to the plugin, so the prompts of the command (i.e. apply
+// confirmation) can be answered. The returned function deletes the request file.
+func (plugin pluginInfo) runnerCommand(params interface{}) (*exec.Cmd, func(), error) {
+	file, err := ioutil.TempFile("", "tgf-plugin-request")
+	if err != nil {
+		return nil, nil, err
+	}
+	remove := func() { os.Remove(file.Name()) }
+	_, err = file.Write(getPluginRequest(pluginRunner, params))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+	cmd := exec.Command(plugin.path)
+	cmd.Env = append(os.Environ(), pluginRequestVariable+"="+file.Name())
+	cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+	return cmd, remove, nil
+}
+
+// call sends the request to the plugin and decodes its result
+func (plugin pluginInfo) call(method string, params, result interface{}) error {
+	output, err := plugin.command(method, params).Output()
+	if err != nil {
+		return fmt.Errorf("The plugin %s has failed on %s: %v", plugin.Name, method, err)
+	}
+	var response pluginResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return fmt.Errorf("The plugin %s has returned an invalid response to %s: %v", plugin.Name, method, err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("The plugin %s has failed on %s: %s", plugin.Name, method, response.Error)
+	}
+	if result != nil && len(response.Result) > 0 {
+		return json.Unmarshal(response.Result, result)
+	}
+	return nil
+}
+
+var (
+	installedPlugins     []pluginInfo
+	installedPluginsOnce sync.Once
+)
+
+// getInstalledPlugins returns the installed plugins, the plugins folder is only scanned once per process
+func getInstalledPlugins() []pluginInfo {
+	installedPluginsOnce.Do(func() {
+		installedPlugins = discoverPlugins()
+	})
+	return installedPlugins
+}
+
+// resetInstalledPlugins forces the plugins folder to be scanned again (once a plugin is installed or removed)
+func resetInstalledPlugins() { installedPluginsOnce = sync.Once{} }
+
+// discoverPlugins returns the plugins installed in the plugins folder sorted by name, the plugins are only described when they are
+// installed or updated (the descriptions are cached)
+func discoverPlugins() (plugins []pluginInfo) {
+	folder := getPluginsFolder()
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+	cache := make(map[string]pluginCacheEntry)
+	if content, err := ioutil.ReadFile(getPluginsCacheFile()); err == nil {
+		json.Unmarshal(content, &cache)
+	}
+	updated := false
+	for _, file := range files {
+		name := getPluginName(file.Name())
+		if name == "" || file.IsDir() || (runtime.GOOS != "windows" && file.Mode()&0111 == 0) {
+			continue
+		}
+		path := filepath.Join(folder, file.Name())
+		entry, found := cache[path]
+		if !found || !entry.ModTime.Equal(file.ModTime()) || entry.Size != file.Size() {
+			entry = pluginCacheEntry{ModTime: file.ModTime(), Size: file.Size()}
+			if err := (pluginInfo{Name: name, path: path}).call("describe", nil, &entry.Info); err != nil {
+				printWarning(msgPluginIgnored, path, err)
+				continue
+			}
+			cache[path], updated = entry, true
+		}
+		entry.Info.Name, entry.Info.path = name, path
+		plugins = append(plugins, entry.Info)
+	}
+	if updated {
+		writeFileAtomic(getPluginsCacheFile(), must(json.MarshalIndent(cache, "", "  ")).([]byte), 0644)
+	}
+	return
+}
+
+// findPlugin returns the plugin with the name if it is installed and supports the capability
+func findPlugin(name, capability string) (pluginInfo, bool) {
+	for _, plugin := range getInstalledPlugins() {
+		if plugin.Name == name && plugin.supports(capability) {
+			return plugin, true
+		}
+	}
+	return pluginInfo{}, false
+}
+
+// getPluginNames returns the names of the installed plugins supporting the capability
+func getPluginNames(capability string) (names []string) {
+	for _, plugin := range getInstalledPlugins() {
+		if plugin.supports(capability) {
+			names = append(names, plugin.Name)
+		}
+	}
+	return
+}
+
+// readPluginConfigs returns the configurations supplied by the config plugins, they have precedence over the remote configurations
+// but not over the configuration files
+func (config *TGFConfig) readPluginConfigs() (configsData []configData) {
+	for _, plugin := range getInstalledPlugins() {
+		if !plugin.supports(pluginConfig) {
+			continue
+		}
+		var result struct {
+			Config string `json:"config"`
+		}
+		params := map[string]string{"working-dir": must(os.Getwd()).(string)}
+		if err := plugin.call(pluginConfig, params, &result); err != nil {
+			printWarning(msgPluginFailed, err)
+			continue
+		}
+		if strings.TrimSpace(result.Config) != "" {
+			configsData = append(configsData, configData{Name: "Plugin/" + plugin.Name, Raw: result.Config})
+		}
+	}
+	return
+}
+
+// pluginCredentialSource resolves the credentials with a plugin (credential-sources refers to the plugin by its name)
+type pluginCredentialSource struct{ plugin pluginInfo }
+
+func (source pluginCredentialSource) resolve(config *TGFConfig) (map[string]string, []string, error) {
+	var result struct {
+		Environment map[string]string `json:"environment"`
+		Volumes     []string          `json:"volumes"`
+	}
+	params := map[string]string{"working-dir": must(os.Getwd()).(string), "aws-profile": config.awsProfile}
+	if err := source.plugin.call(pluginCredentials, params, &result); err != nil {
+		return nil, nil, err
+	}
+	return result.Environment, result.Volumes, nil
+}
+
+// runPlugin runs the command with a runner plugin and returns the exit code of the command. The plugin receives the run in its
+// request and its output is the output of the command (it does not return a JSON response).
+func (config *TGFConfig) runPlugin(plugin pluginInfo, run remoteRun) int {
+	app := config.tgf
+	params := map[string]interface{}{
+		"image":       run.image,
+		"command":     run.command,
+		"environment": run.environment,
+		"root":        run.root,
+		"remote-root": run.remoteRoot,
+		"working-dir": run.workdir,
+		"script":      run.getScript(),
+	}
+	if app.DryRun {
+		fmt.Fprintf(os.Stdout, "# Command run by the plugin %s (%s)\n", plugin.Name, plugin.path)
+		writeDryRun(os.Stdout, run.command, run.environment, config.runBeforeCommands, config.runAfterCommands, config.Hooks)
+		return 0
+	}
+
+	lifecycle := &runLifecycle{config: config, component: "plugin", started: "Starting plugin runner", exited: "Plugin runner exited"}
+	return lifecycle.execute(func() int {
+		command, remove, err := plugin.runnerCommand(params)
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to write the request of the plugin %s: %v", plugin.Name, err))
+		}
+		defer remove()
+		command.Stdout = lifecycle.stdout
+		exitCode, err := lifecycle.runCommand(run.image, run.command, run.span, map[string]interface{}{"plugin": plugin.Name}, func() (int, error) { return runProcess(command) })
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to run the plugin %s: %v", plugin.Name, err))
+		}
+		return exitCode
+	})
+}
+
+// writePluginList prints the installed plugins with their version and capabilities
+func writePluginList(w io.Writer, plugins []pluginInfo) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tVERSION\tCAPABILITIES\tDESCRIPTION")
+	for _, plugin := range plugins {
+		pluginVersion, capabilities := plugin.Version, strings.Join(plugin.Capabilities, ",")
+		if pluginVersion == "" {
+			pluginVersion = "-"
+		}
+		if capabilities == "" {
+			capabilities = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", plugin.Name, pluginVersion, capabilities, plugin.Description)
+	}
+	table.Flush()
+}
+
+// installPlugin copies a plugin from a local path or a go-getter URL in the plugins folder and checks that it can be described
+func installPlugin(source string) error {
+	name := getPluginName(filepath.Base(source))
+	if name == "" {
+		return fmt.Errorf("The plugin executable must be named %s<name> (got %s)", pluginPrefix, filepath.Base(source))
+	}
+	destination := filepath.Join(getPluginsFolder(), filepath.Base(source))
+	if err := os.MkdirAll(getPluginsFolder(), 0755); err != nil {
+		return err
+	}
+	// The local files are copied (go-getter creates a symbolic link by default)
+	getters := make(map[string]getter.Getter, len(getter.Getters))
+	for scheme, get := range getter.Getters {
+		getters[scheme] = get
+	}
+	getters["file"] = &getter.FileGetter{Copy: true}
+	client := &getter.Client{Src: source, Dst: destination, Pwd: must(os.Getwd()).(string), Mode: getter.ClientModeFile, Getters: getters}
+	if err := client.Get(); err != nil {
+		return err
+	}
+	if err := os.Chmod(destination, 0755); err != nil {
+		return err
+	}
+	if err := (pluginInfo{Name: name, path: destination}).call("describe", nil, nil); err != nil {
+		os.Remove(destination)
+		return err
+	}
+	resetInstalledPlugins()
+	return nil
+}
+
+// removePlugin deletes an installed plugin
+func removePlugin(name string) error {
+	for _, plugin := range getInstalledPlugins() {
+		if plugin.Name == name {
+			resetInstalledPlugins()
+			return os.Remove(plugin.path)
+		}
+	}
+	return fmt.Errorf("The plugin %s is not installed in %s", name, getPluginsFolder())
+}
+
+func runPluginsCommand(app *TGFApplication, args []string) int {
+	const usage = "list|path|install <path or url>|rm <name>"
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	var err error
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		writePluginList(os.Stdout, getInstalledPlugins())
+	case len(args) == 1 && args[0] == "path":
+		Println(getPluginsFolder())
+	case len(args) == 2 && args[0] == "install":
+		if err = installPlugin(args[1]); err == nil {
+			name := getPluginName(filepath.Base(args[1]))
+			printInfo("plugin", map[string]interface{}{"plugin": name}, msgPluginInstalled, name, getPluginsFolder())
+		}
+	case len(args) == 2 && args[0] == "rm":
+		err = removePlugin(args[1])
+	default:
+		return printCommandUsage("plugins", usage)
+	}
+	if err != nil {
+		printError(msgPluginFailed, err)
+		return 1
+	}
+	return 0
+}
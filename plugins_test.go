@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPlugin answers the requests of tgf, it records the describe calls and the runner requests (with their stdin) next to itself
+const testPlugin = `#!/bin/sh
+if [ -n "$TGF_PLUGIN_REQUEST" ]; then request=$(cat "$TGF_PLUGIN_REQUEST"); else request=$(cat); fi
+folder=$(dirname "$0")
+case "$request" in
+*'"method":"describe"'*)
+	echo describe >> "$folder/../describe.log"
+	echo '{"result": {"version": "1.0.0", "description": "Test plugin", "capabilities": ["config", "credentials", "runner"]}}' ;;
+*'"method":"config"'*)
+	echo '{"result": {"config": "docker-image-tag: from-plugin"}}' ;;
+*'"method":"credentials"'*)
+	echo '{"result": {"environment": {"VAULT_TOKEN": "token"}, "volumes": ["/tmp/vault:/var/vault"]}}' ;;
+*'"method":"runner"'*)
+	echo "$request" > "$folder/../runner.json"
+	echo "$TGF_PLUGIN_REQUEST" > "$folder/../request-file"
+	cat > "$folder/../stdin"
+	exit 3 ;;
+*)
+	echo '{"error": "unsupported method"}' ;;
+esac
+`
+
+// setupPlugins creates a plugins folder containing the test plugin and returns the function restoring the environment
+func setupPlugins(t *testing.T) (tempDir string, restore func()) {
+	skipOnWindows(t, "the test plugin is a shell script")
+	tempDir = must(ioutil.TempDir("", "TestPlugins")).(string)
+	config, cache := os.Getenv("XDG_CONFIG_HOME"), os.Getenv("XDG_CACHE_HOME")
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(tempDir, "cache"))
+	assert.NoError(t, os.MkdirAll(getPluginsFolder(), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(getPluginsFolder(), pluginPrefix+"test"), []byte(testPlugin), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(getPluginsFolder(), pluginPrefix+"disabled"), []byte(testPlugin), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(getPluginsFolder(), "README.md"), []byte("Not a plugin"), 0755))
+	resetInstalledPlugins()
+	return tempDir, func() {
+		os.Setenv("XDG_CONFIG_HOME", config)
+		os.Setenv("XDG_CACHE_HOME", cache)
+		os.RemoveAll(tempDir)
+		resetInstalledPlugins()
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	tempDir, restore := setupPlugins(t)
+	defer restore()
+
+	want := []pluginInfo{{
+		Name:         "test",
+		Version:      "1.0.0",
+		Description:  "Test plugin",
+		Capabilities: []string{pluginConfig, pluginCredentials, pluginRunner},
+		path:         filepath.Join(getPluginsFolder(), pluginPrefix+"test"),
+	}}
+	assert.Equal(t, want, discoverPlugins())
+	assert.Equal(t, want, discoverPlugins())
+	assert.Equal(t, "describe\n", string(must(ioutil.ReadFile(filepath.Join(tempDir, "config", "tgf", "describe.log"))).([]byte)), "The description is cached")
+
+	plugin, found := findPlugin("test", pluginRunner)
+	assert.True(t, found)
+	assert.Equal(t, want[0], plugin)
+	_, found = findPlugin("disabled", pluginRunner)
+	assert.False(t, found, "The plugin is not executable")
+	assert.Equal(t, []string{"test"}, getPluginNames(pluginCredentials))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(getPluginsFolder(), pluginPrefix+"other"), []byte(testPlugin), 0755))
+	assert.Equal(t, []string{"test"}, getPluginNames(pluginCredentials), "The plugins folder is only scanned once")
+
+	var buffer bytes.Buffer
+	writePluginList(&buffer, getInstalledPlugins())
+	assert.Equal(t, "NAME  VERSION  CAPABILITIES               DESCRIPTION\ntest  1.0.0    config,credentials,runner  Test plugin\n", buffer.String())
+}
+
+func TestPluginSources(t *testing.T) {
+	_, restore := setupPlugins(t)
+	defer restore()
+
+	config := &TGFConfig{tgf: NewTestApplication(nil)}
+	assert.Equal(t, []configData{{Name: "Plugin/test", Raw: "docker-image-tag: from-plugin"}}, config.readPluginConfigs())
+
+	config = &TGFConfig{tgf: NewTestApplication(nil), Environment: map[string]string{}, CredentialSources: []string{"test"}}
+	assert.NoError(t, config.resolveCredentialSources())
+	assert.Equal(t, map[string]string{"VAULT_TOKEN": "token"}, config.Environment)
+	assert.Equal(t, []string{"/tmp/vault:/var/vault"}, config.credentialVolumes)
+
+	config.CredentialSources = []string{"vault"}
+	assert.EqualError(t, config.resolveCredentialSources(), "Unknown credential source vault (available: azure, gcp, test)")
+
+	assert.NoError(t, (&TGFConfig{Runner: "test"}).validateRunner())
+	assert.Error(t, (&TGFConfig{Runner: "disabled"}).validateRunner())
+}
+
+func TestRunPlugin(t *testing.T) {
+	tempDir, restore := setupPlugins(t)
+	defer restore()
+
+	stdin := filepath.Join(tempDir, "answers")
+	must(ioutil.WriteFile(stdin, []byte("yes\n"), 0644))
+	defer func(saved *os.File) { os.Stdin = saved }(os.Stdin)
+	os.Stdin = must(os.Open(stdin)).(*os.File)
+	defer os.Stdin.Close()
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), EntryPoint: "terragrunt"}
+	plugin, _ := findPlugin("test", pluginRunner)
+	run := remoteRun{image: "coveo/tgf:1.21.0", command: []string{"terragrunt", "plan"}, environment: map[string]string{"TGF_COMMAND": "terragrunt"}, workdir: "/var/tgf/live"}
+	assert.Equal(t, 3, config.runPlugin(plugin, run))
+	request := string(must(ioutil.ReadFile(filepath.Join(tempDir, "config", "tgf", "runner.json"))).([]byte))
+	assert.Contains(t, request, `"method":"runner"`)
+	assert.Contains(t, request, `"command":["terragrunt","plan"]`)
+	assert.Contains(t, request, `"image":"coveo/tgf:1.21.0"`)
+	assert.Equal(t, "coveo/tgf:1.21.0", config.runImage)
+	assert.Equal(t, "yes\n", string(must(ioutil.ReadFile(filepath.Join(tempDir, "config", "tgf", "stdin"))).([]byte)), "The stdin of tgf is forwarded to the plugin")
+	requestFile := strings.TrimSpace(string(must(ioutil.ReadFile(filepath.Join(tempDir, "config", "tgf", "request-file"))).([]byte)))
+	assert.NotEmpty(t, requestFile)
+	_, err := os.Stat(requestFile)
+	assert.True(t, os.IsNotExist(err), "The request file is deleted")
+}
+
+func TestInstallPlugin(t *testing.T) {
+	tempDir, restore := setupPlugins(t)
+	defer restore()
+
+	source := filepath.Join(tempDir, pluginPrefix+"vault")
+	assert.NoError(t, ioutil.WriteFile(source, []byte(testPlugin), 0644))
+	assert.NoError(t, installPlugin(source))
+	info := must(os.Lstat(filepath.Join(getPluginsFolder(), pluginPrefix+"vault"))).(os.FileInfo)
+	assert.True(t, info.Mode().IsRegular(), "The plugin is copied")
+	assert.Equal(t, []string{"test", "vault"}, getPluginNames(pluginRunner))
+
+	assert.EqualError(t, installPlugin(filepath.Join(tempDir, "vault")), "The plugin executable must be named tgf-plugin-<name> (got vault)")
+
+	assert.NoError(t, removePlugin("vault"))
+	assert.Equal(t, []string{"test"}, getPluginNames(pluginRunner))
+	assert.Error(t, removePlugin("vault"))
+}
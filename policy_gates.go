@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Scanners supported by the policy gates
+const (
+	scannerTFLint  = "tflint"
+	scannerTFSec   = "tfsec"
+	scannerCheckov = "checkov"
+)
+
+// severities lists the severities of the findings from the lowest to the highest
+var severities = []string{"low", "medium", "high", "critical"}
+
+// defaultGateSeverity is the severity from which a finding fails the run if no threshold is configured
+const defaultGateSeverity = "high"
+
+// PolicyGate is a scanner run against the working directory before the command, the run fails if the scanner reports findings
+// at or above the severity threshold
+type PolicyGate struct {
+	Scanner   string   `yaml:"scanner,omitempty" json:"scanner,omitempty" hcl:"scanner,omitempty"`
+	Image     string   `yaml:"image,omitempty" json:"image,omitempty" hcl:"image,omitempty"`
+	Severity  string   `yaml:"severity,omitempty" json:"severity,omitempty" hcl:"severity,omitempty"`
+	Arguments []string `yaml:"arguments,omitempty" json:"arguments,omitempty" hcl:"arguments,omitempty"`
+}
+
+// policyFinding is an issue reported by a scanner
+type policyFinding struct {
+	Rule     string
+	Severity string
+	Message  string
+	Location string
+}
+
+func (finding policyFinding) String() string {
+	return fmt.Sprintf("[%s] %s: %s (%s)", strings.ToUpper(finding.Severity), finding.Rule, finding.Message, finding.Location)
+}
+
+// getSeverityLevel returns the rank of the severity (-1 if it is unknown)
+func getSeverityLevel(severity string) int {
+	for i, known := range severities {
+		if strings.EqualFold(severity, known) {
+			return i
+		}
+	}
+	return -1
+}
+
+// validate checks the scanner and the severity threshold of the gate
+func (gate PolicyGate) validate() error {
+	switch gate.Scanner {
+	case scannerTFLint, scannerTFSec, scannerCheckov:
+	default:
+		return fmt.Errorf("Unsupported policy gate scanner %q (supported scanners are %s, %s and %s)", gate.Scanner, scannerTFLint, scannerTFSec, scannerCheckov)
+	}
+	if gate.Severity != "" && getSeverityLevel(gate.Severity) < 0 {
+		return fmt.Errorf("Invalid severity %q for the %s policy gate (valid severities are %s)", gate.Severity, gate.Scanner, strings.Join(severities, ", "))
+	}
+	return nil
+}
+
+// getThreshold returns the level from which the findings fail the run
+func (gate PolicyGate) getThreshold() int {
+	if gate.Severity == "" {
+		return getSeverityLevel(defaultGateSeverity)
+	}
+	return getSeverityLevel(gate.Severity)
+}
+
+// getCommand returns the scanner command (the executable and its arguments) producing a JSON report on stdout
+func (gate PolicyGate) getCommand() []string {
+	var command []string
+	switch gate.Scanner {
+	case scannerTFLint:
+		command = []string{"tflint", "--format=json"}
+	case scannerTFSec:
+		command = []string{"tfsec", ".", "--format=json", "--no-colour", "--soft-fail"}
+	case scannerCheckov:
+		command = []string{"checkov", "--directory=.", "--output=json", "--quiet", "--soft-fail"}
+	}
+	return append(command, gate.Arguments...)
+}
+
+// parseFindings extracts the findings of the JSON report of the scanner
+func (gate PolicyGate) parseFindings(report []byte) (findings []policyFinding, err error) {
+	switch gate.Scanner {
+	case scannerTFLint:
+		// The tflint severities are error, warning and notice
+		var result struct {
+			Issues []struct {
+				Rule struct {
+					Name     string `json:"name"`
+					Severity string `json:"severity"`
+				} `json:"rule"`
+				Message string `json:"message"`
+				Range   struct {
+					Filename string `json:"filename"`
+					Start    struct {
+						Line int `json:"line"`
+					} `json:"start"`
+				} `json:"range"`
+			} `json:"issues"`
+		}
+		if err = json.Unmarshal(report, &result); err != nil {
+			return
+		}
+		mapping := map[string]string{"error": "high", "warning": "medium", "notice": "low"}
+		for _, issue := range result.Issues {
+			findings = append(findings, policyFinding{issue.Rule.Name, mapping[strings.ToLower(issue.Rule.Severity)], issue.Message, fmt.Sprintf("%s:%d", issue.Range.Filename, issue.Range.Start.Line)})
+		}
+	case scannerTFSec:
+		var result struct {
+			Results []struct {
+				RuleID      string `json:"rule_id"`
+				Severity    string `json:"severity"`
+				Description string `json:"description"`
+				Location    struct {
+					Filename  string `json:"filename"`
+					StartLine int    `json:"start_line"`
+				} `json:"location"`
+			} `json:"results"`
+		}
+		if err = json.Unmarshal(report, &result); err != nil {
+			return
+		}
+		for _, issue := range result.Results {
+			findings = append(findings, policyFinding{issue.RuleID, strings.ToLower(issue.Severity), issue.Description, fmt.Sprintf("%s:%d", issue.Location.Filename, issue.Location.StartLine)})
+		}
+	case scannerCheckov:
+		// checkov returns a report per framework (a single object if there is only one)
+		type checkovReport struct {
+			Results struct {
+				FailedChecks []struct {
+					CheckID   string  `json:"check_id"`
+					CheckName string  `json:"check_name"`
+					Severity  *string `json:"severity"`
+					FilePath  string  `json:"file_path"`
+					LineRange []int   `json:"file_line_range"`
+				} `json:"failed_checks"`
+			} `json:"results"`
+		}
+		var reports []checkovReport
+		if trimmed := bytes.TrimSpace(report); len(trimmed) > 0 && trimmed[0] == '{' {
+			reports = make([]checkovReport, 1)
+			err = json.Unmarshal(trimmed, &reports[0])
+		} else {
+			err = json.Unmarshal(trimmed, &reports)
+		}
+		if err != nil {
+			return
+		}
+		for _, result := range reports {
+			for _, check := range result.Results.FailedChecks {
+				// The severities are only available with a Prisma Cloud API key, the checks without severity are considered high
+				severity := "high"
+				if check.Severity != nil {
+					severity = strings.ToLower(*check.Severity)
+				}
+				line := 0
+				if len(check.LineRange) > 0 {
+					line = check.LineRange[0]
+				}
+				findings = append(findings, policyFinding{check.CheckID, severity, check.CheckName, fmt.Sprintf("%s:%d", strings.TrimPrefix(check.FilePath, "/"), line)})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return getSeverityLevel(findings[i].Severity) > getSeverityLevel(findings[j].Severity)
+	})
+	return
+}
+
+// runContainerOutput runs a short-lived container (scanners, cost estimation) and returns its output (only changed by the tests)
+var runContainerOutput = func(args []string) ([]byte, error) {
+	var stderr bytes.Buffer
+	command := exec.Command("docker", args...)
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// The tools may return a non zero exit code along with their report (i.e. the scanners reporting findings)
+	return output, nil
+}
+
+// runPolicyGates runs the scanners against the working directory before the command (in the tgf image unless the gate specifies its
+// own image), it returns a non zero exit code if the run must be stopped
+func (config *TGFConfig) runPolicyGates(image string, mountArgs []string) int {
+	if len(config.PolicyGates) == 0 {
+		return 0
+	}
+	defer timings.begin("policy gates")()
+	violations := 0
+	for _, gate := range config.PolicyGates {
+		if err := gate.validate(); err != nil {
+			return failWith(exitConfig, err)
+		}
+		gateImage := image
+		if gate.Image != "" {
+			gateImage = gate.Image
+		}
+		command := gate.getCommand()
+		args := append(append([]string{"run", "--rm", "--entrypoint", command[0]}, mountArgs...), gateImage)
+		config.tgf.Debug("# Running the %s policy gate: docker %s", gate.Scanner, strings.Join(append(args, command[1:]...), " "))
+		report, err := runContainerOutput(append(args, command[1:]...))
+		var findings []policyFinding
+		if err == nil {
+			findings, err = gate.parseFindings(report)
+		}
+		if err != nil {
+			return failWith(1, fmt.Errorf("The %s policy gate has failed: %v", gate.Scanner, err))
+		}
+		threshold := gate.getThreshold()
+		for _, finding := range findings {
+			if getSeverityLevel(finding.Severity) >= threshold {
+				violations++
+				ErrPrintf("%s: %s\n", gate.Scanner, finding)
+			} else {
+				config.tgf.Debug("# %s: %s", gate.Scanner, finding)
+			}
+		}
+	}
+	if violations > 0 {
+		return failWith(exitPolicyViolation, fmt.Errorf("%d finding(s) of the policy gates at or above their severity threshold", violations))
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyGateValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		gate    PolicyGate
+		wantErr string
+	}{
+		{"Default severity", PolicyGate{Scanner: scannerTFSec}, ""},
+		{"Severity", PolicyGate{Scanner: scannerCheckov, Severity: "Critical"}, ""},
+		{"Unknown scanner", PolicyGate{Scanner: "terrascan"}, `Unsupported policy gate scanner "terrascan" (supported scanners are tflint, tfsec and checkov)`},
+		{"Unknown severity", PolicyGate{Scanner: scannerTFLint, Severity: "blocker"}, `Invalid severity "blocker" for the tflint policy gate (valid severities are low, medium, high, critical)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gate.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+	assert.Equal(t, 2, PolicyGate{}.getThreshold())
+	assert.Equal(t, 0, PolicyGate{Severity: "LOW"}.getThreshold())
+}
+
+func TestParseFindings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scanner string
+		report  string
+		want    []policyFinding
+	}{
+		{scannerTFLint, `{"issues": [
+			{"rule": {"name": "terraform_unused_declarations", "severity": "warning"}, "message": "variable \"x\" is declared but not used", "range": {"filename": "variables.tf", "start": {"line": 3}}},
+			{"rule": {"name": "aws_instance_invalid_type", "severity": "error"}, "message": "\"t1.2xlarge\" is an invalid value", "range": {"filename": "main.tf", "start": {"line": 12}}}
+		], "errors": []}`, []policyFinding{
+			{"aws_instance_invalid_type", "high", `"t1.2xlarge" is an invalid value`, "main.tf:12"},
+			{"terraform_unused_declarations", "medium", `variable "x" is declared but not used`, "variables.tf:3"},
+		}},
+		{scannerTFSec, `{"results": [
+			{"rule_id": "AVD-AWS-0086", "severity": "CRITICAL", "description": "No public access block", "location": {"filename": "/var/tgf/s3.tf", "start_line": 1}}
+		]}`, []policyFinding{{"AVD-AWS-0086", "critical", "No public access block", "/var/tgf/s3.tf:1"}}},
+		{scannerTFSec, `{"results": null}`, nil},
+		{scannerCheckov, `{"check_type": "terraform", "results": {"failed_checks": [
+			{"check_id": "CKV_AWS_18", "check_name": "Ensure the S3 bucket has access logging enabled", "severity": null, "file_path": "/s3.tf", "file_line_range": [1, 9]},
+			{"check_id": "CKV_AWS_144", "check_name": "Ensure that S3 bucket has cross-region replication enabled", "severity": "LOW", "file_path": "/s3.tf", "file_line_range": [1, 9]}
+		]}}`, []policyFinding{
+			{"CKV_AWS_18", "high", "Ensure the S3 bucket has access logging enabled", "s3.tf:1"},
+			{"CKV_AWS_144", "low", "Ensure that S3 bucket has cross-region replication enabled", "s3.tf:1"},
+		}},
+		{scannerCheckov, `[{"check_type": "terraform", "results": {"failed_checks": []}}, {"check_type": "secrets", "results": {"failed_checks": [
+			{"check_id": "CKV_SECRET_2", "check_name": "AWS Access Key", "severity": "MEDIUM", "file_path": "/main.tf", "file_line_range": [4, 4]}
+		]}}]`, []policyFinding{{"CKV_SECRET_2", "medium", "AWS Access Key", "main.tf:4"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scanner, func(t *testing.T) {
+			findings, err := PolicyGate{Scanner: tt.scanner}.parseFindings([]byte(tt.report))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, findings)
+		})
+	}
+	_, err := PolicyGate{Scanner: scannerTFLint}.parseFindings([]byte("Failed to load configurations"))
+	assert.Error(t, err)
+}
+
+func TestRunPolicyGates(t *testing.T) {
+	defer func(saved func([]string) ([]byte, error)) { runContainerOutput = saved }(runContainerOutput)
+	var commands []string
+	runContainerOutput = func(args []string) ([]byte, error) {
+		commands = append(commands, strings.Join(args, " "))
+		if strings.Contains(commands[len(commands)-1], "tflint") {
+			return []byte(`{"issues": [{"rule": {"name": "terraform_deprecated_index", "severity": "notice"}, "message": "Deprecated", "range": {"filename": "main.tf", "start": {"line": 1}}}]}`), nil
+		}
+		return []byte(`{"results": [{"rule_id": "AVD-AWS-0086", "severity": "HIGH", "description": "No public access block", "location": {"filename": "s3.tf", "start_line": 1}}]}`), nil
+	}
+	mountArgs := []string{"-v", "/home:/home", "-w", "/home/user/project"}
+
+	config := &TGFConfig{tgf: NewTestApplication(nil), PolicyGates: []PolicyGate{{Scanner: scannerTFLint, Severity: "medium", Arguments: []string{"--recursive"}}}}
+	assert.Equal(t, 0, config.runPolicyGates("coveo/tgf:1.21.0", mountArgs))
+	assert.Equal(t, []string{"run --rm --entrypoint tflint -v /home:/home -w /home/user/project coveo/tgf:1.21.0 --format=json --recursive"}, commands)
+
+	config.PolicyGates = append(config.PolicyGates, PolicyGate{Scanner: scannerTFSec, Image: "aquasec/tfsec:latest"})
+	assert.Equal(t, exitPolicyViolation, config.runPolicyGates("coveo/tgf:1.21.0", mountArgs))
+	assert.Equal(t, "run --rm --entrypoint tfsec -v /home:/home -w /home/user/project aquasec/tfsec:latest . --format=json --no-colour --soft-fail", commands[2])
+
+	config.PolicyGates = []PolicyGate{{Scanner: "terrascan"}}
+	assert.Equal(t, exitConfig, config.runPolicyGates("coveo/tgf:1.21.0", mountArgs))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// prCommentOutputLimit is the maximum size of the output included in the comment (GitHub comments are limited to 65536 characters)
+const prCommentOutputLimit = 60000
+
+var (
+	reResourceChange = regexp.MustCompile(`^\s*# (\S+) (will be created|will be updated in-place|will be destroyed|must be replaced|will be replaced, as requested|will be read during apply)`)
+	reDiffMarker     = regexp.MustCompile(`(?m)^([ \t]+)([-+~]/?[-+]?) `)
+)
+
+// resourceChange is a change of a resource announced by a plan
+type resourceChange struct {
+	Action   string
+	Resource string
+}
+
+// getResourceChanges returns the changes of the resources listed in the output of the plans
+func getResourceChanges(output string) (changes []resourceChange) {
+	actions := map[string]string{
+		"will be created":                "create",
+		"will be updated in-place":       "update",
+		"will be destroyed":              "destroy",
+		"must be replaced":               "replace",
+		"will be replaced, as requested": "replace",
+		"will be read during apply":      "read",
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if match := reResourceChange.FindStringSubmatch(line); match != nil {
+			changes = append(changes, resourceChange{actions[match[2]], match[1]})
+		}
+	}
+	return
+}
+
+// getPRComment returns the markdown describing the result of the command, the output is in a collapsible section formatted as a diff
+func getPRComment(metadata runMetadata, output string) string {
+	var summary terraformSummary
+	summary.Write([]byte(output + "\n"))
+	command := strings.TrimSpace(filepath.Base(metadata.EntryPoint) + " " + strings.Join(metadata.Arguments, " "))
+	status := "succeeded"
+	// terraform returns 2 if there are changes with -detailed-exitcode
+	if metadata.ExitCode != 0 && !(metadata.ExitCode == 2 && strings.Contains(command, "-detailed-exitcode")) {
+		status = "failed"
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "### tgf `%s` %s in `%s`\n\n", command, status, getRepositoryFolder(metadata.WorkingDir))
+	switch {
+	case summary.Plans > 0:
+		fmt.Fprintf(&content, "**Plan:** %d to add, %d to change, %d to destroy", summary.Planned[0], summary.Planned[1], summary.Planned[2])
+		if summary.Plans+summary.NoChanges > 1 {
+			fmt.Fprintf(&content, " (%d modules with changes, %d without changes)", summary.Plans, summary.NoChanges)
+		}
+		content.WriteString("\n\n")
+	case summary.NoChanges > 0:
+		content.WriteString("**No changes.** The infrastructure matches the configuration.\n\n")
+	}
+	if metadata.Cost != nil {
+		fmt.Fprintf(&content, "**Estimated cost:** %s\n\n", metadata.Cost)
+	}
+	if summary.Applies > 0 {
+		fmt.Fprintf(&content, "**Apply:** %d added, %d changed, %d destroyed\n\n", summary.Applied[0], summary.Applied[1], summary.Applied[2])
+	}
+	if changes := getResourceChanges(output); len(changes) > 0 {
+		fmt.Fprintf(&content, "<details><summary>Resource changes (%d)</summary>\n\n| Action | Resource |\n| --- | --- |\n", len(changes))
+		for _, change := range changes {
+			fmt.Fprintf(&content, "| %s | `%s` |\n", change.Action, change.Resource)
+		}
+		content.WriteString("\n</details>\n\n")
+	}
+
+	output = strings.TrimSpace(output)
+	truncated := len(output) > prCommentOutputLimit
+	if truncated {
+		output = output[:prCommentOutputLimit]
+	}
+	// The diff markers are moved to the beginning of the lines to be highlighted
+	output = reDiffMarker.ReplaceAllString(output, "$2$1 ")
+	fmt.Fprintf(&content, "<details><summary>Show output</summary>\n\n```diff\n%s\n```\n", strings.ReplaceAll(output, "```", "'''"))
+	if truncated {
+		fmt.Fprintf(&content, "\n*The output has been truncated to %d characters.*\n", prCommentOutputLimit)
+	}
+	content.WriteString("\n</details>\n")
+	return content.String()
+}
+
+// writePRComment prints the comment describing the run (--pr-comment) and/or writes it in a file (--pr-comment-file)
+func (config *TGFConfig) writePRComment(start time.Time, exitCode int) {
+	app := config.tgf
+	comment := getPRComment(config.getRunMetadata(start, exitCode), string(config.commandOutput.content()))
+	if app.PRComment {
+		fmt.Fprint(os.Stdout, comment)
+	}
+	if app.PRCommentFile != "" {
+		if err := ioutil.WriteFile(app.PRCommentFile, []byte(comment), 0644); err != nil {
+			printWarning(msgFileWriteFailed, app.PRCommentFile, err)
+		}
+	}
+}
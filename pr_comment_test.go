@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPlanOutput = `
+Terraform will perform the following actions:
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + bucket = "logs"
+    }
+
+  # aws_instance.web must be replaced
+-/+ resource "aws_instance" "web" {
+      ~ instance_type = "t3.micro" -> "t3.small"
+    }
+
+  # module.dns.aws_route53_record.www will be destroyed
+  - resource "aws_route53_record" "www" {
+      - name = "www"
+    }
+
+Plan: 2 to add, 0 to change, 2 to destroy.
+`
+
+func TestGetResourceChanges(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []resourceChange{
+		{"create", "aws_s3_bucket.logs"},
+		{"replace", "aws_instance.web"},
+		{"destroy", "module.dns.aws_route53_record.www"},
+	}, getResourceChanges(testPlanOutput))
+	assert.Empty(t, getResourceChanges("No changes. Your infrastructure matches the configuration."))
+}
+
+func TestGetPRComment(t *testing.T) {
+	t.Parallel()
+
+	metadata := runMetadata{EntryPoint: "terragrunt", Arguments: []string{"plan"}, WorkingDir: "/home/user/project"}
+	comment := getPRComment(metadata, testPlanOutput)
+	assert.True(t, strings.HasPrefix(comment, String(`
+		### tgf `+"`terragrunt plan`"+` succeeded in `+"`project`"+`
+
+		**Plan:** 2 to add, 0 to change, 2 to destroy
+
+		<details><summary>Resource changes (3)</summary>
+
+		| Action | Resource |
+		| --- | --- |
+		| create | `+"`aws_s3_bucket.logs`"+` |
+		| replace | `+"`aws_instance.web`"+` |
+		| destroy | `+"`module.dns.aws_route53_record.www`"+` |
+
+		</details>
+
+		<details><summary>Show output</summary>
+
+		`+"```diff"+`
+	`).UnIndent().TrimSpace().Str()), comment)
+	assert.Contains(t, comment, "\n+   resource \"aws_s3_bucket\" \"logs\" {\n+       bucket = \"logs\"\n")
+	assert.Contains(t, comment, "\n-/+ resource \"aws_instance\" \"web\" {\n~       instance_type")
+	assert.True(t, strings.HasSuffix(comment, "Plan: 2 to add, 0 to change, 2 to destroy.\n```\n\n</details>\n"))
+
+	tests := []struct {
+		name      string
+		arguments []string
+		exitCode  int
+		output    string
+		want      string
+	}{
+		{"No changes", []string{"plan"}, 0, "No changes. Your infrastructure matches the configuration.", "succeeded in `project`\n\n**No changes.** The infrastructure matches the configuration.\n\n<details>"},
+		{"Failure", []string{"plan"}, 1, "Error: Invalid reference", "failed in `project`\n\n<details>"},
+		{"Detailed exit code", []string{"plan", "-detailed-exitcode"}, 2, testPlanOutput, "succeeded in `project`"},
+		{"Apply", []string{"apply"}, 0, "Apply complete! Resources: 1 added, 0 changed, 0 destroyed.", "**Apply:** 1 added, 0 changed, 0 destroyed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := runMetadata{EntryPoint: "terragrunt", Arguments: tt.arguments, ExitCode: tt.exitCode, WorkingDir: "/home/user/project"}
+			assert.Contains(t, getPRComment(metadata, tt.output), tt.want)
+		})
+	}
+
+	long := getPRComment(metadata, strings.Repeat("x", prCommentOutputLimit+10))
+	assert.Contains(t, long, "*The output has been truncated to 60000 characters.*")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Refresh rate of the progress display
+const progressInterval = 100 * time.Millisecond
+
+var progressSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressEnabled returns true if the progress of the long operations can be displayed (text diagnostics printed to a terminal)
+func progressEnabled() bool {
+	return logFormat == logFormatText && infoEnabled() && !isCI() && isTerminal(os.Stderr)
+}
+
+// progress displays a spinner with the elapsed time, or the transferred bytes and the ETA if the size is known.
+// All methods can be called on a nil progress (returned if the progress cannot be displayed).
+type progress struct {
+	sync.Mutex
+	message string
+	out     io.Writer
+	start   time.Time
+	current int64
+	total   int64
+	done    chan bool
+}
+
+// startProgress displays the progress of an operation until stop is called
+func startProgress(message string) *progress {
+	if !progressEnabled() {
+		return nil
+	}
+	p := &progress{message: message, out: os.Stderr, start: time.Now(), done: make(chan bool)}
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			select {
+			case <-p.done:
+				fmt.Fprint(p.out, "\r\033[K")
+				p.done <- true
+				return
+			case now := <-ticker.C:
+				fmt.Fprintf(p.out, "\r\033[K%s %s", progressSpinner[frame%len(progressSpinner)], p.render(now))
+			}
+		}
+	}()
+	return p
+}
+
+// update sets the number of bytes transferred and the total size (0 if it is unknown)
+func (p *progress) update(current, total int64) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.current, p.total = current, total
+}
+
+// stop clears the progress display
+func (p *progress) stop() {
+	if p == nil {
+		return
+	}
+	p.done <- true
+	<-p.done
+}
+
+// render returns the progress line at the specified time
+func (p *progress) render(now time.Time) string {
+	p.Lock()
+	defer p.Unlock()
+	elapsed := now.Sub(p.start)
+	switch {
+	case p.total > 0 && p.current > 0:
+		eta := time.Duration(float64(elapsed) * float64(p.total-p.current) / float64(p.current))
+		return fmt.Sprintf("%s %s / %s (%d%%), ETA %v", p.message, formatBytes(p.current), formatBytes(p.total), p.current*100/p.total, eta.Round(time.Second))
+	case p.current > 0:
+		return fmt.Sprintf("%s %s (%v)", p.message, formatBytes(p.current), elapsed.Round(time.Second))
+	}
+	return fmt.Sprintf("%s (%v)", p.message, elapsed.Round(time.Second))
+}
+
+// formatBytes returns a human readable size
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exponent := float64(size)/unit, 0
+	for ; value >= unit && exponent < 3; exponent++ {
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exponent])
+}
+
+// progressReader reports the bytes read through a reader
+type progressReader struct {
+	io.Reader
+	progress *progress
+	current  int64
+	total    int64
+}
+
+func (reader *progressReader) Read(buffer []byte) (int, error) {
+	n, err := reader.Reader.Read(buffer)
+	reader.current += int64(n)
+	reader.progress.update(reader.current, reader.total)
+	return n, err
+}
+
+// pullMessage is a message of the progress stream returned by the docker daemon while pulling an image
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// pullProgress sums the bytes downloaded for each layer of an image
+type pullProgress struct {
+	layers map[string][2]int64
+}
+
+// add records a message of the progress stream and returns an error if the pull has failed
+func (pull *pullProgress) add(message pullMessage) error {
+	if message.Error != "" {
+		return fmt.Errorf("%s", message.Error)
+	}
+	if pull.layers == nil {
+		pull.layers = make(map[string][2]int64)
+	}
+	layer := pull.layers[message.ID]
+	switch message.Status {
+	case "Downloading":
+		layer = [2]int64{message.ProgressDetail.Current, message.ProgressDetail.Total}
+	case "Download complete", "Verifying Checksum", "Pull complete":
+		layer[0] = layer[1]
+	default:
+		return nil
+	}
+	pull.layers[message.ID] = layer
+	return nil
+}
+
+// totals returns the bytes downloaded and the total size of the layers being downloaded
+func (pull *pullProgress) totals() (current, total int64) {
+	for _, layer := range pull.layers {
+		current, total = current+layer[0], total+layer[1]
+	}
+	return
+}
+
+// pullImageWithProgress pulls an image through the docker daemon API while displaying the downloaded bytes.
+// The registry credentials of the docker client are not available through the API, so an error is returned
+// for the images that require an authentication and the caller should fall back to docker pull.
+func pullImageWithProgress(image string) error {
+	cli, ctx := getDockerClient()
+	stream, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	p := startProgress("Pulling " + image)
+	defer p.stop()
+	var pull pullProgress
+	decoder := json.NewDecoder(stream)
+	for {
+		var message pullMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			pulled, _ := pull.totals()
+			runStats.addPulledBytes(pulled)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := pull.add(message); err != nil {
+			return err
+		}
+		p.update(pull.totals())
+	}
+}
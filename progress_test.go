@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536 * 1024, "1.5 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+		{5 * 1024 * 1024 * 1024 * 1024 * 1024, "5120.0 TiB"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatBytes(tt.size))
+	}
+}
+
+func TestProgressRender(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	tests := []struct {
+		name    string
+		current int64
+		total   int64
+		want    string
+	}{
+		{"Unknown size", 0, 0, "Pulling (10s)"},
+		{"Bytes without total", 2048, 0, "Pulling 2.0 KiB (10s)"},
+		{"With total", 25 * 1024 * 1024, 100 * 1024 * 1024, "Pulling 25.0 MiB / 100.0 MiB (25%), ETA 30s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &progress{message: "Pulling", start: start}
+			p.update(tt.current, tt.total)
+			assert.Equal(t, tt.want, p.render(start.Add(10*time.Second)))
+		})
+	}
+}
+
+func TestProgressDisabled(t *testing.T) {
+	// The tests are not executed in a terminal, the progress is not displayed and a nil progress can be used
+	p := startProgress("Pulling")
+	assert.Nil(t, p)
+	p.update(1, 2)
+	p.stop()
+
+	reader := &progressReader{Reader: strings.NewReader("content"), progress: p, total: 7}
+	content, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.Equal(t, int64(7), reader.current)
+}
+
+func TestPullProgress(t *testing.T) {
+	t.Parallel()
+
+	message := func(id, status string, current, total int64) pullMessage {
+		m := pullMessage{ID: id, Status: status}
+		m.ProgressDetail.Current, m.ProgressDetail.Total = current, total
+		return m
+	}
+
+	var pull pullProgress
+	for _, m := range []pullMessage{
+		message("1.21.0", "Pulling from coveo/tgf", 0, 0),
+		message("a", "Pulling fs layer", 0, 0),
+		message("b", "Already exists", 0, 0),
+		message("a", "Downloading", 100, 1000),
+		message("c", "Downloading", 50, 500),
+	} {
+		assert.NoError(t, pull.add(m))
+	}
+	current, total := pull.totals()
+	assert.Equal(t, int64(150), current)
+	assert.Equal(t, int64(1500), total)
+
+	assert.NoError(t, pull.add(message("a", "Download complete", 0, 0)))
+	current, total = pull.totals()
+	assert.Equal(t, int64(1050), current)
+	assert.Equal(t, int64(1500), total)
+
+	assert.EqualError(t, pull.add(pullMessage{Error: "manifest unknown"}), "manifest unknown")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// envMFACode is the environment variable used to supply the MFA code when tgf cannot prompt the user
+const envMFACode = "TGF_MFA_CODE"
+
+// prompter asks questions to the user, it never waits for an answer if the user cannot be prompted
+type prompter struct {
+	reader      *bufio.Reader
+	out         io.Writer
+	interactive bool // false if the input is not a terminal or if --no-input is set
+	assumeYes   bool // true if --yes is set
+}
+
+// prompts is the prompter used by tgf, it is configured from the command line arguments in NewTGFApplication
+var prompts = newPrompter(os.Stdin, os.Stderr, isTerminal(os.Stdin), false)
+
+func newPrompter(in io.Reader, out io.Writer, interactive, assumeYes bool) *prompter {
+	return &prompter{bufio.NewReader(in), out, interactive, assumeYes}
+}
+
+// readLine prints the question and returns the trimmed answer of the user
+func (p *prompter) readLine(format string, args ...interface{}) (string, error) {
+	fmt.Fprintf(p.out, format, args...)
+	answer, err := p.reader.ReadString('\n')
+	if err == io.EOF && answer != "" {
+		err = nil
+	}
+	return strings.TrimSpace(answer), err
+}
+
+// ask returns the answer to an optional question, the default value is returned if nothing is entered or if the user cannot be prompted
+func (p *prompter) ask(question, defaultValue string) string {
+	if !p.interactive {
+		return defaultValue
+	}
+	format := "%s: "
+	args := []interface{}{question}
+	if defaultValue != "" {
+		format, args = "%s [%s]: ", append(args, defaultValue)
+	}
+	if answer, _ := p.readLine(format, args...); answer != "" {
+		return answer
+	}
+	return defaultValue
+}
+
+// askRequired returns the answer to a question that has no default value.
+// The answer is taken from the environment variable if it is defined, otherwise an error is returned if the user cannot be prompted.
+func (p *prompter) askRequired(question, envVar string) (string, error) {
+	if value := os.Getenv(envVar); value != "" {
+		return value, nil
+	}
+	if !p.interactive {
+		return "", fmt.Errorf("%s: tgf cannot prompt for the answer (no terminal or --no-input), set %s", question, envVar)
+	}
+	answer, err := p.readLine("%s: ", question)
+	if answer == "" && err == nil {
+		err = fmt.Errorf("%s: no answer entered", question)
+	}
+	return answer, err
+}
+
+// confirm asks the user to confirm an action (the default answer is no).
+// The action is confirmed without asking if --yes is set and an error is returned if the user cannot be prompted.
+func (p *prompter) confirm(question string) (bool, error) {
+	if p.assumeYes {
+		return true, nil
+	}
+	if !p.interactive {
+		return false, fmt.Errorf("%s: tgf cannot prompt for a confirmation (no terminal or --no-input), use --yes to confirm", question)
+	}
+	answer, err := p.readLine("%s (y/n) [n]: ", question)
+	if answer == "" {
+		return false, err
+	}
+	return String(answer).ParseBool(), nil
+}
+
+// confirmText asks the user to type the expected text to confirm an action.
+// The action is confirmed without asking if --yes is set and an error is returned if the user cannot be prompted or if the answer differs.
+func (p *prompter) confirmText(question, expected string) error {
+	if p.assumeYes {
+		return nil
+	}
+	if !p.interactive {
+		return fmt.Errorf("%s: tgf cannot prompt for a confirmation (no terminal or --no-input), use --yes to confirm", question)
+	}
+	if answer, err := p.readLine("%s: ", question); answer != expected {
+		if err != nil && answer == "" {
+			return fmt.Errorf("Cancelled, no answer entered: %v", err)
+		}
+		return fmt.Errorf("Cancelled, the answer does not match %s", expected)
+	}
+	return nil
+}
+
+// choose asks the user to select one of the choices, the default choice is returned if the user cannot be prompted
+func (p *prompter) choose(title string, choices []string, defaultChoice string) string {
+	if !p.interactive {
+		return defaultChoice
+	}
+	return pickChoice(p.reader, p.out, title, choices, defaultChoice)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompterAsk(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		input        string
+		interactive  bool
+		defaultValue string
+		want         string
+		wantOutput   string
+	}{
+		{"Answer", "value\n", true, "default", "value", "Question [default]: "},
+		{"Empty answer", "\n", true, "default", "default", "Question [default]: "},
+		{"Closed input", "", true, "default", "default", "Question [default]: "},
+		{"No default", "\n", true, "", "", "Question: "},
+		{"Non interactive", "value\n", false, "default", "default", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			p := newPrompter(strings.NewReader(tt.input), &out, tt.interactive, false)
+			assert.Equal(t, tt.want, p.ask("Question", tt.defaultValue))
+			assert.Equal(t, tt.wantOutput, out.String())
+		})
+	}
+}
+
+func TestPrompterAskRequired(t *testing.T) {
+	defer os.Setenv(envMFACode, os.Getenv(envMFACode))
+
+	tests := []struct {
+		name        string
+		input       string
+		interactive bool
+		env         string
+		want        string
+		wantErr     string
+	}{
+		{"Answer", "123456\n", true, "", "123456", ""},
+		{"Answer without new line", "123456", true, "", "123456", ""},
+		{"From environment", "", false, "654321", "654321", ""},
+		{"Environment has precedence", "123456\n", true, "654321", "654321", ""},
+		{"Non interactive", "123456\n", false, "", "", "tgf cannot prompt for the answer (no terminal or --no-input), set " + envMFACode},
+		{"Empty answer", "\n", true, "", "", "no answer entered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(envMFACode, tt.env)
+			p := newPrompter(strings.NewReader(tt.input), &bytes.Buffer{}, tt.interactive, false)
+			got, err := p.askRequired("Enter MFA code", envMFACode)
+			assert.Equal(t, tt.want, got)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrompterConfirm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		interactive bool
+		assumeYes   bool
+		want        bool
+		wantErr     bool
+	}{
+		{"Yes", "y\n", true, false, true, false},
+		{"No", "n\n", true, false, false, false},
+		{"Default is no", "\n", true, false, false, false},
+		{"Assume yes", "", true, true, true, false},
+		{"Assume yes without terminal", "", false, true, true, false},
+		{"Non interactive", "y\n", false, false, false, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := newPrompter(strings.NewReader(tt.input), &bytes.Buffer{}, tt.interactive, tt.assumeYes)
+			got, err := p.confirm("Overwrite")
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestPrompterChoose(t *testing.T) {
+	t.Parallel()
+
+	choices := []string{"coveo/tgf:1.21.0", "coveo/tgf:1.20.0"}
+	assert.Equal(t, choices[0], newPrompter(strings.NewReader("2\n"), &bytes.Buffer{}, false, false).choose("Select", choices, choices[0]))
+	assert.Equal(t, choices[1], newPrompter(strings.NewReader("2\n"), &bytes.Buffer{}, true, false).choose("Select", choices, choices[0]))
+}
+
+func TestPrompterConfiguration(t *testing.T) {
+	defer func(current *prompter) { prompts = current }(prompts)
+
+	NewTestApplication([]string{"--yes"})
+	assert.True(t, prompts.assumeYes)
+	NewTestApplication([]string{"--no-input"})
+	assert.False(t, prompts.interactive)
+	assert.False(t, prompts.assumeYes)
+}
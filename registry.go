@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// registryCheckTimeout limits the time spent to get the digest of an image from its registry before falling back to docker pull
+const registryCheckTimeout = 5 * time.Second
+
+// The registry of the images that do not specify one
+const (
+	dockerHubRegistry    = "registry-1.docker.io"
+	dockerHubAuthsKey    = "https://index.docker.io/v1/"
+	registryDigestHeader = "Docker-Content-Digest"
+)
+
+// registryScheme is the protocol used to contact the registries (only changed by the tests)
+var registryScheme = "https"
+
+// The manifest types accepted when the digest is requested, the multi-platform lists come first since it is what docker pull records
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+var reAuthenticateParameter = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseImageReference splits an image reference into its registry, repository and tag (the digest references are not supported)
+func parseImageReference(image string) (registry, repository, tag string, err error) {
+	if strings.Contains(image, "@") {
+		return "", "", "", fmt.Errorf("%s is referenced by digest", image)
+	}
+	repository, tag = getRepository(image), "latest"
+	if repository != image {
+		tag = image[len(repository)+1:]
+	}
+	registry = dockerHubRegistry
+	if parts := strings.SplitN(repository, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, repository = parts[0], parts[1]
+	} else if len(parts) == 1 {
+		repository = "library/" + repository
+	}
+	return
+}
+
+// getRegistryCredentials returns the credentials of the registry stored by docker login in the docker configuration file (the
+// credentials stored by a credential helper are not available)
+func getRegistryCredentials(registry string) (user, password string, ok bool) {
+	folder := os.Getenv("DOCKER_CONFIG")
+	if folder == "" {
+		home, _ := os.UserHomeDir()
+		folder = filepath.Join(home, ".docker")
+	}
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	content, err := ioutil.ReadFile(filepath.Join(folder, "config.json"))
+	if err != nil || json.Unmarshal(content, &dockerConfig) != nil {
+		return
+	}
+	key := registry
+	if registry == dockerHubRegistry {
+		key = dockerHubAuthsKey
+	}
+	for _, candidate := range []string{key, "https://" + key} {
+		if auth, found := dockerConfig.Auths[candidate]; found && auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return
+			}
+			user, password = Split2(string(decoded), ":")
+			return user, password, true
+		}
+	}
+	return
+}
+
+// getRegistryToken requests a bearer token as specified by the WWW-Authenticate challenge returned by the registry
+func getRegistryToken(client *http.Client, registry, challenge string) (string, error) {
+	parameters := make(map[string]string)
+	for _, match := range reAuthenticateParameter.FindAllStringSubmatch(challenge, -1) {
+		parameters[match[1]] = match[2]
+	}
+	if parameters["realm"] == "" {
+		return "", fmt.Errorf("Unsupported authentication challenge %q", challenge)
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if parameters[key] != "" {
+			query.Set(key, parameters[key])
+		}
+	}
+	request, err := http.NewRequest(http.MethodGet, parameters["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if user, password, ok := getRegistryCredentials(registry); ok {
+		request.SetBasicAuth(user, password)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get a token from %s: %s", parameters["realm"], response.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		return token.AccessToken, nil
+	}
+	return token.Token, nil
+}
+
+// getRemoteDigest returns the digest of the image manifest in its registry, using a HEAD request that does not count as a pull
+func getRemoteDigest(image string) (string, error) {
+	registry, repository, tag, err := parseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	client := newHTTPClient(registryCheckTimeout)
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", registryScheme, registry, repository, tag)
+	head := func(authorization string) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response, err := client.Do(request)
+		if err == nil {
+			response.Body.Close()
+		}
+		return response, err
+	}
+
+	response, err := head("")
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		var authorization string
+		if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+			user, password, ok := getRegistryCredentials(registry)
+			if !ok {
+				return "", fmt.Errorf("No credentials available for %s", registry)
+			}
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+		} else {
+			token, err := getRegistryToken(client, registry, challenge)
+			if err != nil {
+				return "", err
+			}
+			authorization = "Bearer " + token
+		}
+		if response, err = head(authorization); err != nil {
+			return "", err
+		}
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get the manifest of %s: %s", image, response.Status)
+	}
+	digest := response.Header.Get(registryDigestHeader)
+	if digest == "" {
+		return "", fmt.Errorf("The registry did not return the digest of %s", image)
+	}
+	return digest, nil
+}
+
+// isImageUpToDate returns true if the local image has been pulled from the manifest currently referenced by its tag in the registry
+func isImageUpToDate(image string) (bool, error) {
+	local := lookupImage(image).Digest
+	if !strings.Contains(local, "@") {
+		// The image has never been pulled (or it has been built locally)
+		return false, nil
+	}
+	remote, err := getRemoteDigest(image)
+	if err != nil {
+		return false, err
+	}
+	return local[strings.Index(local, "@")+1:] == remote, nil
+}
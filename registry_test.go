@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image      string
+		registry   string
+		repository string
+		tag        string
+		wantErr    bool
+	}{
+		{image: "ubuntu", registry: dockerHubRegistry, repository: "library/ubuntu", tag: "latest"},
+		{image: "coveo/tgf:1.21", registry: dockerHubRegistry, repository: "coveo/tgf", tag: "1.21"},
+		{image: "localhost:5000/tgf:dev", registry: "localhost:5000", repository: "tgf", tag: "dev"},
+		{image: "123456789012.dkr.ecr.us-east-1.amazonaws.com/team/tgf:1.21", registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com", repository: "team/tgf", tag: "1.21"},
+		{image: "coveo/tgf@sha256:1234", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			registry, repository, tag, err := parseImageReference(tt.image)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.registry, registry)
+			assert.Equal(t, tt.repository, repository)
+			assert.Equal(t, tt.tag, tag)
+		})
+	}
+}
+
+func TestGetRegistryCredentials(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestGetRegistryCredentials")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", tempDir)
+
+	_, _, ok := getRegistryCredentials(dockerHubRegistry)
+	assert.False(t, ok, "No docker configuration")
+
+	must(ioutil.WriteFile(filepath.Join(tempDir, "config.json"), []byte(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "am9objpzZWNyZXQ="},
+			"registry.example.com": {}
+		},
+		"credsStore": "desktop"
+	}`), 0600))
+	user, password, ok := getRegistryCredentials(dockerHubRegistry)
+	assert.True(t, ok)
+	assert.Equal(t, "john", user)
+	assert.Equal(t, "secret", password)
+	_, _, ok = getRegistryCredentials("registry.example.com")
+	assert.False(t, ok, "The credentials are stored by a credential helper")
+}
+
+func TestGetRemoteDigest(t *testing.T) {
+	var server *httptest.Server
+	heads := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:coveo/tgf:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:coveo/tgf:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/coveo/tgf/manifests/1.21":
+			heads++
+			assert.Equal(t, http.MethodHead, r.Method)
+			assert.True(t, strings.HasPrefix(r.Header.Get("Accept"), manifestMediaTypes[0]))
+			w.Header().Set(registryDigestHeader, "sha256:abcd")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(scheme string) { registryScheme = scheme }(registryScheme)
+	registryScheme = "http"
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	digest, err := getRemoteDigest(registry + "/coveo/tgf:1.21")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:abcd", digest)
+	assert.Equal(t, 1, heads)
+
+	_, err = getRemoteDigest(registry + "/coveo/tgf:unknown")
+	assert.EqualError(t, err, "Unable to get the manifest of "+registry+"/coveo/tgf:unknown: 404 Not Found")
+
+	tempDir := must(ioutil.TempDir("", "TestGetRemoteDigest")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", tempDir)
+	defer func(inspect func(string) (imageInfo, error)) { inspectImage = inspect }(inspectImage)
+
+	tests := []struct {
+		name  string
+		local imageInfo
+		want  bool
+	}{
+		{"Same digest", imageInfo{Exists: true, Digest: registry + "/coveo/tgf@sha256:abcd"}, true},
+		{"Different digest", imageInfo{Exists: true, Digest: registry + "/coveo/tgf@sha256:0000"}, false},
+		{"Built locally", imageInfo{Exists: true, Digest: "sha256:1234"}, false},
+		{"Missing", imageInfo{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspectImage = func(string) (imageInfo, error) { return tt.local, nil }
+			invalidateImageCache(registry + "/coveo/tgf:1.21")
+			upToDate, err := isImageUpToDate(registry + "/coveo/tgf:1.21")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, upToDate)
+		})
+	}
+}
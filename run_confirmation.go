@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RunConfirmation requires the user to type the AWS account before running a command matching all the criteria
+type RunConfirmation struct {
+	Account    string `yaml:"account,omitempty" json:"account,omitempty" hcl:"account,omitempty"`
+	Profile    string `yaml:"profile,omitempty" json:"profile,omitempty" hcl:"profile,omitempty"`
+	EntryPoint string `yaml:"entry-point,omitempty" json:"entry-point,omitempty" hcl:"entry-point,omitempty"`
+	Arguments  string `yaml:"arguments,omitempty" json:"arguments,omitempty" hcl:"arguments,omitempty"`
+	Message    string `yaml:"message,omitempty" json:"message,omitempty" hcl:"message,omitempty"`
+}
+
+// matches returns true if the run matches all the criteria of the rule.
+// The account, the profile and the entry point are glob patterns, the arguments are matched by a regular expression.
+func (rule RunConfirmation) matches(context awsContext, entryPoint string, args []string) (bool, error) {
+	match := func(pattern, value string) bool {
+		if pattern == "" {
+			return true
+		}
+		matched, _ := filepath.Match(pattern, value)
+		return matched
+	}
+	if !match(rule.Account, context.account) || !match(rule.Profile, context.profile) || !match(rule.EntryPoint, filepath.Base(entryPoint)) {
+		return false, nil
+	}
+	if rule.Arguments == "" {
+		return true, nil
+	}
+	re, err := regexp.Compile(rule.Arguments)
+	if err != nil {
+		return false, fmt.Errorf("Invalid arguments expression %q in run-confirmations: %v", rule.Arguments, err)
+	}
+	return re.MatchString(strings.Join(args, " ")), nil
+}
+
+// getConfirmationPrompt returns the question asked to the user and the answer expected to continue
+func (rule RunConfirmation) getConfirmationPrompt(context awsContext, entryPoint string, args []string) (question, expected string) {
+	command := strings.TrimSpace(filepath.Base(entryPoint) + " " + strings.Join(args, " "))
+	target, expected := "", "yes"
+	if context.account != "" {
+		target, expected = " against account "+context.account, context.account
+	}
+	if context.profile != "" {
+		target += fmt.Sprintf(" (profile %s)", context.profile)
+	}
+	question = fmt.Sprintf("You are about to run %s%s", command, target)
+	if rule.Message != "" {
+		question += ", " + rule.Message
+	}
+	if expected == "yes" {
+		return question + ", type yes to continue", expected
+	}
+	return question + ", type the account id to continue", expected
+}
+
+// confirmRun asks the user to confirm the run if it matches one of the run confirmation rules
+func (config *TGFConfig) confirmRun() error {
+	if len(config.RunConfirmations) == 0 {
+		return nil
+	}
+	context := awsContext{profile: config.getProfileName()}
+	if config.awsSession != nil {
+		context.account = config.getAWSAccount()
+	}
+	args := config.tgf.Unmanaged
+	for _, rule := range config.RunConfirmations {
+		matched, err := rule.matches(context, config.EntryPoint, args)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		question, expected := rule.getConfirmationPrompt(context, config.EntryPoint, args)
+		return prompts.confirmText(question, expected)
+	}
+	return nil
+}
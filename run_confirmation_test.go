@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunConfirmationMatches(t *testing.T) {
+	t.Parallel()
+
+	context := awsContext{account: "123456789012", profile: "prod"}
+	tests := []struct {
+		name    string
+		rule    RunConfirmation
+		args    []string
+		want    bool
+		wantErr bool
+	}{
+		{"No criteria", RunConfirmation{}, nil, true, false},
+		{"Account", RunConfirmation{Account: "123456789012"}, []string{"plan"}, true, false},
+		{"Other account", RunConfirmation{Account: "210987654321"}, []string{"apply"}, false, false},
+		{"Profile pattern", RunConfirmation{Profile: "prod*"}, []string{"apply"}, true, false},
+		{"Entry point", RunConfirmation{EntryPoint: "terraform"}, []string{"apply"}, false, false},
+		{"Arguments", RunConfirmation{Account: "123456789012", Arguments: `\b(apply|destroy)\b`}, []string{"apply", "-auto-approve"}, true, false},
+		{"Other arguments", RunConfirmation{Arguments: `\b(apply|destroy)\b`}, []string{"plan"}, false, false},
+		{"Invalid arguments expression", RunConfirmation{Arguments: `(apply`}, []string{"apply"}, false, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.rule.matches(context, "/usr/local/bin/terragrunt", tt.args)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRunConfirmationPrompt(t *testing.T) {
+	t.Parallel()
+
+	question, expected := RunConfirmation{Message: "this is production"}.getConfirmationPrompt(awsContext{account: "123456789012", profile: "prod"}, "terragrunt", []string{"apply"})
+	assert.Equal(t, "You are about to run terragrunt apply against account 123456789012 (profile prod), this is production, type the account id to continue", question)
+	assert.Equal(t, "123456789012", expected)
+
+	question, expected = RunConfirmation{}.getConfirmationPrompt(awsContext{}, "terragrunt", nil)
+	assert.Equal(t, "You are about to run terragrunt, type yes to continue", question)
+	assert.Equal(t, "yes", expected)
+}
+
+func TestConfirmRun(t *testing.T) {
+	defer func(current *prompter) { prompts = current }(prompts)
+
+	tests := []struct {
+		name        string
+		args        []string
+		input       string
+		interactive bool
+		assumeYes   bool
+		wantErr     string
+	}{
+		{"Not matching", []string{"plan"}, "", false, false, ""},
+		{"Confirmed", []string{"apply"}, "yes\n", true, false, ""},
+		{"Wrong answer", []string{"apply"}, "y\n", true, false, "Cancelled, the answer does not match yes"},
+		{"No terminal", []string{"apply"}, "yes\n", false, false, "use --yes to confirm"},
+		{"Assume yes", []string{"apply"}, "", false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &TGFConfig{
+				tgf:              NewTestApplication(tt.args),
+				EntryPoint:       "terragrunt",
+				RunConfirmations: []RunConfirmation{{Arguments: "^apply"}},
+			}
+			prompts = newPrompter(strings.NewReader(tt.input), &bytes.Buffer{}, tt.interactive, tt.assumeYes)
+			err := config.confirmRun()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Runners supported by the runner configuration key
+const (
+	runnerDocker     = "docker"
+	runnerFargate    = "fargate"
+	runnerKubernetes = "kubernetes"
+	runnerSSH        = "ssh"
+)
+
+// remoteWorkspaceIgnore are the folders that are not transferred to the remote runners (they are recreated by terragrunt)
+var remoteWorkspaceIgnore = []string{".git", ".terraform", ".terragrunt-cache"}
+
+// remoteEnvironmentFile is the file, in the remote container, defining the environment injected by tgf (the environment is
+// not part of the pod or task definition to avoid exposing the credentials to the users allowed to read the definitions)
+const remoteEnvironmentFile = "/tmp/tgf.env"
+
+// remoteWorkspaceMarker is created in the remote container once the workspace is transferred, the files changed by the command are
+// newer than it
+const remoteWorkspaceMarker = "/tmp/tgf.workspace"
+
+// remoteRun describes a run executed outside of the local docker daemon
+type remoteRun struct {
+	image       string
+	command     []string
+	environment map[string]string
+	root        string // The local folder transferred to the remote container (git repository root or current folder)
+	remoteRoot  string // The location of root in the remote container
+	workdir     string // The working directory in the remote container
+	span        *traceSpan
+}
+
+// getRunner returns the runner used to execute the command (docker by default)
+func (config *TGFConfig) getRunner() string {
+	if config.Runner == "" {
+		return runnerDocker
+	}
+	return config.Runner
+}
+
+// validateRunner returns an error if the configured runner is not supported
+func (config *TGFConfig) validateRunner() error {
+	switch runner := config.getRunner(); runner {
+	case runnerDocker, runnerFargate, runnerKubernetes, runnerSSH:
+		return nil
+	default:
+		if _, found := findPlugin(runner, pluginRunner); found {
+			return nil
+		}
+		return fmt.Errorf("Unsupported runner %q (supported runners are %s, %s, %s, %s and the runner plugins)", runner, runnerDocker, runnerFargate, runnerKubernetes, runnerSSH)
+	}
+}
+
+// runRemote runs the command with the configured remote runner and returns the exit code of the command
+func (config *TGFConfig) runRemote(run remoteRun) int {
+	switch runner := config.getRunner(); runner {
+	case runnerFargate:
+		return config.runFargate(run)
+	case runnerKubernetes:
+		return config.runKubernetes(run)
+	case runnerSSH:
+		return config.runSSH(run)
+	default:
+		plugin, _ := findPlugin(runner, pluginRunner)
+		return config.runPlugin(plugin, run)
+	}
+}
+
+// runLifecycle holds the steps common to all the runners around the command: the redirection of the output (--strict-output), the
+// before and after commands, the hooks, the logged metadata, the timings and the span of the command. The runners only prepare the
+// run and wait for the command.
+type runLifecycle struct {
+	config    *TGFConfig
+	component string    // The component of the logged metadata (the runner)
+	started   string    // The message logged when the command is started
+	exited    string    // The message logged when the command is completed
+	stdout    io.Writer // The output of the command
+	start     time.Time
+	completed bool
+}
+
+// execute runs the before commands and the pre-run hooks, then the runner prepares the run and calls runCommand. The after commands
+// and the post-run hooks are executed once the command is completed (they are not if the command has not been run).
+func (lifecycle *runLifecycle) execute(runner func() int) int {
+	config := lifecycle.config
+	stdout := os.Stdout
+	if config.tgf.StrictOutput {
+		var restore func()
+		stdout, restore = redirectStdout()
+		defer restore()
+	}
+	if err := runCommands(config.runBeforeCommands); err != nil {
+		return -1
+	}
+	if !config.runPreRunHooks() {
+		return 1
+	}
+
+	lifecycle.stdout = config.getCommandStdout(stdout)
+	exitCode := runner()
+	if !lifecycle.completed {
+		return exitCode
+	}
+	if err := runCommands(config.runAfterCommands); err != nil {
+		printError(msgCommandFailed, err)
+	}
+	config.runPostRunHooks(lifecycle.start, exitCode)
+	return exitCode
+}
+
+// runCommand records the run of the command in the image, wait runs the command and returns its exit code or an error if the
+// command has not been run (the error must then be reported by the runner)
+func (lifecycle *runLifecycle) runCommand(image string, command []string, span *traceSpan, fields map[string]interface{}, wait func() (int, error)) (int, error) {
+	config := lifecycle.config
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["image"], fields["entrypoint"], fields["arguments"], fields["version"] = image, config.EntryPoint, command, version
+	logMetadata(lifecycle.component, lifecycle.started, fields)
+	config.runImage = image
+	lifecycle.start = time.Now()
+	endContainer := timings.begin("container")
+	tracing.begin(span)
+	exitCode, err := wait()
+	tracing.finish(span)
+	endContainer()
+	if err != nil {
+		return exitCode, err
+	}
+	lifecycle.completed = true
+	endCommand(span, exitCode)
+	logMetadata(lifecycle.component, lifecycle.exited, map[string]interface{}{"exit-code": exitCode, "duration": time.Since(lifecycle.start).Seconds()})
+	return exitCode, nil
+}
+
+// runProcess runs the command and returns its exit code, or an error if the command has not been started
+func runProcess(command *exec.Cmd) (int, error) {
+	err := command.Run()
+	if command.ProcessState == nil {
+		return 0, err
+	}
+	return command.ProcessState.ExitCode(), nil
+}
+
+// newRemoteRun prepares the image, the command, the environment and the folders of a run executed by a remote runner
+func (config *TGFConfig) newRemoteRun() (run remoteRun, err error) {
+	app := config.tgf
+	if app.DockerBuild && len(config.imageBuildConfigs) > 0 {
+		return run, fmt.Errorf("docker-image-build is not supported by the %s runner, the image must be published in a registry", config.getRunner())
+	}
+	if len(config.PolicyGates) > 0 {
+		return run, fmt.Errorf("policy-gates are not supported by the %s runner, they require the docker runner", config.getRunner())
+	}
+	run.command = config.Hooks.getContainerCommand(config.getCommand())
+	run.image = config.GetImageName()
+	if !strings.Contains(run.image[strings.LastIndex(run.image, "/")+1:], ":") {
+		run.image += ":latest"
+	}
+
+	cwd := must(filepath.EvalSymlinks(must(os.Getwd()).(string))).(string)
+	run.root = findGitRoot(cwd)
+	if run.root == "" {
+		run.root = cwd
+	}
+	toRemote := func(folder string) string {
+		return filepath.ToSlash(filepath.Join("/", app.MountPoint, strings.TrimPrefix(folder, filepath.VolumeName(folder))))
+	}
+	run.remoteRoot, run.workdir = toRemote(run.root), toRemote(cwd)
+
+	config.setTGFEnvironment(run.image, run.workdir)
+	run.span = config.traceCommand(config.getCommand())
+	config.removeAWSProfileVariables()
+	maskCISecrets(os.Stderr, config.Environment)
+	run.environment = config.Environment
+	return run, nil
+}
+
+// getScript returns the shell script executed in the remote container to run the command
+func (run remoteRun) getScript() string {
+	quoted := make([]string, len(run.command))
+	for i := range run.command {
+		quoted[i] = shellQuote(run.command[i])
+	}
+	return fmt.Sprintf("set -a && . %s && rm -f %[1]s && set +a && cd %s && exec %s", remoteEnvironmentFile, shellQuote(run.workdir), strings.Join(quoted, " "))
+}
+
+// getEnvironmentFile returns the content of the file defining the environment injected by tgf
+func (run remoteRun) getEnvironmentFile() []byte {
+	keys := make([]string, 0, len(run.environment))
+	for key := range run.environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var content strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&content, "%s=%s\n", key, shellQuote(run.environment[key]))
+	}
+	return []byte(content.String())
+}
+
+// writeEnvironment adds the environment file to the archive
+func (run remoteRun) writeEnvironment(archive *tar.Writer) error {
+	environment := run.getEnvironmentFile()
+	if err := archive.WriteHeader(&tar.Header{Name: strings.TrimPrefix(remoteEnvironmentFile, "/"), Mode: 0600, Size: int64(len(environment))}); err != nil {
+		return err
+	}
+	_, err := archive.Write(environment)
+	return err
+}
+
+// writeWorkspace writes a tar archive containing the local folder (at its remote location) and the environment file. The archive
+// is streamed, so the workspace is never kept in memory.
+func (run remoteRun) writeWorkspace(w io.Writer) error {
+	archive := tar.NewWriter(w)
+	if err := run.writeEnvironment(archive); err != nil {
+		return err
+	}
+
+	err := filepath.Walk(run.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != run.root {
+			for _, ignored := range remoteWorkspaceIgnore {
+				if info.Name() == ignored {
+					return filepath.SkipDir
+				}
+			}
+		}
+		relative := must(filepath.Rel(run.root, path)).(string)
+		name := strings.TrimPrefix(filepath.ToSlash(filepath.Join(run.remoteRoot, relative)), "/")
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			// Sockets, devices and pipes are not transferred
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// getChangesScript returns the shell script writing on stdout a tar archive of the files of the workspace changed by the command
+// (plan files, lock files, etc.), the folders recreated by terragrunt are excluded
+func (run remoteRun) getChangesScript() string {
+	find := "find . -type f -newer " + remoteWorkspaceMarker
+	for _, ignored := range remoteWorkspaceIgnore {
+		find += fmt.Sprintf(" ! -path '*/%s/*'", ignored)
+	}
+	return fmt.Sprintf("cd %s && %s > %s.changes && if [ -s %[3]s.changes ]; then tar -cf - -T %[3]s.changes; fi", shellQuote(run.remoteRoot), find, remoteWorkspaceMarker)
+}
+
+// readChanges extracts the files of the archive written by getChangesScript in the local folder
+func (run remoteRun) readChanges(r io.Reader) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// The names are cleaned as absolute paths, so the files cannot be written outside of the local folder
+		name := filepath.Join(run.root, filepath.FromSlash(path.Clean("/"+header.Name)))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, archive)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		os.Chtimes(name, header.ModTime, header.ModTime)
+	}
+}
+
+// copyChanges copies the files changed by the command back to the local folder, the command must run getChangesScript in the remote
+// container
+func (run remoteRun) copyChanges(command *exec.Cmd) error {
+	command.Stderr = os.Stderr
+	output, err := command.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := command.Start(); err != nil {
+		return err
+	}
+	if err = run.readChanges(output); err != nil {
+		// The remaining output is discarded, so the command is not blocked
+		io.Copy(ioutil.Discard, output)
+	}
+	if waitErr := command.Wait(); err == nil {
+		err = waitErr
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteRunScript(t *testing.T) {
+	t.Parallel()
+
+	run := remoteRun{
+		command:     []string{"terragrunt", "plan", "-var", "name=my value"},
+		environment: map[string]string{"TGF_COMMAND": "plan", "SECRET": "it's secret"},
+		workdir:     "/var/tgf/live/dev",
+	}
+	assert.Equal(t, "set -a && . /tmp/tgf.env && rm -f /tmp/tgf.env && set +a && cd /var/tgf/live/dev && exec terragrunt plan -var 'name=my value'", run.getScript())
+	assert.Equal(t, "SECRET='it'\\''s secret'\nTGF_COMMAND=plan\n", string(run.getEnvironmentFile()))
+}
+
+func TestValidateRunner(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, (&TGFConfig{}).validateRunner())
+	assert.NoError(t, (&TGFConfig{Runner: runnerKubernetes}).validateRunner())
+	assert.NoError(t, (&TGFConfig{Runner: runnerSSH}).validateRunner())
+	assert.EqualError(t, (&TGFConfig{Runner: "lambda"}).validateRunner(), `Unsupported runner "lambda" (supported runners are docker, fargate, kubernetes, ssh and the runner plugins)`)
+}
+
+func TestRemoteRunWorkspace(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestRemoteRunWorkspace")).(string)
+	defer os.RemoveAll(tempDir)
+	for _, folder := range []string{"live/dev", ".git", "live/dev/.terragrunt-cache", "live/dev/.terraform"} {
+		must(os.MkdirAll(filepath.Join(tempDir, folder), 0755))
+	}
+	for _, file := range []string{"live/dev/terragrunt.hcl", ".git/HEAD", "live/dev/.terragrunt-cache/main.tf", "live/dev/.terraform/plugin"} {
+		must(ioutil.WriteFile(filepath.Join(tempDir, file), []byte(file), 0644))
+	}
+	must(os.Symlink("dev", filepath.Join(tempDir, "live", "current")))
+
+	run := remoteRun{root: tempDir, remoteRoot: "/var/tgf", environment: map[string]string{"TGF_COMMAND": "plan"}}
+	var buffer bytes.Buffer
+	assert.NoError(t, run.writeWorkspace(&buffer))
+
+	entries := make(map[string]string)
+	archive := tar.NewReader(&buffer)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content := must(ioutil.ReadAll(archive)).([]byte)
+		if header.Typeflag == tar.TypeSymlink {
+			content = []byte("-> " + header.Linkname)
+		}
+		entries[header.Name] = string(content)
+	}
+	assert.Equal(t, map[string]string{
+		"tmp/tgf.env":                     "TGF_COMMAND=plan\n",
+		"var/tgf/":                        "",
+		"var/tgf/live/":                   "",
+		"var/tgf/live/current":            "-> dev",
+		"var/tgf/live/dev/":               "",
+		"var/tgf/live/dev/terragrunt.hcl": "live/dev/terragrunt.hcl",
+	}, entries)
+}
+
+func TestRunLifecycle(t *testing.T) {
+	skipOnWindows(t, "the after commands are shell scripts")
+	tempDir := must(ioutil.TempDir("", "TestRunLifecycle")).(string)
+	defer os.RemoveAll(tempDir)
+	marker := filepath.Join(tempDir, "after")
+
+	tests := []struct {
+		name      string
+		wait      func() (int, error)
+		want      int
+		wantAfter bool
+	}{
+		{"Completed", func() (int, error) { return 3, nil }, 3, true},
+		{"Not started", func() (int, error) { return 0, errors.New("not started") }, exitDockerUnavailable, false},
+		{"Not run", nil, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(marker)
+			config := &TGFConfig{tgf: NewTestApplication(nil), EntryPoint: "terragrunt", runAfterCommands: []string{"touch " + marker}}
+			lifecycle := &runLifecycle{config: config, component: "test", started: "Starting", exited: "Exited"}
+			exitCode := lifecycle.execute(func() int {
+				if tt.wait == nil {
+					return 1
+				}
+				exitCode, err := lifecycle.runCommand("coveo/tgf:1.21.0", []string{"terragrunt", "plan"}, nil, nil, tt.wait)
+				if err != nil {
+					return exitDockerUnavailable
+				}
+				return exitCode
+			})
+			assert.Equal(t, tt.want, exitCode)
+			if tt.wantAfter {
+				assert.FileExists(t, marker, "The after commands are executed once the command is completed")
+			} else {
+				_, err := os.Stat(marker)
+				assert.True(t, os.IsNotExist(err), "The after commands are not executed if the command has not been run")
+			}
+		})
+	}
+}
+
+func TestRemoteRunChanges(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestRemoteRunChanges")).(string)
+	defer os.RemoveAll(tempDir)
+
+	run := remoteRun{root: filepath.Join(tempDir, "root"), remoteRoot: "/var/tgf"}
+	assert.Equal(t, "cd /var/tgf && find . -type f -newer /tmp/tgf.workspace ! -path '*/.git/*' ! -path '*/.terraform/*' ! -path '*/.terragrunt-cache/*' > /tmp/tgf.workspace.changes && if [ -s /tmp/tgf.workspace.changes ]; then tar -cf - -T /tmp/tgf.workspace.changes; fi", run.getChangesScript())
+
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	for name, content := range map[string]string{"./live/plan.out": "plan", "./.terraform.lock.hcl": "lock", "../../escape": "escape"} {
+		archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		archive.Write([]byte(content))
+	}
+	archive.WriteHeader(&tar.Header{Name: "./link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})
+	archive.Close()
+	assert.NoError(t, run.readChanges(&buffer))
+
+	assert.Equal(t, "plan", string(must(ioutil.ReadFile(filepath.Join(run.root, "live", "plan.out"))).([]byte)))
+	assert.Equal(t, "lock", string(must(ioutil.ReadFile(filepath.Join(run.root, ".terraform.lock.hcl"))).([]byte)))
+	assert.Equal(t, "escape", string(must(ioutil.ReadFile(filepath.Join(run.root, "escape"))).([]byte)), "The files are kept in the local folder")
+	_, err := os.Lstat(filepath.Join(run.root, "link"))
+	assert.True(t, os.IsNotExist(err), "Only the regular files are copied back")
+	assert.NoError(t, run.readChanges(&bytes.Buffer{}), "There is no archive if no file has been changed")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// relaySignals keeps tgf alive while the process runs and forwards the termination requests to it, so tgf can still run the
+// run-after scripts and the hooks once the process has exited. The interruptions typed in the console (Ctrl+C) are not forwarded
+// since the process receives them directly. The returned function stops the relay.
+func relaySignals(process *os.Process) func() {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-received:
+				if sig != os.Interrupt {
+					forwardSignal(process, sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(received)
+		close(done)
+	}
+}
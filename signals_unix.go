@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "os"
+
+// forwardSignal sends the signal to the process
+func forwardSignal(process *os.Process, sig os.Signal) {
+	process.Signal(sig)
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelaySignals(t *testing.T) {
+	command := exec.Command("sleep", "30")
+	must(command.Start())
+	stopRelay := relaySignals(command.Process)
+	defer stopRelay()
+
+	start := time.Now()
+	// tgf survives the termination request, it is forwarded to the process
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	err := command.Wait()
+	assert.Error(t, err)
+	assert.Equal(t, syscall.SIGTERM, command.ProcessState.Sys().(syscall.WaitStatus).Signal())
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
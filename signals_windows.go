@@ -0,0 +1,7 @@
+package main
+
+import "os"
+
+// forwardSignal does nothing on Windows: the console events translated to SIGTERM (close, logoff, shutdown) are sent to every process
+// attached to the console, so docker already receives them (os.Process.Signal only supports os.Kill on Windows)
+func forwardSignal(process *os.Process, sig os.Signal) {}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultSSHWorkspaces = "/tmp/tgf-workspaces" // The folder of the runner host where the workspaces are synchronized if none is configured
+	sshConnectionFailure = 255                   // Exit code returned by ssh if the connection has failed or has been lost
+)
+
+// Programs used to communicate with the runner host (only changed by the tests)
+var (
+	sshProgram   = "ssh"
+	rsyncProgram = "rsync"
+)
+
+// SSHConfig defines the host where the ssh runner starts the containers and how the workspace is made available on it
+type SSHConfig struct {
+	Host          string            `yaml:"host,omitempty" json:"host,omitempty" hcl:"host,omitempty"`
+	Port          int               `yaml:"port,omitempty" json:"port,omitempty" hcl:"port,omitempty"`
+	IdentityFile  string            `yaml:"identity-file,omitempty" json:"identity-file,omitempty" hcl:"identity-file,omitempty"`
+	Options       []string          `yaml:"options,omitempty" json:"options,omitempty" hcl:"options,omitempty"`
+	Workspaces    string            `yaml:"workspaces,omitempty" json:"workspaces,omitempty" hcl:"workspaces,omitempty"`
+	SharedFolders map[string]string `yaml:"shared-folders,omitempty" json:"shared-folders,omitempty" hcl:"shared-folders,omitempty"`
+	DockerOptions []string          `yaml:"docker-options,omitempty" json:"docker-options,omitempty" hcl:"docker-options,omitempty"`
+}
+
+// getSSHArgs returns the ssh arguments preceding the destination (port, identity and options)
+func (ssh SSHConfig) getSSHArgs() (args []string) {
+	if ssh.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(ssh.Port))
+	}
+	if ssh.IdentityFile != "" {
+		identity := ssh.IdentityFile
+		if strings.HasPrefix(identity, "~/") {
+			identity = filepath.Join(getHomeFolder(), identity[2:])
+		}
+		args = append(args, "-i", identity)
+	}
+	for _, option := range ssh.Options {
+		args = append(args, "-o", option)
+	}
+	return
+}
+
+// command returns a ssh command executing the shell command line on the runner host
+func (ssh SSHConfig) command(tty bool, commandLine string) *exec.Cmd {
+	args := ssh.getSSHArgs()
+	if tty {
+		args = append(args, "-t")
+	}
+	return exec.Command(sshProgram, append(args, ssh.Host, commandLine)...)
+}
+
+// getRemoteFolder returns the location of the local folder on the runner host and true if the folder is shared with the host (the
+// folder must be synchronized otherwise). The synchronized workspaces are identified by the local host and folder so the files kept on
+// the runner host (.terraform, .terragrunt-cache) are reused by the next runs.
+func (ssh SSHConfig) getRemoteFolder(folder string) (string, bool) {
+	locals := make([]string, 0, len(ssh.SharedFolders))
+	for local := range ssh.SharedFolders {
+		locals = append(locals, local)
+	}
+	// The most specific shared folder is used
+	sort.Sort(sort.Reverse(sort.StringSlice(locals)))
+	for _, local := range locals {
+		if relative, err := filepath.Rel(local, folder); err == nil && !strings.HasPrefix(relative, "..") {
+			return path.Join(ssh.SharedFolders[local], filepath.ToSlash(relative)), true
+		}
+	}
+
+	workspaces := ssh.Workspaces
+	if workspaces == "" {
+		workspaces = defaultSSHWorkspaces
+	}
+	hostname, _ := os.Hostname()
+	return path.Join(workspaces, fmt.Sprintf("%x", sha256.Sum256([]byte(hostname+":"+folder)))[:16]), false
+}
+
+// getRsh returns the remote shell used by rsync (ssh with the configured arguments)
+func (ssh SSHConfig) getRsh() string {
+	quoted := []string{sshProgram}
+	for _, arg := range ssh.getSSHArgs() {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// getRsyncArgs returns the rsync arguments synchronizing the local folder to the runner host, the folders recreated by terragrunt
+// are neither transferred nor deleted
+func (ssh SSHConfig) getRsyncArgs(folder, remoteFolder string) []string {
+	args := []string{"--archive", "--compress", "--delete", "--rsh", ssh.getRsh(), "--rsync-path", fmt.Sprintf("mkdir -p -m 700 %s && rsync", shellQuote(remoteFolder))}
+	for _, ignored := range remoteWorkspaceIgnore {
+		args = append(args, "--exclude", ignored+"/")
+	}
+	return append(args, strings.TrimSuffix(folder, string(filepath.Separator))+string(filepath.Separator), ssh.Host+":"+remoteFolder+"/")
+}
+
+// getCopyBackArgs returns the rsync arguments copying the files changed by the command on the runner host back to the local folder.
+// The local files are never deleted and the local files changed during the run are kept.
+func (ssh SSHConfig) getCopyBackArgs(remoteFolder, folder string) []string {
+	args := []string{"--archive", "--compress", "--update", "--rsh", ssh.getRsh()}
+	for _, ignored := range remoteWorkspaceIgnore {
+		args = append(args, "--exclude", ignored+"/")
+	}
+	return append(args, ssh.Host+":"+remoteFolder+"/", strings.TrimSuffix(folder, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// getCreateCommand returns the docker command creating the container on the runner host, the environment is copied in the container
+// before it is started to avoid exposing the credentials in the container definition
+func (ssh SSHConfig) getCreateCommand(name string, run remoteRun, remoteFolder string, tty bool) []string {
+	args := []string{"docker", "create", "--rm", "--interactive", "--name", name, "--label", tgfLabel + "=" + version}
+	if tty {
+		args = append(args, "--tty")
+	}
+	args = append(args, "--volume", remoteFolder+":"+run.remoteRoot, "--workdir", run.workdir)
+	args = append(args, ssh.DockerOptions...)
+	return append(args, "--entrypoint", "sh", run.image, "-c", run.getScript())
+}
+
+// getCommandLine returns the arguments quoted for the shell of the runner host
+func getCommandLine(args ...string) string {
+	quoted := make([]string, len(args))
+	for i := range args {
+		quoted[i] = shellQuote(args[i])
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runSSH runs the command in a container started on the runner host through ssh and returns the exit code of the command
+func (config *TGFConfig) runSSH(run remoteRun) int {
+	app, ssh := config.tgf, config.SSH
+	if ssh.Host == "" {
+		return failWith(exitConfig, fmt.Errorf("The ssh runner requires ssh.host"))
+	}
+	name := "tgf-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	remoteFolder, shared := ssh.getRemoteFolder(run.root)
+	tty := app.DockerInteractive && !isCI() && isTerminal(os.Stdin)
+	create := ssh.getCreateCommand(name, run, remoteFolder, tty)
+	if app.DryRun {
+		if shared {
+			fmt.Fprintf(os.Stdout, "# Container started by tgf on %s (the folder %s is shared as %s)\n", ssh.Host, run.root, remoteFolder)
+		} else {
+			fmt.Fprintf(os.Stdout, "# Container started by tgf on %s (the folder %s is synchronized to %s)\n", ssh.Host, run.root, remoteFolder)
+		}
+		writeDryRun(os.Stdout, create, run.environment, config.runBeforeCommands, config.runAfterCommands, config.Hooks)
+		return 0
+	}
+
+	lifecycle := &runLifecycle{config: config, component: "ssh", started: "Starting container", exited: "Container exited"}
+	return lifecycle.execute(func() int {
+		// The interruptions are handled by ssh, tgf must survive them to remove the container
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupt)
+
+		if !shared {
+			endTransfer := timings.begin("workspace transfer")
+			app.Debug("# Synchronizing %s to %s:%s", run.root, ssh.Host, remoteFolder)
+			rsync := exec.Command(rsyncProgram, ssh.getRsyncArgs(run.root, remoteFolder)...)
+			rsync.Stdout, rsync.Stderr = os.Stderr, os.Stderr
+			err := rsync.Run()
+			endTransfer()
+			if err != nil {
+				return failWith(exitDockerUnavailable, fmt.Errorf("Unable to synchronize %s to %s:%s: %v", run.root, ssh.Host, remoteFolder, err))
+			}
+		}
+
+		app.Debug("# Creating container %s with image %s on %s", name, run.image, ssh.Host)
+		command := ssh.command(false, getCommandLine(create...))
+		command.Stderr = os.Stderr
+		if err := command.Run(); err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to create the container %s on %s: %v", name, ssh.Host, err))
+		}
+		defer func() {
+			app.Debug("# Removing container %s from %s", name, ssh.Host)
+			if output, err := ssh.command(false, getCommandLine("docker", "rm", "--force", name)).CombinedOutput(); err != nil && !strings.Contains(string(output), "No such container") {
+				printWarning(msgSSHContainerNotRemoved, name, ssh.Host, err)
+			}
+		}()
+
+		reader, writer := io.Pipe()
+		go func() {
+			archive := tar.NewWriter(writer)
+			err := run.writeEnvironment(archive)
+			if err == nil {
+				err = archive.Close()
+			}
+			writer.CloseWithError(err)
+		}()
+		command = ssh.command(false, getCommandLine("docker", "cp", "-", name+":/"))
+		command.Stdin, command.Stderr = reader, os.Stderr
+		err := command.Run()
+		reader.Close()
+		if err != nil {
+			return failWith(exitDockerUnavailable, fmt.Errorf("Unable to transfer the environment to the container %s on %s: %v", name, ssh.Host, err))
+		}
+
+		command = ssh.command(tty, getCommandLine("docker", "start", "--attach", "--interactive", name))
+		command.Stdin, command.Stdout, command.Stderr = os.Stdin, lifecycle.stdout, os.Stderr
+		fields := map[string]interface{}{"host": ssh.Host, "container": name}
+		exitCode, err := lifecycle.runCommand(run.image, run.command, run.span, fields, func() (int, error) {
+			exitCode, err := runProcess(command)
+			if err != nil {
+				return exitCode, fmt.Errorf("Unable to start the container %s on %s: %v", name, ssh.Host, err)
+			}
+			if exitCode == sshConnectionFailure {
+				// The exit code is the one of ssh, not the one of the command
+				return exitCode, fmt.Errorf("The connection to %s has failed or has been lost while running the container %s (ssh exit code %d)", ssh.Host, name, exitCode)
+			}
+			return exitCode, nil
+		})
+		if err != nil {
+			return failWith(exitDockerUnavailable, err)
+		}
+
+		if !shared {
+			// The files written by the command (plan files, lock files) are copied back, the shared folders are already up to date
+			endTransfer := timings.begin("workspace transfer")
+			rsync := exec.Command(rsyncProgram, ssh.getCopyBackArgs(remoteFolder, run.root)...)
+			rsync.Stdout, rsync.Stderr = os.Stderr, os.Stderr
+			err := rsync.Run()
+			endTransfer()
+			if err != nil {
+				printWarning(msgRemoteChangesNotCopied, run.root, err)
+			}
+		}
+		return exitCode
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSSHArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, SSHConfig{Host: "runner"}.getSSHArgs())
+	ssh := SSHConfig{Host: "runner", Port: 2222, IdentityFile: "/keys/runner", Options: []string{"StrictHostKeyChecking=accept-new"}}
+	assert.Equal(t, []string{"-p", "2222", "-i", "/keys/runner", "-o", "StrictHostKeyChecking=accept-new"}, ssh.getSSHArgs())
+	assert.Equal(t, []string{sshProgram, "-p", "2222", "-i", "/keys/runner", "-o", "StrictHostKeyChecking=accept-new", "-t", "runner", "ls -l"}, ssh.command(true, "ls -l").Args)
+}
+
+func TestGetRemoteFolder(t *testing.T) {
+	t.Parallel()
+
+	ssh := SSHConfig{SharedFolders: map[string]string{"/home/user": "/mnt/home", "/home/user/projects": "/projects"}}
+	tests := []struct {
+		name       string
+		folder     string
+		wantPrefix string
+		wantShared bool
+	}{
+		{"Shared folder", "/home/user/live", "/mnt/home/live", true},
+		{"Most specific shared folder", "/home/user/projects/infra", "/projects/infra", true},
+		{"Synchronized folder", "/opt/infra", defaultSSHWorkspaces + "/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, shared := ssh.getRemoteFolder(tt.folder)
+			assert.True(t, strings.HasPrefix(got, tt.wantPrefix), got)
+			assert.Equal(t, tt.wantShared, shared)
+		})
+	}
+
+	first, _ := SSHConfig{Workspaces: "/data/tgf"}.getRemoteFolder("/opt/infra")
+	second, _ := SSHConfig{Workspaces: "/data/tgf"}.getRemoteFolder("/opt/infra")
+	other, _ := SSHConfig{Workspaces: "/data/tgf"}.getRemoteFolder("/opt/other")
+	assert.Equal(t, first, second, "The workspace is reused by the next runs")
+	assert.NotEqual(t, first, other)
+	assert.True(t, strings.HasPrefix(first, "/data/tgf/"))
+}
+
+func TestGetRsyncArgs(t *testing.T) {
+	t.Parallel()
+
+	ssh := SSHConfig{Host: "user@runner", Port: 2222}
+	assert.Equal(t, []string{
+		"--archive", "--compress", "--delete", "--rsh", sshProgram + " -p 2222", "--rsync-path", "mkdir -p -m 700 /tmp/ws && rsync",
+		"--exclude", ".git/", "--exclude", ".terraform/", "--exclude", ".terragrunt-cache/",
+		"/home/user/live/", "user@runner:/tmp/ws/",
+	}, ssh.getRsyncArgs("/home/user/live", "/tmp/ws"))
+	assert.Equal(t, []string{
+		"--archive", "--compress", "--update", "--rsh", sshProgram + " -p 2222",
+		"--exclude", ".git/", "--exclude", ".terraform/", "--exclude", ".terragrunt-cache/",
+		"user@runner:/tmp/ws/", "/home/user/live/",
+	}, ssh.getCopyBackArgs("/tmp/ws", "/home/user/live"))
+}
+
+func TestRunSSH(t *testing.T) {
+	skipOnWindows(t, "ssh and rsync are replaced by shell scripts")
+	tempDir := must(ioutil.TempDir("", "TestRunSSH")).(string)
+	defer os.RemoveAll(tempDir)
+	log := filepath.Join(tempDir, "ssh.log")
+	environment := filepath.Join(tempDir, "environment.tar")
+	status := filepath.Join(tempDir, "status")
+	must(ioutil.WriteFile(status, []byte("3"), 0644))
+	must(ioutil.WriteFile(filepath.Join(tempDir, "ssh"), []byte(fmt.Sprintf(String(`
+		#!/bin/sh
+		echo "ssh $*" >> %[1]s
+		case "$*" in
+		  *"docker cp - "*) cat > %[2]s;;
+		  *"docker start "*) exit $(cat %[3]s);;
+		esac
+	`).UnIndent().TrimSpace().Str(), log, environment, status)), 0755))
+	must(ioutil.WriteFile(filepath.Join(tempDir, "rsync"), []byte(fmt.Sprintf("#!/bin/sh\necho \"rsync $*\" >> %s\n", log)), 0755))
+	defer func(ssh, rsync string) { sshProgram, rsyncProgram = ssh, rsync }(sshProgram, rsyncProgram)
+	sshProgram, rsyncProgram = filepath.Join(tempDir, "ssh"), filepath.Join(tempDir, "rsync")
+
+	must(os.MkdirAll(filepath.Join(tempDir, "live"), 0755))
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), SSH: SSHConfig{Host: "runner", Workspaces: "/data"}}
+	run := remoteRun{image: "coveo/tgf:1.21.0", command: []string{"terragrunt", "plan"}, root: filepath.Join(tempDir, "live"), remoteRoot: "/var/tgf", workdir: "/var/tgf", environment: map[string]string{"TF_VAR_a": "1"}}
+	assert.Equal(t, 3, config.runSSH(run), "The exit code of the command is returned")
+
+	calls := strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(log)).([]byte))), "\n")
+	if assert.Len(t, calls, 6) {
+		assert.True(t, strings.HasPrefix(calls[0], "rsync --archive"), calls[0])
+		assert.Contains(t, calls[0], filepath.Join(tempDir, "live")+"/ runner:/data/")
+		assert.True(t, strings.HasPrefix(calls[1], "ssh runner docker create --rm --interactive --name tgf-"), calls[1])
+		assert.Contains(t, calls[1], "--volume /data/")
+		assert.NotContains(t, calls[1], "TF_VAR_a", "The environment is not part of the container definition")
+		name := strings.Fields(calls[1])[7]
+		assert.Equal(t, "ssh runner docker cp - "+name+":/", calls[2])
+		assert.Equal(t, "ssh runner docker start --attach --interactive "+name, calls[3])
+		remoteFolder, _ := config.SSH.getRemoteFolder(run.root)
+		assert.Equal(t, "rsync "+strings.Join(config.SSH.getCopyBackArgs(remoteFolder, run.root), " "), calls[4], "The files changed by the command are copied back")
+		assert.Equal(t, "ssh runner docker rm --force "+name, calls[5])
+	}
+	assert.Contains(t, string(must(ioutil.ReadFile(environment)).([]byte)), "TF_VAR_a=1")
+
+	os.Remove(log)
+	config.SSH.SharedFolders = map[string]string{tempDir: "/shared"}
+	assert.Equal(t, 3, config.runSSH(run))
+	calls = strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(log)).([]byte))), "\n")
+	if assert.Len(t, calls, 4, "The shared folders are not synchronized") {
+		assert.Contains(t, calls[0], "--volume /shared/live:/var/tgf")
+	}
+
+	os.Remove(log)
+	must(ioutil.WriteFile(status, []byte("255"), 0644))
+	assert.Equal(t, exitDockerUnavailable, config.runSSH(run), "The ssh connection failures are not the exit code of the command")
+	calls = strings.Split(strings.TrimSpace(string(must(ioutil.ReadFile(log)).([]byte))), "\n")
+	if assert.Len(t, calls, 4) {
+		assert.Contains(t, calls[3], "docker rm --force", "The container is removed")
+	}
+}
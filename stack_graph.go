@@ -0,0 +1,411 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	tgconfig "github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// runOrderedCommand is the command running the stacks in dependency order
+const runOrderedCommand = "run-ordered"
+
+// stackGraphIgnore are the folders that are not searched for terragrunt stacks
+var stackGraphIgnore = []string{".git", ".terraform", ".terragrunt-cache", "node_modules"}
+
+// stackGraph contains the terragrunt stacks found under a folder and the stacks that each of them depends on
+type stackGraph struct {
+	root         string
+	stacks       []string            // The folders of the stacks (sorted)
+	dependencies map[string][]string // The dependencies of each stack (the stacks outside of the root folder are not included)
+	external     map[string][]string // The dependencies of each stack that are outside of the root folder
+}
+
+// getStackDependencies returns the folders of the dependency blocks (and of the legacy dependencies paths) of the terragrunt
+// configuration of the folder
+func getStackDependencies(folder string) ([]string, error) {
+	var paths []string
+	filename := filepath.Join(folder, "terragrunt.hcl")
+	if _, err := os.Stat(filename); err == nil {
+		if paths, err = parseStackDependencies(filename); err != nil {
+			return nil, err
+		}
+	} else {
+		// The terraform.tfvars files are read by the terragrunt library (terragrunt = { dependencies { paths = [...] } }), its logs
+		// are limited to the warnings (2) since it reports each configuration loaded
+		util.SetLoggingLevel(2)
+		config, err := tgconfig.ReadTerragruntConfig(options.NewTerragruntOptions(tgconfig.DefaultConfigPath(folder)))
+		if err != nil {
+			return nil, err
+		}
+		if config.Dependencies != nil {
+			paths = config.Dependencies.Paths
+		}
+	}
+	dependencies := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(folder, path)
+		}
+		dependencies = append(dependencies, filepath.Clean(path))
+	}
+	return dependencies, nil
+}
+
+// parseStackDependencies returns the config_path of the dependency blocks and the paths of the dependencies block of a terragrunt.hcl
+// file, the paths can refer to the folder of the file with get_terragrunt_dir(). The file is not evaluated by terragrunt: the locals,
+// the other functions (find_in_parent_folders(), etc.) cannot be used in the paths and the included files are ignored.
+func parseStackDependencies(filename string) ([]string, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{
+		{Type: "dependency", LabelNames: []string{"name"}},
+		{Type: "dependencies"},
+	}})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	folder := filepath.Dir(filename)
+	getFolder := function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func([]cty.Value, cty.Type) (cty.Value, error) { return cty.StringVal(filepath.ToSlash(folder)), nil },
+	})
+	context := &hcl.EvalContext{Functions: map[string]function.Function{
+		"get_terragrunt_dir":          getFolder,
+		"get_original_terragrunt_dir": getFolder,
+	}}
+
+	var paths []string
+	for _, block := range content.Blocks {
+		attribute := "config_path"
+		if block.Type == "dependencies" {
+			attribute = "paths"
+		}
+		blockContent, _, diags := block.Body.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: attribute, Required: true}}})
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		value, diags := blockContent.Attributes[attribute].Expr.Value(context)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("%v (only the literal paths and get_terragrunt_dir() are supported in the %s of the %s block)", diags, attribute, block.Type)
+		}
+		switch {
+		case value.Type() == cty.String:
+			paths = append(paths, filepath.FromSlash(value.AsString()))
+		case value.CanIterateElements():
+			for it := value.ElementIterator(); it.Next(); {
+				_, element := it.Element()
+				if element.Type() != cty.String {
+					return nil, fmt.Errorf("%s: the dependencies paths must be strings", filename)
+				}
+				paths = append(paths, filepath.FromSlash(element.AsString()))
+			}
+		default:
+			return nil, fmt.Errorf("%s: invalid %s in the %s block", filename, attribute, block.Type)
+		}
+	}
+	return paths, nil
+}
+
+// getStackGraph returns the terragrunt stacks found in the folder and its sub folders along with their dependencies
+func getStackGraph(root string) (*stackGraph, error) {
+	graph := &stackGraph{root: root, dependencies: make(map[string][]string), external: make(map[string][]string)}
+	err := filepath.Walk(root, func(folder string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		for _, ignored := range stackGraphIgnore {
+			if info.Name() == ignored && folder != root {
+				return filepath.SkipDir
+			}
+		}
+		if isTerragruntFolder(folder) {
+			graph.stacks = append(graph.stacks, folder)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(graph.stacks)
+
+	known := make(map[string]bool, len(graph.stacks))
+	for _, stack := range graph.stacks {
+		known[stack] = true
+	}
+	for _, stack := range graph.stacks {
+		dependencies, err := getStackDependencies(stack)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the dependencies of %s: %v", graph.getName(stack), err)
+		}
+		for _, dependency := range dependencies {
+			if known[dependency] {
+				graph.dependencies[stack] = append(graph.dependencies[stack], dependency)
+			} else {
+				graph.external[stack] = append(graph.external[stack], dependency)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// getName returns the folder of the stack relative to the root folder
+func (graph *stackGraph) getName(stack string) string {
+	if relative, err := filepath.Rel(graph.root, stack); err == nil {
+		return filepath.ToSlash(relative)
+	}
+	return stack
+}
+
+// getLevels returns the stacks grouped by level, each stack only depends on the stacks of the previous levels. The order is reversed
+// if the dependents must be processed before their dependencies (destroy).
+func (graph *stackGraph) getLevels(reverse bool) ([][]string, error) {
+	levels := make(map[string]int, len(graph.stacks))
+	var getLevel func(stack string, path []string) (int, error)
+	getLevel = func(stack string, path []string) (int, error) {
+		if level, found := levels[stack]; found {
+			if level < 0 {
+				return 0, fmt.Errorf("Dependency cycle between the stacks: %s", strings.Join(append(path, graph.getName(stack)), " -> "))
+			}
+			return level, nil
+		}
+		levels[stack] = -1
+		level := 0
+		for _, dependency := range graph.dependencies[stack] {
+			dependencyLevel, err := getLevel(dependency, append(path[:len(path):len(path)], graph.getName(stack)))
+			if err != nil {
+				return 0, err
+			}
+			if dependencyLevel+1 > level {
+				level = dependencyLevel + 1
+			}
+		}
+		levels[stack] = level
+		return level, nil
+	}
+
+	var result [][]string
+	for _, stack := range graph.stacks {
+		level, err := getLevel(stack, nil)
+		if err != nil {
+			return nil, err
+		}
+		for len(result) <= level {
+			result = append(result, nil)
+		}
+		result[level] = append(result[level], stack)
+	}
+	if reverse {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+	return result, nil
+}
+
+// getPrerequisites returns the stacks that must be completed successfully before the stack (its dependencies, or its dependents if the
+// order is reversed)
+func (graph *stackGraph) getPrerequisites(stack string, reverse bool) []string {
+	if !reverse {
+		return graph.dependencies[stack]
+	}
+	var dependents []string
+	for _, dependent := range graph.stacks {
+		for _, dependency := range graph.dependencies[dependent] {
+			if dependency == stack {
+				dependents = append(dependents, dependent)
+			}
+		}
+	}
+	return dependents
+}
+
+// writeText prints the stacks in execution order with their dependencies
+func (graph *stackGraph) writeText(w io.Writer, levels [][]string) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ORDER\tSTACK\tDEPENDENCIES")
+	for i, level := range levels {
+		for _, stack := range level {
+			var dependencies []string
+			for _, dependency := range graph.dependencies[stack] {
+				dependencies = append(dependencies, graph.getName(dependency))
+			}
+			for _, dependency := range graph.external[stack] {
+				dependencies = append(dependencies, graph.getName(dependency)+" (external)")
+			}
+			fmt.Fprintf(table, "%d\t%s\t%s\n", i+1, graph.getName(stack), strings.Join(dependencies, ", "))
+		}
+	}
+	table.Flush()
+}
+
+// writeDot prints the graph in the DOT language (the edges go from the stacks to their dependencies)
+func (graph *stackGraph) writeDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph {")
+	for _, stack := range graph.stacks {
+		fmt.Fprintf(w, "\t%q;\n", graph.getName(stack))
+		for _, dependency := range graph.dependencies[stack] {
+			fmt.Fprintf(w, "\t%q -> %q;\n", graph.getName(stack), graph.getName(dependency))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// runStackGraph runs the function for each stack once its prerequisites are completed successfully, with at most jobs concurrent
+// executions. The stacks whose prerequisites have failed are skipped. The results are returned in execution order and each result is
+// also sent to the done function as soon as it is available.
+func runStackGraph(graph *stackGraph, levels [][]string, reverse bool, jobs int, run func(folder string) foreachResult, done func(foreachResult)) []foreachResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var ordered []string
+	for _, level := range levels {
+		ordered = append(ordered, level...)
+	}
+	results := make(map[string]*foreachResult, len(ordered))
+	completed := make(map[string]chan struct{}, len(ordered))
+	for _, stack := range ordered {
+		completed[stack] = make(chan struct{})
+	}
+	slots := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, stack := range ordered {
+		wg.Add(1)
+		go func(stack string) {
+			defer wg.Done()
+			defer close(completed[stack])
+			result := foreachResult{folder: graph.getName(stack)}
+			for _, prerequisite := range graph.getPrerequisites(stack, reverse) {
+				<-completed[prerequisite]
+				mutex.Lock()
+				failed := results[prerequisite].exitCode != 0 || results[prerequisite].skipped != ""
+				mutex.Unlock()
+				if failed && result.skipped == "" {
+					result.skipped = graph.getName(prerequisite)
+				}
+			}
+			if result.skipped == "" {
+				slots <- struct{}{}
+				result = run(stack)
+				result.folder = graph.getName(stack)
+				<-slots
+			}
+			mutex.Lock()
+			results[stack] = &result
+			done(result)
+			mutex.Unlock()
+		}(stack)
+	}
+	wg.Wait()
+
+	sorted := make([]foreachResult, len(ordered))
+	for i, stack := range ordered {
+		sorted[i] = *results[stack]
+	}
+	return sorted
+}
+
+// isReversedCommand returns true if the stacks must be processed from the dependents to their dependencies (destroy)
+func isReversedCommand(args []string) bool {
+	return util.ListContainsElement(args, "destroy") || util.ListContainsElement(args, "-destroy")
+}
+
+// getRunOrderedArguments returns the arguments supplied to tgf in each stack (the run-ordered command is removed)
+func getRunOrderedArguments(args []string) []string {
+	result := getForeachArguments(args)
+	for i, arg := range result {
+		if arg == runOrderedCommand {
+			return append(result[:i:i], result[i+1:]...)
+		}
+	}
+	return result
+}
+
+// getCurrentStackGraph returns the stacks of the current folder along with their execution order
+func getCurrentStackGraph(reverse bool) (*stackGraph, [][]string, error) {
+	graph, err := getStackGraph(must(os.Getwd()).(string))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(graph.stacks) == 0 {
+		return nil, nil, fmt.Errorf("No terragrunt stack found in %s", graph.root)
+	}
+	levels, err := graph.getLevels(reverse)
+	return graph, levels, err
+}
+
+func runStacksCommand(app *TGFApplication, args []string) int {
+	dot := getSubcommandAction(args, "dot") != ""
+	if len(args) > 0 && !dot {
+		return printCommandUsage("stacks", "[dot]")
+	}
+	graph, levels, err := getCurrentStackGraph(false)
+	if err != nil {
+		printError(msgError, err)
+		return 1
+	}
+	if dot {
+		graph.writeDot(os.Stdout)
+	} else {
+		graph.writeText(os.Stdout, levels)
+	}
+	return 0
+}
+
+// runOrdered runs tgf in every stack of the current folder in dependency order (each stack is run in its own container) and prints a
+// summary of the exit codes
+func runOrdered(app *TGFApplication, args []string) int {
+	if len(args) == 0 {
+		return printCommandUsage(runOrderedCommand, "<args>")
+	}
+	reverse := isReversedCommand(args)
+	graph, levels, err := getCurrentStackGraph(reverse)
+	if err != nil {
+		printError(msgError, err)
+		return 1
+	}
+	executable := must(os.Executable()).(string)
+	tgfArgs := getRunOrderedArguments(app.Arguments)
+	app.Debug("Running %s %s in %d stack(s)", executable, strings.Join(tgfArgs, " "), len(graph.stacks))
+
+	report := newForeachReport(app.ForeachReport)
+	results := runStackGraph(graph, levels, reverse, app.ForeachJobs, func(folder string) foreachResult {
+		return runForeachFolder(executable, tgfArgs, folder)
+	}, func(result foreachResult) {
+		if result.skipped != "" {
+			ErrPrintln(color.New(color.Bold).Sprintf("==> %s (skipped, %s has failed)", result.folder, result.skipped))
+			return
+		}
+		ErrPrintln(color.New(color.Bold).Sprintf("==> %s (exit code %d)", result.folder, result.exitCode))
+		report.add(result)
+		result.flush(os.Stdout, os.Stderr)
+	})
+	ErrPrintln()
+	writeForeachSummary(color.Error, results)
+	report.write(getForeachCommand(tgfArgs), results)
+	exitCode := getForeachExitCode(results)
+	if exitCode == 0 {
+		for _, result := range results {
+			if result.skipped != "" {
+				return 1
+			}
+		}
+	}
+	return exitCode
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeStacks creates the terragrunt configuration files of the stacks in the folder
+func writeStacks(folder string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(folder, name)
+		must(os.MkdirAll(filepath.Dir(path), 0755))
+		must(ioutil.WriteFile(path, []byte(String(content).UnIndent().TrimSpace().Str()), 0644))
+	}
+}
+
+func TestGetStackGraph(t *testing.T) {
+	tempDir := must(filepath.EvalSymlinks(must(ioutil.TempDir("", "TestGetStackGraph")).(string))).(string)
+	defer os.RemoveAll(tempDir)
+	root := filepath.Join(tempDir, "live")
+	writeStacks(tempDir, map[string]string{
+		"live/vpc/terragrunt.hcl": `terraform { source = "../../modules/vpc" }`,
+		"live/db/terragrunt.hcl": `
+			dependency "vpc" {
+			  config_path = "${get_terragrunt_dir()}/../vpc"
+			}
+			inputs = { vpc_id = dependency.vpc.outputs.vpc_id }
+		`,
+		"live/app/terragrunt.hcl": `
+			# dependency "old" { config_path = "../old" }
+			dependencies {
+			  paths = ["../vpc", "../db"]
+			}
+			dependency "shared" {
+			  config_path = "../../shared"
+			}
+		`,
+		"live/legacy/terraform.tfvars": `
+			terragrunt = {
+			  dependencies {
+			    paths = ["../app"]
+			  }
+			}
+		`,
+		"live/app/.terragrunt-cache/abc/terragrunt.hcl": `dependency "x" { config_path = "../../x" }`,
+		"shared/terragrunt.hcl":                         ``,
+	})
+
+	graph, err := getStackGraph(root)
+	if !assert.NoError(t, err) {
+		return
+	}
+	stack := func(name string) string { return filepath.Join(root, name) }
+	assert.Equal(t, []string{stack("app"), stack("db"), stack("legacy"), stack("vpc")}, graph.stacks, "The cache folders are ignored")
+	assert.Equal(t, map[string][]string{
+		stack("db"):     {stack("vpc")},
+		stack("app"):    {stack("vpc"), stack("db")},
+		stack("legacy"): {stack("app")},
+	}, graph.dependencies)
+	assert.Equal(t, map[string][]string{stack("app"): {filepath.Join(tempDir, "shared")}}, graph.external)
+
+	levels, err := graph.getLevels(false)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{stack("vpc")}, {stack("db")}, {stack("app")}, {stack("legacy")}}, levels)
+	reversed, err := graph.getLevels(true)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{stack("legacy")}, {stack("app")}, {stack("db")}, {stack("vpc")}}, reversed)
+	assert.Equal(t, []string{stack("app"), stack("db")}, graph.getPrerequisites(stack("vpc"), true))
+
+	var text, dot bytes.Buffer
+	graph.writeText(&text, levels)
+	assert.Equal(t, String(`
+		ORDER  STACK   DEPENDENCIES
+		1      vpc     
+		2      db      vpc
+		3      app     vpc, db, ../shared (external)
+		4      legacy  app
+	`).UnIndent().TrimSpace().Str()+"\n", text.String())
+	graph.writeDot(&dot)
+	assert.Contains(t, dot.String(), "\t\"app\" -> \"db\";\n")
+
+	writeStacks(tempDir, map[string]string{"live/vpc/terragrunt.hcl": `dependency "legacy" { config_path = "../legacy" }`})
+	graph, err = getStackGraph(root)
+	assert.NoError(t, err)
+	_, err = graph.getLevels(false)
+	assert.EqualError(t, err, "Dependency cycle between the stacks: app -> vpc -> legacy -> app")
+
+	writeStacks(tempDir, map[string]string{"live/vpc/terragrunt.hcl": `dependency "x" { config_path = find_in_parent_folders("x") }`})
+	_, err = getStackGraph(root)
+	assert.Error(t, err, "The unsupported functions are reported")
+}
+
+func TestRunStackGraph(t *testing.T) {
+	t.Parallel()
+
+	graph := &stackGraph{
+		root:   "/live",
+		stacks: []string{"/live/app", "/live/db", "/live/dns", "/live/vpc"},
+		dependencies: map[string][]string{
+			"/live/app": {"/live/db"},
+			"/live/db":  {"/live/vpc"},
+			"/live/dns": {"/live/vpc"},
+		},
+	}
+	levels, _ := graph.getLevels(false)
+	var mutex sync.Mutex
+	var order []string
+	results := runStackGraph(graph, levels, false, 2, func(folder string) foreachResult {
+		mutex.Lock()
+		order = append(order, folder)
+		mutex.Unlock()
+		if folder == "/live/db" {
+			return foreachResult{folder: folder, exitCode: 3}
+		}
+		return foreachResult{folder: folder}
+	}, func(foreachResult) {})
+
+	assert.Equal(t, "/live/vpc", order[0], "The dependencies are run first")
+	assert.Len(t, order, 3, "The dependents of the failed stacks are not run")
+	assert.Equal(t, []foreachResult{
+		{folder: "vpc"},
+		{folder: "db", exitCode: 3},
+		{folder: "dns"},
+		{folder: "app", skipped: "db"},
+	}, results)
+	assert.Equal(t, 3, getForeachExitCode(results))
+
+	levels, _ = graph.getLevels(true)
+	results = runStackGraph(graph, levels, true, 1, func(folder string) foreachResult {
+		if folder == "/live/app" {
+			return foreachResult{folder: folder, exitCode: 1}
+		}
+		return foreachResult{folder: folder}
+	}, func(foreachResult) {})
+	assert.Equal(t, []foreachResult{
+		{folder: "app", exitCode: 1},
+		{folder: "db", skipped: "app"},
+		{folder: "dns"},
+		{folder: "vpc", skipped: "db"},
+	}, results, "The dependencies of the failed stacks are not destroyed")
+}
+
+func TestGetRunOrderedArguments(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"--no-interactive", "-P", "dev", "plan"}, getRunOrderedArguments([]string{"-P", "dev", runOrderedCommand, "plan"}))
+	assert.Equal(t, []string{"--no-interactive", "--foo", "plan"}, getRunOrderedArguments([]string{"--foreach-jobs=2", runOrderedCommand, "--foo", "plan"}))
+	assert.True(t, isReversedCommand([]string{"destroy"}))
+	assert.True(t, isReversedCommand([]string{"plan", "-destroy"}))
+	assert.False(t, isReversedCommand([]string{"plan"}))
+}
+
+func TestParseStackDependenciesUnsupported(t *testing.T) {
+	tempDir := must(filepath.EvalSymlinks(must(ioutil.TempDir("", "TestParseStackDependenciesUnsupported")).(string))).(string)
+	defer os.RemoveAll(tempDir)
+	writeStacks(tempDir, map[string]string{
+		"terragrunt.hcl": `dependency "vpc" { config_path = "vpc" }`,
+		"locals/terragrunt.hcl": `
+			locals {
+			  vpc = "../vpc"
+			}
+			dependency "vpc" {
+			  config_path = local.vpc
+			}
+		`,
+		"function/terragrunt.hcl": `
+			dependencies {
+			  paths = [find_in_parent_folders("vpc")]
+			}
+		`,
+		"include/terragrunt.hcl": `
+			include {
+			  path = find_in_parent_folders()
+			}
+		`,
+	})
+
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{"locals", "only the literal paths and get_terragrunt_dir() are supported in the config_path of the dependency block"},
+		{"function", "only the literal paths and get_terragrunt_dir() are supported in the paths of the dependencies block"},
+		{"include", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := parseStackDependencies(filepath.Join(tempDir, tt.name, "terragrunt.hcl"))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Empty(t, paths, "The dependencies of the included files are ignored")
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
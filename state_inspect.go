@@ -0,0 +1,52 @@
+package main
+
+import "github.com/aws/aws-sdk-go/aws"
+
+// readOnlyStatePolicy is the session policy restricting the assumed role to the reading of the remote state, the permissions of the
+// session are the intersection of the role policies and of this policy
+const readOnlyStatePolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "TGFReadOnlyState",
+      "Effect": "Allow",
+      "Action": [
+        "s3:GetObject",
+        "s3:GetObjectVersion",
+        "s3:GetBucketVersioning",
+        "s3:ListBucket",
+        "dynamodb:DescribeTable",
+        "dynamodb:GetItem",
+        "kms:Decrypt",
+        "kms:DescribeKey",
+        "sts:GetCallerIdentity"
+      ],
+      "Resource": "*"
+    }
+  ]
+}`
+
+// getSessionPolicy returns the session policy of the role assumed last (the configured role or the last hop of the role chain) if the
+// credentials must be read-only
+func (config *TGFConfig) getSessionPolicy(last bool) *string {
+	if !last || !config.tgf.ReadOnlyState {
+		return nil
+	}
+	return aws.String(readOnlyStatePolicy)
+}
+
+// isStateInspection returns true if the state command only reads the state (list or show)
+func isStateInspection(args []string) bool {
+	return len(args) > 0 && (args[0] == "list" || args[0] == "show")
+}
+
+// runStateCommand runs terraform state list/show with read-only credentials, the other state commands (mv, rm, pull, push, etc.) and a
+// bare state are passed to the entry point as before
+func runStateCommand(app *TGFApplication, args []string) int {
+	if len(args) == 1 && args[0] == "show" {
+		return printCommandUsage("state", "list|show <address>")
+	}
+	app.ReadOnlyState = isStateInspection(args)
+	app.Unmanaged = append([]string{"state"}, args...)
+	return app.run()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSessionPolicy(t *testing.T) {
+	t.Parallel()
+
+	config := &TGFConfig{tgf: NewTestApplication(nil)}
+	assert.Nil(t, config.getSessionPolicy(true), "The credentials are only restricted for the state inspection")
+
+	config.tgf.ReadOnlyState = true
+	assert.Nil(t, config.getSessionPolicy(false), "The intermediate roles of the chain must be able to assume the next role")
+	policy := config.getSessionPolicy(true)
+	if assert.NotNil(t, policy) {
+		var document struct {
+			Statement []struct {
+				Effect string
+				Action []string
+			}
+		}
+		assert.NoError(t, json.Unmarshal([]byte(*policy), &document))
+		assert.Equal(t, "Allow", document.Statement[0].Effect)
+		assert.Contains(t, document.Statement[0].Action, "s3:GetObject")
+		assert.NotContains(t, document.Statement[0].Action, "s3:PutObject")
+	}
+}
+
+func TestIsStateInspection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"list"}, true},
+		{[]string{"show", "aws_s3_bucket.logs"}, true},
+		{[]string{"mv", "aws_s3_bucket.logs", "aws_s3_bucket.archive"}, false},
+		{[]string{"rm", "aws_s3_bucket.logs"}, false},
+		{[]string{"push", "terraform.tfstate"}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isStateInspection(tt.args), "%v", tt.args)
+	}
+}
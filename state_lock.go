@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	tgconfig "github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/remote"
+)
+
+// stateLockInfo is the lock information written by terraform in the DynamoDB lock table
+type stateLockInfo struct {
+	ID        string
+	Operation string
+	Info      string
+	Who       string
+	Version   string
+	Created   time.Time
+	Path      string
+}
+
+// stateLock identifies the DynamoDB item used by terraform to lock a S3 state file
+type stateLock struct {
+	Table  string
+	Region string
+	LockID string
+}
+
+// getStateLock returns the lock item corresponding to the S3 remote state configured by terragrunt
+func getStateLock(remoteState *remote.RemoteState) (*stateLock, error) {
+	if remoteState == nil || remoteState.Backend != "s3" {
+		return nil, fmt.Errorf("No S3 remote state configured")
+	}
+	value := func(key string) string {
+		if value, ok := remoteState.Config[key].(string); ok {
+			return value
+		}
+		return ""
+	}
+	lock := stateLock{Table: value("dynamodb_table"), Region: value("region")}
+	if lock.Table == "" {
+		// Name used by older versions of terraform
+		lock.Table = value("lock_table")
+	}
+	if lock.Table == "" {
+		return nil, fmt.Errorf("The S3 remote state of bucket %s is not locked with a DynamoDB table", value("bucket"))
+	}
+	lock.LockID = fmt.Sprintf("%s/%s", value("bucket"), value("key"))
+	return &lock, nil
+}
+
+// String returns a human readable description of the lock
+func (info stateLockInfo) String() string {
+	result := fmt.Sprintf("Lock ID:   %s\nWho:       %s\nOperation: %s\nCreated:   %s (%v ago)\n",
+		info.ID, info.Who, info.Operation, info.Created.Local().Format(time.RFC1123), time.Since(info.Created).Round(time.Second))
+	if info.Version != "" {
+		result += fmt.Sprintf("Version:   %s\n", info.Version)
+	}
+	if info.Path != "" {
+		result += fmt.Sprintf("Path:      %s\n", info.Path)
+	}
+	if info.Info != "" {
+		result += fmt.Sprintf("Info:      %s\n", info.Info)
+	}
+	return result
+}
+
+// whoHoldsLock reads the terragrunt remote state configuration of the current folder and prints the holder of the state lock
+func (config *TGFConfig) whoHoldsLock() int {
+	if config.awsSession == nil {
+		if err := config.InitAWS(""); err != nil {
+			printError(msgAWSSessionFailed, err)
+			return 1
+		}
+	}
+	folder := must(os.Getwd()).(string)
+	terragruntConfig, err := tgconfig.ReadTerragruntConfig(options.NewTerragruntOptions(tgconfig.DefaultConfigPath(folder)))
+	if err != nil {
+		printError(msgTerragruntConfigFailed, folder, err)
+		return 1
+	}
+	lock, err := getStateLock(terragruntConfig.RemoteState)
+	if err != nil {
+		printError(msgStateLockUnavailable, err)
+		return 1
+	}
+
+	awsSession := config.awsSession
+	if lock.Region != "" {
+		awsSession = awsSession.Copy(aws.NewConfig().WithRegion(lock.Region))
+	}
+	config.tgf.Debug("# Looking for %s in DynamoDB table %s", lock.LockID, lock.Table)
+	result, err := dynamodb.New(awsSession, awsRetryConfig()).GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(lock.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"LockID": {S: aws.String(lock.LockID)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		printError(msgLockTableUnreadable, lock.Table, describeAWSError(err))
+		return 1
+	}
+	if len(result.Item) == 0 || result.Item["Info"] == nil {
+		Printf("The state %s is not locked\n", lock.LockID)
+		return 0
+	}
+
+	var info stateLockInfo
+	if err := json.Unmarshal([]byte(aws.StringValue(result.Item["Info"].S)), &info); err != nil {
+		printError(msgLockUndecodable, lock.LockID, err)
+		return 1
+	}
+	Printf("The state %s is locked\n%s", lock.LockID, info)
+	Printf("\nIf the holder is no longer running, release the lock with: tgf force-unlock %s\n", info.ID)
+	return 0
+}
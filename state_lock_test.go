@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terragrunt/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStateLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		remoteState *remote.RemoteState
+		want        *stateLock
+		wantErr     bool
+	}{
+		{"No remote state", nil, nil, true},
+		{"Other backend", &remote.RemoteState{Backend: "gcs"}, nil, true},
+		{"No lock table", &remote.RemoteState{Backend: "s3", Config: map[string]interface{}{"bucket": "states", "key": "app/terraform.tfstate"}}, nil, true},
+		{"DynamoDB table", &remote.RemoteState{Backend: "s3", Config: map[string]interface{}{
+			"bucket": "states", "key": "app/terraform.tfstate", "region": "us-west-2", "dynamodb_table": "locks",
+		}}, &stateLock{Table: "locks", Region: "us-west-2", LockID: "states/app/terraform.tfstate"}, false},
+		{"Legacy lock table", &remote.RemoteState{Backend: "s3", Config: map[string]interface{}{
+			"bucket": "states", "key": "terraform.tfstate", "lock_table": "locks",
+		}}, &stateLock{Table: "locks", LockID: "states/terraform.tfstate"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getStateLock(tt.remoteState)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStateLockInfoString(t *testing.T) {
+	t.Parallel()
+
+	info := stateLockInfo{ID: "1234", Who: "jdoe@laptop", Operation: "OperationTypeApply", Created: time.Now().Add(-time.Hour)}
+	result := info.String()
+	assert.Contains(t, result, "Lock ID:   1234")
+	assert.Contains(t, result, "Who:       jdoe@laptop")
+	assert.Contains(t, result, "(1h0m0s ago)")
+	assert.False(t, strings.Contains(result, "Path:"))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// tgf writes its own messages (errors, warnings, image refresh, usage, timings) on stderr, only the entry point and the commands
+// returning a result (tgf --get-image-name, config dump, images list, etc.) write on stdout, so the output of the entry point can
+// be piped to other tools (i.e. tgf output -json | jq).
+
+// redirectStdout makes everything written on the standard output of tgf go to stderr (--strict-output).
+// It returns the original stdout, which should only be given to the entry point, and the function restoring it.
+func redirectStdout() (stdout *os.File, restore func()) {
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, color.Error
+	return stdout, func() { os.Stdout, color.Output = stdout, output }
+}
+
+// The output of the entry point is never buffered by tgf: stdout is given directly to the container (or to the --foreach child
+// processes through temporary files) and only the end of the container stderr is kept to report the errors.
+const containerStderrLimit = 64 * 1024
+
+// tailBuffer is a writer that keeps only the last bytes written to it
+type tailBuffer struct {
+	mutex     sync.Mutex
+	limit     int
+	content   []byte
+	truncated bool
+}
+
+func newTailBuffer(limit int) *tailBuffer { return &tailBuffer{limit: limit} }
+
+func (buffer *tailBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	written := len(p)
+	if len(p) >= buffer.limit {
+		buffer.truncated = buffer.truncated || len(p) > buffer.limit || len(buffer.content) > 0
+		buffer.content = append(buffer.content[:0], p[len(p)-buffer.limit:]...)
+		return written, nil
+	}
+	if overflow := len(buffer.content) + len(p) - buffer.limit; overflow > 0 {
+		buffer.truncated = true
+		buffer.content = append(buffer.content[:0], buffer.content[overflow:]...)
+	}
+	buffer.content = append(buffer.content, p...)
+	return written, nil
+}
+
+// Len returns the number of bytes kept in the buffer
+func (buffer *tailBuffer) Len() int {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return len(buffer.content)
+}
+
+// String returns the bytes kept in the buffer, preceded by an ellipsis if the beginning has been discarded
+func (buffer *tailBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	if buffer.truncated {
+		return "...\n" + string(buffer.content)
+	}
+	return string(buffer.content)
+}
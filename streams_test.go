@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStreams replaces stdout and stderr by temporary files and returns what has been written on each of them
+func captureStreams(t *testing.T, testFunction func()) (stdout, stderr string) {
+	tempDir := must(ioutil.TempDir("", "TestStreams")).(string)
+	defer func() { assert.NoError(t, os.RemoveAll(tempDir)) }()
+	outFile := must(os.Create(tempDir + "/stdout")).(*os.File)
+	errFile := must(os.Create(tempDir + "/stderr")).(*os.File)
+
+	savedStdout, savedStderr, savedOutput, savedError := os.Stdout, os.Stderr, color.Output, color.Error
+	os.Stdout, os.Stderr, color.Output, color.Error, logOutput = outFile, errFile, outFile, errFile, errFile
+	defer func() {
+		os.Stdout, os.Stderr, color.Output, color.Error, logOutput = savedStdout, savedStderr, savedOutput, savedError, savedStderr
+	}()
+
+	testFunction()
+	outFile.Close()
+	errFile.Close()
+	return string(must(ioutil.ReadFile(outFile.Name())).([]byte)), string(must(ioutil.ReadFile(errFile.Name())).([]byte))
+}
+
+func TestTgfMessagesOnStderr(t *testing.T) {
+	for _, format := range []string{logFormatText, logFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			logFormat, currentLogLevel = format, logLevelDebug
+			defer func() { logFormat, currentLogLevel = logFormatText, logLevelInfo }()
+
+			stdout, stderr := captureStreams(t, func() {
+				printError(msgImagePullFailed, "Unable to pull coveo/tgf")
+				printWarning(msgConfigWarning, "TGF v1.0 does not meet the recommended version range >= 2.0")
+				printInfo("docker", nil, msgImageRefresh, "coveo/tgf")
+				logMetadata("docker", "Container exited", map[string]interface{}{"exit-code": 0})
+				(&TGFApplication{}).Debug("# Using AWS region %s", "us-east-1")
+			})
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "does not meet the recommended version range")
+		})
+	}
+}
+
+func TestRedirectStdout(t *testing.T) {
+	var restored bool
+	stdout, stderr := captureStreams(t, func() {
+		original := os.Stdout
+		entryPoint, restore := redirectStdout()
+		assert.Equal(t, original, entryPoint)
+
+		Println("tgf message")
+		fmt.Println("tgf fmt message")
+		assert.NoError(t, runCommands([]string{"echo run-before"}))
+		fmt.Fprintln(entryPoint, `{"entry-point": "output"}`)
+
+		restore()
+		restored = os.Stdout == original && color.Output == original
+	})
+	assert.True(t, restored)
+	assert.Equal(t, "{\"entry-point\": \"output\"}\n", stdout)
+	assert.Equal(t, "tgf message\ntgf fmt message\nrun-before\n", stderr)
+}
+
+func TestUsageOnStderr(t *testing.T) {
+	stdout, stderr := captureStreams(t, func() {
+		app := NewTestApplication(nil)
+		app.UsageWriter(color.Error).Usage(nil)
+	})
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "--strict-output")
+}
+
+func TestTailBuffer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"Empty", nil, ""},
+		{"Under the limit", []string{"abc", "de"}, "abcde"},
+		{"Exactly the limit", []string{"abcdefgh"}, "abcdefgh"},
+		{"Overflow", []string{"abcde", "fghij"}, "...\ncdefghij"},
+		{"Single large write", []string{"abcdefghijkl"}, "...\nefghijkl"},
+		{"Large write after content", []string{"a", "bcdefghi"}, "...\nbcdefghi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := newTailBuffer(8)
+			for _, write := range tt.writes {
+				n, err := buffer.Write([]byte(write))
+				assert.NoError(t, err)
+				assert.Equal(t, len(write), n)
+			}
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// subcommand describes a tgf command invoked through the first argument (tgf <command> [<args>])
+type subcommand struct {
+	name    string
+	args    string
+	help    string
+	execute func(app *TGFApplication, args []string) int
+}
+
+// subcommands contains the tgf commands, any other first argument is passed to the entry point
+var subcommands []subcommand
+
+func init() {
+	// The commands are initialized here since the completion command refers to the list of commands
+	subcommands = []subcommand{
+		{"run", "<args>", "Run the entry point with the arguments, even if the first one is named as a tgf command", runPassthrough},
+		{"update", "", "Refresh the docker image (to update tgf itself, use get-latest-tgf.sh)", runUpdate},
+		{"config", "dump|lint|migrate|paths|init", "Show, validate or create the tgf configuration", runConfigCommand},
+		{"images", "list|name|prune|rm <image>...", "List, prune or remove the local docker images used by tgf", runImagesCommand},
+		{"plugins", "list|path|install <path or url>|rm <name>", "List, install or remove the tgf plugins (runners, credential sources and config sources)", runPluginsCommand},
+		{"state", "list|show <address>", "Inspect the terraform state with read-only credentials (other state commands are passed to the entry point)", runStateCommand},
+		{"stacks", "[dot]", "Print the terragrunt stacks of the current folder in dependency order (or as a DOT graph)", runStacksCommand},
+		{runOrderedCommand, "<args>", "Run tgf in every terragrunt stack of the current folder in dependency order, each stack in its own container", runOrdered},
+		{"lock", "", "Write the resolved image digest, tgf version and configuration to " + lockFile + " (verified by --locked)", runLock},
+		{"cache", "list|clean [missing]", "List or remove the central terragrunt caches (terragrunt-cache: central)", runCacheCommand},
+		{"integrity", "[seal <file>...]", "Verify that the tgf executable matches the checksum embedded in the released binary", runIntegrityCommand},
+		{"doctor", "", "Check the environment (docker, disk space, configuration, AWS credentials, image, tgf update)", runDoctor},
+		{completionCommand, "bash|fish|zsh", "Print the completion script of a shell", (*TGFApplication).runCompletion},
+	}
+}
+
+// getSubcommand returns the command matching the name (or nil if the name is not a tgf command)
+func getSubcommand(name string) *subcommand {
+	for i := range subcommands {
+		if subcommands[i].name == name {
+			return &subcommands[i]
+		}
+	}
+	return nil
+}
+
+// getSubcommandNames returns the names of the tgf commands
+func getSubcommandNames() []string {
+	names := make([]string, 0, len(subcommands))
+	for _, command := range subcommands {
+		names = append(names, command.name)
+	}
+	return names
+}
+
+// getSubcommandsHelp returns the list of the commands formatted for the application help
+func getSubcommandsHelp() string {
+	lines := make([]string, 0, len(subcommands))
+	for _, command := range subcommands {
+		lines = append(lines, fmt.Sprintf("%-40s %s", strings.TrimSpace("tgf "+command.name+" "+command.args), command.help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printCommandUsage reports an invalid invocation of a command
+func printCommandUsage(name, args string) int {
+	printError(msgCommandUsage, strings.TrimSpace("tgf "+name+" "+args))
+	return 1
+}
+
+// getSubcommandAction returns the single action argument of a command (or an empty string if it is invalid)
+func getSubcommandAction(args []string, actions ...string) string {
+	if len(args) != 1 {
+		return ""
+	}
+	for _, action := range actions {
+		if args[0] == action {
+			return action
+		}
+	}
+	return ""
+}
+
+func runPassthrough(app *TGFApplication, args []string) int {
+	app.Unmanaged = args
+	return app.run()
+}
+
+func runUpdate(app *TGFApplication, args []string) int {
+	if len(args) > 0 {
+		return printCommandUsage("update", "")
+	}
+	app.Refresh, app.RefreshOnly, app.Unmanaged = true, true, nil
+	return app.run()
+}
+
+func runLock(app *TGFApplication, args []string) int {
+	if len(args) > 0 {
+		return printCommandUsage("lock", "")
+	}
+	app.WriteLock, app.Unmanaged = true, nil
+	return app.run()
+}
+
+func runConfigCommand(app *TGFApplication, args []string) int {
+	switch getSubcommandAction(args, "dump", "lint", "migrate", "paths", "init") {
+	case "dump":
+		app.ConfigDump = true
+	case "lint":
+		app.ConfigLint = true
+	case "migrate":
+		app.ConfigMigrate = true
+	case "paths":
+		app.PrintPaths = true
+	case "init":
+		app.InitConfig = true
+	default:
+		return printCommandUsage("config", "dump|lint|migrate|paths|init")
+	}
+	app.Unmanaged = nil
+	return app.run()
+}
+
+func runImagesCommand(app *TGFApplication, args []string) int {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	if args[0] == "rm" && len(args) > 1 {
+		app.RemoveImages = args[1:]
+	} else {
+		switch getSubcommandAction(args, "list", "name", "prune") {
+		case "list":
+			app.ListImages = true
+		case "name":
+			app.GetImageName = true
+		case "prune":
+			app.PruneImages = true
+		default:
+			return printCommandUsage("images", "list|name|prune|rm <image>...")
+		}
+	}
+	app.Unmanaged = nil
+	return app.run()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSubcommand(t *testing.T) {
+	assert.Equal(t, "config", getSubcommand("config").name)
+	assert.Nil(t, getSubcommand("plan"))
+	assert.Equal(t, []string{"run", "update", "config", "images", "plugins", "state", "stacks", runOrderedCommand, "lock", "cache", "integrity", "doctor", completionCommand}, getSubcommandNames())
+	assert.Contains(t, getSubcommandsHelp(), "tgf config dump|lint|migrate|paths|init")
+}
+
+func TestGetSubcommandAction(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Valid action", []string{"lint"}, "lint"},
+		{"No action", nil, ""},
+		{"Unknown action", []string{"show"}, ""},
+		{"Too many arguments", []string{"lint", "dump"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getSubcommandAction(tt.args, "dump", "lint"))
+		})
+	}
+}
+
+func TestSubcommandsInvalidUsage(t *testing.T) {
+	tests := [][]string{
+		{"config"},
+		{"config", "show"},
+		{"images", "list", "all"},
+		{"images", "rm"},
+		{"plugins", "install"},
+		{"update", "now"},
+		{"doctor", "now"},
+		{"lock", "now"},
+		{"state", "show"},
+		{completionCommand, "powershell"},
+	}
+	for _, args := range tests {
+		t.Run(args[0], func(t *testing.T) {
+			app := NewTestApplication(args)
+			assert.Equal(t, args, app.Unmanaged)
+			assert.Equal(t, 1, app.Run())
+		})
+	}
+}
+
+func TestEntryPointFlagsArePassedThrough(t *testing.T) {
+	for _, args := range [][]string{{"plan", "--lock=false"}, {"plan", "-lock=false"}, {"plan", "--strict"}, {"graph", "-type=plan"}, {"plan", "--log-level=debug"}, {"plan", "--log-level"}, {"plan", "--log-format=json"}} {
+		app := NewTestApplication(args)
+		assert.Equal(t, args[0], app.Unmanaged[0])
+		assert.Contains(t, app.Unmanaged, args[1])
+		assert.False(t, app.WriteLock)
+		assert.False(t, app.StrictLint)
+		assert.Equal(t, logFormatText, app.LogFormat)
+	}
+}
+
+func TestConfigPathsSubcommand(t *testing.T) {
+	app := NewTestApplication([]string{"config", "paths"})
+	assert.Equal(t, 0, app.Run())
+	assert.True(t, app.PrintPaths)
+	assert.Nil(t, app.Unmanaged)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Locations of the .terragrunt-cache folders (terragrunt-cache)
+const (
+	terragruntCacheLocal   = "local"
+	terragruntCacheCentral = "central"
+)
+
+const (
+	terragruntCacheMount  = "/var/tgf-terragrunt" // The location of the central terragrunt cache in the container
+	terragruntCacheMarker = ".tgf-folder"         // The file of each cached folder containing the folder that uses it
+)
+
+// getTerragruntCacheFolder returns the host folder containing the central terragrunt caches
+func getTerragruntCacheFolder() string { return filepath.Join(getCacheFolder(), "terragrunt") }
+
+// getTerragruntCacheEntry returns the central cache of the folder, it is identified by the hash of the folder so each module keeps its
+// own downloaded sources
+func getTerragruntCacheEntry(folder string) string {
+	return filepath.Join(getTerragruntCacheFolder(), fmt.Sprintf("%x", sha256.Sum256([]byte(folder)))[:16])
+}
+
+// mountTerragruntCache returns the volume mounting the central cache of the folder as the terragrunt download folder, the
+// .terragrunt-cache folder is no longer created in the module
+func (config *TGFConfig) mountTerragruntCache(folder string) (string, error) {
+	entry := getTerragruntCacheEntry(folder)
+	if err := os.MkdirAll(entry, 0755); err != nil {
+		return "", err
+	}
+	// The marker is rewritten on each run, its modification date is the last use of the cache
+	if err := ioutil.WriteFile(filepath.Join(entry, terragruntCacheMarker), []byte(folder+"\n"), 0644); err != nil {
+		return "", err
+	}
+	config.Environment["TERRAGRUNT_DOWNLOAD"] = terragruntCacheMount
+	config.terragruntCacheFolder = entry
+	return fmt.Sprintf("%s:%s", convertDrive(filepath.ToSlash(entry)), terragruntCacheMount), nil
+}
+
+// terragruntCacheEntry describes the central terragrunt cache of a folder
+type terragruntCacheEntry struct {
+	path    string
+	folder  string
+	size    int64
+	lastUse time.Time
+}
+
+// getTerragruntCacheEntries returns the central terragrunt caches sorted by folder
+func getTerragruntCacheEntries() (entries []terragruntCacheEntry, err error) {
+	root := getTerragruntCacheFolder()
+	infos, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		entry := terragruntCacheEntry{path: filepath.Join(root, info.Name()), folder: "?", lastUse: info.ModTime()}
+		marker := filepath.Join(entry.path, terragruntCacheMarker)
+		if content, err := ioutil.ReadFile(marker); err == nil {
+			entry.folder = strings.TrimSpace(string(content))
+			if markerInfo, err := os.Stat(marker); err == nil {
+				entry.lastUse = markerInfo.ModTime()
+			}
+		}
+		filepath.Walk(entry.path, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				entry.size += info.Size()
+			}
+			return nil
+		})
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].folder < entries[j].folder })
+	return
+}
+
+// writeTerragruntCacheList prints the folders using the central terragrunt cache with the size and the last use of their cache
+func writeTerragruntCacheList(w io.Writer, entries []terragruntCacheEntry) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FOLDER\tSIZE\tLAST USED")
+	for _, entry := range entries {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", entry.folder, formatBytes(entry.size), entry.lastUse.Local().Format("2006-01-02 15:04"))
+	}
+	table.Flush()
+}
+
+// cleanTerragruntCache removes the central terragrunt caches (all of them or only those of the folders that no longer exist)
+func cleanTerragruntCache(missingOnly bool) (removed int, size int64, err error) {
+	entries, err := getTerragruntCacheEntries()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if missingOnly {
+			if _, statErr := os.Stat(entry.folder); statErr == nil {
+				continue
+			}
+		}
+		if err = os.RemoveAll(entry.path); err != nil {
+			return
+		}
+		removed++
+		size += entry.size
+	}
+	return
+}
+
+// runCacheCommand lists or removes the central terragrunt caches (tgf cache list|clean [missing])
+func runCacheCommand(app *TGFApplication, args []string) int {
+	const usage = "list|clean [missing]"
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		entries, err := getTerragruntCacheEntries()
+		if err != nil {
+			printError(msgError, err)
+			return 1
+		}
+		writeTerragruntCacheList(os.Stdout, entries)
+	case args[0] == "clean" && (len(args) == 1 || len(args) == 2 && args[1] == "missing"):
+		removed, size, err := cleanTerragruntCache(len(args) == 2)
+		if err != nil {
+			printError(msgError, err)
+			return 1
+		}
+		printInfo("cache", map[string]interface{}{"removed": removed, "size": size}, msgTerragruntCacheCleaned, removed, getTerragruntCacheFolder(), formatBytes(size))
+	default:
+		return printCommandUsage("cache", usage)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerragruntCache(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestTerragruntCache")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(tempDir, "cache"))
+
+	existing, removed := filepath.Join(tempDir, "live", "vpc"), filepath.Join(tempDir, "live", "legacy")
+	must(os.MkdirAll(existing, 0755))
+	for _, folder := range []string{existing, removed} {
+		config := &TGFConfig{Environment: map[string]string{}}
+		volume, err := config.mountTerragruntCache(folder)
+		assert.NoError(t, err)
+		entry := getTerragruntCacheEntry(folder)
+		assert.Equal(t, entry, config.terragruntCacheFolder)
+		assert.Equal(t, filepath.ToSlash(entry)+":"+terragruntCacheMount, volume)
+		assert.Equal(t, terragruntCacheMount, config.Environment["TERRAGRUNT_DOWNLOAD"])
+		assert.Equal(t, terragruntCacheMount+"/abc/main.tf", config.getContainerPath(filepath.Join(entry, "abc", "main.tf")))
+		must(os.MkdirAll(filepath.Join(entry, "abc"), 0755))
+		must(ioutil.WriteFile(filepath.Join(entry, "abc", "main.tf"), make([]byte, 1000), 0644))
+	}
+	assert.NotEqual(t, getTerragruntCacheEntry(existing), getTerragruntCacheEntry(removed), "Each folder has its own cache")
+
+	entries, err := getTerragruntCacheEntries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, removed, entries[0].folder)
+	assert.Equal(t, existing, entries[1].folder)
+	var list bytes.Buffer
+	writeTerragruntCacheList(&list, entries)
+	assert.Contains(t, list.String(), "FOLDER")
+	assert.Contains(t, list.String(), existing)
+
+	count, _, err := cleanTerragruntCache(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "Only the cache of the removed folder is cleaned")
+	entries, _ = getTerragruntCacheEntries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, existing, entries[0].folder)
+
+	count, _, err = cleanTerragruntCache(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	entries, err = getTerragruntCacheEntries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// timingRecorder records the time spent in each phase of a run (configuration, credentials, image refresh, container, etc.).
+//
+// Phases can be nested (i.e. credentials are resolved while the configuration is loaded), the time of a nested phase is not
+// included in the time of its parent, so the sum of the phases never exceeds the total duration.
+type timingRecorder struct {
+	sync.Mutex
+	now    func() time.Time
+	start  time.Time
+	last   time.Time
+	order  []string
+	phases map[string]time.Duration
+	stack  []string
+	tracer *tracer
+	events *eventStream
+}
+
+// phaseTiming is the JSON representation of a phase duration
+type phaseTiming struct {
+	Phase   string  `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+var timings = newTimingRecorder(time.Now)
+
+func newTimingRecorder(now func() time.Time) *timingRecorder {
+	start := now()
+	return &timingRecorder{now: now, start: start, last: start, phases: make(map[string]time.Duration)}
+}
+
+// begin starts measuring a phase, the returned function must be called at the end of the phase (the phase is also recorded as
+// a span if tracing is enabled)
+func (recorder *timingRecorder) begin(phase string) func() {
+	endSpan := recorder.tracer.start(phase)
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.charge()
+	start := recorder.last
+	recorder.events.emit(eventPhaseStarted, map[string]interface{}{"phase": phase})
+	if _, exist := recorder.phases[phase]; !exist {
+		recorder.order = append(recorder.order, phase)
+		recorder.phases[phase] = 0
+	}
+	recorder.stack = append(recorder.stack, phase)
+	return func() {
+		defer endSpan()
+		recorder.Lock()
+		defer recorder.Unlock()
+		recorder.charge()
+		recorder.events.emit(eventPhaseFinished, map[string]interface{}{"phase": phase, "seconds": recorder.last.Sub(start).Seconds()})
+		// The phases run concurrently (i.e. remote configurations) may not end in the reverse order of their beginning
+		for i := len(recorder.stack) - 1; i >= 0; i-- {
+			if recorder.stack[i] == phase {
+				recorder.stack = append(recorder.stack[:i], recorder.stack[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// charge adds the time elapsed since the last change to the current phase
+func (recorder *timingRecorder) charge() {
+	now := recorder.now()
+	if len(recorder.stack) > 0 {
+		recorder.phases[recorder.stack[len(recorder.stack)-1]] += now.Sub(recorder.last)
+	}
+	recorder.last = now
+}
+
+// summary returns the duration of each phase (in order of first occurrence) and the total duration of the run
+func (recorder *timingRecorder) summary() (phases []phaseTiming, total time.Duration) {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.charge()
+	var measured time.Duration
+	for _, phase := range recorder.order {
+		phases = append(phases, phaseTiming{phase, recorder.phases[phase].Seconds()})
+		measured += recorder.phases[phase]
+	}
+	total = recorder.last.Sub(recorder.start)
+	phases = append(phases, phaseTiming{"other", (total - measured).Seconds()})
+	return
+}
+
+// getOverhead returns the time spent by tgf itself (everything but the container)
+func getOverhead(phases []phaseTiming, total time.Duration) time.Duration {
+	for _, phase := range phases {
+		if phase.Phase == "container" {
+			return total - time.Duration(phase.Seconds*float64(time.Second))
+		}
+	}
+	return total
+}
+
+// reportTimings prints the timings summary (--timings) and/or writes it as JSON (--timings-file)
+func (app *TGFApplication) reportTimings() {
+	if !app.Timings && app.TimingsFile == "" {
+		return
+	}
+	phases, total := timings.summary()
+	overhead := getOverhead(phases, total)
+	if app.TimingsFile != "" {
+		content := must(json.MarshalIndent(map[string]interface{}{"total": total.Seconds(), "overhead": overhead.Seconds(), "phases": phases}, "", "  ")).([]byte)
+		if err := ioutil.WriteFile(app.TimingsFile, content, 0644); err != nil {
+			printError(msgTimingsFailed, app.TimingsFile, err)
+		}
+	}
+	if !app.Timings {
+		return
+	}
+	if logFormat == logFormatJSON {
+		writeLogRecord(logLevelInfo.String(), "tgf", "", "Timings", map[string]interface{}{"total": total.Seconds(), "overhead": overhead.Seconds(), "phases": phases})
+		return
+	}
+	ErrPrintln("\nTimings:")
+	for _, phase := range phases {
+		ErrPrintf("  %-15s %8.3fs\n", phase.Phase, phase.Seconds)
+	}
+	ErrPrintf("  %-15s %8.3fs\n", "total", total.Seconds())
+	ErrPrintf("  %-15s %8.3fs\n", "tgf overhead", overhead.Seconds())
+}
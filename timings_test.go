@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingRecorder(t *testing.T) {
+	t.Parallel()
+
+	current := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	advance := func(seconds int) { current = current.Add(time.Duration(seconds) * time.Second) }
+	recorder := newTimingRecorder(func() time.Time { return current })
+
+	advance(1) // other
+	endConfiguration := recorder.begin("configuration")
+	advance(2)
+	endCredentials := recorder.begin("credentials") // nested in configuration
+	advance(3)
+	endCredentials()
+	advance(1)
+	endConfiguration()
+	endCredentials = recorder.begin("credentials")
+	advance(4)
+	endCredentials()
+	endContainer := recorder.begin("container")
+	advance(10)
+	endContainer()
+	advance(1) // other
+
+	phases, total := recorder.summary()
+	assert.Equal(t, 22*time.Second, total)
+	assert.Equal(t, []phaseTiming{
+		{"configuration", 3},
+		{"credentials", 7},
+		{"container", 10},
+		{"other", 2},
+	}, phases)
+	assert.Equal(t, 12*time.Second, getOverhead(phases, total))
+	assert.Equal(t, total, getOverhead(phases[:2], total), "Everything is overhead if the container is not run")
+}
+
+func TestTimingRecorderConcurrentPhases(t *testing.T) {
+	t.Parallel()
+
+	current := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder := newTimingRecorder(func() time.Time { return current })
+
+	endConfiguration := recorder.begin("configuration")
+	endFirst := recorder.begin("remote config")
+	endCredentials := recorder.begin("credentials")
+	current = current.Add(2 * time.Second)
+	endFirst() // ends before the phase started after it
+	current = current.Add(3 * time.Second)
+	endCredentials()
+	current = current.Add(time.Second)
+	endConfiguration()
+
+	phases, total := recorder.summary()
+	assert.Equal(t, []phaseTiming{
+		{"configuration", 1},
+		{"remote config", 0},
+		{"credentials", 5},
+		{"other", 0},
+	}, phases)
+	assert.Equal(t, 6*time.Second, total)
+}
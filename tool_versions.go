@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// versionedTools are the programs whose version can be required with the required-tool-versions configuration key
+var versionedTools = []string{"terragrunt", imageFamilyTerraform, imageFamilyTofu}
+
+// Values of the tool-version-check configuration key
+const (
+	toolVersionCheckError = "error"
+	toolVersionCheckWarn  = "warn"
+)
+
+// checkToolVersions verifies that the versions of the programs of the image satisfy the required ranges, the versions are kept in the
+// cache by image ID so the image is only inspected once. It returns a non zero exit code if the run must be stopped.
+func (config *TGFConfig) checkToolVersions(image string) int {
+	if len(config.RequiredToolVersions) == 0 {
+		return 0
+	}
+	switch config.ToolVersionCheck {
+	case "", toolVersionCheckError, toolVersionCheckWarn:
+	default:
+		return failWith(exitConfig, fmt.Errorf("Invalid tool-version-check %q (valid values are %s and %s)", config.ToolVersionCheck, toolVersionCheckError, toolVersionCheckWarn))
+	}
+	defer timings.begin("tool versions")()
+
+	tools := make([]string, 0, len(config.RequiredToolVersions))
+	for tool := range config.RequiredToolVersions {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	var mismatches []string
+	for _, tool := range tools {
+		required := config.RequiredToolVersions[tool]
+		if !util.ListContainsElement(versionedTools, tool) {
+			return failWith(exitConfig, fmt.Errorf("Unsupported tool %q in required-tool-versions (supported tools are %s)", tool, strings.Join(versionedTools, ", ")))
+		}
+		if _, err := semver.ParseRange(required); err != nil {
+			return failWith(exitConfig, fmt.Errorf("Invalid version range %q for %s in required-tool-versions: %v", required, tool, err))
+		}
+		toolVersion := getToolVersion(image, tool)
+		if toolVersion == "" {
+			mismatches = append(mismatches, fmt.Sprintf("the version of %s cannot be determined", tool))
+			continue
+		}
+		config.tgf.Debug("# %s %s is required to be %s", tool, toolVersion, required)
+		if valid, err := CheckVersionRange(toolVersion, required); err != nil || !valid {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s does not satisfy %s", tool, toolVersion, required))
+		}
+	}
+	if len(mismatches) == 0 {
+		return 0
+	}
+	err := fmt.Errorf("The image %s does not meet the required tool versions: %s", image, strings.Join(mismatches, ", "))
+	if config.ToolVersionCheck == toolVersionCheckWarn {
+		printWarning(msgToolVersionMismatch, err)
+		return 0
+	}
+	return failWith(exitConfig, err)
+}
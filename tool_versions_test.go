@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckToolVersions(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestCheckToolVersions")).(string)
+	defer os.RemoveAll(tempDir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", tempDir)
+	defer func(inspect func(string) (imageInfo, error)) { inspectImage = inspect }(inspectImage)
+	inspectImage = func(image string) (imageInfo, error) {
+		return imageInfo{Exists: true, ID: "sha256:" + image}, nil
+	}
+	defer func(run func(string, string) (string, error)) { runToolVersion = run }(runToolVersion)
+	runToolVersion = func(image, tool string) (string, error) {
+		switch tool {
+		case "terragrunt":
+			return "terragrunt version v0.54.12\n", nil
+		case imageFamilyTerraform:
+			return "Terraform v1.5.7\non linux_amd64\n", nil
+		}
+		return "", fmt.Errorf("executable file not found")
+	}
+
+	tests := []struct {
+		name     string
+		required map[string]string
+		check    string
+		want     int
+	}{
+		{"No requirement", nil, "", 0},
+		{"Satisfied", map[string]string{"terragrunt": ">=0.50.0", "terraform": ">=1.5.0 <1.6.0"}, "", 0},
+		{"Terraform upgraded", map[string]string{"terraform": "<1.5.0"}, "", exitConfig},
+		{"Warning only", map[string]string{"terraform": "<1.5.0"}, "warn", 0},
+		{"Missing tool", map[string]string{"tofu": ">=1.6.0"}, "", exitConfig},
+		{"Unsupported tool", map[string]string{"pulumi": ">=1.0.0"}, "warn", exitConfig},
+		{"Invalid range", map[string]string{"terraform": "1.x.y"}, "warn", exitConfig},
+		{"Invalid check", map[string]string{"terraform": ">=1.5.0"}, "ignore", exitConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &TGFConfig{tgf: NewTestApplication(nil), RequiredToolVersions: tt.required, ToolVersionCheck: tt.check}
+			assert.Equal(t, tt.want, config.checkToolVersions("coveo/tgf:1.21.0"))
+		})
+	}
+}
@@ -1,42 +1,87 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
-	"os/user"
 	"path/filepath"
 	"time"
 
 	"github.com/gruntwork-io/terragrunt/util"
 )
 
+// touchDebounce is the delay during which a state file touched by a run is not written again by the following (or parallel) runs,
+// which avoids thrashing the cache folder when it is on a network home directory
+const touchDebounce = time.Minute
+
+// writeFileAtomic replaces the content of a state file by renaming a temporary file, so the concurrent invocations never read a
+// partially written file
+func writeFileAtomic(filename string, content []byte, perm os.FileMode) error {
+	folder := filepath.Dir(filename)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(folder, "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+// touchFile sets the modification time of a state file to now, unless it has already been touched recently
+func touchFile(filename string) {
+	if info, err := os.Stat(filename); err == nil && time.Since(info.ModTime()) < touchDebounce {
+		return
+	}
+	writeFileAtomic(filename, nil, 0644)
+}
+
 func getTouchFilename(image string) string {
-	usr := must(user.Current()).(*user.User)
-	return filepath.Join(usr.HomeDir, ".tgf", util.EncodeBase64Sha1(image))
+	return filepath.Join(getCacheFolder(), util.EncodeBase64Sha1(image))
 }
 
 func getLastRefresh(image string) time.Time {
-	filename := getTouchFilename(image)
-	if util.FileExists(filename) {
-		info := must(os.Stat(filename)).(os.FileInfo)
+	if info, err := os.Stat(getTouchFilename(image)); err == nil {
 		return info.ModTime()
 	}
 	return time.Time{}
 }
 
 func touchImageRefresh(image string) {
-	filename := getTouchFilename(image)
-	if _, err := os.Stat(filepath.Dir(filename)); os.IsNotExist(err) {
-		os.Mkdir(filepath.Dir(filename), 0755)
-	}
-
-	if util.FileExists(filename) {
-		must(os.Chtimes(filename, time.Now(), time.Now()))
-	} else {
-		fp := must(os.OpenFile(filename, os.O_RDONLY|os.O_CREATE, 0644)).(*os.File)
-		fp.Close()
-	}
+	invalidateImageCache(image)
+	touchFile(getTouchFilename(image))
 }
 
 func lastRefresh(image string) time.Duration {
 	return time.Since(getLastRefresh(image))
 }
+
+// getLastUseFilename returns the file whose modification time is the last time the image has been used to run a container
+func getLastUseFilename(image string) string {
+	return filepath.Join(getCacheFolder(), "image-usage", util.EncodeBase64Sha1(image))
+}
+
+// getLastUse returns the last time the image has been used to run a container (zero if it is unknown)
+func getLastUse(image string) time.Time {
+	if info, err := os.Stat(getLastUseFilename(image)); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// touchImageUse records that the image is used to run a container
+func touchImageUse(image string) {
+	touchFile(getLastUseFilename(image))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestWriteFileAtomic")).(string)
+	defer os.RemoveAll(tempDir)
+	filename := filepath.Join(tempDir, "state", "file.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, writeFileAtomic(filename, []byte(`{"exists": true}`), 0644))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, `{"exists": true}`, string(must(ioutil.ReadFile(filename)).([]byte)))
+	info := must(os.Stat(filename)).(os.FileInfo)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	files := must(ioutil.ReadDir(filepath.Dir(filename))).([]os.FileInfo)
+	assert.Len(t, files, 1, "The temporary files should be renamed")
+}
+
+func TestTouchFile(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestTouchFile")).(string)
+	defer os.RemoveAll(tempDir)
+	filename := filepath.Join(tempDir, "touch")
+
+	touchFile(filename)
+	first := must(os.Stat(filename)).(os.FileInfo).ModTime()
+	assert.WithinDuration(t, time.Now(), first, time.Minute)
+
+	recent := time.Now().Add(-touchDebounce / 2).Truncate(time.Second)
+	must(os.Chtimes(filename, recent, recent))
+	touchFile(filename)
+	assert.Equal(t, recent, must(os.Stat(filename)).(os.FileInfo).ModTime(), "The file is not touched again within the debounce delay")
+
+	old := time.Now().Add(-touchDebounce)
+	must(os.Chtimes(filename, old, old))
+	touchFile(filename)
+	assert.WithinDuration(t, time.Now(), must(os.Stat(filename)).(os.FileInfo).ModTime(), time.Minute)
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tracingTimeout is the maximum delay to send the spans of a run to the OTLP endpoint
+const tracingTimeout = 10 * time.Second
+
+// traceSpan is an operation of the run exported as an OpenTelemetry span (the phases of the timings and the wrapped command)
+type traceSpan struct {
+	name       string
+	id         [8]byte
+	parent     [8]byte
+	start, end time.Time
+	attributes map[string]interface{}
+	failed     bool
+}
+
+// tracer records the spans of a run, they are exported with OTLP/HTTP (JSON) if an OTLP endpoint is configured with the standard
+// OpenTelemetry environment variables
+type tracer struct {
+	sync.Mutex
+	now      func() time.Time
+	endpoint string
+	traceID  [16]byte
+	root     *traceSpan
+	spans    []*traceSpan
+	stack    []*traceSpan
+}
+
+var tracing = newTracer(time.Now, getOTLPTracesEndpoint(), os.Getenv("TRACEPARENT"))
+
+func init() { timings.tracer = tracing }
+
+var reTraceParent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// newTracer returns a tracer continuing the trace of the W3C traceparent (i.e. set by the CI system) or starting a new trace, the
+// tracer does not record anything if there is no endpoint
+func newTracer(now func() time.Time, endpoint, traceParent string) *tracer {
+	recorder := &tracer{now: now, endpoint: endpoint}
+	if !recorder.enabled() {
+		return recorder
+	}
+	recorder.root = &traceSpan{name: "tgf", start: now(), attributes: make(map[string]interface{})}
+	if match := reTraceParent.FindStringSubmatch(traceParent); match != nil {
+		hex.Decode(recorder.traceID[:], []byte(match[1]))
+		hex.Decode(recorder.root.parent[:], []byte(match[2]))
+	} else {
+		rand.Read(recorder.traceID[:])
+	}
+	rand.Read(recorder.root.id[:])
+	return recorder
+}
+
+// getOTLPTracesEndpoint returns the URL receiving the spans (empty if tracing is not enabled)
+func getOTLPTracesEndpoint() string {
+	if os.Getenv("OTEL_TRACES_EXPORTER") == "none" || os.Getenv("OTEL_SDK_DISABLED") == "true" {
+		return ""
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+// enabled returns true if the spans are recorded
+func (recorder *tracer) enabled() bool { return recorder != nil && recorder.endpoint != "" }
+
+// reserve creates a span that is started later, its ID can be supplied to the processes started by the span before it begins
+func (recorder *tracer) reserve(name string) *traceSpan {
+	if !recorder.enabled() {
+		return nil
+	}
+	recorder.Lock()
+	defer recorder.Unlock()
+	span := &traceSpan{name: name, attributes: make(map[string]interface{})}
+	rand.Read(span.id[:])
+	return span
+}
+
+// start begins a span nested in the current span, the returned function must be called at the end of the operation
+func (recorder *tracer) start(name string) func() {
+	span := recorder.reserve(name)
+	if span == nil {
+		return func() {}
+	}
+	recorder.begin(span)
+	return func() { recorder.finish(span) }
+}
+
+// begin starts a reserved span, it is nested in the current span
+func (recorder *tracer) begin(span *traceSpan) {
+	if span == nil {
+		return
+	}
+	recorder.Lock()
+	defer recorder.Unlock()
+	span.parent = recorder.root.id
+	if len(recorder.stack) > 0 {
+		span.parent = recorder.stack[len(recorder.stack)-1].id
+	}
+	span.start = recorder.now()
+	recorder.spans = append(recorder.spans, span)
+	recorder.stack = append(recorder.stack, span)
+}
+
+// finish ends a span started by begin (the first call determines the end of the span)
+func (recorder *tracer) finish(span *traceSpan) {
+	if span == nil {
+		return
+	}
+	recorder.Lock()
+	defer recorder.Unlock()
+	if span.end.IsZero() {
+		span.end = recorder.now()
+	}
+	for i := len(recorder.stack) - 1; i >= 0; i-- {
+		if recorder.stack[i] == span {
+			recorder.stack = append(recorder.stack[:i], recorder.stack[i+1:]...)
+			break
+		}
+	}
+}
+
+// traceParent returns the W3C traceparent identifying the span
+func (recorder *tracer) traceParent(span *traceSpan) string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(recorder.traceID[:]), hex.EncodeToString(span.id[:]))
+}
+
+// getOTLPAttributes converts the attributes in the OTLP JSON representation
+func getOTLPAttributes(attributes map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		var value map[string]interface{}
+		switch typed := attributes[key].(type) {
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(typed)}
+		case bool:
+			value = map[string]interface{}{"boolValue": typed}
+		case float64:
+			value = map[string]interface{}{"doubleValue": typed}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(typed)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": value})
+	}
+	return result
+}
+
+// parseOTELList parses the key=value lists of the OpenTelemetry environment variables (the values are URL encoded)
+func parseOTELList(list string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		parts := strings.SplitN(item, "=", 2)
+		if key := strings.TrimSpace(parts[0]); key != "" && len(parts) == 2 {
+			value := strings.TrimSpace(parts[1])
+			if decoded, err := url.QueryUnescape(value); err == nil {
+				value = decoded
+			}
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// getPayload returns the OTLP/JSON export request containing the recorded spans
+func (recorder *tracer) getPayload() []byte {
+	resource := map[string]interface{}{"service.name": "tgf", "service.version": version}
+	for key, value := range parseOTELList(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		resource[key] = value
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		resource["service.name"] = name
+	}
+
+	spans := make([]map[string]interface{}, 0, len(recorder.spans)+1)
+	for _, span := range append([]*traceSpan{recorder.root}, recorder.spans...) {
+		if span.end.IsZero() {
+			span.end = recorder.now()
+		}
+		otlpSpan := map[string]interface{}{
+			"traceId":           hex.EncodeToString(recorder.traceID[:]),
+			"spanId":            hex.EncodeToString(span.id[:]),
+			"name":              span.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        getOTLPAttributes(span.attributes),
+		}
+		if span.parent != [8]byte{} {
+			otlpSpan["parentSpanId"] = hex.EncodeToString(span.parent[:])
+		}
+		if span.failed {
+			otlpSpan["status"] = map[string]interface{}{"code": 2} // STATUS_CODE_ERROR
+		}
+		spans = append(spans, otlpSpan)
+	}
+	return must(json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": getOTLPAttributes(resource)},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "tgf", "version": version},
+				"spans": spans,
+			}},
+		}},
+	})).([]byte)
+}
+
+// export sends the spans of the run to the OTLP endpoint and starts a new root span for the next run (--watch), a failure to
+// export does not change the result of the run
+func (recorder *tracer) export(metadata runMetadata) {
+	if !recorder.enabled() {
+		return
+	}
+	recorder.Lock()
+	attributes := recorder.root.attributes
+	attributes["tgf.entrypoint"] = metadata.EntryPoint
+	attributes["tgf.arguments"] = strings.Join(metadata.Arguments, " ")
+	attributes["tgf.working_dir"] = metadata.WorkingDir
+	attributes["tgf.exit_code"] = metadata.ExitCode
+	if metadata.Image != "" {
+		attributes["tgf.image"] = metadata.Image
+	}
+	if metadata.AWSAccount != "" {
+		attributes["cloud.account.id"] = metadata.AWSAccount
+	}
+	recorder.root.failed = metadata.ExitCode != 0
+	payload := recorder.getPayload()
+	recorder.root = &traceSpan{name: "tgf", parent: recorder.root.parent, start: recorder.now(), attributes: make(map[string]interface{})}
+	rand.Read(recorder.root.id[:])
+	recorder.spans, recorder.stack = nil, nil
+	recorder.Unlock()
+
+	request, err := http.NewRequest(http.MethodPost, recorder.endpoint, bytes.NewReader(payload))
+	if err == nil {
+		request.Header.Set("Content-Type", "application/json")
+		headers := parseOTELList(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+		for key, value := range parseOTELList(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")) {
+			headers[key] = value
+		}
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		var response *http.Response
+		if response, err = newHTTPClient(tracingTimeout).Do(request); err == nil {
+			response.Body.Close()
+			if response.StatusCode >= 300 {
+				err = fmt.Errorf("%s returned %s", recorder.endpoint, response.Status)
+			}
+		}
+	}
+	if err != nil {
+		printWarning(msgTracesExportFailed, err)
+	}
+}
+
+// traceCommand reserves the span of the wrapped command and supplies its context to the container (TRACEPARENT), so the spans
+// emitted by the command (i.e. terragrunt) are attached to it
+func (config *TGFConfig) traceCommand(command []string) *traceSpan {
+	name := filepath.Base(command[0])
+	for _, arg := range command[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			name += " " + arg
+			break
+		}
+	}
+	span := tracing.reserve(name)
+	if span != nil {
+		span.attributes["process.command_args"] = strings.Join(command, " ")
+		config.Environment["TRACEPARENT"] = tracing.traceParent(span)
+	}
+	return span
+}
+
+// endCommand records the exit code of the wrapped command and ends its span if it is not already ended
+func endCommand(span *traceSpan, exitCode int) {
+	if span == nil {
+		return
+	}
+	tracing.Lock()
+	span.attributes["process.exit_code"] = exitCode
+	span.failed = exitCode != 0
+	tracing.Unlock()
+	tracing.finish(span)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOTLPTracesEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment map[string]string
+		want        string
+	}{
+		{"Not configured", nil, ""},
+		{"Base endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"}, "http://collector:4318/v1/traces"},
+		{"Traces endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://traces:4318/custom"}, "http://traces:4318/custom"},
+		{"Disabled", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_TRACES_EXPORTER": "none"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_TRACES_EXPORTER", "OTEL_SDK_DISABLED"} {
+				defer os.Setenv(key, os.Getenv(key))
+				os.Setenv(key, tt.environment[key])
+			}
+			assert.Equal(t, tt.want, getOTLPTracesEndpoint())
+		})
+	}
+}
+
+func TestParseOTELList(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret token", "x-team": "platform"}, parseOTELList("Authorization=Bearer%20secret%20token, x-team=platform,invalid"))
+	assert.Empty(t, parseOTELList(""))
+}
+
+func TestTracerDisabled(t *testing.T) {
+	t.Parallel()
+	recorder := newTracer(time.Now, "", "")
+	assert.False(t, recorder.enabled())
+	assert.Nil(t, recorder.reserve("terragrunt plan"))
+	recorder.start("configuration")()
+	recorder.export(runMetadata{})
+
+	var nilRecorder *tracer
+	assert.False(t, nilRecorder.enabled())
+	nilRecorder.start("configuration")()
+}
+
+func TestTracerExport(t *testing.T) {
+	var received map[string]interface{}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+	for key, value := range map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "Authorization=Bearer%20secret", "OTEL_SERVICE_NAME": "", "OTEL_RESOURCE_ATTRIBUTES": "team=platform"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+
+	clock := time.Unix(1600000000, 0)
+	now := func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	recorder := newTracer(now, server.URL, "00-0af7651916cd43dd8448eb211c96f319-b7ad6b7169203331-01")
+	defer func(saved *tracer) { tracing = saved }(tracing)
+	tracing = recorder
+	timingsRecorder := newTimingRecorder(now)
+	timingsRecorder.tracer = recorder
+
+	endConfiguration := timingsRecorder.begin("configuration")
+	timingsRecorder.begin("credentials")()
+	endConfiguration()
+	command := (&TGFConfig{Environment: map[string]string{}}).traceCommand([]string{"terragrunt", "plan", "-out", "plan.out", "--terragrunt-logging-level", "info"})
+	endContainer := timingsRecorder.begin("container")
+	recorder.begin(command)
+	recorder.finish(command)
+	endContainer()
+	endCommand(command, 2)
+	recorder.export(runMetadata{EntryPoint: "terragrunt", Arguments: []string{"plan"}, ExitCode: 2, AWSAccount: "123456789012", WorkingDir: "/infra"})
+
+	assert.Equal(t, "Bearer secret", authorization)
+	resourceSpans := received["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, resourceSpans["resource"].(map[string]interface{})["attributes"], map[string]interface{}{"key": "team", "value": map[string]interface{}{"stringValue": "platform"}})
+	spans := map[string]map[string]interface{}{}
+	for _, span := range resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{}) {
+		span := span.(map[string]interface{})
+		assert.Equal(t, "0af7651916cd43dd8448eb211c96f319", span["traceId"], "The trace of the traceparent is continued")
+		spans[span["name"].(string)] = span
+	}
+	if !assert.Len(t, spans, 5) {
+		return
+	}
+	parent := func(name string) interface{} { return spans[name]["parentSpanId"] }
+	assert.Equal(t, "b7ad6b7169203331", parent("tgf"))
+	assert.Equal(t, spans["tgf"]["spanId"], parent("configuration"))
+	assert.Equal(t, spans["configuration"]["spanId"], parent("credentials"))
+	assert.Equal(t, spans["tgf"]["spanId"], parent("container"))
+	assert.Equal(t, spans["container"]["spanId"], parent("terragrunt plan"))
+	assert.Equal(t, map[string]interface{}{"code": float64(2)}, spans["terragrunt plan"]["status"])
+	assert.Equal(t, map[string]interface{}{"code": float64(2)}, spans["tgf"]["status"])
+	assert.Nil(t, spans["configuration"]["status"])
+	assert.Equal(t, "1600000003000000000", spans["configuration"]["startTimeUnixNano"])
+	assert.Equal(t, "1600000010000000000", spans["configuration"]["endTimeUnixNano"])
+	assert.Contains(t, spans["tgf"]["attributes"], map[string]interface{}{"key": "cloud.account.id", "value": map[string]interface{}{"stringValue": "123456789012"}})
+	assert.Contains(t, spans["terragrunt plan"]["attributes"], map[string]interface{}{"key": "process.exit_code", "value": map[string]interface{}{"intValue": "2"}})
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c96f319-"+spans["terragrunt plan"]["spanId"].(string)+"-01", recorder.traceParent(command))
+
+	assert.Empty(t, recorder.spans, "The spans are reset for the next run")
+	assert.NotEqual(t, spans["tgf"]["spanId"], recorder.traceParent(recorder.root)[36:52])
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+)
+
+// supportedRuntimes lists the container runtimes that tgf can drive
+var supportedRuntimes = []string{"docker"}
+
+// versionInfo describes the tgf build for inventory tools
+type versionInfo struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildDate string   `json:"build-date"`
+	GoVersion string   `json:"go-version"`
+	Platform  string   `json:"platform"`
+	Runtimes  []string `json:"runtimes"`
+}
+
+func getVersionInfo() versionInfo {
+	return versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		Runtimes:  supportedRuntimes,
+	}
+}
+
+// printVersion prints the tgf version, the build metadata are included if JSON is requested
+func printVersion(asJSON bool) int {
+	if !asJSON {
+		Printf("tgf v%s\n", version)
+		return 0
+	}
+	Println(string(must(json.MarshalIndent(getVersionInfo(), "", "  ")).([]byte)))
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersionInfo(t *testing.T) {
+	defer func(c, d string) { commit, date = c, d }(commit, date)
+	commit, date = "abc1234", "2023-01-02T15:04:05Z"
+
+	info := getVersionInfo()
+	assert.Equal(t, version, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+
+	var result map[string]interface{}
+	assert.NoError(t, json.Unmarshal(must(json.Marshal(info)).([]byte), &result))
+	assert.Equal(t, "abc1234", result["commit"])
+	assert.Equal(t, "2023-01-02T15:04:05Z", result["build-date"])
+	assert.Equal(t, []interface{}{"docker"}, result["runtimes"])
+}
+
+func TestVersionArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantUnmanaged []string
+	}{
+		{"Current version", []string{"--current-version"}, nil},
+		{"Version alias", []string{"--version"}, nil},
+		{"JSON", []string{"--version", "--json"}, []string{"--json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewTestApplication(tt.args)
+			assert.True(t, app.GetCurrentVersion)
+			assert.Equal(t, tt.wantUnmanaged, app.Unmanaged)
+		})
+	}
+}
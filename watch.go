@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchPollInterval is the delay between two checks of the watched files
+const watchPollInterval = 500 * time.Millisecond
+
+// watchIgnoreDefaults are the files and folders that are never watched (in addition to --watch-ignore)
+var watchIgnoreDefaults = []string{".git", ".terraform", ".terragrunt-cache", "*.tfstate", "*.tfstate.backup", "*.swp", "*~", ".DS_Store"}
+
+// fileState is the modification time and the size of a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileSnapshot is the state of the watched files indexed by their path (relative to the watched folder)
+type fileSnapshot map[string]fileState
+
+// isWatchIgnored returns true if the file base name or its relative path matches one of the patterns
+func isWatchIgnored(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.ToSlash(path)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// takeSnapshot returns the state of the files under the folder, the ignored folders are not visited
+func takeSnapshot(root string, ignore []string) fileSnapshot {
+	snapshot := make(fileSnapshot)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// The file may have been removed while walking the folder
+			return nil
+		}
+		relative, _ := filepath.Rel(root, path)
+		if relative != "." && isWatchIgnored(relative, ignore) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			snapshot[relative] = fileState{info.ModTime(), info.Size()}
+		}
+		return nil
+	})
+	return snapshot
+}
+
+// changes returns the files added, modified or removed since the previous snapshot (sorted)
+func (snapshot fileSnapshot) changes(previous fileSnapshot) (changed []string) {
+	for path, state := range snapshot {
+		if before, exist := previous[path]; !exist || before != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, exist := snapshot[path]; !exist {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return
+}
+
+// waitForChanges checks the files under the folder until some of them are changed.
+// It returns the changed files once no other change has been detected during the delay (to run only once when several files are saved).
+func waitForChanges(root string, ignore []string, interval, delay time.Duration) []string {
+	previous := takeSnapshot(root, ignore)
+	changed := make(map[string]bool)
+	var lastChange time.Time
+	for {
+		time.Sleep(interval)
+		current := takeSnapshot(root, ignore)
+		if files := current.changes(previous); len(files) > 0 {
+			for _, file := range files {
+				changed[file] = true
+			}
+			lastChange = time.Now()
+		} else if len(changed) > 0 && time.Since(lastChange) >= delay {
+			result := make([]string, 0, len(changed))
+			for file := range changed {
+				result = append(result, file)
+			}
+			sort.Strings(result)
+			return result
+		}
+		previous = current
+	}
+}
+
+// watch runs the command, then runs it again each time files are changed under the current folder (until tgf is interrupted)
+func (app *TGFApplication) watch() int {
+	ignore := append(append([]string{}, watchIgnoreDefaults...), app.WatchIgnore...)
+	for {
+		exitCode := app.runOnce()
+		// The image has been refreshed by the first run if it was requested, there is no need to do it on each change
+		app.Refresh = false
+		printInfo("tgf", map[string]interface{}{"exit-code": exitCode}, msgWatchWaiting, exitCode)
+		changed := waitForChanges(".", ignore, watchPollInterval, app.WatchDelay)
+		files := strings.Join(changed, ", ")
+		if len(changed) > 3 {
+			files = strings.Join(changed[:3], ", ") + ", ..."
+		}
+		printInfo("tgf", map[string]interface{}{"files": changed}, msgWatchChanged, len(changed), files)
+		timings = newTimingRecorder(time.Now)
+	}
+}
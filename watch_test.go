@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWatchIgnored(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.tf", false},
+		{".terragrunt-cache", true},
+		{"modules/.terraform", true},
+		{"terraform.tfstate", true},
+		{"main.tf~", true},
+		{"plans/plan.out", true},
+		{"modules/plan.out", false},
+		{"output.json", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, isWatchIgnored(tt.path, append(watchIgnoreDefaults, "plans/*", "*.json")))
+		})
+	}
+}
+
+func TestSnapshotChanges(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestSnapshotChanges")).(string)
+	defer os.RemoveAll(tempDir)
+	write := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		must(os.MkdirAll(filepath.Dir(path), 0755))
+		must(ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	write("main.tf", "1")
+	write("removed.tf", "1")
+	write("modules/vpc/main.tf", "1")
+	write(".terragrunt-cache/main.tf", "1")
+
+	before := takeSnapshot(tempDir, watchIgnoreDefaults)
+	assert.Len(t, before, 3)
+
+	write("main.tf", "12")
+	write("added.tf", "1")
+	write(".terragrunt-cache/main.tf", "12")
+	must(os.Remove(filepath.Join(tempDir, "removed.tf")))
+	after := takeSnapshot(tempDir, watchIgnoreDefaults)
+
+	assert.Equal(t, []string{"added.tf", "main.tf", "removed.tf"}, after.changes(before))
+	assert.Empty(t, after.changes(after))
+}
+
+func TestWaitForChanges(t *testing.T) {
+	tempDir := must(ioutil.TempDir("", "TestWaitForChanges")).(string)
+	defer os.RemoveAll(tempDir)
+
+	go func() {
+		for _, name := range []string{"a.tf", "ignored.swp", "b.tf"} {
+			time.Sleep(20 * time.Millisecond)
+			ioutil.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644)
+		}
+	}()
+	changed := waitForChanges(tempDir, watchIgnoreDefaults, 5*time.Millisecond, 100*time.Millisecond)
+	assert.Equal(t, []string{"a.tf", "b.tf"}, changed)
+}
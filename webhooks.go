@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// webhookTimeout is the maximum delay to send an event to a webhook
+const webhookTimeout = 10 * time.Second
+
+// Events sent to the webhooks
+const (
+	webhookImageUpdated = "image-updated"
+	webhookRunFailed    = "run-failed"
+	webhookRunSlow      = "run-slow"
+)
+
+// Formats of the default payloads
+const (
+	webhookGeneric = "generic"
+	webhookSlack   = "slack"
+	webhookTeams   = "teams"
+)
+
+// Webhook is an HTTP endpoint notified when the events occur
+type Webhook struct {
+	URL       string            `yaml:"url,omitempty" json:"url,omitempty" hcl:"url,omitempty"`
+	Type      string            `yaml:"type,omitempty" json:"type,omitempty" hcl:"type,omitempty"`
+	Events    []string          `yaml:"events,omitempty" json:"events,omitempty" hcl:"events,omitempty"`
+	Threshold time.Duration     `yaml:"duration-threshold,omitempty" json:"duration-threshold,omitempty" hcl:"duration-threshold,omitempty"`
+	Payload   string            `yaml:"payload,omitempty" json:"payload,omitempty" hcl:"payload,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" hcl:"headers,omitempty"`
+}
+
+// webhookEvent is the data sent to the webhooks (the generic payload) and supplied to the payload templates
+type webhookEvent struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+	auditRecord
+}
+
+// accepts returns true if the webhook is notified of the event (all events by default, run-slow requires a duration threshold)
+func (webhook Webhook) accepts(event string, duration time.Duration) bool {
+	if event == webhookRunSlow && (webhook.Threshold <= 0 || duration < webhook.Threshold) {
+		return false
+	}
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, accepted := range webhook.Events {
+		if accepted == event {
+			return true
+		}
+	}
+	return false
+}
+
+// getWebhookMessage returns the text describing the event
+func getWebhookMessage(event webhookEvent) string {
+	var message string
+	switch event.Event {
+	case webhookImageUpdated:
+		message = fmt.Sprintf("tgf image %s has been updated", event.Image)
+	default:
+		duration := time.Duration(event.Duration * float64(time.Second))
+		message = fmt.Sprintf("tgf %s in %s", getCompletionMessage(event.EntryPoint, event.Arguments, duration, event.ExitCode), event.WorkingDir)
+	}
+	if event.AWSAccount != "" {
+		message += fmt.Sprintf(" (AWS account %s)", event.AWSAccount)
+	}
+	return message
+}
+
+// getPayload returns the body sent to the webhook, the payload templates use [[ ]] as delimiters since the {{ }} expressions are
+// evaluated when the configuration is loaded
+func (webhook Webhook) getPayload(event webhookEvent) ([]byte, error) {
+	if webhook.Payload != "" {
+		funcs := template.FuncMap{
+			"json": func(value interface{}) (string, error) {
+				content, err := json.Marshal(value)
+				return string(content), err
+			},
+			"base": filepath.Base,
+		}
+		payload, err := template.New("payload").Delims("[[", "]]").Funcs(funcs).Parse(webhook.Payload)
+		if err != nil {
+			return nil, err
+		}
+		var buffer bytes.Buffer
+		if err := payload.Execute(&buffer, event); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+
+	switch strings.ToLower(webhook.Type) {
+	case webhookSlack:
+		return json.Marshal(map[string]string{"text": event.Message})
+	case webhookTeams:
+		color := "2EB886"
+		if event.ExitCode != 0 {
+			color = "D00000"
+		}
+		return json.Marshal(map[string]string{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": event.Message, "themeColor": color, "text": event.Message})
+	case webhookGeneric, "":
+		return json.Marshal(event)
+	}
+	return nil, fmt.Errorf("Unsupported webhook type %q (supported types are %s, %s and %s)", webhook.Type, webhookGeneric, webhookSlack, webhookTeams)
+}
+
+// send posts the payload to the webhook
+func (webhook Webhook) send(payload []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range webhook.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := newHTTPClient(webhookTimeout).Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s", response.Status)
+	}
+	return nil
+}
+
+// getWebhookHost returns the host of the webhook, it is used to report the failures (the URL of a webhook often contains a secret)
+func getWebhookHost(webhookURL string) string {
+	if parsed, err := url.Parse(webhookURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return "webhook"
+}
+
+// sendWebhooks notifies the webhooks accepting the event, a failure to notify does not change the result of the run
+func (config *TGFConfig) sendWebhooks(event webhookEvent) {
+	event.Message = getWebhookMessage(event)
+	duration := time.Duration(event.Duration * float64(time.Second))
+	for _, webhook := range config.Webhooks {
+		if !webhook.accepts(event.Event, duration) {
+			continue
+		}
+		payload, err := webhook.getPayload(event)
+		if err == nil {
+			err = webhook.send(payload)
+		}
+		if err != nil {
+			printWarning(msgWebhookFailed, event.Event, getWebhookHost(webhook.URL), err)
+		} else {
+			config.tgf.Debug("# %s event sent to %s", event.Event, getWebhookHost(webhook.URL))
+		}
+	}
+}
+
+// sendRunWebhooks sends the run-failed and run-slow events once the command is completed
+func (config *TGFConfig) sendRunWebhooks(start time.Time, exitCode int) {
+	record := config.getAuditRecord(start, exitCode)
+	if exitCode != 0 {
+		config.sendWebhooks(webhookEvent{Event: webhookRunFailed, auditRecord: record})
+	}
+	config.sendWebhooks(webhookEvent{Event: webhookRunSlow, auditRecord: record})
+}
+
+// sendImageUpdatedWebhooks sends the image-updated event once a newer version of the image has been pulled
+func (config *TGFConfig) sendImageUpdatedWebhooks(image string) {
+	record := config.getAuditRecord(time.Now(), 0)
+	record.Image, record.Duration = image, 0
+	config.sendWebhooks(webhookEvent{Event: webhookImageUpdated, auditRecord: record})
+}
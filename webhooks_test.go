@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookAccepts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		webhook  Webhook
+		event    string
+		duration time.Duration
+		want     bool
+	}{
+		{"All events", Webhook{}, webhookRunFailed, 0, true},
+		{"Filtered", Webhook{Events: []string{webhookImageUpdated}}, webhookRunFailed, 0, false},
+		{"Selected", Webhook{Events: []string{webhookImageUpdated}}, webhookImageUpdated, 0, true},
+		{"Slow without threshold", Webhook{}, webhookRunSlow, time.Hour, false},
+		{"Slow", Webhook{Threshold: 30 * time.Minute}, webhookRunSlow, time.Hour, true},
+		{"Fast", Webhook{Threshold: 30 * time.Minute}, webhookRunSlow, time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.webhook.accepts(tt.event, tt.duration))
+		})
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	t.Parallel()
+
+	event := webhookEvent{Event: webhookRunFailed, auditRecord: auditRecord{User: "jdoe", runMetadata: runMetadata{
+		EntryPoint: "terragrunt",
+		Arguments:  []string{"apply"},
+		ExitCode:   2,
+		Duration:   75,
+		AWSAccount: "123456789012",
+		WorkingDir: "/home/jdoe/infra/live/dev",
+	}}}
+	event.Message = getWebhookMessage(event)
+	assert.Equal(t, "tgf terragrunt apply failed (exit code 2) in 1m15s in /home/jdoe/infra/live/dev (AWS account 123456789012)", event.Message)
+
+	tests := []struct {
+		name    string
+		webhook Webhook
+		want    string
+		wantErr bool
+	}{
+		{"Slack", Webhook{Type: "slack"}, `{"text":"` + event.Message + `"}`, false},
+		{"Teams", Webhook{Type: "Teams"}, `{"@context":"https://schema.org/extensions","@type":"MessageCard","summary":"` + event.Message + `","text":"` + event.Message + `","themeColor":"D00000"}`, false},
+		{"Template", Webhook{Type: "slack", Payload: `{"text": [[ printf "%s failed in %s" .User (base .WorkingDir) | json ]], "code": [[ .ExitCode ]]}`}, `{"text": "jdoe failed in dev", "code": 2}`, false},
+		{"Invalid template", Webhook{Payload: `[[ .Unknown ]]`}, "", true},
+		{"Invalid type", Webhook{Type: "discord"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := tt.webhook.getPayload(event)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+			assert.Equal(t, tt.want, string(payload))
+		})
+	}
+
+	payload := must(Webhook{}.getPayload(event)).([]byte)
+	var generic map[string]interface{}
+	assert.NoError(t, json.Unmarshal(payload, &generic))
+	assert.Equal(t, webhookRunFailed, generic["event"])
+	assert.Equal(t, "123456789012", generic["aws-account"])
+	assert.Equal(t, float64(2), generic["exit-code"])
+}
+
+func TestSendRunWebhooks(t *testing.T) {
+	var received []map[string]interface{}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+		if value := r.Header.Get("Authorization"); value != "" {
+			authorization = value
+		}
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	config := &TGFConfig{tgf: NewTestApplication([]string{"plan"}), EntryPoint: "terragrunt", Webhooks: []Webhook{
+		{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}},
+		{URL: server.URL, Type: webhookSlack, Events: []string{webhookRunSlow}, Threshold: time.Hour},
+		{URL: failing.URL},
+	}}
+	config.sendRunWebhooks(time.Now(), 0)
+	assert.Empty(t, received, "Successful and fast run")
+
+	config.sendRunWebhooks(time.Now().Add(-2*time.Hour), 1)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, webhookRunFailed, received[0]["event"])
+		assert.Equal(t, []interface{}{"plan"}, received[0]["arguments"])
+		assert.Contains(t, received[1]["text"], "tgf terragrunt plan failed (exit code 1) in 2h0m0s")
+	}
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, "127.0.0.1:1234", getWebhookHost("https://127.0.0.1:1234/services/T000/B000/secret"))
+}